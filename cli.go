@@ -0,0 +1,594 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"github.com/Mirrdhyn/code-rag-mcp/pkg/coderag"
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/Mirrdhyn/code-rag-mcp/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// cliLogger logs warnings and errors only, unless verbose is set
+func cliLogger(verbose bool) *zap.Logger {
+	level := zapcore.WarnLevel
+	if verbose {
+		level = zapcore.InfoLevel
+	}
+	cfg := zap.NewDevelopmentConfig()
+	cfg.Level = zap.NewAtomicLevelAt(level)
+	logger, err := cfg.Build()
+	if err != nil {
+		return zap.NewNop()
+	}
+	return logger
+}
+
+// parseArgs parses flags placed before or after positional arguments,
+// so "search 'auth middleware' --json" works like "search --json 'auth middleware'"
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...)
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// signalContext returns a context cancelled on interrupt, so long-running
+// commands stop cleanly (indexing saves its progress)
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// runIndex indexes directories without starting an MCP session
+func runIndex(configPath string, args []string) error {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to config file")
+	extensions := fs.String("ext", "", "Comma-separated file extensions (default: config file_extensions)")
+	exclude := fs.String("exclude", "", "Comma-separated globs to skip, added to config exclude_patterns")
+	include := fs.String("include", "", "Comma-separated globs; only matching files are indexed (added to config include_patterns)")
+	full := fs.Bool("full", false, "Ignore saved progress and re-index everything")
+	verbose := fs.Bool("v", false, "Verbose logging")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: code-rag-mcp index [-ext .go,.py] [-exclude '*.pb.go'] [-include 'src/**'] [-full] [-v] [paths...]")
+		fs.PrintDefaults()
+	}
+	paths := parseArgs(fs, args)
+
+	logger := cliLogger(*verbose)
+	defer logger.Sync()
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(paths) == 0 {
+		paths = cfg.CodePaths
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths given and no code_paths configured")
+	}
+	exts := cfg.FileExtensions
+	if *extensions != "" {
+		exts = strings.Split(*extensions, ",")
+	}
+
+	pathFilter := rag.PathFilter{Exclude: cfg.ExcludePatterns, Include: cfg.IncludePatterns}
+	if *exclude != "" {
+		pathFilter.Exclude = append(pathFilter.Exclude, strings.Split(*exclude, ",")...)
+	}
+	if *include != "" {
+		pathFilter.Include = append(pathFilter.Include, strings.Split(*include, ",")...)
+	}
+
+	embedder, vectorDB, err := openBackends(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer vectorDB.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	if err := vectorDB.CreateCollection(ctx, cfg.CollectionName, embedder.Dimension()); err != nil {
+		logger.Debug("Collection might already exist", zap.Error(err))
+	}
+
+	workDir, _ := os.Getwd()
+	_, incrementalIndexer, err := newIndexers(cfg, embedder, vectorDB, pathFilter, workDir, logger)
+	if err != nil {
+		return err
+	}
+
+	if *full {
+		if err := incrementalIndexer.ReindexAll(ctx, paths, exts, cfg.CollectionName, false, embedder.Dimension()); err != nil {
+			return err
+		}
+	} else {
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "Indexing %s...\n", path)
+			if err := incrementalIndexer.IndexDirectoryIncremental(ctx, path, exts, cfg.CollectionName); err != nil {
+				return fmt.Errorf("failed to index %s: %w", path, err)
+			}
+		}
+	}
+
+	if state := incrementalIndexer.GetState(); state != nil {
+		stats := state.GetStats()
+		fmt.Printf("Indexed %d/%d files (%d chunks, %d failed)\n",
+			stats["indexed_files"], stats["total_files"], stats["total_chunks"], stats["failed_files"])
+	}
+	return nil
+}
+
+// runSearch searches the index and prints matches as text or JSON
+func runSearch(configPath string, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to config file")
+	limit := fs.Int("limit", 0, "Number of results (default: config top_k)")
+	minScore := fs.Float64("min-score", -1, "Minimum similarity 0-1 (default: config min_score)")
+	asJSON := fs.Bool("json", false, "Print results as JSON")
+	compact := fs.Bool("compact", false, "Print file:line references only")
+	verbose := fs.Bool("v", false, "Verbose logging")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: code-rag-mcp search [-limit N] [-min-score X] [-json] [-compact] <query>")
+		fs.PrintDefaults()
+	}
+	query := strings.Join(parseArgs(fs, args), " ")
+	if strings.TrimSpace(query) == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	logger := cliLogger(*verbose)
+	defer logger.Sync()
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if *limit <= 0 {
+		*limit = cfg.TopK
+	}
+	if *minScore < 0 {
+		*minScore = float64(cfg.MinScore)
+	}
+
+	embedder, vectorDB, err := openBackends(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer vectorDB.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	engine, err := coderag.New(ctx,
+		coderag.WithConfig(cfg),
+		coderag.WithEmbedder(embedder),
+		coderag.WithVectorDB(vectorDB),
+		coderag.WithLogger(logger),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Fall back to lexical search when the embedder is unavailable
+	outcome, err := engine.Search(ctx, query, coderag.Limit(*limit), coderag.MinScore(float32(*minScore)), coderag.LexicalFallback())
+	if err != nil {
+		return err
+	}
+	results, degraded := outcome.Results, outcome.Degraded
+
+	if *asJSON {
+		hits := make([]server.SearchHit, 0, len(results))
+		for _, r := range results {
+			hits = append(hits, server.SearchHit{
+				FilePath:  r.FilePath,
+				LineStart: r.LineStart,
+				LineEnd:   r.LineEnd,
+				Language:  r.Language,
+				Score:     r.Score,
+				Content:   r.Content,
+			})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(server.BatchQueryResult{Query: query, Degraded: degraded, Results: hits})
+	}
+
+	if degraded {
+		fmt.Fprintln(os.Stderr, "warning: embedding service unavailable, showing lexical (BM25) matches")
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "No results.")
+		return nil
+	}
+	for _, r := range results {
+		fmt.Printf("%s:%d-%d\t%.3f\t%s\n", r.FilePath, r.LineStart, r.LineEnd, r.Score, r.Language)
+		if !*compact {
+			fmt.Println(r.Content)
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+// runStats prints collection statistics
+func runStats(configPath string, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to config file")
+	asJSON := fs.Bool("json", false, "Print statistics as JSON")
+	fs.Parse(args)
+
+	logger := cliLogger(false)
+	defer logger.Sync()
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	_, vectorDB, err := openBackends(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer vectorDB.Close()
+
+	info, err := vectorDB.GetCollectionInfo(context.Background(), cfg.CollectionName)
+	if err != nil {
+		return fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"collection":      cfg.CollectionName,
+			"points":          info.PointsCount,
+			"vector_dim":      info.VectorDim,
+			"embedding_model": cfg.EmbeddingModel,
+		})
+	}
+
+	fmt.Printf("Collection:      %s\n", cfg.CollectionName)
+	fmt.Printf("Chunks:          %d\n", info.PointsCount)
+	fmt.Printf("Vector dim:      %d\n", info.VectorDim)
+	fmt.Printf("Embedding model: %s (%s)\n", cfg.EmbeddingModel, cfg.EmbeddingType)
+	return nil
+}
+
+// runBenchmarkEmbedders indexes a sample of a directory with each of
+// benchmark_embedders (or the configured embedder) and prints their recall@k
+// against golden query -> file pairs
+func runBenchmarkEmbedders(configPath string, args []string) error {
+	fs := flag.NewFlagSet("benchmark_embedders", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to config file")
+	goldenPath := fs.String("golden", "golden_queries.json", `JSON or YAML file of golden queries: [{"query": "...", "files": ["path/relative/to/root.go", "other.go:12-40"]}]`)
+	ksFlag := fs.String("k", "1,5,10", "Comma-separated K of the recall@K columns")
+	sample := fs.Int("sample", 200, "Files indexed besides the golden ones (-1: all)")
+	seed := fs.Int64("seed", 1, "Seed of the file sample, so runs compare the same files")
+	asJSON := fs.Bool("json", false, "Print results as JSON")
+	verbose := fs.Bool("v", false, "Verbose logging")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: code-rag-mcp benchmark_embedders [-golden queries.json] [-k 1,5,10] [-sample 200] [-seed 1] [-json] [path]")
+		fs.PrintDefaults()
+	}
+	paths := parseArgs(fs, args)
+
+	logger := cliLogger(*verbose)
+	defer logger.Sync()
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	root := "."
+	switch {
+	case len(paths) > 1:
+		return fmt.Errorf("benchmark_embedders takes one path")
+	case len(paths) == 1:
+		root = paths[0]
+	case len(cfg.CodePaths) > 0:
+		root = cfg.CodePaths[0]
+	}
+
+	var ks []int
+	for _, field := range strings.Split(*ksFlag, ",") {
+		k, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || k <= 0 {
+			return fmt.Errorf("invalid -k %q: K must be positive integers", *ksFlag)
+		}
+		ks = append(ks, k)
+	}
+
+	queries, err := rag.LoadGoldenQueries(*goldenPath, root)
+	if err != nil {
+		return err
+	}
+
+	embedders := cfg.BenchmarkEmbedders
+	if len(embedders) == 0 {
+		embedders = []config.BenchmarkEmbedder{{
+			Name:      cfg.EmbeddingModel,
+			Type:      cfg.EmbeddingType,
+			Model:     cfg.EmbeddingModel,
+			APIKey:    cfg.EmbeddingAPIKey,
+			BaseURL:   cfg.EmbeddingBaseURL,
+			Dimension: cfg.EmbeddingDim,
+			Options:   cfg.EmbeddingOptions,
+		}}
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	// The sample is chunked like the index, so results carry over to it
+	indexer := rag.NewIndexer(nil, nil, logger)
+	indexer.SetPathFilter(rag.PathFilter{Exclude: cfg.ExcludePatterns, Include: cfg.IncludePatterns})
+	indexer.SetSensitiveFiles(append(append([]string{}, rag.DefaultSensitiveFiles...), cfg.SensitiveFiles...))
+	indexer.SetChunking(rag.ChunkingConfig{
+		ChunkSize:    cfg.ChunkSize,
+		ChunkOverlap: cfg.ChunkOverlap,
+		MaxFileSize:  cfg.MaxFileSize,
+		Languages:    languageChunking(cfg.Chunking),
+	})
+	if cfg.EnrichmentTemplate != "" {
+		if err := indexer.SetEnrichmentTemplate(cfg.EnrichmentTemplate); err != nil {
+			return err
+		}
+	}
+	files, err := indexer.BenchmarkSample(root, cfg.FileExtensions, queries, *sample, *seed)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Benchmarking %d embedders on %d files and %d golden queries...\n", len(embedders), len(files), len(queries))
+
+	var results []*rag.EmbedderBenchmark
+	for _, e := range embedders {
+		embedder, err := rag.NewEmbedderFromConfig(e.Type, rag.EmbedderConfig{
+			Model:     e.Model,
+			APIKey:    e.APIKey,
+			BaseURL:   e.BaseURL,
+			Dimension: e.Dimension,
+			Options:   e.Options,
+			Batch:     rag.BatchOptions(cfg.EmbeddingBatch),
+		})
+		if err != nil {
+			return fmt.Errorf("embedder %s: %w", e.Name, err)
+		}
+		prefixes := rag.EmbeddingPrefixes(cfg.EmbeddingPrefixes).Resolve(e.Model)
+		result, err := indexer.BenchmarkEmbedder(ctx, e.Name, rag.NewValidatingEmbedder(embedder, logger), prefixes, files, queries, ks)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "EMBEDDER\tCHUNKS")
+	for _, k := range ks {
+		fmt.Fprintf(w, "\tRECALL@%d", k)
+	}
+	fmt.Fprintln(w, "\tMRR\tINDEX TIME\tQUERY TIME")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d", r.Name, r.Chunks)
+		for _, recall := range r.Recall {
+			fmt.Fprintf(w, "\t%.3f", recall)
+		}
+		fmt.Fprintf(w, "\t%.3f\t%s\t%s\n", r.MRR, r.IndexTime.Round(time.Millisecond), r.QueryTime.Round(time.Millisecond))
+	}
+	return w.Flush()
+}
+
+// runEvaluateIndex runs evaluation queries through the server's search with
+// the current index and settings and prints recall, MRR and nDCG
+func runEvaluateIndex(configPath string, args []string) error {
+	fs := flag.NewFlagSet("evaluate_index", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to config file")
+	queriesPath := fs.String("queries", "", "YAML or JSON file of golden queries, files relative to it (default: config eval_queries)")
+	k := fs.Int("k", 0, "Results scored per query (default: config top_k)")
+	minScore := fs.Float64("min-score", -1, "Minimum similarity 0-1 (default: config min_score)")
+	asJSON := fs.Bool("json", false, "Print results as JSON")
+	verbose := fs.Bool("v", false, "Verbose logging")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: code-rag-mcp evaluate_index [-queries eval_queries.yaml] [-k N] [-min-score X] [-json]")
+		fs.PrintDefaults()
+	}
+	parseArgs(fs, args)
+
+	logger := cliLogger(*verbose)
+	defer logger.Sync()
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if *queriesPath == "" {
+		*queriesPath = cfg.EvalQueries
+	}
+	if *k <= 0 {
+		*k = cfg.TopK
+	}
+	if *minScore < 0 {
+		*minScore = float64(cfg.MinScore)
+	}
+
+	queries, err := rag.LoadGoldenQueries(*queriesPath, filepath.Dir(*queriesPath))
+	if err != nil {
+		return err
+	}
+
+	embedder, vectorDB, err := openBackends(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer vectorDB.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	workDir, _ := os.Getwd()
+	indexer, incrementalIndexer, err := newIndexers(cfg, embedder, vectorDB, rag.PathFilter{Exclude: cfg.ExcludePatterns, Include: cfg.IncludePatterns}, workDir, logger)
+	if err != nil {
+		return err
+	}
+	ragServer := server.NewRAGServer(indexer, incrementalIndexer, vectorDB, embedder, cfg, logger)
+
+	evaluation := ragServer.Evaluate(ctx, queries, *k, float32(*minScore))
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(evaluation)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUERY\tRECALL\tRR\tNDCG\tMISSED")
+	for _, q := range evaluation.Queries {
+		missed := strings.Join(q.Missed, ", ")
+		if q.Error != "" {
+			missed = "error: " + q.Error
+		}
+		fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%.3f\t%s\n", q.Query, q.Recall, q.ReciprocalRank, q.NDCG, missed)
+	}
+	fmt.Fprintf(w, "MEAN (%d queries, k=%d)\t%.3f\t%.3f\t%.3f\t\n", len(evaluation.Queries), evaluation.K, evaluation.Recall, evaluation.MRR, evaluation.NDCG)
+	return w.Flush()
+}
+
+// runDrain asks the server on this host to drain, for a Kubernetes preStop
+// hook: the request is signed with webhook_secret and authenticated with the
+// first api_keys key allowed to index, as /drain requires
+func runDrain(configPath string, args []string) error {
+	fs := flag.NewFlagSet("drain", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to config file")
+	url := fs.String("url", "", "Drain endpoint (default: /drain of the HTTP API on 127.0.0.1)")
+	certFile := fs.String("cert", "", "PEM client certificate presented under mTLS (default: config http_api_client_cert)")
+	keyFile := fs.String("key", "", "PEM private key of -cert (default: config http_api_client_key)")
+	caFile := fs.String("ca", "", "PEM CA bundle verifying the server of -url (default: system roots)")
+	fs.Parse(args)
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if *certFile == "" && *keyFile == "" {
+		*certFile, *keyFile = cfg.HTTPAPIClientCert, cfg.HTTPAPIClientKey
+	}
+
+	loopback := *url == ""
+	if loopback {
+		scheme := "http"
+		if cfg.HTTPAPITLSCert != "" {
+			scheme = "https"
+		}
+		*url = fmt.Sprintf("%s://127.0.0.1:%d/drain", scheme, cfg.HTTPAPIPort)
+	}
+	tlsCfg, err := drainTLSConfig(cfg, loopback, *caFile, *certFile, *keyFile)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout:   cfg.DrainTimeout + 5*time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsCfg},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, *url, nil)
+	if err != nil {
+		return err
+	}
+	for _, key := range cfg.APIKeys {
+		if key.Indexing {
+			request.Header.Set("Authorization", "Bearer "+key.Key)
+			break
+		}
+	}
+	if cfg.WebhookSecret != "" {
+		server.SignRequest(request, nil, cfg.WebhookSecret)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("drain request failed: %w", err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("drain failed: %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	fmt.Print(string(body))
+	return nil
+}
+
+// drainTLSConfig returns the TLS configuration of the drain request: the
+// client certificate, when given, and how the server is verified. The
+// loopback default only accepts the server's own certificate, which names
+// the service rather than 127.0.0.1.
+func drainTLSConfig(cfg *config.Config, loopback bool, caFile, certFile, keyFile string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	switch {
+	case loopback && cfg.HTTPAPITLSCert != "":
+		pinned, err := tls.LoadX509KeyPair(cfg.HTTPAPITLSCert, cfg.HTTPAPITLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load http_api_tls_cert: %w", err)
+		}
+		// Verified below instead of by host name
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], pinned.Certificate[0]) {
+				return fmt.Errorf("the server on 127.0.0.1:%d does not present http_api_tls_cert", cfg.HTTPAPIPort)
+			}
+			return nil
+		}
+	case caFile != "":
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA %s", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	return tlsCfg, nil
+}
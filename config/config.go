@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,33 +14,306 @@ type Config struct {
 	ServerName    string
 	ServerVersion string
 
+	// Logging
+	LogLevel  string // "debug", "info", "warn" or "error"
+	LogFormat string // "json" or "console"
+	LogFile   string // Log to this file instead of stderr
+
+	// MCP transport
+	Transport  string // "stdio", or "sse" to serve MCP over HTTP (containers)
+	SSEAddress string // Listen address of the SSE transport
+	SSEBaseURL string // URL clients reach the SSE transport at
+
+	// Workspace roots advertised by stdio clients
+	ClientRoots ClientRoots
+
 	// HTTP API
-	HTTPAPIEnabled bool
-	HTTPAPIPort    int
+	HTTPAPIEnabled  bool
+	HTTPAPIPort     int
+	DrainTimeout    time.Duration // How long /drain and SIGTERM wait for indexing to stop
+	RequestTimeout  time.Duration // Deadline of tool calls and HTTP requests
+	ReindexTimeout  time.Duration // Deadline of tool calls and HTTP requests that index files
+	WebhookSecret   string        // When set, indexing endpoints require requests signed with it (HMAC-SHA256)
+	WebhookMaxSkew  time.Duration // How old or early a signed request may be
+	HTTPAPITLSCert  string        // PEM certificate file: serve HTTPS instead of HTTP
+	HTTPAPITLSKey   string        // PEM private key file of HTTPAPITLSCert
+	HTTPAPIClientCA string        // PEM CA bundle: require client certificates it signed (mTLS)
+
+	// Client certificate the drain command presents under mTLS
+	HTTPAPIClientCert string
+	HTTPAPIClientKey  string
+	APIKeys           []APIKey // When set, HTTP requests other than probes require one of these bearer tokens
+
+	HTTPRateLimit        float64 // Requests per second allowed to each client (API key, or IP address); 0 disables it
+	HTTPRateBurst        int     // Requests a client may send at once before HTTPRateLimit applies
+	ReindexMaxBody       int64   // Bytes; larger indexing request bodies are rejected (0: unlimited)
+	ReindexMaxFiles      int     // Files one /reindex or /reindex-pending request may name (0: unlimited)
+	ReindexMaxConcurrent int     // /reindex and /reindex-pending requests processed at once; others get 429 (0: unlimited)
+
+	// Vector database
+	VectorDBType    string                 // "qdrant", "memory", or a type added with rag.RegisterVectorDB
+	VectorDBOptions map[string]interface{} // Settings for registered backends
+	QdrantURL       string
+	QdrantAPIKey    string
+	CollectionName  string
+
+	// Warm standby: writes are mirrored here and searches fail over to it
+	QdrantSecondaryURL    string
+	QdrantSecondaryAPIKey string
 
-	// Qdrant
-	QdrantURL      string
-	QdrantAPIKey   string
-	CollectionName string
+	// How collections are created in Qdrant (distance metric, storage, optimizer)
+	Qdrant QdrantCollection
 
 	// Embeddings
-	EmbeddingType    string // "local", "lmstudio", or "openai"
+	EmbeddingType    string // "local", "lmstudio", "openai", "vllm", "llamacpp", or a type added with rag.RegisterEmbedder
 	EmbeddingModel   string
 	EmbeddingAPIKey  string
-	EmbeddingBaseURL string // LM Studio URL
+	EmbeddingBaseURL string // LM Studio, vLLM or llama.cpp server URL
 	EmbeddingDim     int
+	EmbeddingOptions map[string]interface{} // Settings for registered embedders
+
+	EmbeddingBatch     EmbeddingBatch    // Request sizing of LM Studio and vLLM
+	EmbeddingMaxTokens int               // Model input limit: longer chunks are truncated before embedding (0 = no limit)
+	EmbeddingPrefixes  EmbeddingPrefixes // Query and document prefixes of asymmetric models
+
+	BenchmarkEmbedders []BenchmarkEmbedder // Embedders compared by the benchmark_embedders command
+	EvalQueries        string              // Queries and expected files scored by evaluate_index
 
 	// Indexing
 	AutoIndexOnStartup bool
 	CodePaths          []string
 	FileExtensions     []string
-	MaxFileSize        int64
-	ChunkSize          int
-	ChunkOverlap       int
+	ExcludePatterns    []string                    // Globs relative to each code path, e.g. "**/generated/**", "*.pb.go"
+	IncludePatterns    []string                    // When set, only files matching these globs are indexed, e.g. "src/**"
+	MaxFileSize        int64                       // Bytes; larger files are skipped
+	ChunkSize          int                         // Characters per chunk; chunks end on line boundaries
+	ChunkOverlap       int                         // Characters of trailing lines repeated in the next chunk
+	Chunking           map[string]LanguageChunking // Per-language chunk_size/chunk_overlap overrides, keyed by language tag
+	AutoMigrateChunks  bool                        // Re-chunk files indexed by an older chunker version on startup
+	PruneInterval      time.Duration               // How often stale chunks are pruned in the background (0 = never)
+	ReindexSchedule    string                      // Cron expression for syncing code_paths with the index (empty = never)
+	OverlayInterval    time.Duration               // How often uncommitted files are indexed into the overlay (0 = never)
+	ChunkHookPlugins   []string                    // Go plugins (.so) exporting a ChunkHook run before embedding
+	DedupChunks        bool                        // Skip chunks whose content is already indexed from another file
+	EnrichmentTemplate string                      // Go template of the text embedded for each chunk (empty = built-in)
+	PathTags           []PathTags                  // Tags stored with the chunks under each path, to scope searches
+	SensitiveFiles     []string                    // File name globs never indexed, added to the built-in deny list (.env, *.pem, *.tfstate...)
+	ScrubSecrets       bool                        // Mask keys, tokens and private keys in chunks before embedding and storing them
+	StoreContent       bool                        // Store chunk content in Qdrant payloads; false reads it from disk when searched
+	CompressContent    bool                        // Store chunk content zstd-compressed
+	SecretPatterns     []SecretPattern             // Detectors added to the built-in ones of ScrubSecrets
 
 	// Search
-	TopK     int
-	MinScore float32
+	TopK            int
+	MinScore        float32
+	SearchTimeout   time.Duration // Deadline for optional search stages
+	HybridSearch    bool          // Merge BM25 lexical matches into vector results by default
+	LexicalFallback bool          // Return BM25 keyword matches when no vector match passes min_score
+	TwoTierSearch   bool          // Find candidate files by their summaries, then rank chunks within them
+	TwoTierFiles    int           // Candidate files of a two-tier search
+	SessionDedup    bool          // Reference excerpts already sent in the session instead of repeating them
+	HotFileCache    int           // Files most returned by searches kept in memory for expansion and reads (0 = off)
+	RelativePaths   bool          // Show result paths relative to their code path, prefixed with its name
+	Ranking         Ranking       // Boosts applied on top of similarity
+
+	// Deduplication of overlapping search results of the same file
+	ResultDedup          bool
+	ResultDedupThreshold float64 // Share of either chunk's lines an overlap must exceed for the worse chunk to be dropped
+	SearchOverFetch      int     // Points matched per requested result, so enough remain after deduplication
+
+	// Qdrant search parameters (latency vs recall on large collections)
+	SearchParams SearchParams
+
+	// Chat model answering ask_codebase, separate from the embedding model
+	Generation Generation
+
+	// Search log (queries, results and latency, for get_search_analytics)
+	SearchLog          bool
+	SearchLogRetention time.Duration // Older entries are dropped on startup and hourly (0 = keep all)
+
+	// File access (read_file_range); defaults to CodePaths when empty
+	AllowedReadPaths []string
+
+	// Trash (soft-deleted points kept for restore)
+	TrashRetention time.Duration
+
+	// Fault injection for resilience testing; never enable in production
+	FaultInjection FaultInjection
+
+	// Other MCP servers whose tools are mounted as "<name>_<tool>"
+	ProxyServers []ProxyServer
+
+	// Single indexing leader among instances sharing Qdrant
+	LeaderElection LeaderElection
+
+	// Pausing indexing while the embedder is down
+	EmbeddingBreaker EmbeddingBreaker
+
+	// Retrying files that failed to index at the end of a run
+	FailedFileRetry FailedFileRetry
+
+	// Message bus carrying file-change events, another trigger besides git hooks
+	EventBus EventBus
+}
+
+// Ranking re-ranks search results: matches whose path or file name contain
+// query terms go up, files untouched for long go down
+type Ranking struct {
+	PathBoost       float32       // Added when the query terms appear in the file's directories, pro rata
+	FilenameBoost   float32       // Added when the query terms appear in the file name, pro rata
+	RecencyWeight   float32       // Largest share of the score removed from old files (0-1)
+	RecencyHalfLife time.Duration // File age at which half of recency_weight applies (0 = no decay)
+}
+
+// QdrantCollection tunes the collections created in Qdrant. It applies to new
+// collections: changing it requires reindex_all with recreate_collection.
+type QdrantCollection struct {
+	Distance             string // "cosine", "dot" or "euclidean"; use the metric the embedding model was trained for
+	OnDiskPayload        bool   // Keep payloads on disk instead of in RAM
+	IndexingThreshold    int    // Optimizer: KB of vectors a segment holds before it is HNSW-indexed (0 = Qdrant default)
+	MemmapThreshold      int    // Optimizer: KB of vectors above which a segment is memory-mapped (0 = Qdrant default)
+	DefaultSegmentNumber int    // Optimizer: segments it aims for (0 = Qdrant default)
+
+	SparseVectors bool // Store BM25 sparse vectors and run hybrid searches in Qdrant instead of in-process
+}
+
+// SearchParams tune Qdrant's approximate (HNSW) vector search
+type SearchParams struct {
+	HnswEf int  // Candidates explored per search; higher finds more true neighbors, slower (0 = collection default)
+	Exact  bool // Compare the query with every vector instead of using the index
+}
+
+// Generation configures the chat model that answers ask_codebase from
+// retrieved code. Any OpenAI-compatible chat completions endpoint works.
+type Generation struct {
+	Type          string  // "openai", "ollama", "lmstudio" or "" (ask_codebase disabled)
+	Model         string  // Chat model, e.g. "gpt-4o-mini" or "qwen2.5-coder:7b"
+	BaseURL       string  // Default: the type's usual endpoint
+	APIKey        string  // Required for openai
+	MaxTokens     int     // Longest answer
+	Temperature   float32 // Sampling temperature; low values keep answers close to the code
+	ContextTokens int     // Budget of retrieved code sent with the question
+}
+
+// EventBus subscribes to file-change events published as JSON
+// {"files": ["/abs/path", ...]} on a NATS JetStream subject or Kafka topic
+type EventBus struct {
+	Type    string   // "nats", "kafka" or "" (disabled)
+	Servers []string // NATS server URLs or Kafka brokers
+	Topic   string   // NATS subject or Kafka topic
+	Stream  string   // JetStream stream holding Topic (NATS only)
+	Group   string   // Durable consumer (NATS) or consumer group (Kafka)
+}
+
+// LeaderElection lets several instances share one collection: all of them
+// serve searches, only the holder of a lease stored in Qdrant indexes.
+type LeaderElection struct {
+	Enabled    bool
+	InstanceID string        // Default: <hostname>-<pid>
+	Candidate  bool          // false: search-only node, never indexes
+	LeaseTTL   time.Duration // How long a lease outlives its last renewal
+}
+
+// EmbeddingBatch bounds the requests sent to LM Studio and vLLM. Batches
+// grow while requests return within TargetLatency and shrink when slower or
+// rejected as too large.
+type EmbeddingBatch struct {
+	MaxSize       int           // Most texts per request
+	MaxTokens     int           // Most estimated tokens per request (the server's context)
+	TargetLatency time.Duration // Request duration batches grow towards
+}
+
+// EmbeddingPrefixes are prepended to the texts an asymmetric model embeds
+// ("search_query: " and "search_document: " for nomic-embed). "auto" uses the
+// prefixes of the configured model, "" none.
+type EmbeddingPrefixes struct {
+	Query    string // Prepended to search queries
+	Document string // Prepended to indexed chunks and summaries
+}
+
+// EmbeddingBreaker stops calling an embedder that keeps failing: searches
+// fall back to lexical matches and indexing waits until health probes succeed
+type EmbeddingBreaker struct {
+	Failures      int           // Consecutive failed calls that open it (0 = disabled)
+	ProbeInterval time.Duration // Time between health probes while open
+	MaxWait       time.Duration // How long indexing waits before failing (0 = no limit)
+}
+
+// ClientRoots uses the workspace roots an MCP client advertises over stdio:
+// searches default to the code path of the project open in the client
+type ClientRoots struct {
+	Enabled bool // Ask clients supporting roots for them
+	Index   bool // Sync client roots outside code_paths into the collection
+}
+
+// FailedFileRetry retries the files an indexing run failed to embed before
+// it completes, waiting Backoff, then twice as long each time
+type FailedFileRetry struct {
+	Attempts int           // Retries (0 = none)
+	Backoff  time.Duration // Wait before the first retry
+}
+
+// PathTags assigns tags (e.g. "team:payments") to the files under Path
+type PathTags struct {
+	Path string   `mapstructure:"path"`
+	Tags []string `mapstructure:"tags"`
+}
+
+// APIKey is a bearer token of the HTTP API. A key with Tags only searches
+// code carrying one of them, so teams sharing a server only see their own
+// repositories.
+type APIKey struct {
+	Name     string   `mapstructure:"name"`     // Logged instead of the key
+	Key      string   `mapstructure:"key"`      // Token sent as "Authorization: Bearer <key>"
+	KeyFile  string   `mapstructure:"key_file"` // File holding Key, e.g. a mounted secret
+	Tags     []string `mapstructure:"tags"`     // Tags searches are limited to (empty: all code)
+	Indexing bool     `mapstructure:"indexing"` // May call /reindex, /reindex-pending, /reindex-all and /drain
+}
+
+// BenchmarkEmbedder is an embedder compared with the others by the
+// benchmark_embedders command. Fields mirror the embedding_* settings.
+type BenchmarkEmbedder struct {
+	Name       string                 `mapstructure:"name"`
+	Type       string                 `mapstructure:"type"`
+	Model      string                 `mapstructure:"model"`
+	APIKey     string                 `mapstructure:"api_key"`
+	APIKeyFile string                 `mapstructure:"api_key_file"`
+	BaseURL    string                 `mapstructure:"base_url"`
+	Dimension  int                    `mapstructure:"dimension"`
+	Options    map[string]interface{} `mapstructure:"options"`
+}
+
+// SecretPattern detects one kind of secret; a group named "secret" limits
+// the mask to that group
+type SecretPattern struct {
+	Name    string `mapstructure:"name"`
+	Pattern string `mapstructure:"pattern"`
+}
+
+// ProxyServer is an MCP server reached over stdio (Command) or SSE (URL)
+type ProxyServer struct {
+	Name    string   `mapstructure:"name"`    // Tool name prefix
+	Command string   `mapstructure:"command"` // Launched with Args; inherits the environment
+	Args    []string `mapstructure:"args"`
+	URL     string   `mapstructure:"url"`   // SSE endpoint
+	Tools   []string `mapstructure:"tools"` // Tools to mount (default: all)
+}
+
+// FaultInjection makes the embedder and Qdrant fail at random. Rates are
+// probabilities between 0 and 1.
+type FaultInjection struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	Seed                int64         `mapstructure:"seed"`                  // 0 = random
+	EmbedderTimeoutRate float64       `mapstructure:"embedder_timeout_rate"` // Embedding calls that hang, then time out
+	EmbedderTimeout     time.Duration `mapstructure:"embedder_timeout"`      // How long those calls hang
+	VectorDBErrorRate   float64       `mapstructure:"vectordb_error_rate"`   // Qdrant calls that fail
+	PartialBatchRate    float64       `mapstructure:"partial_batch_rate"`    // Upserts that store only part of a batch
+}
+
+// LanguageChunking overrides chunk_size/chunk_overlap for one language
+type LanguageChunking struct {
+	ChunkSize    int `mapstructure:"chunk_size"`
+	ChunkOverlap int `mapstructure:"chunk_overlap"`
 }
 
 func Load(configPath string) (*Config, error) {
@@ -55,12 +331,32 @@ func Load(configPath string) (*Config, error) {
 	// Defaults pour embeddings locaux
 	viper.SetDefault("server_name", "code-rag")
 	viper.SetDefault("server_version", "1.0.0")
+	viper.SetDefault("log_level", "info")
+	viper.SetDefault("log_format", "json")
+	viper.SetDefault("log_file", "")
+	viper.SetDefault("vectordb_type", "qdrant")
 	viper.SetDefault("qdrant_url", "localhost:6334")
+	viper.SetDefault("qdrant.distance", "cosine")
+	viper.SetDefault("qdrant.on_disk_payload", false)
+	viper.SetDefault("qdrant.sparse_vectors", false)
 	viper.SetDefault("collection_name", "code_embeddings")
 
 	// HTTP API defaults
 	viper.SetDefault("http_api_enabled", true)
 	viper.SetDefault("http_api_port", 9333)
+	viper.SetDefault("webhook_max_skew", "5m")
+	viper.SetDefault("drain_timeout", "25s")
+	viper.SetDefault("request_timeout", "2m")
+	viper.SetDefault("reindex_timeout", "30m")
+	viper.SetDefault("http_rate_limit", 10)
+	viper.SetDefault("http_rate_burst", 20)
+	viper.SetDefault("reindex_max_body", 1024*1024)
+	viper.SetDefault("reindex_max_files", 1000)
+	viper.SetDefault("reindex_max_concurrent", 2)
+
+	viper.SetDefault("transport", "stdio")
+	viper.SetDefault("sse_address", "127.0.0.1:9334")
+	viper.SetDefault("sse_base_url", "http://localhost:9334")
 
 	// Local embeddings par défaut
 	viper.SetDefault("embedding_type", "local")
@@ -69,12 +365,66 @@ func Load(configPath string) (*Config, error) {
 	viper.SetDefault("embedding_dim", 768) // nomic-embed default
 
 	viper.SetDefault("auto_index_on_startup", false)
-	viper.SetDefault("file_extensions", []string{".go", ".py", ".js", ".ts", ".tf", ".yaml", ".yml", ".md"})
+	viper.SetDefault("file_extensions", []string{
+		".go", ".py", ".js", ".jsx", ".ts", ".tsx", ".tf", ".yaml", ".yml", ".md",
+		".rb", ".php", ".kt", ".swift", ".scala", ".cs", ".sql", ".proto", ".graphql", ".vue", ".svelte", ".dart",
+		"Dockerfile", "Makefile", "Jenkinsfile",
+	})
 	viper.SetDefault("max_file_size", 1024*1024)
 	viper.SetDefault("chunk_size", 1000)
 	viper.SetDefault("chunk_overlap", 200)
+	viper.SetDefault("auto_migrate_chunks", true)
+	viper.SetDefault("dedup_chunks", true)
+	viper.SetDefault("scrub_secrets", true)
+	viper.SetDefault("store_content", true)
+	viper.SetDefault("compress_content", false)
+	viper.SetDefault("prune_interval", "0")
+	viper.SetDefault("reindex_schedule", "")
+	viper.SetDefault("overlay_interval", "0")
 	viper.SetDefault("top_k", 5)
 	viper.SetDefault("min_score", 0.7)
+	viper.SetDefault("search_timeout", "5s")
+	viper.SetDefault("hybrid_search", false)
+	viper.SetDefault("relative_result_paths", false)
+	viper.SetDefault("two_tier_search", false)
+	viper.SetDefault("two_tier_files", 20)
+	viper.SetDefault("result_dedup", true)
+	viper.SetDefault("result_dedup_threshold", 0.5)
+	viper.SetDefault("search_overfetch", 3)
+	viper.SetDefault("session_dedup", true)
+	viper.SetDefault("hot_file_cache", 50)
+	viper.SetDefault("ranking.path_boost", 0.05)
+	viper.SetDefault("ranking.filename_boost", 0.1)
+	viper.SetDefault("ranking.recency_weight", 0.1)
+	viper.SetDefault("ranking.recency_half_life", "17520h")
+	viper.SetDefault("search_params.hnsw_ef", 0)
+	viper.SetDefault("search_params.exact", false)
+	viper.SetDefault("lexical_fallback", true)
+	viper.SetDefault("generation.max_tokens", 1024)
+	viper.SetDefault("generation.temperature", 0.2)
+	viper.SetDefault("generation.context_tokens", 6000)
+	viper.SetDefault("search_log", true)
+	viper.SetDefault("search_log_retention", "720h")
+	viper.SetDefault("eval_queries", "eval_queries.yaml")
+	viper.SetDefault("trash_retention", "72h")
+	viper.SetDefault("leader_election.candidate", true)
+	viper.SetDefault("leader_election.lease_ttl", "30s")
+	viper.SetDefault("embedding_max_tokens", 8192)
+	viper.SetDefault("embedding_prefixes.query", "auto")
+	viper.SetDefault("embedding_prefixes.document", "auto")
+	viper.SetDefault("embedding_batch.max_size", 256)
+	viper.SetDefault("embedding_batch.max_tokens", 28000)
+	viper.SetDefault("embedding_batch.target_latency", "5s")
+	viper.SetDefault("embedding_breaker.failures", 3)
+	viper.SetDefault("embedding_breaker.probe_interval", "10s")
+	viper.SetDefault("embedding_breaker.max_wait", "30m")
+	viper.SetDefault("client_roots.enabled", true)
+	viper.SetDefault("client_roots.index", false)
+	viper.SetDefault("failed_file_retry.attempts", 3)
+	viper.SetDefault("failed_file_retry.backoff", "30s")
+	viper.SetDefault("event_bus.servers", []string{"nats://localhost:4222"})
+	viper.SetDefault("event_bus.topic", "code-rag.file-changes")
+	viper.SetDefault("event_bus.group", "code-rag")
 
 	viper.AutomaticEnv()
 
@@ -87,26 +437,255 @@ func Load(configPath string) (*Config, error) {
 	cfg := &Config{
 		ServerName:         viper.GetString("server_name"),
 		ServerVersion:      viper.GetString("server_version"),
+		LogLevel:           viper.GetString("log_level"),
+		LogFormat:          viper.GetString("log_format"),
+		LogFile:            viper.GetString("log_file"),
 		HTTPAPIEnabled:     viper.GetBool("http_api_enabled"),
 		HTTPAPIPort:        viper.GetInt("http_api_port"),
+		DrainTimeout:       viper.GetDuration("drain_timeout"),
+		RequestTimeout:     viper.GetDuration("request_timeout"),
+		ReindexTimeout:     viper.GetDuration("reindex_timeout"),
+		WebhookMaxSkew:     viper.GetDuration("webhook_max_skew"),
+		HTTPAPITLSCert:     viper.GetString("http_api_tls_cert"),
+		HTTPAPITLSKey:      viper.GetString("http_api_tls_key"),
+		HTTPAPIClientCA:    viper.GetString("http_api_client_ca"),
+		HTTPAPIClientCert:  viper.GetString("http_api_client_cert"),
+		HTTPAPIClientKey:   viper.GetString("http_api_client_key"),
+		Transport:          viper.GetString("transport"),
+		SSEAddress:         viper.GetString("sse_address"),
+		SSEBaseURL:         viper.GetString("sse_base_url"),
+		VectorDBType:       viper.GetString("vectordb_type"),
+		VectorDBOptions:    viper.GetStringMap("vectordb_options"),
 		QdrantURL:          viper.GetString("qdrant_url"),
-		QdrantAPIKey:       viper.GetString("qdrant_api_key"),
+		QdrantSecondaryURL: viper.GetString("qdrant_secondary_url"),
 		CollectionName:     viper.GetString("collection_name"),
 		EmbeddingType:      viper.GetString("embedding_type"),
 		EmbeddingModel:     viper.GetString("embedding_model"),
-		EmbeddingAPIKey:    viper.GetString("embedding_api_key"),
 		EmbeddingBaseURL:   viper.GetString("embedding_base_url"),
 		EmbeddingDim:       viper.GetInt("embedding_dim"),
+		EmbeddingOptions:   viper.GetStringMap("embedding_options"),
 		AutoIndexOnStartup: viper.GetBool("auto_index_on_startup"),
 		CodePaths:          viper.GetStringSlice("code_paths"),
 		FileExtensions:     viper.GetStringSlice("file_extensions"),
+		ExcludePatterns:    viper.GetStringSlice("exclude_patterns"),
+		IncludePatterns:    viper.GetStringSlice("include_patterns"),
 		MaxFileSize:        viper.GetInt64("max_file_size"),
 		ChunkSize:          viper.GetInt("chunk_size"),
 		ChunkOverlap:       viper.GetInt("chunk_overlap"),
+		AutoMigrateChunks:  viper.GetBool("auto_migrate_chunks"),
+		PruneInterval:      viper.GetDuration("prune_interval"),
+		ReindexSchedule:    viper.GetString("reindex_schedule"),
+		OverlayInterval:    viper.GetDuration("overlay_interval"),
+		ChunkHookPlugins:   viper.GetStringSlice("chunk_hook_plugins"),
+		DedupChunks:        viper.GetBool("dedup_chunks"),
+		EnrichmentTemplate: viper.GetString("enrichment_template"),
+		SensitiveFiles:     viper.GetStringSlice("sensitive_files"),
+		ScrubSecrets:       viper.GetBool("scrub_secrets"),
+		StoreContent:       viper.GetBool("store_content"),
+		CompressContent:    viper.GetBool("compress_content"),
 		TopK:               viper.GetInt("top_k"),
 		MinScore:           float32(viper.GetFloat64("min_score")),
+		SearchTimeout:      viper.GetDuration("search_timeout"),
+		HybridSearch:       viper.GetBool("hybrid_search"),
+		LexicalFallback:    viper.GetBool("lexical_fallback"),
+		RelativePaths:      viper.GetBool("relative_result_paths"),
+		TwoTierSearch:      viper.GetBool("two_tier_search"),
+		TwoTierFiles:       viper.GetInt("two_tier_files"),
+		SessionDedup:       viper.GetBool("session_dedup"),
+		HotFileCache:       viper.GetInt("hot_file_cache"),
+		SearchLog:          viper.GetBool("search_log"),
+		SearchLogRetention: viper.GetDuration("search_log_retention"),
+		TrashRetention:     viper.GetDuration("trash_retention"),
+		AllowedReadPaths:   viper.GetStringSlice("allowed_read_paths"),
 	}
 
+	if err := viper.UnmarshalKey("chunking", &cfg.Chunking); err != nil {
+		return nil, fmt.Errorf("invalid chunking config: %w", err)
+	}
+	if err := viper.UnmarshalKey("fault_injection", &cfg.FaultInjection); err != nil {
+		return nil, fmt.Errorf("invalid fault_injection config: %w", err)
+	}
+	if err := viper.UnmarshalKey("proxy_servers", &cfg.ProxyServers); err != nil {
+		return nil, fmt.Errorf("invalid proxy_servers config: %w", err)
+	}
+	if err := viper.UnmarshalKey("path_tags", &cfg.PathTags); err != nil {
+		return nil, fmt.Errorf("invalid path_tags config: %w", err)
+	}
+	for _, entry := range cfg.PathTags {
+		if entry.Path == "" {
+			return nil, fmt.Errorf("path_tags entries require a path")
+		}
+	}
+	if err := viper.UnmarshalKey("secret_patterns", &cfg.SecretPatterns); err != nil {
+		return nil, fmt.Errorf("invalid secret_patterns config: %w", err)
+	}
+	if (cfg.HTTPAPITLSCert == "") != (cfg.HTTPAPITLSKey == "") {
+		return nil, fmt.Errorf("http_api_tls_cert and http_api_tls_key must be set together")
+	}
+	if cfg.HTTPAPIClientCA != "" && cfg.HTTPAPITLSCert == "" {
+		return nil, fmt.Errorf("http_api_client_ca requires http_api_tls_cert and http_api_tls_key")
+	}
+	if (cfg.HTTPAPIClientCert == "") != (cfg.HTTPAPIClientKey == "") {
+		return nil, fmt.Errorf("http_api_client_cert and http_api_client_key must be set together")
+	}
+	cfg.HTTPRateLimit = viper.GetFloat64("http_rate_limit")
+	cfg.HTTPRateBurst = viper.GetInt("http_rate_burst")
+	cfg.ReindexMaxBody = viper.GetInt64("reindex_max_body")
+	cfg.ReindexMaxFiles = viper.GetInt("reindex_max_files")
+	cfg.ReindexMaxConcurrent = viper.GetInt("reindex_max_concurrent")
+	cfg.EvalQueries = viper.GetString("eval_queries")
+	cfg.ResultDedup = viper.GetBool("result_dedup")
+	cfg.ResultDedupThreshold = viper.GetFloat64("result_dedup_threshold")
+	if cfg.ResultDedupThreshold <= 0 || cfg.ResultDedupThreshold > 1 {
+		return nil, fmt.Errorf("result_dedup_threshold must be in (0, 1], got %v", cfg.ResultDedupThreshold)
+	}
+	cfg.SearchOverFetch = viper.GetInt("search_overfetch")
+	if cfg.SearchOverFetch < 1 {
+		return nil, fmt.Errorf("search_overfetch must be at least 1, got %d", cfg.SearchOverFetch)
+	}
+	if err := viper.UnmarshalKey("benchmark_embedders", &cfg.BenchmarkEmbedders); err != nil {
+		return nil, fmt.Errorf("invalid benchmark_embedders config: %w", err)
+	}
+	for i, embedder := range cfg.BenchmarkEmbedders {
+		if embedder.Name == "" || embedder.Type == "" {
+			return nil, fmt.Errorf("benchmark_embedders entries require a name and a type")
+		}
+		if embedder.APIKeyFile != "" {
+			data, err := os.ReadFile(embedder.APIKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read api_key_file of benchmark embedder %s: %w", embedder.Name, err)
+			}
+			cfg.BenchmarkEmbedders[i].APIKey = strings.TrimSpace(string(data))
+		}
+	}
+	if err := viper.UnmarshalKey("api_keys", &cfg.APIKeys); err != nil {
+		return nil, fmt.Errorf("invalid api_keys config: %w", err)
+	}
+	seenKeys := make(map[string]bool)
+	for i, key := range cfg.APIKeys {
+		if key.Name == "" {
+			return nil, fmt.Errorf("api_keys entries require a name")
+		}
+		if key.KeyFile != "" {
+			data, err := os.ReadFile(key.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key_file of api key %s: %w", key.Name, err)
+			}
+			cfg.APIKeys[i].Key = strings.TrimSpace(string(data))
+		}
+		if cfg.APIKeys[i].Key == "" {
+			return nil, fmt.Errorf("api key %s requires a key or key_file", key.Name)
+		}
+		if seenKeys[cfg.APIKeys[i].Key] {
+			return nil, fmt.Errorf("api key %s reuses the key of another entry", key.Name)
+		}
+		seenKeys[cfg.APIKeys[i].Key] = true
+	}
+	// Secrets can come from files, e.g. Kubernetes secrets mounted as volumes
+	for key, value := range map[string]*string{
+		"qdrant_api_key":           &cfg.QdrantAPIKey,
+		"qdrant_secondary_api_key": &cfg.QdrantSecondaryAPIKey,
+		"embedding_api_key":        &cfg.EmbeddingAPIKey,
+		"generation.api_key":       &cfg.Generation.APIKey,
+		"webhook_secret":           &cfg.WebhookSecret,
+	} {
+		secret, err := secretValue(key)
+		if err != nil {
+			return nil, err
+		}
+		*value = secret
+	}
+
+	// Read key by key: defaults of nested keys are lost when unmarshalling the section
+	cfg.LeaderElection = LeaderElection{
+		Enabled:    viper.GetBool("leader_election.enabled"),
+		InstanceID: viper.GetString("leader_election.instance_id"),
+		Candidate:  viper.GetBool("leader_election.candidate"),
+		LeaseTTL:   viper.GetDuration("leader_election.lease_ttl"),
+	}
+	cfg.EmbeddingMaxTokens = viper.GetInt("embedding_max_tokens")
+	if cfg.EmbeddingMaxTokens < 0 {
+		return nil, fmt.Errorf("embedding_max_tokens must not be negative")
+	}
+	cfg.EmbeddingPrefixes = EmbeddingPrefixes{
+		Query:    viper.GetString("embedding_prefixes.query"),
+		Document: viper.GetString("embedding_prefixes.document"),
+	}
+	cfg.EmbeddingBatch = EmbeddingBatch{
+		MaxSize:       viper.GetInt("embedding_batch.max_size"),
+		MaxTokens:     viper.GetInt("embedding_batch.max_tokens"),
+		TargetLatency: viper.GetDuration("embedding_batch.target_latency"),
+	}
+	if cfg.EmbeddingBatch.MaxSize < 1 || cfg.EmbeddingBatch.MaxTokens < 1 || cfg.EmbeddingBatch.TargetLatency <= 0 {
+		return nil, fmt.Errorf("embedding_batch: max_size, max_tokens and target_latency must be positive")
+	}
+	cfg.EmbeddingBreaker = EmbeddingBreaker{
+		Failures:      viper.GetInt("embedding_breaker.failures"),
+		ProbeInterval: viper.GetDuration("embedding_breaker.probe_interval"),
+		MaxWait:       viper.GetDuration("embedding_breaker.max_wait"),
+	}
+	if cfg.EmbeddingBreaker.Failures < 0 || cfg.EmbeddingBreaker.ProbeInterval <= 0 || cfg.EmbeddingBreaker.MaxWait < 0 {
+		return nil, fmt.Errorf("embedding_breaker: failures and max_wait must not be negative, probe_interval must be positive")
+	}
+	cfg.ClientRoots = ClientRoots{
+		Enabled: viper.GetBool("client_roots.enabled"),
+		Index:   viper.GetBool("client_roots.index"),
+	}
+	cfg.FailedFileRetry = FailedFileRetry{
+		Attempts: viper.GetInt("failed_file_retry.attempts"),
+		Backoff:  viper.GetDuration("failed_file_retry.backoff"),
+	}
+	if cfg.FailedFileRetry.Attempts < 0 || cfg.FailedFileRetry.Backoff < 0 {
+		return nil, fmt.Errorf("failed_file_retry: attempts and backoff must not be negative")
+	}
+	if cfg.LeaderElection.InstanceID == "" {
+		hostname, _ := os.Hostname()
+		cfg.LeaderElection.InstanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	cfg.EventBus = EventBus{
+		Type:    viper.GetString("event_bus.type"),
+		Servers: viper.GetStringSlice("event_bus.servers"),
+		Topic:   viper.GetString("event_bus.topic"),
+		Stream:  viper.GetString("event_bus.stream"),
+		Group:   viper.GetString("event_bus.group"),
+	}
+	cfg.Ranking = Ranking{
+		PathBoost:       float32(viper.GetFloat64("ranking.path_boost")),
+		FilenameBoost:   float32(viper.GetFloat64("ranking.filename_boost")),
+		RecencyWeight:   float32(viper.GetFloat64("ranking.recency_weight")),
+		RecencyHalfLife: viper.GetDuration("ranking.recency_half_life"),
+	}
+	if cfg.Ranking.RecencyWeight < 0 || cfg.Ranking.RecencyWeight > 1 {
+		return nil, fmt.Errorf("ranking.recency_weight must be between 0 and 1")
+	}
+	cfg.Qdrant = QdrantCollection{
+		Distance:             strings.ToLower(viper.GetString("qdrant.distance")),
+		OnDiskPayload:        viper.GetBool("qdrant.on_disk_payload"),
+		IndexingThreshold:    viper.GetInt("qdrant.optimizers.indexing_threshold"),
+		MemmapThreshold:      viper.GetInt("qdrant.optimizers.memmap_threshold"),
+		DefaultSegmentNumber: viper.GetInt("qdrant.optimizers.default_segment_number"),
+		SparseVectors:        viper.GetBool("qdrant.sparse_vectors"),
+	}
+	switch cfg.Qdrant.Distance {
+	case "cosine", "dot", "euclidean":
+	default:
+		return nil, fmt.Errorf("qdrant.distance must be cosine, dot or euclidean, got %q", cfg.Qdrant.Distance)
+	}
+	cfg.SearchParams = SearchParams{
+		HnswEf: viper.GetInt("search_params.hnsw_ef"),
+		Exact:  viper.GetBool("search_params.exact"),
+	}
+	if cfg.SearchParams.HnswEf < 0 {
+		return nil, fmt.Errorf("search_params.hnsw_ef must not be negative")
+	}
+
+	cfg.Generation.Type = viper.GetString("generation.type")
+	cfg.Generation.Model = viper.GetString("generation.model")
+	cfg.Generation.BaseURL = viper.GetString("generation.base_url")
+	cfg.Generation.MaxTokens = viper.GetInt("generation.max_tokens")
+	cfg.Generation.Temperature = float32(viper.GetFloat64("generation.temperature"))
+	cfg.Generation.ContextTokens = viper.GetInt("generation.context_tokens")
+
 	// Override from env
 	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
 		cfg.EmbeddingAPIKey = apiKey
@@ -123,3 +702,18 @@ func Load(configPath string) (*Config, error) {
 
 	return cfg, nil
 }
+
+// secretValue returns the setting key, read from the file named by
+// "<key>_file" when that is set
+func secretValue(key string) (string, error) {
+	path := viper.GetString(key + "_file")
+	if path == "" {
+		return viper.GetString(key), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s_file: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
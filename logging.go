@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newLogger builds the server logger from log_level, log_format and
+// log_file. Logs never go to stdout, which carries the MCP stdio transport.
+func newLogger(cfg *config.Config) (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log_level: %w", err)
+	}
+
+	var zapCfg zap.Config
+	switch cfg.LogFormat {
+	case "", "json":
+		zapCfg = zap.NewProductionConfig()
+	case "console":
+		zapCfg = zap.NewDevelopmentConfig()
+	default:
+		return nil, fmt.Errorf("invalid log_format %q: expected json or console", cfg.LogFormat)
+	}
+	zapCfg.Level = zap.NewAtomicLevelAt(level)
+	zapCfg.Development = false
+
+	if cfg.LogFile != "" {
+		zapCfg.OutputPaths = []string{cfg.LogFile}
+		zapCfg.ErrorOutputPaths = []string{cfg.LogFile, "stderr"}
+	}
+
+	return zapCfg.Build()
+}
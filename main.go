@@ -3,10 +3,9 @@ package main
 import (
 	"context"
 	"flag"
-	"net"
+	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -17,8 +16,9 @@ import (
 	"go.uber.org/zap"
 )
 
-// processPendingReindex checks for pending re-index requests from git hooks
-func processPendingReindex(workDir string, incrementalIndexer *rag.IncrementalIndexer, collectionName string, logger *zap.Logger) {
+// processPendingReindex checks for pending re-index requests from git hooks.
+// When ctx is canceled the marker file is kept, so the next run picks it up.
+func processPendingReindex(ctx context.Context, workDir string, incrementalIndexer *rag.IncrementalIndexer, collectionName string, logger *zap.Logger) {
 	markerFile := workDir + "/.code-rag-pending-reindex"
 
 	// Check if marker file exists
@@ -48,9 +48,12 @@ func processPendingReindex(workDir string, incrementalIndexer *rag.IncrementalIn
 	logger.Info("Re-indexing files from git hook", zap.Int("file_count", len(filePaths)))
 
 	// Re-index each file
-	ctx := context.Background()
 	successCount := 0
 	for _, filePath := range filePaths {
+		if ctx.Err() != nil {
+			logger.Info("Pending re-index interrupted, keeping marker file", zap.Int("success", successCount))
+			return
+		}
 		if err := incrementalIndexer.ReindexFiles(ctx, []string{filePath}, collectionName); err != nil {
 			logger.Error("Failed to re-index file", zap.String("file", filePath), zap.Error(err))
 		} else {
@@ -68,54 +71,93 @@ func processPendingReindex(workDir string, incrementalIndexer *rag.IncrementalIn
 	}
 }
 
+const usage = `Usage: code-rag-mcp [-config path] <command> [options]
+
+Commands:
+  serve              Run the MCP server over stdio (default when no command is given)
+  index [paths...]   Index directories (default: code_paths), resuming saved progress
+  search <query>     Search the index and print matches
+  stats              Print index statistics
+  benchmark_embedders [path]
+                     Compare benchmark_embedders on a sample of path against golden queries
+  evaluate_index     Score the index on eval_queries (recall, MRR, nDCG)
+  drain              Ask the server on this host to stop indexing before shutdown (preStop hook)
+  help               Show this help
+
+Run "code-rag-mcp <command> -h" for command options.
+`
+
 func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
 	configPath := flag.String("config", "", "Path to config file")
 	flag.Parse()
 
-	// Initialize logger
-	logger, _ := zap.NewProduction()
-	defer logger.Sync()
-
-	// Load configuration
-	cfg, err := config.Load(*configPath)
-	if err != nil {
-		logger.Fatal("Failed to load config", zap.Error(err))
+	command, args := "serve", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	logger.Info("Starting Code RAG MCP Server",
-		zap.String("embedding_type", cfg.EmbeddingType),
-		zap.String("embedding_model", cfg.EmbeddingModel),
-		zap.Int("embedding_dim", cfg.EmbeddingDim),
-	)
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(*configPath, args)
+	case "index":
+		err = runIndex(*configPath, args)
+	case "search":
+		err = runSearch(*configPath, args)
+	case "stats":
+		err = runStats(*configPath, args)
+	case "benchmark_embedders":
+		err = runBenchmarkEmbedders(*configPath, args)
+	case "evaluate_index":
+		err = runEvaluateIndex(*configPath, args)
+	case "drain":
+		err = runDrain(*configPath, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", command, usage)
+		os.Exit(2)
+	}
 
-	// Initialize embedder based on type
-	embedder, err := rag.NewEmbedder(
-		cfg.EmbeddingType,
-		cfg.EmbeddingModel,
-		cfg.EmbeddingAPIKey,
-		cfg.EmbeddingBaseURL,
-		cfg.EmbeddingDim,
-	)
 	if err != nil {
-		logger.Fatal("Failed to create embedder", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	logger.Info("Embedder initialized successfully", zap.Int("dimension", embedder.Dimension()))
+// runServe runs the MCP server (and the HTTP API if enabled) until interrupted
+func runServe(configPath string, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to config file")
+	leaderElection := fs.Bool("leader-election", false, "Index only while holding the leader lease (overrides leader_election.enabled)")
+	fs.Parse(args)
 
-	// Initialize vector database
-	// Parse Qdrant URL to extract host and port
-	host, portStr, err := net.SplitHostPort(cfg.QdrantURL)
+	// Load configuration
+	cfg, err := config.Load(configPath)
 	if err != nil {
-		logger.Fatal("Failed to parse Qdrant URL", zap.Error(err))
+		return fmt.Errorf("failed to load config: %w", err)
 	}
-	port, err := strconv.Atoi(portStr)
+
+	// Initialize logger
+	logger, err := newLogger(cfg)
 	if err != nil {
-		logger.Fatal("Failed to parse Qdrant port", zap.Error(err))
+		return err
+	}
+	defer logger.Sync()
+	if *leaderElection {
+		cfg.LeaderElection.Enabled = true
 	}
 
-	vectorDB, err := rag.NewQdrantDB(host, port, cfg.QdrantAPIKey)
+	logger.Info("Starting Code RAG MCP Server",
+		zap.String("embedding_type", cfg.EmbeddingType),
+		zap.String("embedding_model", cfg.EmbeddingModel),
+		zap.Int("embedding_dim", cfg.EmbeddingDim),
+	)
+
+	embedder, vectorDB, err := openBackends(cfg, logger)
 	if err != nil {
-		logger.Fatal("Failed to connect to Qdrant", zap.Error(err))
+		logger.Fatal("Failed to initialize backends", zap.Error(err))
 	}
 	defer vectorDB.Close()
 
@@ -125,44 +167,105 @@ func main() {
 		logger.Warn("Collection might already exist", zap.Error(err))
 	}
 
-	// Initialize indexer
-	indexer := rag.NewIndexer(embedder, vectorDB, logger)
-
-	// Initialize incremental indexer
+	// Initialize indexers
 	workDir, _ := os.Getwd()
-	incrementalIndexer := rag.NewIncrementalIndexer(indexer, workDir)
+	pathFilter := rag.PathFilter{Exclude: cfg.ExcludePatterns, Include: cfg.IncludePatterns}
+	indexer, incrementalIndexer, err := newIndexers(cfg, embedder, vectorDB, pathFilter, workDir, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize indexer", zap.Error(err))
+	}
 
-	// Process pending re-index requests from git hooks
-	processPendingReindex(workDir, incrementalIndexer, cfg.CollectionName, logger)
+	// Mixed embedding models, collection setups or chunkers make searches silently worse
+	if manifest, err := rag.ReadManifest(ctx, vectorDB, cfg.CollectionName); err != nil {
+		logger.Warn("Failed to read index manifest", zap.Error(err))
+	} else if manifest != nil {
+		for _, problem := range manifest.Problems(indexer.Setup()) {
+			logger.Error("Index manifest mismatch", zap.String("collection", cfg.CollectionName), zap.String("problem", problem))
+		}
+	}
 
-	// Auto-index configured paths in background (if enabled)
-	go func() {
-		if cfg.AutoIndexOnStartup && len(cfg.CodePaths) > 0 {
-			logger.Info("Starting background indexing", zap.Strings("paths", cfg.CodePaths))
+	// Indexing duties: pending git hook requests, chunker migration and
+	// auto-indexing. With leader election only the leader runs them, and they
+	// stop when leadership is lost.
+	startIndexing := func(ctx context.Context) {
+		// Process pending re-index requests from git hooks
+		processPendingReindex(ctx, workDir, incrementalIndexer, cfg.CollectionName, logger)
+
+		// Migrate chunks produced by an older chunker in background (if enabled).
+		// Searches prefer the newest chunks per file while this runs.
+		if cfg.AutoMigrateChunks {
+			go func() {
+				if _, err := indexer.MigrateChunkerVersion(ctx, cfg.CollectionName); err != nil {
+					logger.Error("Chunker migration failed", zap.Error(err))
+				}
+			}()
+		}
 
-			for _, path := range cfg.CodePaths {
-				if _, err := os.Stat(path); os.IsNotExist(err) {
-					logger.Warn("Skipping non-existent path", zap.String("path", path))
-					continue
+		// Apply path_tags edits to chunks indexed before them
+		if len(cfg.PathTags) > 0 {
+			go func() {
+				for _, entry := range cfg.PathTags {
+					if _, err := indexer.RetagPath(ctx, entry.Path, cfg.CollectionName); err != nil {
+						logger.Warn("Failed to update path tags", zap.String("path", entry.Path), zap.Error(err))
+					}
 				}
+			}()
+		}
 
-				logger.Info("Indexing path", zap.String("path", path))
-				if err := incrementalIndexer.IndexDirectoryIncremental(
-					context.Background(),
-					path,
-					cfg.FileExtensions,
-					cfg.CollectionName,
-				); err != nil {
-					logger.Error("Background indexing failed", zap.String("path", path), zap.Error(err))
+		// Record the code path of chunks indexed before roots were stored
+		go func() {
+			if _, err := indexer.RecordRoots(ctx, cfg.CollectionName); err != nil {
+				logger.Warn("Failed to update roots of indexed files", zap.Error(err))
+			}
+		}()
+
+		// Auto-index configured paths in background (if enabled)
+		go func() {
+			if cfg.AutoIndexOnStartup && len(cfg.CodePaths) > 0 {
+				logger.Info("Starting background indexing", zap.Strings("paths", cfg.CodePaths))
+
+				for _, path := range cfg.CodePaths {
+					if _, err := os.Stat(path); os.IsNotExist(err) {
+						logger.Warn("Skipping non-existent path", zap.String("path", path))
+						continue
+					}
+
+					logger.Info("Indexing path", zap.String("path", path))
+					if err := incrementalIndexer.IndexDirectoryIncremental(
+						ctx,
+						path,
+						cfg.FileExtensions,
+						cfg.CollectionName,
+					); err != nil {
+						logger.Error("Background indexing failed", zap.String("path", path), zap.Error(err))
+					}
 				}
+
+				logger.Info("Background indexing complete")
 			}
+		}()
+	}
 
-			logger.Info("Background indexing complete")
-		}
-	}()
+	// Canceled on shutdown, stopping background indexing
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lease *rag.Lease
+	if election := cfg.LeaderElection; election.Enabled {
+		lease = rag.NewLease(vectorDB, cfg.CollectionName+rag.LeaseCollectionSuffix, election.InstanceID, election.LeaseTTL, logger)
+		logger.Info("Leader election enabled",
+			zap.String("instance", election.InstanceID),
+			zap.Bool("candidate", election.Candidate),
+		)
+	} else {
+		startIndexing(ctx)
+	}
 
 	// Create MCP server
 	mcpServer := server.NewRAGServer(indexer, incrementalIndexer, vectorDB, embedder, cfg, logger)
+	if lease != nil {
+		mcpServer.SetLeaderLease(lease)
+	}
 
 	// Start HTTP API server if enabled
 	var httpAPIServer *server.HTTPAPIServer
@@ -180,17 +283,28 @@ func main() {
 	}
 
 	// Start server
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if lease != nil && cfg.LeaderElection.Candidate {
+		go lease.Run(ctx, startIndexing)
+	}
 
 	// Handle shutdown gracefully
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	stopping, stopped := make(chan struct{}), make(chan struct{})
 	go func() {
 		<-sigChan
+		close(stopping)
+		defer close(stopped)
 		logger.Info("Shutting down gracefully...")
-		
+
+		// Stop indexing and hand it over to another instance
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+		defer drainCancel()
+		if err := mcpServer.Drain(drainCtx); err != nil {
+			logger.Warn("Indexing did not stop in time", zap.Error(err))
+		}
+
 		// Stop HTTP API server
 		if httpAPIServer != nil {
 			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -199,7 +313,7 @@ func main() {
 				logger.Error("Failed to stop HTTP API server", zap.Error(err))
 			}
 		}
-		
+
 		cancel()
 	}()
 
@@ -207,4 +321,162 @@ func main() {
 	if err := mcpServer.Serve(ctx); err != nil {
 		logger.Fatal("Server error", zap.Error(err))
 	}
+
+	// The stdio transport stops on signals by itself: finish draining first
+	select {
+	case <-stopping:
+		<-stopped
+	default:
+	}
+	return nil
+}
+
+// languageChunking converts the per-language chunking config for the indexer
+func languageChunking(chunking map[string]config.LanguageChunking) map[string]rag.LanguageChunking {
+	languages := make(map[string]rag.LanguageChunking, len(chunking))
+	for language, c := range chunking {
+		languages[language] = rag.LanguageChunking{ChunkSize: c.ChunkSize, ChunkOverlap: c.ChunkOverlap}
+	}
+	return languages
+}
+
+// newIndexers creates the indexers configured by cfg, indexing files matching
+// filter and saving progress in workDir. The server and the CLI share it, so
+// chunks indexed by either are scrubbed, filtered and embedded alike.
+func newIndexers(cfg *config.Config, embedder rag.Embedder, vectorDB rag.VectorDB, filter rag.PathFilter, workDir string, logger *zap.Logger) (*rag.Indexer, *rag.IncrementalIndexer, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid exclude_patterns or include_patterns: %w", err)
+	}
+	if err := rag.ValidatePathPatterns(cfg.SensitiveFiles); err != nil {
+		return nil, nil, fmt.Errorf("invalid sensitive_files: %w", err)
+	}
+
+	indexer := rag.NewIndexer(embedder, vectorDB, logger)
+	indexer.SetPathFilter(filter)
+	indexer.SetRoots(cfg.CodePaths)
+	indexer.SetSensitiveFiles(append(append([]string{}, rag.DefaultSensitiveFiles...), cfg.SensitiveFiles...))
+	indexer.SetFileExtensions(cfg.FileExtensions)
+	indexer.SetChunkDedup(cfg.DedupChunks)
+	if cfg.EnrichmentTemplate != "" {
+		if err := indexer.SetEnrichmentTemplate(cfg.EnrichmentTemplate); err != nil {
+			return nil, nil, fmt.Errorf("invalid enrichment_template: %w", err)
+		}
+	}
+	indexer.SetEmbeddingModel(cfg.EmbeddingModel)
+	indexer.SetDistance(cfg.Qdrant.Distance)
+	indexer.SetMaxEmbedTokens(cfg.EmbeddingMaxTokens)
+	indexer.SetEmbeddingPrefixes(rag.EmbeddingPrefixes(cfg.EmbeddingPrefixes).Resolve(cfg.EmbeddingModel))
+	indexer.SetFileSummaries(cfg.TwoTierSearch)
+	indexer.SetStoreContent(cfg.StoreContent)
+	indexer.SetCompressContent(cfg.CompressContent)
+	if cfg.Qdrant.SparseVectors {
+		indexer.SetSparseEncoder(rag.BM25Encoder{})
+	}
+	if cfg.ScrubSecrets {
+		detectors := rag.DefaultSecretDetectors()
+		for _, pattern := range cfg.SecretPatterns {
+			detector, err := rag.NewSecretDetector(pattern.Name, pattern.Pattern)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid secret_patterns: %w", err)
+			}
+			detectors = append(detectors, detector)
+		}
+		indexer.SetSecretScrubber(rag.NewSecretScrubber(detectors...))
+	}
+	indexer.SetChunking(rag.ChunkingConfig{
+		ChunkSize:    cfg.ChunkSize,
+		ChunkOverlap: cfg.ChunkOverlap,
+		MaxFileSize:  cfg.MaxFileSize,
+		Languages:    languageChunking(cfg.Chunking),
+	})
+	for _, path := range cfg.ChunkHookPlugins {
+		hook, err := rag.LoadChunkHookPlugin(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load chunk hook plugin: %w", err)
+		}
+		indexer.AddChunkHooks(hook)
+	}
+	for _, entry := range cfg.PathTags {
+		indexer.SetPathTags(entry.Path, entry.Tags)
+	}
+
+	incrementalIndexer := rag.NewIncrementalIndexer(indexer, workDir)
+	incrementalIndexer.SetRetryPolicy(rag.RetryPolicy(cfg.FailedFileRetry))
+	return indexer, incrementalIndexer, nil
+}
+
+// openBackends creates the embedder and connects to the vector database,
+// wrapping both with fault injection when it is enabled. Embeddings are
+// always validated, and go through a circuit breaker unless it is disabled.
+func openBackends(cfg *config.Config, logger *zap.Logger) (rag.Embedder, rag.VectorDB, error) {
+	// Initialize embedder based on type
+	embedder, err := rag.NewEmbedderFromConfig(cfg.EmbeddingType, rag.EmbedderConfig{
+		Model:     cfg.EmbeddingModel,
+		APIKey:    cfg.EmbeddingAPIKey,
+		BaseURL:   cfg.EmbeddingBaseURL,
+		Dimension: cfg.EmbeddingDim,
+		Options:   cfg.EmbeddingOptions,
+		Batch:     rag.BatchOptions(cfg.EmbeddingBatch),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+
+	if local, ok := embedder.(*rag.LocalEmbedder); ok && local.Model() != cfg.EmbeddingModel {
+		logger.Warn("Configured embedding model not served, using the only embedding model available",
+			zap.String("configured", cfg.EmbeddingModel), zap.String("model", local.Model()))
+		cfg.EmbeddingModel = local.Model()
+	}
+	logger.Info("Embedder initialized successfully", zap.String("model", cfg.EmbeddingModel), zap.Int("dimension", embedder.Dimension()))
+
+	vectorDB, err := rag.NewVectorDB(cfg.VectorDBType, rag.VectorDBConfig{
+		URL:        cfg.QdrantURL,
+		APIKey:     cfg.QdrantAPIKey,
+		Options:    cfg.VectorDBOptions,
+		Collection: rag.CollectionOptions(cfg.Qdrant),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.QdrantSecondaryURL != "" {
+		secondary, err := rag.NewVectorDB(cfg.VectorDBType, rag.VectorDBConfig{
+			URL:        cfg.QdrantSecondaryURL,
+			APIKey:     cfg.QdrantSecondaryAPIKey,
+			Options:    cfg.VectorDBOptions,
+			Collection: rag.CollectionOptions(cfg.Qdrant),
+		})
+		if err != nil {
+			vectorDB.Close()
+			return nil, nil, fmt.Errorf("failed to connect to secondary vector database: %w", err)
+		}
+		logger.Info("Mirroring the index to a secondary vector database", zap.String("url", cfg.QdrantSecondaryURL))
+		vectorDB = rag.NewFailoverDB(vectorDB, secondary, logger)
+	}
+
+	// Fault injection goes under the breaker, so injected outages trip it
+	wrap := func(embedder rag.Embedder) rag.Embedder {
+		if breaker := cfg.EmbeddingBreaker; breaker.Failures > 0 {
+			embedder = rag.NewCircuitBreakerEmbedder(embedder, rag.BreakerConfig(breaker), logger)
+		}
+		return rag.NewValidatingEmbedder(embedder, logger)
+	}
+
+	if faults := cfg.FaultInjection; faults.Enabled {
+		logger.Warn("Fault injection enabled: embedder and Qdrant calls will fail at random",
+			zap.Float64("embedder_timeout_rate", faults.EmbedderTimeoutRate),
+			zap.Float64("vectordb_error_rate", faults.VectorDBErrorRate),
+			zap.Float64("partial_batch_rate", faults.PartialBatchRate),
+		)
+		injector := rag.NewFaultInjector(rag.FaultConfig{
+			Seed:                faults.Seed,
+			EmbedderTimeoutRate: faults.EmbedderTimeoutRate,
+			EmbedderTimeout:     faults.EmbedderTimeout,
+			VectorDBErrorRate:   faults.VectorDBErrorRate,
+			PartialBatchRate:    faults.PartialBatchRate,
+		}, logger)
+		return wrap(injector.Embedder(embedder)), injector.VectorDB(vectorDB), nil
+	}
+
+	return wrap(embedder), vectorDB, nil
 }
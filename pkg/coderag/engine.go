@@ -0,0 +1,457 @@
+// Package coderag embeds the code-RAG engine in Go programs: index code
+// directories and search them semantically without running the MCP server.
+//
+//	engine, err := coderag.New(ctx, coderag.WithConfig(cfg))
+//	if err != nil {
+//		return err
+//	}
+//	defer engine.Close()
+//
+//	if err := engine.Index(ctx, "/path/to/project"); err != nil {
+//		return err
+//	}
+//	results, err := engine.Search(ctx, "auth middleware", coderag.Limit(10))
+package coderag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"go.uber.org/zap"
+)
+
+// DefaultCollection is the collection used when none is configured
+const DefaultCollection = "code_embeddings"
+
+// Engine indexes and searches code. It is safe to search concurrently; index
+// calls share resumable progress state and should not overlap.
+type Engine struct {
+	embedder   rag.Embedder
+	vectorDB   rag.VectorDB
+	logger     *zap.Logger
+	collection string
+	fileTypes  []string
+	filter     rag.PathFilter
+	chunking   rag.ChunkingConfig
+	stateDir   string
+	topK       int
+	minScore   float32
+	hooks      []rag.ChunkHook
+	dedup      bool
+	sensitive  []string               // File name globs never indexed, added to the built-in ones
+	scrub      bool                   // Mask secrets before embedding
+	store      bool                   // Store chunk content in payloads
+	compress   bool                   // Store it zstd-compressed
+	detectors  []rag.SecretDetector   // Added to the built-in secret detectors
+	patterns   []config.SecretPattern // Detectors of the config, compiled by New
+	ownsDB     bool                   // Close the vector database on Close
+	results    rag.DedupOptions       // Deduplication of search results
+	params     rag.SearchParams       // Vector index settings of searches
+	model      string                 // Embedding model recorded in the index manifest
+
+	cfg         *config.Config // Backends to create when not given directly
+	indexer     *rag.Indexer
+	incremental *rag.IncrementalIndexer
+}
+
+// Option configures an Engine
+type Option func(*Engine)
+
+// WithConfig takes backends, settings and chunk hook plugins from a loaded
+// configuration. Options given after it override its values.
+func WithConfig(cfg *config.Config) Option {
+	return func(e *Engine) {
+		e.cfg = cfg
+		e.collection = cfg.CollectionName
+		e.fileTypes = cfg.FileExtensions
+		e.filter = rag.PathFilter{Exclude: cfg.ExcludePatterns, Include: cfg.IncludePatterns}
+		e.topK = cfg.TopK
+		e.minScore = cfg.MinScore
+		e.dedup = cfg.DedupChunks
+		e.sensitive = cfg.SensitiveFiles
+		e.scrub = cfg.ScrubSecrets
+		e.store = cfg.StoreContent
+		e.compress = cfg.CompressContent
+		e.patterns = cfg.SecretPatterns
+		e.model = cfg.EmbeddingModel
+		e.results = rag.DedupOptions{
+			Disabled:  !cfg.ResultDedup,
+			Threshold: cfg.ResultDedupThreshold,
+			OverFetch: cfg.SearchOverFetch,
+		}
+		e.params = rag.SearchParams{HnswEf: cfg.SearchParams.HnswEf, Exact: cfg.SearchParams.Exact}
+
+		languages := make(map[string]rag.LanguageChunking, len(cfg.Chunking))
+		for language, c := range cfg.Chunking {
+			languages[language] = rag.LanguageChunking{ChunkSize: c.ChunkSize, ChunkOverlap: c.ChunkOverlap}
+		}
+		e.chunking = rag.ChunkingConfig{
+			ChunkSize:    cfg.ChunkSize,
+			ChunkOverlap: cfg.ChunkOverlap,
+			MaxFileSize:  cfg.MaxFileSize,
+			Languages:    languages,
+		}
+	}
+}
+
+// WithEmbedder sets the embedder
+func WithEmbedder(embedder rag.Embedder) Option {
+	return func(e *Engine) { e.embedder = embedder }
+}
+
+// WithVectorDB sets the vector database. The caller keeps ownership: Close
+// does not close it.
+func WithVectorDB(db rag.VectorDB) Option {
+	return func(e *Engine) { e.vectorDB = db }
+}
+
+// WithCollection sets the collection name
+func WithCollection(name string) Option {
+	return func(e *Engine) { e.collection = name }
+}
+
+// WithFileTypes sets the extensions (".go") and file names ("Dockerfile") to index
+func WithFileTypes(fileTypes ...string) Option {
+	return func(e *Engine) { e.fileTypes = fileTypes }
+}
+
+// WithPathFilter sets glob patterns excluding or restricting indexed paths
+func WithPathFilter(filter rag.PathFilter) Option {
+	return func(e *Engine) { e.filter = filter }
+}
+
+// WithChunking sets chunk sizes
+func WithChunking(chunking rag.ChunkingConfig) Option {
+	return func(e *Engine) { e.chunking = chunking }
+}
+
+// WithChunkDedup enables or disables skipping chunks whose content is
+// already indexed from another file (default: enabled)
+func WithChunkDedup(enabled bool) Option {
+	return func(e *Engine) { e.dedup = enabled }
+}
+
+// WithSensitiveFiles adds file name globs never indexed to the built-in deny
+// list (rag.DefaultSensitiveFiles)
+func WithSensitiveFiles(patterns ...string) Option {
+	return func(e *Engine) { e.sensitive = append(e.sensitive, patterns...) }
+}
+
+// WithStoreContent sets whether chunk content is stored in the vector
+// database (default: true); without it content is read from disk on search
+func WithStoreContent(enabled bool) Option {
+	return func(e *Engine) { e.store = enabled }
+}
+
+// WithCompressContent sets whether chunk content is stored zstd-compressed
+// (default: false)
+func WithCompressContent(enabled bool) Option {
+	return func(e *Engine) { e.compress = enabled }
+}
+
+// WithSecretScrubbing enables or disables masking secrets (keys, tokens,
+// private keys) in chunks before they are embedded and stored (default:
+// enabled), with detectors added to the built-in ones
+func WithSecretScrubbing(enabled bool, detectors ...rag.SecretDetector) Option {
+	return func(e *Engine) {
+		e.scrub = enabled
+		e.detectors = append(e.detectors, detectors...)
+	}
+}
+
+// WithChunkHooks adds hooks run on every chunk before it is embedded
+func WithChunkHooks(hooks ...rag.ChunkHook) Option {
+	return func(e *Engine) { e.hooks = append(e.hooks, hooks...) }
+}
+
+// WithStateDir sets where resumable indexing progress is saved (default: working directory)
+func WithStateDir(dir string) Option {
+	return func(e *Engine) { e.stateDir = dir }
+}
+
+// WithLogger sets the logger (default: no logging)
+func WithLogger(logger *zap.Logger) Option {
+	return func(e *Engine) { e.logger = logger }
+}
+
+// WithSearchDefaults sets the default result count and minimum score of Search
+func WithSearchDefaults(topK int, minScore float32) Option {
+	return func(e *Engine) {
+		e.topK = topK
+		e.minScore = minScore
+	}
+}
+
+// WithResultDedup sets how Search deduplicates overlapping results of the
+// same file and how many extra points it matches to make up for them
+func WithResultDedup(opts rag.DedupOptions) Option {
+	return func(e *Engine) { e.results = opts }
+}
+
+// WithSearchParams sets the HNSW settings of Search, trading latency for
+// recall on large collections
+func WithSearchParams(params rag.SearchParams) Option {
+	return func(e *Engine) { e.params = params }
+}
+
+// New creates an engine and makes sure its collection exists. An embedder
+// and a vector database are required, given directly or through WithConfig.
+func New(ctx context.Context, opts ...Option) (*Engine, error) {
+	e := &Engine{
+		logger:     zap.NewNop(),
+		collection: DefaultCollection,
+		chunking:   rag.DefaultChunking,
+		topK:       5,
+		dedup:      true,
+		scrub:      true,
+		store:      true,
+		results:    rag.DefaultDedup,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if err := e.openBackends(); err != nil {
+		return nil, err
+	}
+	if err := e.filter.Validate(); err != nil {
+		e.Close()
+		return nil, err
+	}
+	if err := rag.ValidatePathPatterns(e.sensitive); err != nil {
+		e.Close()
+		return nil, err
+	}
+	if e.stateDir == "" {
+		e.stateDir, _ = os.Getwd()
+	}
+
+	if _, err := e.vectorDB.GetCollectionInfo(ctx, e.collection); err != nil {
+		if err := e.vectorDB.CreateCollection(ctx, e.collection, e.embedder.Dimension()); err != nil {
+			e.Close()
+			return nil, fmt.Errorf("failed to create collection %s: %w", e.collection, err)
+		}
+	}
+
+	e.indexer = rag.NewIndexer(e.embedder, e.vectorDB, e.logger)
+	e.indexer.SetPathFilter(e.filter)
+	e.indexer.SetChunking(e.chunking)
+	e.indexer.SetChunkDedup(e.dedup)
+	e.indexer.SetStoreContent(e.store)
+	e.indexer.SetCompressContent(e.compress)
+	e.indexer.SetSensitiveFiles(append(append([]string{}, rag.DefaultSensitiveFiles...), e.sensitive...))
+	if e.scrub {
+		detectors := append(rag.DefaultSecretDetectors(), e.detectors...)
+		for _, pattern := range e.patterns {
+			detector, err := rag.NewSecretDetector(pattern.Name, pattern.Pattern)
+			if err != nil {
+				e.Close()
+				return nil, err
+			}
+			detectors = append(detectors, detector)
+		}
+		e.indexer.SetSecretScrubber(rag.NewSecretScrubber(detectors...))
+	}
+	e.indexer.AddChunkHooks(e.hooks...)
+	if e.cfg != nil {
+		e.indexer.SetEmbeddingModel(e.model)
+		e.indexer.SetDistance(e.cfg.Qdrant.Distance)
+		e.indexer.SetRoots(e.cfg.CodePaths)
+		e.indexer.SetMaxEmbedTokens(e.cfg.EmbeddingMaxTokens)
+		e.indexer.SetEmbeddingPrefixes(rag.EmbeddingPrefixes(e.cfg.EmbeddingPrefixes).Resolve(e.model))
+		if e.cfg.EnrichmentTemplate != "" {
+			if err := e.indexer.SetEnrichmentTemplate(e.cfg.EnrichmentTemplate); err != nil {
+				e.Close()
+				return nil, err
+			}
+		}
+		if e.cfg.Qdrant.SparseVectors {
+			e.indexer.SetSparseEncoder(rag.BM25Encoder{})
+		}
+		for _, path := range e.cfg.ChunkHookPlugins {
+			hook, err := rag.LoadChunkHookPlugin(path)
+			if err != nil {
+				e.Close()
+				return nil, err
+			}
+			e.indexer.AddChunkHooks(hook)
+		}
+	}
+	e.incremental = rag.NewIncrementalIndexer(e.indexer, e.stateDir)
+	if e.cfg != nil {
+		e.incremental.SetRetryPolicy(rag.RetryPolicy(e.cfg.FailedFileRetry))
+	}
+	return e, nil
+}
+
+// openBackends creates the backends missing from the options from the config
+func (e *Engine) openBackends() error {
+	if e.embedder == nil {
+		if e.cfg == nil {
+			return errors.New("coderag: an embedder is required (WithEmbedder or WithConfig)")
+		}
+		embedder, err := rag.NewEmbedderFromConfig(e.cfg.EmbeddingType, rag.EmbedderConfig{
+			Model:     e.cfg.EmbeddingModel,
+			APIKey:    e.cfg.EmbeddingAPIKey,
+			BaseURL:   e.cfg.EmbeddingBaseURL,
+			Dimension: e.cfg.EmbeddingDim,
+			Options:   e.cfg.EmbeddingOptions,
+			Batch:     rag.BatchOptions(e.cfg.EmbeddingBatch),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create embedder: %w", err)
+		}
+		if local, ok := embedder.(*rag.LocalEmbedder); ok && local.Model() != e.model {
+			e.logger.Warn("Configured embedding model not served, using the only embedding model available",
+				zap.String("configured", e.model), zap.String("model", local.Model()))
+			e.model = local.Model()
+		}
+		if breaker := e.cfg.EmbeddingBreaker; breaker.Failures > 0 {
+			embedder = rag.NewCircuitBreakerEmbedder(embedder, rag.BreakerConfig(breaker), e.logger)
+		}
+		e.embedder = rag.NewValidatingEmbedder(embedder, e.logger)
+	}
+
+	if e.vectorDB == nil {
+		if e.cfg == nil {
+			return errors.New("coderag: a vector database is required (WithVectorDB or WithConfig)")
+		}
+		db, err := rag.NewVectorDB(e.cfg.VectorDBType, rag.VectorDBConfig{
+			URL:        e.cfg.QdrantURL,
+			APIKey:     e.cfg.QdrantAPIKey,
+			Options:    e.cfg.VectorDBOptions,
+			Collection: rag.CollectionOptions(e.cfg.Qdrant),
+		})
+		if err != nil {
+			return err
+		}
+		if e.cfg.QdrantSecondaryURL != "" {
+			secondary, err := rag.NewVectorDB(e.cfg.VectorDBType, rag.VectorDBConfig{
+				URL:        e.cfg.QdrantSecondaryURL,
+				APIKey:     e.cfg.QdrantSecondaryAPIKey,
+				Options:    e.cfg.VectorDBOptions,
+				Collection: rag.CollectionOptions(e.cfg.Qdrant),
+			})
+			if err != nil {
+				db.Close()
+				return fmt.Errorf("failed to connect to secondary vector database: %w", err)
+			}
+			db = rag.NewFailoverDB(db, secondary, e.logger)
+		}
+		e.vectorDB = db
+		e.ownsDB = true
+	}
+	return nil
+}
+
+// Index indexes directories, resuming an interrupted run of the same path
+// and skipping files whose content is already indexed
+func (e *Engine) Index(ctx context.Context, paths ...string) error {
+	for _, path := range paths {
+		if err := e.incremental.IndexDirectoryIncremental(ctx, path, e.fileTypes, e.collection); err != nil {
+			return fmt.Errorf("failed to index %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Reindex re-indexes specific files, e.g. after they changed; deleted files
+// are removed from the index
+func (e *Engine) Reindex(ctx context.Context, files ...string) error {
+	return e.indexer.ReindexFiles(ctx, files, e.collection)
+}
+
+// SearchOption configures one Search call
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	limit    int
+	offset   int
+	minScore float32
+	lexical  bool
+}
+
+// Limit sets the number of results
+func Limit(n int) SearchOption {
+	return func(o *searchOptions) { o.limit = n }
+}
+
+// Offset skips the first n results, for paging
+func Offset(n int) SearchOption {
+	return func(o *searchOptions) { o.offset = n }
+}
+
+// MinScore sets the minimum similarity (0-1)
+func MinScore(score float32) SearchOption {
+	return func(o *searchOptions) { o.minScore = score }
+}
+
+// LexicalFallback falls back to BM25 keyword search when the embedder fails
+func LexicalFallback() SearchOption {
+	return func(o *searchOptions) { o.lexical = true }
+}
+
+// SearchResults are the matches of a search
+type SearchResults struct {
+	Results  []rag.SearchResult
+	Degraded bool // Embedder unavailable; Results are lexical (BM25) matches
+}
+
+// Search finds the chunks closest in meaning to query
+func (e *Engine) Search(ctx context.Context, query string, opts ...SearchOption) (*SearchResults, error) {
+	o := searchOptions{limit: e.topK, minScore: e.minScore}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	embedding, err := e.embedder.Embed(ctx, e.indexer.QueryText(query))
+	if err != nil {
+		if !o.lexical {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		e.logger.Warn("Embedding failed, falling back to lexical search", zap.Error(err))
+		results, err := rag.LexicalSearch(ctx, e.vectorDB, e.collection, nil, query, o.offset+o.limit, e.results)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+		if o.offset < len(results) {
+			results = results[o.offset:]
+		} else {
+			results = nil
+		}
+		return &SearchResults{Results: results, Degraded: true}, nil
+	}
+
+	results, err := e.vectorDB.Search(ctx, e.collection, nil, embedding, o.limit, o.offset, o.minScore, rag.SearchOptions{Dedup: e.results, Params: e.params})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	return &SearchResults{Results: results}, nil
+}
+
+// Embedder returns the engine's embedder
+func (e *Engine) Embedder() rag.Embedder {
+	return e.embedder
+}
+
+// VectorDB returns the engine's vector database
+func (e *Engine) VectorDB() rag.VectorDB {
+	return e.vectorDB
+}
+
+// Indexer returns the engine's indexer, for lower-level operations
+func (e *Engine) Indexer() *rag.Indexer {
+	return e.indexer
+}
+
+// Close releases the vector database if the engine created it
+func (e *Engine) Close() error {
+	if e.ownsDB && e.vectorDB != nil {
+		return e.vectorDB.Close()
+	}
+	return nil
+}
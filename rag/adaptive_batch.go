@@ -0,0 +1,132 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// initialBatchSize is the batch size an adaptive embedder starts from
+const initialBatchSize = 20
+
+// BatchOptions bound the batches an embedding server is sent. Batches start
+// at initialBatchSize texts and adapt to the server: they grow while requests
+// return well within TargetLatency, shrink when they take longer, and are
+// halved when the server rejects one as too large.
+type BatchOptions struct {
+	MaxSize       int           // Most texts per request
+	MaxTokens     int           // Most estimated tokens per request
+	TargetLatency time.Duration // Request duration batches grow towards
+}
+
+// DefaultBatch matches the config defaults
+var DefaultBatch = BatchOptions{MaxSize: 256, MaxTokens: 28000, TargetLatency: 5 * time.Second}
+
+// normalized replaces unset values by defaults
+func (o BatchOptions) normalized() BatchOptions {
+	if o.MaxSize <= 0 {
+		o.MaxSize = DefaultBatch.MaxSize
+	}
+	if o.MaxTokens <= 0 {
+		o.MaxTokens = DefaultBatch.MaxTokens
+	}
+	if o.TargetLatency <= 0 {
+		o.TargetLatency = DefaultBatch.TargetLatency
+	}
+	return o
+}
+
+// statusError is a non-200 response of an embedding server
+type statusError struct {
+	server string
+	code   int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.server, e.code, e.body)
+}
+
+// batchTooLarge reports whether err is the server rejecting a request it
+// could not fit in its context, so a smaller batch may succeed
+func batchTooLarge(err error) bool {
+	var status *statusError
+	if !errors.As(err, &status) {
+		return false
+	}
+	switch status.code {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// batchSizer adapts the batch size of an embedder to its server's latency
+// and limits. It is shared by concurrent calls.
+type batchSizer struct {
+	options BatchOptions
+	tokens  func(texts []string) int // Estimated tokens of a request
+
+	mu       sync.Mutex
+	size     int
+	rejected int // Smallest request size rejected as too large (0 = none)
+}
+
+func newBatchSizer(options BatchOptions, tokens func(texts []string) int) *batchSizer {
+	options = options.normalized()
+	return &batchSizer{options: options, tokens: tokens, size: min(initialBatchSize, options.MaxSize)}
+}
+
+// next returns how many of texts to send in the next request: the current
+// batch size, fewer to stay under MaxTokens, at least one
+func (b *batchSizer) next(texts []string) int {
+	b.mu.Lock()
+	n := min(b.size, len(texts))
+	b.mu.Unlock()
+
+	for n > 1 {
+		tokens := b.tokens(texts[:n])
+		if tokens <= b.options.MaxTokens {
+			break
+		}
+		n = max(1, min(n-1, n*b.options.MaxTokens/tokens))
+	}
+	return n
+}
+
+// succeeded records that a request of n texts took latency: full batches
+// returned in under half the target latency double the size, or close half
+// the gap to the smallest rejected size, and requests over it shrink the size
+// by a quarter
+func (b *batchSizer) succeeded(n int, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case latency > b.options.TargetLatency:
+		b.size = max(1, b.size*3/4)
+	case n >= b.size && latency < b.options.TargetLatency/2:
+		grown := min(b.options.MaxSize, b.size*2)
+		if b.rejected > 0 {
+			grown = min(grown, (b.size+b.rejected)/2)
+		}
+		b.size = max(b.size, grown)
+	}
+}
+
+// tooLarge records that a request of n texts was rejected as too large. It
+// returns the size to retry with, 0 when a single text is already too large.
+func (b *batchSizer) tooLarge(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rejected == 0 || n < b.rejected {
+		b.rejected = n
+	}
+	b.size = max(1, min(b.size, n/2))
+	return b.size
+}
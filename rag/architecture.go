@@ -0,0 +1,275 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ArchitectureSuffix names the collection holding generated directory
+// summaries, next to the collection of code, so code searches never see them
+const ArchitectureSuffix = "_architecture"
+
+// ArchitectureCollection returns the summary collection of a collection
+func ArchitectureCollection(collection string) string {
+	return collection + ArchitectureSuffix
+}
+
+// DirectoryStats aggregates the indexed chunks under a directory
+type DirectoryStats struct {
+	Path       string // Absolute directory
+	Root       string // Code path containing it ("" when outside all code paths)
+	Files      int
+	Chunks     int
+	Languages  map[string]int // Chunks per language
+	TopSymbols []RankedSymbol
+	FileNames  []string // Files of the directory, relative to it
+	Summary    *DirectorySummary
+}
+
+// RankedSymbol is a symbol defined in a directory with the number of other
+// files using its name
+type RankedSymbol struct {
+	Symbol
+	FilePath string
+	UsedBy   int
+}
+
+// DirectorySummary is a generated description of a directory
+type DirectorySummary struct {
+	Text        string
+	Files       int // Files of the directory when it was generated
+	GeneratedAt time.Time
+}
+
+// ArchitectureOverview groups the indexed chunks by directory, depth levels
+// below the code path containing each file (files outside roots are grouped by
+// their own directory). Only files under the given paths are included, all when
+// none are given. Each directory lists its topSymbols symbols used by the most
+// other files of the index.
+func ArchitectureOverview(ctx context.Context, db VectorDB, collection string, roots, under []string, depth, topSymbols int) ([]*DirectoryStats, error) {
+	dirs := make(map[string]*DirectoryStats)
+	files := make(map[string]string)           // file -> directory key
+	defined := make(map[string][]RankedSymbol) // directory -> symbols defined
+	usedBy := make(map[string]map[string]bool) // identifier -> files using it
+
+	fields := []string{"file_path", "language", "symbol_defs", "identifiers"}
+	err := db.Scroll(ctx, collection, nil, fields, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		// Uses count across the whole index, whatever the scope
+		if idents, ok := point.Payload["identifiers"].([]interface{}); ok {
+			for _, ident := range idents {
+				name, _ := ident.(string)
+				if usedBy[name] == nil {
+					usedBy[name] = make(map[string]bool)
+				}
+				usedBy[name][filePath] = true
+			}
+		}
+		if !underAny(filePath, under) {
+			return nil
+		}
+
+		dirPath, ok := files[filePath]
+		if !ok {
+			root := rootOf(filePath, roots)
+			dirPath = directoryAtDepth(filePath, root, depth)
+			files[filePath] = dirPath
+			if dirs[dirPath] == nil {
+				dirs[dirPath] = &DirectoryStats{Path: dirPath, Root: root, Languages: make(map[string]int)}
+			}
+			dir := dirs[dirPath]
+			dir.Files++
+			if rel, err := filepath.Rel(dirPath, filePath); err == nil {
+				dir.FileNames = append(dir.FileNames, rel)
+			}
+		}
+
+		dir := dirs[dirPath]
+		dir.Chunks++
+		language, _ := point.Payload["language"].(string)
+		dir.Languages[language]++
+
+		for _, sym := range symbolsFromPayload(point.Payload) {
+			defined[dirPath] = append(defined[dirPath], RankedSymbol{Symbol: sym, FilePath: filePath})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	overview := make([]*DirectoryStats, 0, len(dirs))
+	for dirPath, dir := range dirs {
+		symbols := defined[dirPath]
+		for i := range symbols {
+			for user := range usedBy[symbols[i].Name] {
+				if user != symbols[i].FilePath {
+					symbols[i].UsedBy++
+				}
+			}
+		}
+		sort.SliceStable(symbols, func(i, j int) bool {
+			if symbols[i].UsedBy != symbols[j].UsedBy {
+				return symbols[i].UsedBy > symbols[j].UsedBy
+			}
+			return symbols[i].Name < symbols[j].Name
+		})
+		for _, sym := range symbols {
+			if len(dir.TopSymbols) >= topSymbols {
+				break
+			}
+			if !containsRankedSymbol(dir.TopSymbols, sym.Name) {
+				dir.TopSymbols = append(dir.TopSymbols, sym)
+			}
+		}
+		sort.Strings(dir.FileNames)
+		overview = append(overview, dir)
+	}
+
+	sort.Slice(overview, func(i, j int) bool { return overview[i].Path < overview[j].Path })
+	return overview, nil
+}
+
+// rootOf returns the code path containing filePath, or ""
+func rootOf(filePath string, roots []string) string {
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if strings.HasPrefix(filePath, root+string(os.PathSeparator)) {
+			return root
+		}
+	}
+	return ""
+}
+
+// underAny reports whether filePath is under one of paths (true when none)
+func underAny(filePath string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if filePath == path || strings.HasPrefix(filePath, path+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// directoryAtDepth returns the directory of filePath cut depth levels below root
+func directoryAtDepth(filePath, root string, depth int) string {
+	dir := filepath.Dir(filePath)
+	if root == "" {
+		return dir
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return root
+	}
+	parts := strings.Split(rel, string(os.PathSeparator))
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return filepath.Join(append([]string{root}, parts...)...)
+}
+
+func containsRankedSymbol(symbols []RankedSymbol, name string) bool {
+	for _, sym := range symbols {
+		if sym.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DirectorySummaries reads the stored summaries of collection, by directory.
+// It fails when no summary was ever stored (no summary collection).
+func DirectorySummaries(ctx context.Context, db VectorDB, collection string) (map[string]*DirectorySummary, error) {
+	summaries := make(map[string]*DirectorySummary)
+	fields := []string{"file_path", "content", "summary_files", "generated_at"}
+	err := db.Scroll(ctx, ArchitectureCollection(collection), nil, fields, func(point StoredPoint) error {
+		dirPath, _ := point.Payload["file_path"].(string)
+		summary := &DirectorySummary{Files: payloadInt(point.Payload["summary_files"])}
+		summary.Text, _ = point.Payload["content"].(string)
+		if raw, ok := point.Payload["generated_at"].(string); ok {
+			summary.GeneratedAt, _ = time.Parse(time.RFC3339, raw)
+		}
+		summaries[dirPath] = summary
+		return nil
+	})
+	return summaries, err
+}
+
+// StoreDirectorySummaries embeds the summaries of dirs and stores them as
+// chunks of the summary collection of collection, replacing earlier ones
+func (idx *Indexer) StoreDirectorySummaries(ctx context.Context, collection string, dirs []*DirectoryStats) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	target := ArchitectureCollection(collection)
+	if err := idx.vectorDB.CreateCollection(ctx, target, idx.embedder.Dimension()); err != nil {
+		idx.logger.Debug("Architecture collection might already exist", zap.Error(err))
+	}
+
+	texts := make([]string, len(dirs))
+	for i, dir := range dirs {
+		texts[i] = dir.Path + "\n" + dir.Summary.Text
+	}
+	embeddings, err := idx.embedBatch(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed summaries: %w", err)
+	}
+
+	points := make([]Point, len(dirs))
+	for i, dir := range dirs {
+		points[i] = Point{
+			ID:     ChunkPointID(dir.Path, 0),
+			Vector: embeddings[i],
+			Payload: map[string]interface{}{
+				"file_path":     dir.Path,
+				"content":       dir.Summary.Text,
+				"language":      "summary",
+				"summary_files": dir.Summary.Files,
+				"generated_at":  dir.Summary.GeneratedAt.UTC().Format(time.RFC3339),
+			},
+		}
+	}
+	return idx.vectorDB.Upsert(ctx, target, points)
+}
+
+// directorySummaryInstructions is the system prompt of DirectorySummaryMessages
+const directorySummaryInstructions = `You describe one directory of a codebase for developers new to it, from its file names and main symbols.
+Answer in 2-3 sentences: what the directory is responsible for and how the rest of the code uses it.
+Do not list every file, and do not invent behavior the names do not suggest.`
+
+// DirectorySummaryMessages builds the conversation asking a generator to summarize dir
+func DirectorySummaryMessages(dir *DirectoryStats, maxFiles int) []ChatMessage {
+	var facts strings.Builder
+	facts.WriteString(fmt.Sprintf("Directory: %s\n", dir.Path))
+	facts.WriteString(fmt.Sprintf("Files (%d):\n", dir.Files))
+	for i, name := range dir.FileNames {
+		if i == maxFiles {
+			facts.WriteString(fmt.Sprintf("- ... %d more\n", len(dir.FileNames)-i))
+			break
+		}
+		facts.WriteString("- " + name + "\n")
+	}
+	if len(dir.TopSymbols) > 0 {
+		facts.WriteString("Main symbols (used by other files):\n")
+		for _, sym := range dir.TopSymbols {
+			facts.WriteString(fmt.Sprintf("- %s %s in %s (used by %d files)\n", sym.Kind, sym.Name, filepath.Base(sym.FilePath), sym.UsedBy))
+		}
+	}
+
+	return []ChatMessage{
+		{Role: "system", Content: directorySummaryInstructions},
+		{Role: "user", Content: facts.String()},
+	}
+}
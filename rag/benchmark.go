@@ -0,0 +1,247 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// benchmarkCollection is the throwaway collection embedders are benchmarked in
+const benchmarkCollection = "benchmark"
+
+// GoldenQuery is a query and the code a good search returns for it, shared
+// by benchmark_embedders and evaluate_index
+type GoldenQuery struct {
+	Query string       `yaml:"query"`
+	Files []GoldenFile `yaml:"-"`
+	Tags  []string     `yaml:"tags"` // Scope the search like the tags argument of searches
+}
+
+// GoldenFile is a file, or a range of lines of it, a query should return.
+// A result matches it when it comes from the file and overlaps the lines.
+type GoldenFile struct {
+	File      string
+	LineStart int // 0: anywhere in the file
+	LineEnd   int
+}
+
+func (g GoldenFile) String() string {
+	if g.LineStart == 0 {
+		return g.File
+	}
+	return fmt.Sprintf("%s:%d-%d", g.File, g.LineStart, g.LineEnd)
+}
+
+// matches reports whether result returns the golden code
+func (g GoldenFile) matches(result SearchResult) bool {
+	if result.FilePath != g.File {
+		return false
+	}
+	return g.LineStart == 0 || (result.LineStart <= g.LineEnd && result.LineEnd >= g.LineStart)
+}
+
+// LoadGoldenQueries reads golden queries from a JSON or YAML file of the form
+// [{"query": "...", "files": ["auth/middleware.go", "auth/token.go:12-40"], "tags": ["backend"]}]:
+// files are matched anywhere, or on the given lines; tags are optional.
+// Relative files are resolved against root.
+func LoadGoldenQueries(path, root string) ([]GoldenQuery, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// YAML is a superset of JSON
+	var raw []struct {
+		GoldenQuery `yaml:",inline"`
+		Files       []string `yaml:"files"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid golden queries %s: %w", path, err)
+	}
+
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	queries := make([]GoldenQuery, len(raw))
+	for i, q := range raw {
+		if q.Query == "" || len(q.Files) == 0 {
+			return nil, fmt.Errorf("golden query %d needs a query and at least one file", i+1)
+		}
+		queries[i] = q.GoldenQuery
+		for _, spec := range q.Files {
+			file, err := parseGoldenFile(spec, root)
+			if err != nil {
+				return nil, fmt.Errorf("golden query %q: %w", q.Query, err)
+			}
+			queries[i].Files = append(queries[i].Files, file)
+		}
+	}
+	return queries, nil
+}
+
+// parseGoldenFile parses "file" or "file:start-end"
+func parseGoldenFile(spec, root string) (GoldenFile, error) {
+	var golden GoldenFile
+	file := spec
+	if i := strings.LastIndex(spec, ":"); i > 0 {
+		if start, end, ok := strings.Cut(spec[i+1:], "-"); ok {
+			s, errStart := strconv.Atoi(start)
+			e, errEnd := strconv.Atoi(end)
+			if errStart != nil || errEnd != nil || s < 1 || e < s {
+				return golden, fmt.Errorf("invalid line range in %q", spec)
+			}
+			file, golden.LineStart, golden.LineEnd = spec[:i], s, e
+		}
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, file)
+	}
+	golden.File = filepath.Clean(file)
+	return golden, nil
+}
+
+// BenchmarkSample returns the files of root an embedder benchmark indexes:
+// every file of queries, plus up to sample other indexable files picked at
+// random with seed, so runs with the same seed compare the same files
+func (idx *Indexer) BenchmarkSample(root string, extensions []string, queries []GoldenQuery, sample int, seed int64) ([]string, error) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	files, err := idx.collectFiles(root, extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	golden := make(map[string]bool)
+	for _, q := range queries {
+		for _, file := range q.Files {
+			golden[file.File] = true
+		}
+	}
+
+	var picked, others []string
+	for file := range golden {
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("golden file %s: %w", file, err)
+		}
+		picked = append(picked, file)
+	}
+	for _, file := range files {
+		if !golden[file] {
+			others = append(others, file)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+	if sample >= 0 && len(others) > sample {
+		others = others[:sample]
+	}
+
+	picked = append(picked, others...)
+	sort.Strings(picked)
+	return picked, nil
+}
+
+// EmbedderBenchmark is how well one embedder finds the golden files
+type EmbedderBenchmark struct {
+	Name      string        `json:"name"`
+	Chunks    int           `json:"chunks"`
+	Ks        []int         `json:"ks"`
+	Recall    []float64     `json:"recall"`        // Recall@K for each of Ks: share of golden files in the first K files, averaged over queries
+	MRR       float64       `json:"mrr"`           // Mean reciprocal rank of the first golden file
+	IndexTime time.Duration `json:"index_time_ns"` // Embedding and storing all chunks
+	QueryTime time.Duration `json:"query_time_ns"` // Mean per query, embedding included
+}
+
+// BenchmarkEmbedder indexes files with embedder into a throwaway in-memory
+// collection, chunked like idx, and measures recall@k of queries against it.
+// Documents and queries get the prefixes of the embedder's model.
+func (idx *Indexer) BenchmarkEmbedder(ctx context.Context, name string, embedder Embedder, prefixes EmbeddingPrefixes, files []string, queries []GoldenQuery, ks []int) (*EmbedderBenchmark, error) {
+	db := NewMemoryDB()
+	if err := db.CreateCollection(ctx, benchmarkCollection, embedder.Dimension()); err != nil {
+		return nil, err
+	}
+	bench := NewIndexer(embedder, db, idx.logger)
+	bench.SetChunking(idx.chunking)
+	bench.SetPathFilter(idx.filter)
+	bench.SetChunkDedup(idx.dedup)
+	bench.SetEmbeddingPrefixes(prefixes)
+	bench.enrichment = idx.enrichment
+
+	started := time.Now()
+	if err := bench.ReindexFiles(ctx, files, benchmarkCollection); err != nil {
+		return nil, fmt.Errorf("%s: indexing failed: %w", name, err)
+	}
+	result := &EmbedderBenchmark{
+		Name:      name,
+		Ks:        ks,
+		Recall:    make([]float64, len(ks)),
+		IndexTime: time.Since(started),
+	}
+	count, err := db.Count(ctx, benchmarkCollection, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Chunks = int(count)
+
+	var queryTime time.Duration
+	for _, q := range queries {
+		started := time.Now()
+		vector, err := embedder.Embed(ctx, bench.QueryText(q.Query))
+		if err != nil {
+			return nil, fmt.Errorf("%s: embedding %q failed: %w", name, q.Query, err)
+		}
+		// Files have several chunks: rank every chunk so each K sees K files
+		results, err := db.Search(ctx, benchmarkCollection, nil, vector, result.Chunks, 0, -1, SearchOptions{})
+		if err != nil {
+			return nil, err
+		}
+		queryTime += time.Since(started)
+
+		// Scored like evaluate_index, on the best chunk of each file
+		ranked := bestPerFile(results)
+		for i, k := range ks {
+			result.Recall[i] += EvaluateResults(q, ranked, k).Recall
+		}
+		result.MRR += EvaluateResults(q, ranked, len(ranked)).ReciprocalRank
+	}
+
+	if len(queries) > 0 {
+		for i := range result.Recall {
+			result.Recall[i] /= float64(len(queries))
+		}
+		result.MRR /= float64(len(queries))
+		result.QueryTime = queryTime / time.Duration(len(queries))
+	}
+
+	idx.logger.Info("Benchmarked embedder",
+		zap.String("embedder", name),
+		zap.Int("chunks", result.Chunks),
+		zap.Float64("mrr", result.MRR),
+		zap.Duration("index_time", result.IndexTime),
+	)
+	return result, nil
+}
+
+// bestPerFile keeps the first result of each file, so the K first results
+// are K distinct files
+func bestPerFile(results []SearchResult) []SearchResult {
+	seen := make(map[string]bool)
+	var best []SearchResult
+	for _, r := range results {
+		if !seen[r.FilePath] {
+			seen[r.FilePath] = true
+			best = append(best, r)
+		}
+	}
+	return best
+}
@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ContentHash identifies chunk content, to find copies of it in other files
+func ContentHash(content string) string {
+	return HashContent([]byte(content))
+}
+
+// SetChunkDedup enables or disables skipping chunks whose content is already
+// indexed from another file (enabled by default)
+func (idx *Indexer) SetChunkDedup(enabled bool) {
+	idx.dedup = enabled
+}
+
+// dropDuplicateChunks removes chunks whose content is already stored for
+// another file, or appears earlier in the batch for another file, so vendored
+// copies, generated files and license headers are embedded once. Only copies
+// with the same root and tags count, so searches scoped to either still find
+// the content. Copies within one file are kept, as are overlay chunks, which
+// must shadow their file completely. On lookup errors every chunk is kept.
+func (idx *Indexer) dropDuplicateChunks(ctx context.Context, chunks []CodeChunk, collectionName string) []CodeChunk {
+	if !idx.dedup {
+		return chunks
+	}
+
+	hashes := make([]string, len(chunks))
+	var lookup []string
+	for i, chunk := range chunks {
+		hashes[i] = ContentHash(chunk.Content)
+		if !chunk.Dirty {
+			lookup = append(lookup, hashes[i])
+		}
+		// Copies only count within the same scope
+		hashes[i] += "\x00" + dedupScope(idx.RootFor(chunk.FilePath), idx.TagsFor(chunk.FilePath))
+	}
+	if len(lookup) == 0 {
+		return chunks
+	}
+
+	// Files already holding each hash, by scope
+	stored := make(map[string]map[string]bool)
+	err := idx.vectorDB.Scroll(ctx, collectionName, map[string]interface{}{
+		"content_hash": lookup,
+	}, []string{"file_path", "content_hash", "root", "tags"}, func(p StoredPoint) error {
+		hash, _ := p.Payload["content_hash"].(string)
+		filePath, _ := p.Payload["file_path"].(string)
+		root, _ := p.Payload["root"].(string)
+		hash += "\x00" + dedupScope(root, payloadStrings(p.Payload["tags"]))
+		if stored[hash] == nil {
+			stored[hash] = make(map[string]bool)
+		}
+		stored[hash][filePath] = true
+		return nil
+	})
+	if err != nil {
+		idx.logger.Warn("Failed to look up duplicate chunks", zap.Error(err))
+		return chunks
+	}
+
+	kept := chunks[:0]
+	seen := make(map[string]string, len(chunks)) // Hash and scope -> first file in the batch
+	skipped := 0
+	for i, chunk := range chunks {
+		if !chunk.Dirty {
+			hash := hashes[i]
+			if isCopy(stored[hash], chunk.FilePath) {
+				skipped++
+				continue
+			}
+			if first, ok := seen[hash]; ok && first != chunk.FilePath {
+				skipped++
+				continue
+			} else if !ok {
+				seen[hash] = chunk.FilePath
+			}
+		}
+		kept = append(kept, chunk)
+	}
+
+	if skipped > 0 {
+		idx.logger.Debug("Skipped duplicate chunks", zap.Int("skipped", skipped), zap.Int("kept", len(kept)))
+	}
+	return kept
+}
+
+// dedupScope identifies the root and tags of a chunk, whatever the order of
+// its tags
+func dedupScope(root string, tags []string) string {
+	tags = slices.Clone(tags)
+	slices.Sort(tags)
+	return root + "\x00" + strings.Join(tags, "\x00")
+}
+
+// isCopy reports whether files other than filePath hold the content
+func isCopy(files map[string]bool, filePath string) bool {
+	for file := range files {
+		if file != filePath {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package rag
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// ChunkHook transforms a chunk after chunking and before embedding, e.g. to
+// redact secrets, add Metadata or enrich Content. Returning false drops the
+// chunk. Hooks run in the order they were added, each on the previous
+// hook's output.
+type ChunkHook func(CodeChunk) (CodeChunk, bool)
+
+// ChunkHookSymbol is the name under which a Go plugin exports its hook:
+//
+//	func ChunkHook(chunk rag.CodeChunk) (rag.CodeChunk, bool)
+const ChunkHookSymbol = "ChunkHook"
+
+// AddChunkHooks appends hooks to the chain run on every indexed chunk.
+// Call it before indexing starts.
+func (idx *Indexer) AddChunkHooks(hooks ...ChunkHook) {
+	idx.hooks = append(idx.hooks, hooks...)
+}
+
+// applyChunkHooks runs the hook chain over chunks, dropping rejected ones
+func (idx *Indexer) applyChunkHooks(chunks []CodeChunk) []CodeChunk {
+	if len(idx.hooks) == 0 {
+		return chunks
+	}
+
+	kept := make([]CodeChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		keep := true
+		for _, hook := range idx.hooks {
+			if chunk, keep = hook(chunk); !keep {
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, chunk)
+		}
+	}
+	return kept
+}
+
+// LoadChunkHookPlugin opens a Go plugin (built with -buildmode=plugin against
+// the same version of this module) and returns its ChunkHook
+func LoadChunkHookPlugin(path string) (ChunkHook, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chunk hook plugin %s: %w", path, err)
+	}
+	sym, err := p.Lookup(ChunkHookSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("chunk hook plugin %s: %w", path, err)
+	}
+
+	switch hook := sym.(type) {
+	case func(CodeChunk) (CodeChunk, bool):
+		return hook, nil
+	case *ChunkHook:
+		return *hook, nil
+	case *func(CodeChunk) (CodeChunk, bool):
+		return *hook, nil
+	default:
+		return nil, fmt.Errorf("chunk hook plugin %s: %s has type %T, want func(rag.CodeChunk) (rag.CodeChunk, bool)", path, ChunkHookSymbol, sym)
+	}
+}
@@ -0,0 +1,171 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestChunkFileLineRanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		chunkSize int
+		// lines as an editor numbers them, line 1 first
+		lines []string
+		// wantRanges are the [LineStart, LineEnd] of the chunks, in order
+		wantRanges [][2]int
+	}{
+		{
+			name:       "LF with trailing newline",
+			file:       "main.go",
+			content:    "package main\n\nfunc main() {}\n",
+			lines:      []string{"package main", "", "func main() {}"},
+			wantRanges: [][2]int{{1, 3}},
+		},
+		{
+			name:       "no trailing newline",
+			file:       "main.go",
+			content:    "package main\n\nfunc main() {}",
+			lines:      []string{"package main", "", "func main() {}"},
+			wantRanges: [][2]int{{1, 3}},
+		},
+		{
+			name:       "CRLF",
+			file:       "main.go",
+			content:    "package main\r\n\r\nfunc main() {}\r\n",
+			lines:      []string{"package main", "", "func main() {}"},
+			wantRanges: [][2]int{{1, 3}},
+		},
+		{
+			name:       "CRLF without trailing newline",
+			file:       "main.go",
+			content:    "package main\r\n\r\nfunc main() {}",
+			lines:      []string{"package main", "", "func main() {}"},
+			wantRanges: [][2]int{{1, 3}},
+		},
+		{
+			name:       "lone CR",
+			file:       "main.go",
+			content:    "package main\r\rfunc main() {}\r",
+			lines:      []string{"package main", "", "func main() {}"},
+			wantRanges: [][2]int{{1, 3}},
+		},
+		{
+			name:       "UTF-8 BOM",
+			file:       "main.go",
+			content:    "\xEF\xBB\xBFpackage main\n",
+			lines:      []string{"package main"},
+			wantRanges: [][2]int{{1, 1}},
+		},
+		{
+			// Line endings do not count towards the chunk size twice
+			name:       "CRLF split into windows",
+			file:       "notes.txt",
+			content:    "line1\r\nline2\r\nline3\r\nline4\r\nline5\r\nline6\r\n",
+			chunkSize:  12,
+			lines:      []string{"line1", "line2", "line3", "line4", "line5", "line6"},
+			wantRanges: [][2]int{{1, 2}, {3, 4}, {5, 6}},
+		},
+		{
+			name:       "leading blank lines",
+			file:       "main.go",
+			content:    "\n\nfunc main() {}\n",
+			lines:      []string{"", "", "func main() {}"},
+			wantRanges: [][2]int{{1, 3}},
+		},
+		{
+			// The blank window is dropped without shifting the next one
+			name:       "leading blank lines in their own window",
+			file:       "main.go",
+			content:    "\n\n\nfunc main() {}\n",
+			chunkSize:  5,
+			lines:      []string{"", "", "", "func main() {}"},
+			wantRanges: [][2]int{{4, 4}},
+		},
+		{
+			name:       "Terraform block after blank lines, CRLF, no trailing newline",
+			file:       "main.tf",
+			content:    "\r\n\r\nresource \"aws_vpc\" \"main\" {\r\n  cidr_block = \"10.0.0.0/16\"\r\n}",
+			lines:      []string{"", "", `resource "aws_vpc" "main" {`, `  cidr_block = "10.0.0.0/16"`, "}"},
+			wantRanges: [][2]int{{3, 5}},
+		},
+		{
+			name:       "markdown with CRLF",
+			file:       "README.md",
+			content:    "\r\n# Title\r\n\r\nSome text.\r\n",
+			lines:      []string{"", "# Title", "", "Some text."},
+			wantRanges: [][2]int{{2, 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			idx := NewIndexer(nil, nil, zap.NewNop())
+			if tt.chunkSize > 0 {
+				idx.SetChunking(ChunkingConfig{ChunkSize: tt.chunkSize, MaxFileSize: DefaultChunking.MaxFileSize})
+			}
+
+			chunks, err := idx.chunkFile(filePath)
+			if err != nil {
+				t.Fatalf("chunkFile: %v", err)
+			}
+
+			var ranges [][2]int
+			for _, chunk := range chunks {
+				ranges = append(ranges, [2]int{chunk.LineStart, chunk.LineEnd})
+				if chunk.LineStart < 1 || chunk.LineEnd < chunk.LineStart || chunk.LineEnd > len(tt.lines) {
+					t.Errorf("chunk lines %d-%d outside the file's %d lines", chunk.LineStart, chunk.LineEnd, len(tt.lines))
+					continue
+				}
+				// What an editor shows at the reported lines
+				want := strings.Join(tt.lines[chunk.LineStart-1:chunk.LineEnd], "\n")
+				if chunk.Content != want {
+					t.Errorf("chunk lines %d-%d content = %q, want %q", chunk.LineStart, chunk.LineEnd, chunk.Content, want)
+				}
+			}
+			if !slices.Equal(ranges, tt.wantRanges) {
+				t.Errorf("chunk ranges = %v, want %v", ranges, tt.wantRanges)
+			}
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty", "", []string{""}},
+		{"LF", "a\nb\n", []string{"a", "b"}},
+		{"no trailing newline", "a\nb", []string{"a", "b"}},
+		{"CRLF", "a\r\nb\r\n", []string{"a", "b"}},
+		{"lone CR", "a\rb\r", []string{"a", "b"}},
+		{"mixed endings", "a\r\nb\rc\nd", []string{"a", "b", "c", "d"}},
+		{"leading blank lines", "\n\na\n", []string{"", "", "a"}},
+		{"trailing blank line", "a\n\n", []string{"a", ""}},
+		{"UTF-8 BOM", "\xEF\xBB\xBFa\nb\n", []string{"a", "b"}},
+		{"UTF-16LE BOM", "\xFF\xFEa\x00\r\x00\n\x00b\x00", []string{"a", "b"}},
+		{"UTF-16BE BOM", "\xFE\xFF\x00a\x00\n\x00b", []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitLines([]byte(tt.content))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SplitLines(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
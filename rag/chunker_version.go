@@ -0,0 +1,115 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// ChunkerVersion identifies the chunking logic that produced a point.
+// Bump it whenever chunk boundaries or payload fields change so existing
+// collections get migrated. Points without a version predate versioning.
+//
+//	1: fixed 50-line windows
+//	2: symbol definitions in payload
+//	3: referenced identifiers in payload
+//	4: character-sized windows from chunk_size/chunk_overlap
+//	5: BOM stripped, CRLF and CR line endings normalized
+//	6: markdown chunked on headings, with the heading hierarchy in payload
+//	7: terraform chunked per top-level block, with its address in payload
+//	8: secrets masked in content, including chunks indexed by the CLI
+//	9: enclosing definition (parent) in payload
+const ChunkerVersion = 9
+
+// preferNewestChunks drops results from files that also have results
+// produced by a newer chunker, so old and new chunks for the same lines
+// are never returned together while a migration is in flight.
+func preferNewestChunks(results []SearchResult) []SearchResult {
+	newest := make(map[string]int)
+	for _, r := range results {
+		if r.ChunkerVersion > newest[r.FilePath] {
+			newest[r.FilePath] = r.ChunkerVersion
+		}
+	}
+
+	filtered := results[:0]
+	for _, r := range results {
+		if r.ChunkerVersion == newest[r.FilePath] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// MigrateChunkerVersion re-chunks every file whose points were produced by an
+// older chunker. New chunks are upserted before the old ones are deleted, so
+// the file stays searchable during the migration. Files indexing now leaves
+// out (deleted, or excluded by the filters, ignore files or the sensitive
+// list) only lose their old chunks. Returns the number of files migrated.
+func (idx *Indexer) MigrateChunkerVersion(ctx context.Context, collectionName string) (int, error) {
+	stale := make(map[string][]string) // file -> outdated point IDs
+
+	err := idx.vectorDB.Scroll(ctx, collectionName, nil, []string{"file_path", "chunker_version"}, func(point StoredPoint) error {
+		if payloadInt(point.Payload["chunker_version"]) >= ChunkerVersion {
+			return nil
+		}
+		filePath, _ := point.Payload["file_path"].(string)
+		stale[filePath] = append(stale[filePath], point.ID)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(stale) == 0 {
+		idx.recordChunkerVersion(ctx, collectionName)
+		return 0, nil
+	}
+
+	files := make([]string, 0, len(stale))
+	for filePath := range stale {
+		files = append(files, filePath)
+	}
+	sort.Strings(files)
+
+	idx.logger.Info("Migrating chunks to current chunker version",
+		zap.Int("chunker_version", ChunkerVersion),
+		zap.Int("files", len(files)),
+	)
+
+	migrated := 0
+	matchers := make(map[string]*pathMatcher)
+	for _, filePath := range files {
+		if err := ctx.Err(); err != nil {
+			return migrated, err
+		}
+
+		if _, err := os.Stat(filePath); err == nil && idx.indexable(filePath, matchers) {
+			chunks, err := idx.chunkFile(filePath)
+			if err != nil {
+				idx.logger.Warn("Failed to chunk file during migration", zap.String("file", filePath), zap.Error(err))
+				continue
+			}
+
+			if err := idx.indexChunks(ctx, chunks, collectionName); err != nil {
+				idx.logger.Warn("Failed to index file during migration", zap.String("file", filePath), zap.Error(err))
+				continue
+			}
+		}
+
+		// Only drop the old chunks once their replacement is stored
+		if err := idx.vectorDB.DeleteIDs(ctx, collectionName, stale[filePath]); err != nil {
+			idx.logger.Warn("Failed to delete outdated chunks", zap.String("file", filePath), zap.Error(err))
+			continue
+		}
+		migrated++
+	}
+
+	idx.logger.Info("Chunker migration complete", zap.Int("migrated_files", migrated))
+	if migrated == len(files) {
+		idx.recordChunkerVersion(ctx, collectionName)
+	}
+	return migrated, nil
+}
@@ -0,0 +1,196 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrEmbedderUnavailable is returned by a CircuitBreakerEmbedder while its
+// circuit is open, by calls that do not wait for the embedder to recover
+var ErrEmbedderUnavailable = errors.New("embedder unavailable")
+
+// healthProbe is embedded to check whether an unavailable embedder is back
+const healthProbe = "health check"
+
+// BreakerConfig sets when a CircuitBreakerEmbedder opens and how it waits
+type BreakerConfig struct {
+	Failures      int           // Consecutive failed calls that open the circuit
+	ProbeInterval time.Duration // Time between health probes while open
+	MaxWait       time.Duration // How long indexing waits for recovery (0 = until canceled)
+}
+
+// CircuitBreakerEmbedder stops calling an embedder that keeps failing (LM
+// Studio restarting, a GPU server rebooting) and probes it until it answers
+// again. While it is down, searches fail fast, so they fall back to lexical
+// search, and indexing pauses: its calls wait for the probes to succeed and
+// are retried, so a long index run resumes where it stopped.
+type CircuitBreakerEmbedder struct {
+	Embedder
+	config BreakerConfig
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	failures  int           // Consecutive failures while closed
+	recovered chan struct{} // Non-nil while open, closed when a probe succeeds
+}
+
+// NewCircuitBreakerEmbedder wraps embedder with a circuit breaker
+func NewCircuitBreakerEmbedder(embedder Embedder, config BreakerConfig, logger *zap.Logger) *CircuitBreakerEmbedder {
+	if config.Failures <= 0 {
+		config.Failures = 3
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = 10 * time.Second
+	}
+	return &CircuitBreakerEmbedder{Embedder: embedder, config: config, logger: logger}
+}
+
+type waitForEmbedderKey struct{}
+
+// waitForEmbedder marks ctx so embedding calls made with it wait for an
+// unavailable embedder to recover instead of failing fast
+func waitForEmbedder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, waitForEmbedderKey{}, true)
+}
+
+// Open reports whether the embedder is considered down
+func (b *CircuitBreakerEmbedder) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.recovered != nil
+}
+
+func (b *CircuitBreakerEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	var vector []float32
+	err := b.call(ctx, func() error {
+		var err error
+		vector, err = b.Embedder.Embed(ctx, text)
+		return err
+	})
+	return vector, err
+}
+
+func (b *CircuitBreakerEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var embeddings [][]float32
+	err := b.call(ctx, func() error {
+		var err error
+		embeddings, err = b.Embedder.EmbedBatch(ctx, texts)
+		return err
+	})
+	return embeddings, err
+}
+
+// call runs fn unless the circuit is open. Calls waiting for the embedder
+// wait for it to recover, then retry when their own failure opened it.
+func (b *CircuitBreakerEmbedder) call(ctx context.Context, fn func() error) error {
+	wait, _ := ctx.Value(waitForEmbedderKey{}).(bool)
+	for {
+		if recovered := b.openCircuit(); recovered != nil {
+			if !wait {
+				return ErrEmbedderUnavailable
+			}
+			if err := b.awaitRecovery(ctx, recovered); err != nil {
+				return err
+			}
+		}
+
+		err := fn()
+		if err == nil {
+			b.recordSuccess()
+			return nil
+		}
+		// A canceled call says nothing about the embedder
+		if ctx.Err() != nil {
+			return err
+		}
+		if !b.recordFailure(err) || !wait {
+			return err
+		}
+	}
+}
+
+// openCircuit returns the channel closed on recovery, nil when closed
+func (b *CircuitBreakerEmbedder) openCircuit() chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.recovered
+}
+
+// awaitRecovery waits for the open circuit to close, for up to MaxWait
+func (b *CircuitBreakerEmbedder) awaitRecovery(ctx context.Context, recovered chan struct{}) error {
+	var timeout <-chan time.Time
+	if b.config.MaxWait > 0 {
+		timer := time.NewTimer(b.config.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-recovered:
+		return nil
+	case <-timeout:
+		return fmt.Errorf("%w: not back after %s", ErrEmbedderUnavailable, b.config.MaxWait)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *CircuitBreakerEmbedder) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+}
+
+// recordFailure counts a failed call and opens the circuit after
+// config.Failures in a row. It reports whether the circuit is open.
+func (b *CircuitBreakerEmbedder) recordFailure(err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.recovered != nil {
+		return true
+	}
+	b.failures++
+	if b.failures < b.config.Failures {
+		return false
+	}
+
+	b.logger.Warn("Embedder unavailable, pausing indexing until it recovers",
+		zap.Int("failures", b.failures),
+		zap.Duration("probe_interval", b.config.ProbeInterval),
+		zap.Error(err),
+	)
+	b.recovered = make(chan struct{})
+	go b.probe(b.recovered)
+	return true
+}
+
+// probe embeds a probe text every ProbeInterval until one succeeds, then
+// closes the circuit
+func (b *CircuitBreakerEmbedder) probe(recovered chan struct{}) {
+	ticker := time.NewTicker(b.config.ProbeInterval)
+	defer ticker.Stop()
+
+	started := time.Now()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), b.config.ProbeInterval)
+		_, err := b.Embedder.Embed(ctx, healthProbe)
+		cancel()
+		if err != nil {
+			b.logger.Debug("Embedder health probe failed", zap.Error(err))
+			continue
+		}
+
+		b.mu.Lock()
+		b.failures = 0
+		b.recovered = nil
+		b.mu.Unlock()
+		close(recovered)
+		b.logger.Info("Embedder recovered, resuming indexing", zap.Duration("down_for", time.Since(started)))
+		return
+	}
+}
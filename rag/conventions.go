@@ -0,0 +1,191 @@
+package rag
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ConventionPattern is a line shape recurring across the matched code
+type ConventionPattern struct {
+	Pattern string // Normalized line, e.g. `return nil, fmt.Errorf("…%w", err)`
+	Count   int    // Occurrences
+	Files   int    // Distinct files using it
+	Example Reference
+}
+
+var (
+	stringLiteralPattern = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`")
+	formatVerbPattern    = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+	numberPattern        = regexp.MustCompile(`\b\d+(\.\d+)?\b`)
+	tokenPattern         = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+)
+
+// conventionIdentifiers are variable names kept by NormalizePattern because
+// they are part of common idioms (error handling, context passing)
+var conventionIdentifiers = map[string]bool{
+	"err": true, "ctx": true, "cls": true, "e": true, "ex": true,
+}
+
+// minPatternLength skips lines too short to be meaningful conventions (braces, "else", ...)
+const minPatternLength = 10
+
+// NormalizePattern reduces a line of code to its shape: string literals keep
+// only their format verbs, numbers become N, and plain identifiers become _.
+// Keywords, called functions and selector names (fmt.Errorf, .Error) are kept,
+// since they carry the convention.
+func NormalizePattern(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || isCommentLine(line) {
+		return ""
+	}
+
+	// Literals are swapped for placeholders so identifiers inside them are left alone
+	var literals []string
+	line = stringLiteralPattern.ReplaceAllStringFunc(line, func(lit string) string {
+		literals = append(literals, `"…`+strings.Join(formatVerbPattern.FindAllString(lit, -1), "")+`"`)
+		return "\x00"
+	})
+	line = numberPattern.ReplaceAllString(line, "N")
+
+	var out strings.Builder
+	last := 0
+	for _, loc := range tokenPattern.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		out.WriteString(line[last:start])
+		last = end
+
+		ident := line[start:end]
+		before := strings.TrimRight(line[:start], " ")
+		after := strings.TrimLeft(line[end:], " ")
+
+		keep := commonKeywords[ident] || conventionIdentifiers[ident] ||
+			strings.HasSuffix(before, ".") ||
+			strings.HasPrefix(after, "(") ||
+			strings.HasPrefix(after, ".") ||
+			ident == "N"
+		if keep {
+			out.WriteString(ident)
+		} else {
+			out.WriteString("_")
+		}
+	}
+	out.WriteString(line[last:])
+
+	pattern := out.String()
+	for _, lit := range literals {
+		pattern = strings.Replace(pattern, "\x00", lit, 1)
+	}
+	if len(pattern) < minPatternLength {
+		return ""
+	}
+	return pattern
+}
+
+// AnalyzeConventions finds line shapes used in at least two files of the
+// results, most widespread first
+func AnalyzeConventions(results []SearchResult, limit int) []ConventionPattern {
+	type stats struct {
+		count   int
+		files   map[string]bool
+		example Reference
+	}
+	byPattern := make(map[string]*stats)
+
+	for _, result := range results {
+		for i, line := range strings.Split(result.Content, "\n") {
+			pattern := NormalizePattern(line)
+			if pattern == "" {
+				continue
+			}
+			st, ok := byPattern[pattern]
+			if !ok {
+				st = &stats{
+					files: make(map[string]bool),
+					example: Reference{
+						FilePath: result.FilePath,
+						Line:     result.LineStart + i,
+						Text:     strings.TrimSpace(line),
+						Language: result.Language,
+					},
+				}
+				byPattern[pattern] = st
+			}
+			st.count++
+			st.files[result.FilePath] = true
+		}
+	}
+
+	var patterns []ConventionPattern
+	for pattern, st := range byPattern {
+		if len(st.files) < 2 {
+			continue
+		}
+		patterns = append(patterns, ConventionPattern{
+			Pattern: pattern,
+			Count:   st.count,
+			Files:   len(st.files),
+			Example: st.example,
+		})
+	}
+
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Files != patterns[j].Files {
+			return patterns[i].Files > patterns[j].Files
+		}
+		if patterns[i].Count != patterns[j].Count {
+			return patterns[i].Count > patterns[j].Count
+		}
+		return patterns[i].Pattern < patterns[j].Pattern
+	})
+
+	if limit > 0 && len(patterns) > limit {
+		patterns = patterns[:limit]
+	}
+	return patterns
+}
+
+// RepresentativeResults ranks results by how many of the given patterns they
+// use, so the returned examples show the conventions together
+func RepresentativeResults(results []SearchResult, patterns []ConventionPattern, limit int) []SearchResult {
+	wanted := make(map[string]bool, len(patterns))
+	for _, p := range patterns {
+		wanted[p.Pattern] = true
+	}
+
+	type scored struct {
+		result SearchResult
+		hits   int
+	}
+	var ranked []scored
+	for _, result := range results {
+		used := make(map[string]bool)
+		for _, line := range strings.Split(result.Content, "\n") {
+			if pattern := NormalizePattern(line); wanted[pattern] {
+				used[pattern] = true
+			}
+		}
+		if len(used) > 0 {
+			ranked = append(ranked, scored{result: result, hits: len(used)})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].hits > ranked[j].hits
+	})
+
+	var representative []SearchResult
+	seenFiles := make(map[string]bool)
+	for _, r := range ranked {
+		if len(representative) == limit {
+			break
+		}
+		// One example per file shows more of the codebase
+		if seenFiles[r.result.FilePath] {
+			continue
+		}
+		seenFiles[r.result.FilePath] = true
+		representative = append(representative, r.result)
+	}
+	return representative
+}
@@ -0,0 +1,102 @@
+package rag
+
+import (
+	"os"
+	"strings"
+)
+
+// maxDefinitionLines caps the range returned for a single definition
+const maxDefinitionLines = 200
+
+// DefinitionRange returns the lines of the definition starting at line in
+// filePath. The definition ends before the next definition at the same or a
+// shallower indentation (so methods stay inside their class), or at the end
+// of the file. Trailing blank lines and the next definition's doc comment
+// are dropped.
+func DefinitionRange(filePath string, line int) (start, end int, lines []string, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	fileLines := SplitLines(data)
+	if line < 1 || line > len(fileLines) {
+		line = 1
+	}
+
+	symbols := ExtractSymbols(DetectLanguageFromContent(filePath, data), fileLines)
+	end = definitionEnd(fileLines, symbols, line)
+
+	return line, end, fileLines[line-1 : end], nil
+}
+
+// definitionEnd finds the last line of the definition starting at line
+func definitionEnd(fileLines []string, symbols []Symbol, line int) int {
+	indent := indentation(fileLines[line-1])
+
+	end := len(fileLines)
+	for _, sym := range symbols {
+		if sym.Line <= line {
+			continue
+		}
+		if indentation(fileLines[sym.Line-1]) <= indent {
+			end = sym.Line - 1
+			// Leave the next definition's doc comment out
+			for end > line && isCommentLine(fileLines[end-1]) {
+				end--
+			}
+			break
+		}
+	}
+
+	for end > line && strings.TrimSpace(fileLines[end-1]) == "" {
+		end--
+	}
+	if end-line+1 > maxDefinitionLines {
+		end = line + maxDefinitionLines - 1
+	}
+	return end
+}
+
+// EnclosingSymbol returns the innermost definition in filePath whose range
+// contains line, or nil if the line is outside any definition.
+func EnclosingSymbol(filePath string, line int) *Symbol {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	fileLines := SplitLines(data)
+	symbols := ExtractSymbols(DetectLanguageFromContent(filePath, data), fileLines)
+
+	var enclosing *Symbol
+	for i, sym := range symbols {
+		if sym.Line > line {
+			break
+		}
+		if definitionEnd(fileLines, symbols, sym.Line) >= line {
+			enclosing = &symbols[i]
+		}
+	}
+	return enclosing
+}
+
+// indentation returns the width of a line's leading whitespace (tabs count as 4)
+func indentation(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// isCommentLine reports whether a line is a single-line comment
+func isCommentLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#")
+}
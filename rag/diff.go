@@ -0,0 +1,119 @@
+package rag
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DiffHunk is one hunk of a unified diff
+type DiffHunk struct {
+	FilePath string // Path in the new version ("" for deleted files)
+	OldPath  string
+	OldStart int
+	NewStart int
+	NewLines int
+	Added    []string
+	Removed  []string
+	Text     string // Raw hunk including the @@ header
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseUnifiedDiff splits a unified diff (git diff output) into hunks.
+// Relative file paths are joined to root when root is set.
+func ParseUnifiedDiff(diff, root string) []DiffHunk {
+	var hunks []DiffHunk
+	var current *DiffHunk
+	var text strings.Builder
+	oldPath, newPath := "", ""
+
+	flush := func() {
+		if current != nil {
+			current.Text = text.String()
+			hunks = append(hunks, *current)
+			current = nil
+		}
+		text.Reset()
+	}
+
+	resolve := func(path string) string {
+		if path == "/dev/null" {
+			return ""
+		}
+		path = strings.TrimPrefix(strings.TrimPrefix(path, "a/"), "b/")
+		if root != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		return path
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+		case strings.HasPrefix(line, "--- ") && current == nil:
+			oldPath = resolve(strings.Fields(line[4:] + " ")[0])
+		case strings.HasPrefix(line, "+++ ") && current == nil:
+			newPath = resolve(strings.Fields(line[4:] + " ")[0])
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			m := hunkHeaderPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			current = &DiffHunk{FilePath: newPath, OldPath: oldPath, NewLines: 1}
+			current.OldStart, _ = strconv.Atoi(m[1])
+			current.NewStart, _ = strconv.Atoi(m[3])
+			if m[4] != "" {
+				current.NewLines, _ = strconv.Atoi(m[4])
+			}
+			text.WriteString(line + "\n")
+		case current != nil:
+			switch {
+			case strings.HasPrefix(line, "+"):
+				current.Added = append(current.Added, line[1:])
+			case strings.HasPrefix(line, "-"):
+				current.Removed = append(current.Removed, line[1:])
+			}
+			text.WriteString(line + "\n")
+		}
+	}
+	flush()
+
+	return hunks
+}
+
+// GitDiff returns the unified diff of a commit range (e.g. "main..HEAD") in repoDir
+func GitDiff(ctx context.Context, repoDir, commitRange string) (string, error) {
+	if strings.HasPrefix(commitRange, "-") {
+		return "", fmt.Errorf("invalid commit range: %s", commitRange)
+	}
+	return runGit(ctx, repoDir, "diff", "--no-color", "--no-ext-diff", commitRange, "--")
+}
+
+// GitStagedDiff returns the unified diff of the changes staged in repoDir
+func GitStagedDiff(ctx context.Context, repoDir string) (string, error) {
+	return runGit(ctx, repoDir, "diff", "--cached", "--no-color", "--no-ext-diff")
+}
+
+// runGit runs a git command in repoDir and returns its output
+func runGit(ctx context.Context, repoDir string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", append([]string{"-C", repoDir}, args...)...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,178 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// ErrInvalidEmbedding marks a vector that would silently poison cosine
+// search: NaN or infinite values, a zero norm, or the wrong dimension
+var ErrInvalidEmbedding = errors.New("invalid embedding")
+
+// ValidateEmbedding checks that vector has dim finite values and a non-zero
+// norm. dim <= 0 skips the dimension check.
+func ValidateEmbedding(vector []float32, dim int) error {
+	if dim > 0 && len(vector) != dim {
+		return fmt.Errorf("%w: dimension %d, expected %d", ErrInvalidEmbedding, len(vector), dim)
+	}
+	var norm float64
+	for _, v := range vector {
+		f := float64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%w: non-finite value", ErrInvalidEmbedding)
+		}
+		norm += f * f
+	}
+	if norm == 0 {
+		return fmt.Errorf("%w: zero vector", ErrInvalidEmbedding)
+	}
+	return nil
+}
+
+// InvalidEmbeddingsError is returned by a ValidatingEmbedder's EmbedBatch
+// when some texts got no valid vector even after a retry. The embeddings
+// returned with it are valid except at Indexes, which are nil.
+type InvalidEmbeddingsError struct {
+	Indexes []int
+	Last    error // Validation error of the last failed text
+}
+
+func (e *InvalidEmbeddingsError) Error() string {
+	return fmt.Sprintf("%d texts got invalid embeddings: %v", len(e.Indexes), e.Last)
+}
+
+func (e *InvalidEmbeddingsError) Unwrap() error {
+	return e.Last
+}
+
+// EmbeddingValidationStats counts invalid vectors returned by the embedder
+type EmbeddingValidationStats struct {
+	NonFinite      int64 `json:"non_finite"`      // Vectors with NaN or infinite values
+	ZeroNorm       int64 `json:"zero_norm"`       // All-zero vectors
+	WrongDimension int64 `json:"wrong_dimension"` // Vectors of the wrong length
+	Recovered      int64 `json:"recovered"`       // Invalid vectors replaced by a valid one on retry
+	Failed         int64 `json:"failed"`          // Texts left without a valid vector
+}
+
+// Invalid returns the total number of invalid vectors received
+func (s EmbeddingValidationStats) Invalid() int64 {
+	return s.NonFinite + s.ZeroNorm + s.WrongDimension
+}
+
+// ValidatingEmbedder wraps an embedder and checks every vector it returns.
+// A text whose vector is invalid is embedded once more on its own; if that
+// fails too, Embed returns an error and EmbedBatch an
+// *InvalidEmbeddingsError so only that text is skipped.
+type ValidatingEmbedder struct {
+	Embedder
+	logger *zap.Logger
+
+	nonFinite, zeroNorm, wrongDimension atomic.Int64
+	recovered, failed                   atomic.Int64
+}
+
+// NewValidatingEmbedder wraps embedder with output validation
+func NewValidatingEmbedder(embedder Embedder, logger *zap.Logger) *ValidatingEmbedder {
+	return &ValidatingEmbedder{Embedder: embedder, logger: logger}
+}
+
+// Stats returns the invalid vectors counted so far
+func (v *ValidatingEmbedder) Stats() EmbeddingValidationStats {
+	return EmbeddingValidationStats{
+		NonFinite:      v.nonFinite.Load(),
+		ZeroNorm:       v.zeroNorm.Load(),
+		WrongDimension: v.wrongDimension.Load(),
+		Recovered:      v.recovered.Load(),
+		Failed:         v.failed.Load(),
+	}
+}
+
+func (v *ValidatingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector, err := v.Embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return v.validate(ctx, text, vector)
+}
+
+func (v *ValidatingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := v.Embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("%w: embedder returned %d vectors for %d texts", ErrInvalidEmbedding, len(embeddings), len(texts))
+	}
+
+	var invalid *InvalidEmbeddingsError
+	for i, vector := range embeddings {
+		valid, err := v.validate(ctx, texts[i], vector)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if invalid == nil {
+				invalid = &InvalidEmbeddingsError{}
+			}
+			invalid.Indexes = append(invalid.Indexes, i)
+			invalid.Last = err
+		}
+		embeddings[i] = valid
+	}
+
+	if invalid != nil {
+		return embeddings, invalid
+	}
+	return embeddings, nil
+}
+
+// validate returns vector if it is valid, or a valid vector for text from a
+// retry, or an error
+func (v *ValidatingEmbedder) validate(ctx context.Context, text string, vector []float32) ([]float32, error) {
+	err := ValidateEmbedding(vector, v.Dimension())
+	if err == nil {
+		return vector, nil
+	}
+	v.count(vector)
+
+	retried, retryErr := v.Embedder.Embed(ctx, text)
+	if retryErr == nil {
+		if retryErr = ValidateEmbedding(retried, v.Dimension()); retryErr == nil {
+			v.recovered.Add(1)
+			v.logger.Debug("Invalid embedding recovered on retry", zap.Error(err))
+			return retried, nil
+		}
+		v.count(retried)
+	}
+
+	v.failed.Add(1)
+	v.logger.Warn("Embedder returned an invalid vector", zap.Error(err), zap.NamedError("retry_error", retryErr))
+	return nil, err
+}
+
+// count records why vector is invalid
+func (v *ValidatingEmbedder) count(vector []float32) {
+	switch err := ValidateEmbedding(vector, v.Dimension()); {
+	case err == nil:
+	case v.Dimension() > 0 && len(vector) != v.Dimension():
+		v.wrongDimension.Add(1)
+	case isNonFinite(vector):
+		v.nonFinite.Add(1)
+	default:
+		v.zeroNorm.Add(1)
+	}
+}
+
+func isNonFinite(vector []float32) bool {
+	for _, x := range vector {
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return true
+		}
+	}
+	return false
+}
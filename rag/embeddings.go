@@ -75,14 +75,16 @@ func (e *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	return embeddings, nil
 }
 
-// LM Studio Local Embedder
+// LM Studio Local Embedder. It speaks the OpenAI embeddings API, so it also
+// serves vLLM (NewVLLMEmbedder).
 type LocalEmbedder struct {
-	baseURL       string
-	model         string
-	dim           int
-	httpClient    *http.Client
-	maxBatchSize  int // Maximum number of texts per batch
-	maxTokensHint int // Estimated max tokens per batch (rough approximation)
+	name       string // Server named in errors
+	baseURL    string
+	model      string
+	apiKey     string // Sent as a bearer token when set
+	dim        int
+	httpClient *http.Client
+	batches    *batchSizer // Texts per request, adapted to the server
 }
 
 type EmbeddingRequest struct {
@@ -104,28 +106,39 @@ type EmbeddingResponse struct {
 	} `json:"usage"`
 }
 
-func NewLocalEmbedder(baseURL, model string, dim int) (Embedder, error) {
+func NewLocalEmbedder(baseURL, model string, dim int, batch BatchOptions) (Embedder, error) {
 	if baseURL == "" {
 		baseURL = "http://localhost:1234/v1"
 	}
+	return newLocalEmbedder("LM Studio", baseURL, model, "", dim, batch)
+}
+
+// NewVLLMEmbedder creates an embedder using the OpenAI-compatible embeddings
+// endpoint of a vLLM server (vllm serve <model> --task embed). apiKey is the
+// server's --api-key, empty when it has none.
+func NewVLLMEmbedder(baseURL, model, apiKey string, dim int, batch BatchOptions) (Embedder, error) {
+	if baseURL == "" {
+		baseURL = "http://localhost:8000/v1"
+	}
+	return newLocalEmbedder("vLLM", baseURL, model, apiKey, dim, batch)
+}
 
+func newLocalEmbedder(name, baseURL, model, apiKey string, dim int, batch BatchOptions) (Embedder, error) {
 	embedder := &LocalEmbedder{
-		baseURL:       baseURL,
-		model:         model,
-		dim:           dim,
-		maxBatchSize:  20,    // Aggressive: max 20 chunks per API call (20 × 1,500 tokens ≈ 30k)
-		maxTokensHint: 28000, // Target ~28k tokens per batch (safe margin under 32k limit)
+		name:    name,
+		baseURL: baseURL,
+		model:   model,
+		apiKey:  apiKey,
+		dim:     dim,
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second, // Increased timeout for larger batches
 		},
 	}
+	embedder.batches = newBatchSizer(batch, embedder.estimateTokens)
 
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := embedder.testConnection(ctx); err != nil {
-		return nil, fmt.Errorf("failed to connect to LM Studio: %w", err)
+	// Check the model is served and measure its vectors
+	if err := embedder.discover(); err != nil {
+		return nil, err
 	}
 
 	return embedder, nil
@@ -135,23 +148,10 @@ func (e *LocalEmbedder) Dimension() int {
 	return e.dim
 }
 
-func (e *LocalEmbedder) testConnection(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", e.baseURL+"/models", nil)
-	if err != nil {
-		return err
-	}
-
-	resp, err := e.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("LM Studio returned status %d", resp.StatusCode)
-	}
-
-	return nil
+// Model returns the model embedding texts: the configured one, or the one
+// picked by discovery when the server does not serve it
+func (e *LocalEmbedder) Model() string {
+	return e.model
 }
 
 func (e *LocalEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
@@ -162,62 +162,48 @@ func (e *LocalEmbedder) Embed(ctx context.Context, text string) ([]float32, erro
 	return embeddings[0], nil
 }
 
+// EmbedBatch embeds texts in requests sized by the batch sizer, halving a
+// request the server rejects as too large until it fits
 func (e *LocalEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
-	// If batch is small enough, process directly
-	if len(texts) <= e.maxBatchSize {
-		estimatedTokens := e.estimateTokens(texts)
-		if estimatedTokens <= e.maxTokensHint {
-			return e.embedBatchDirect(ctx, texts)
-		}
-	}
-
-	// Otherwise, split into smaller sub-batches
-	var allEmbeddings [][]float32
-
-	for i := 0; i < len(texts); i += e.maxBatchSize {
-		end := i + e.maxBatchSize
-		if end > len(texts) {
-			end = len(texts)
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); {
+		n := e.batches.next(texts[start:])
+		started := time.Now()
+		batch, err := e.embedBatchDirect(ctx, texts[start:start+n])
+		if err != nil {
+			if batchTooLarge(err) && e.batches.tooLarge(n) > 0 {
+				continue
+			}
+			return nil, fmt.Errorf("failed to embed sub-batch [%d:%d]: %w", start, start+n, err)
 		}
+		e.batches.succeeded(n, time.Since(started))
 
-		subBatch := texts[i:end]
+		embeddings = append(embeddings, batch...)
+		start += n
+	}
 
-		// Double-check token count for this sub-batch
-		estimatedTokens := e.estimateTokens(subBatch)
-		if estimatedTokens > e.maxTokensHint {
-			// If still too large, reduce batch size for this iteration
-			reducedSize := (e.maxBatchSize * e.maxTokensHint) / estimatedTokens
-			if reducedSize < 1 {
-				reducedSize = 1
-			}
-			end = i + reducedSize
-			if end > len(texts) {
-				end = len(texts)
-			}
-			subBatch = texts[i:end]
-		}
+	return embeddings, nil
+}
 
-		embeddings, err := e.embedBatchDirect(ctx, subBatch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to embed sub-batch [%d:%d]: %w", i, end, err)
-		}
+// textTokenOverhead is the tokens a server adds to each embedded text
+const textTokenOverhead = 4
 
-		allEmbeddings = append(allEmbeddings, embeddings...)
+// authorize adds the API key to req, if any
+func (e *LocalEmbedder) authorize(req *http.Request) {
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
 	}
-
-	return allEmbeddings, nil
 }
 
-// estimateTokens provides a rough estimate of token count
-// Rule of thumb: ~4 characters per token for English text
+// estimateTokens counts the tokens of a request with the cl100k tokenizer,
+// plus a few per text for the special tokens the server adds. Code tokenizes
+// far denser than the usual 4 characters per token.
 func (e *LocalEmbedder) estimateTokens(texts []string) int {
-	totalChars := 0
+	total := 0
 	for _, text := range texts {
-		totalChars += len(text)
+		total += CountTokens(text) + textTokenOverhead
 	}
-	// Add overhead for JSON structure (~100 chars per text)
-	totalChars += len(texts) * 100
-	return totalChars / 4
+	return total
 }
 
 // embedBatchDirect sends a single batch request to the API
@@ -238,6 +224,7 @@ func (e *LocalEmbedder) embedBatchDirect(ctx context.Context, texts []string) ([
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	e.authorize(req)
 
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
@@ -247,7 +234,7 @@ func (e *LocalEmbedder) embedBatchDirect(ctx context.Context, texts []string) ([
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LM Studio returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &statusError{server: e.name, code: resp.StatusCode, body: string(body)}
 	}
 
 	var embResp EmbeddingResponse
@@ -263,14 +250,12 @@ func (e *LocalEmbedder) embedBatchDirect(ctx context.Context, texts []string) ([
 	return embeddings, nil
 }
 
-// Factory function qui choisit le bon embedder
+// Factory function qui choisit le bon embedder (types enregistrés via RegisterEmbedder)
 func NewEmbedder(embedType, model, apiKey, baseURL string, dim int) (Embedder, error) {
-	switch embedType {
-	case "local", "lmstudio":
-		return NewLocalEmbedder(baseURL, model, dim)
-	case "openai":
-		return NewOpenAIEmbedder(model, apiKey, dim)
-	default:
-		return nil, fmt.Errorf("unknown embedding type: %s", embedType)
-	}
+	return NewEmbedderFromConfig(embedType, EmbedderConfig{
+		Model:     model,
+		APIKey:    apiKey,
+		BaseURL:   baseURL,
+		Dimension: dim,
+	})
 }
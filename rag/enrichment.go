@@ -0,0 +1,120 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// DefaultEnrichmentTemplate wraps each chunk with its file name, language and,
+// when known, Markdown section and Terraform block before it is embedded
+const DefaultEnrichmentTemplate = `File: {{.FileName}}
+Language: {{.Language}}
+{{with .Section}}Section: {{.}}
+{{end}}{{with .Block}}Block: {{.}}
+{{end}}Code:
+{{.Code}}`
+
+var defaultEnrichment = template.Must(ParseEnrichmentTemplate(DefaultEnrichmentTemplate))
+
+// ChunkContext is what an enrichment template can use to describe a chunk
+type ChunkContext struct {
+	Path     string   // Absolute file path
+	RelPath  string   // File path relative to Repo, Path outside repositories
+	FileName string   // Base name of the file
+	Repo     string   // Name of the Git repository containing the file ("" outside one)
+	Language string   // Language tag, e.g. "go"
+	Symbol   string   // Definition enclosing the chunk ("" for none)
+	Symbols  []string // Definitions starting inside the chunk
+	Imports  []string // Import statements of the file
+	Section  string   // Markdown heading hierarchy, "A > B"
+	Block    string   // Terraform address, e.g. aws_vpc.main
+	Code     string   // Content of the chunk
+}
+
+// enrichmentFuncs are available to enrichment templates
+var enrichmentFuncs = template.FuncMap{"join": strings.Join}
+
+// ParseEnrichmentTemplate parses an enrichment template and checks it renders
+// a sample chunk, so mistakes surface on startup rather than while indexing
+func ParseEnrichmentTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("enrichment").Funcs(enrichmentFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	sample := ChunkContext{
+		Path:     "/repo/main.go",
+		RelPath:  "main.go",
+		FileName: "main.go",
+		Repo:     "repo",
+		Language: "go",
+		Symbol:   "main",
+		Symbols:  []string{"main"},
+		Imports:  []string{`"fmt"`},
+		Code:     "func main() {}",
+	}
+	if err := tmpl.Execute(&strings.Builder{}, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// SetEnrichmentTemplate sets the template rendering each chunk into the text
+// embedded for it (default: DefaultEnrichmentTemplate). Vectors depend on it:
+// reindex after changing it. Call it before indexing starts.
+func (idx *Indexer) SetEnrichmentTemplate(text string) error {
+	tmpl, err := ParseEnrichmentTemplate(text)
+	if err != nil {
+		return fmt.Errorf("invalid enrichment template: %w", err)
+	}
+	idx.enrichment = tmpl
+	return nil
+}
+
+// enrich renders chunk with the enrichment template
+func (idx *Indexer) enrich(chunk CodeChunk) (string, error) {
+	data := ChunkContext{
+		Path:     chunk.FilePath,
+		RelPath:  chunk.FilePath,
+		FileName: filepath.Base(chunk.FilePath),
+		Language: chunk.Language,
+		Symbol:   chunk.Parent.Symbol,
+		Imports:  chunk.Imports,
+		Section:  strings.Join(chunk.Headings, " > "),
+		Block:    chunk.Address,
+		Code:     chunk.Content,
+	}
+	for _, sym := range chunk.Symbols {
+		data.Symbols = append(data.Symbols, sym.Name)
+	}
+	if repo := idx.repoOf(filepath.Dir(chunk.FilePath)); repo != "" {
+		data.Repo = filepath.Base(repo)
+		if rel, err := filepath.Rel(repo, chunk.FilePath); err == nil {
+			data.RelPath = rel
+		}
+	}
+
+	var text strings.Builder
+	if err := idx.enrichment.Execute(&text, data); err != nil {
+		return "", fmt.Errorf("enrichment template failed on %s: %w", chunk.FilePath, err)
+	}
+	return text.String(), nil
+}
+
+// repoOf returns the Git repository containing dir, "" outside one. Lookups
+// are cached by directory.
+func (idx *Indexer) repoOf(dir string) string {
+	if repo, ok := idx.repos.Load(dir); ok {
+		return repo.(string)
+	}
+	repo := ""
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		repo = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		repo = idx.repoOf(parent)
+	}
+	idx.repos.Store(dir, repo)
+	return repo
+}
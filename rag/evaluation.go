@@ -0,0 +1,92 @@
+package rag
+
+import "math"
+
+// EvalQueriesFileName is the default file of evaluation queries, golden
+// queries as LoadGoldenQueries reads them
+const EvalQueriesFileName = "eval_queries.yaml"
+
+// QueryEvaluation scores the results of one evaluation query
+type QueryEvaluation struct {
+	Query          string   `json:"query"`
+	Recall         float64  `json:"recall"`          // Share of the golden files among the results
+	ReciprocalRank float64  `json:"reciprocal_rank"` // 1/rank of the first golden result, 0 if none
+	NDCG           float64  `json:"ndcg"`            // Normalized discounted cumulative gain
+	Missed         []string `json:"missed,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Evaluation averages the scores of evaluation queries over the first K results
+type Evaluation struct {
+	K       int               `json:"k"`
+	Recall  float64           `json:"recall"`
+	MRR     float64           `json:"mrr"`
+	NDCG    float64           `json:"ndcg"`
+	Queries []QueryEvaluation `json:"queries"`
+}
+
+// EvaluateResults scores the first k results returned for query. Each
+// golden file counts once, at the first result matching it; relevance is
+// binary.
+func EvaluateResults(query GoldenQuery, results []SearchResult, k int) QueryEvaluation {
+	if len(results) > k {
+		results = results[:k]
+	}
+	eval := QueryEvaluation{Query: query.Query}
+	found := make([]bool, len(query.Files))
+	dcg := 0.0
+
+	for rank, result := range results {
+		relevant := false
+		for i, golden := range query.Files {
+			if !found[i] && golden.matches(result) {
+				found[i] = true
+				relevant = true
+			}
+		}
+		if !relevant {
+			continue
+		}
+		dcg += 1 / math.Log2(float64(rank+2))
+		if eval.ReciprocalRank == 0 {
+			eval.ReciprocalRank = 1 / float64(rank+1)
+		}
+	}
+
+	hits := 0
+	for i, ok := range found {
+		if ok {
+			hits++
+		} else {
+			eval.Missed = append(eval.Missed, query.Files[i].String())
+		}
+	}
+	eval.Recall = float64(hits) / float64(len(query.Files))
+
+	ideal := 0.0
+	for rank := 0; rank < min(len(query.Files), k); rank++ {
+		ideal += 1 / math.Log2(float64(rank+2))
+	}
+	if ideal > 0 {
+		eval.NDCG = dcg / ideal
+	}
+	return eval
+}
+
+// Summarize averages query evaluations; failed queries score 0
+func Summarize(k int, queries []QueryEvaluation) *Evaluation {
+	summary := &Evaluation{K: k, Queries: queries}
+	if len(queries) == 0 {
+		return summary
+	}
+	for _, q := range queries {
+		summary.Recall += q.Recall
+		summary.MRR += q.ReciprocalRank
+		summary.NDCG += q.NDCG
+	}
+	n := float64(len(queries))
+	summary.Recall /= n
+	summary.MRR /= n
+	summary.NDCG /= n
+	return summary
+}
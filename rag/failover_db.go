@@ -0,0 +1,258 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failoverCooldown is how long reads skip an unreachable primary before
+// trying it again
+const failoverCooldown = 30 * time.Second
+
+// FailoverDB keeps a warm standby of a vector database. Writes go to the
+// primary and are mirrored to the secondary; reads go to the primary and
+// fail over to the secondary when it errors, then stay there for
+// failoverCooldown before the primary is tried again.
+//
+// Writes made while one side is down are not replayed: re-index to bring
+// it back in sync (point IDs are deterministic, so this is idempotent).
+type FailoverDB struct {
+	primary   VectorDB
+	secondary VectorDB
+	logger    *zap.Logger
+
+	mu        sync.Mutex
+	downUntil time.Time // Reads use the secondary until then
+}
+
+// NewFailoverDB mirrors primary to secondary and fails reads over to it
+func NewFailoverDB(primary, secondary VectorDB, logger *zap.Logger) *FailoverDB {
+	return &FailoverDB{
+		primary:   primary,
+		secondary: secondary,
+		logger:    logger,
+	}
+}
+
+// primaryDown reports whether reads currently skip the primary
+func (f *FailoverDB) primaryDown() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return time.Now().Before(f.downUntil)
+}
+
+// markDown routes reads to the secondary for failoverCooldown
+func (f *FailoverDB) markDown(op string, err error) {
+	f.mu.Lock()
+	wasDown := time.Now().Before(f.downUntil)
+	f.downUntil = time.Now().Add(failoverCooldown)
+	f.mu.Unlock()
+
+	if !wasDown {
+		f.logger.Warn("Primary vector database failed, failing over to secondary",
+			zap.String("operation", op),
+			zap.Duration("retry_after", failoverCooldown),
+			zap.Error(err),
+		)
+	}
+}
+
+// failsOver reports whether err means the database could not serve the
+// call, rather than rejecting it (missing collection, bad request)
+func failsOver(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		// Not a gRPC error: other backends give no way to tell
+		return true
+	}
+	switch st.Code() {
+	case codes.NotFound, codes.AlreadyExists, codes.InvalidArgument, codes.FailedPrecondition,
+		codes.OutOfRange, codes.PermissionDenied, codes.Unauthenticated, codes.Unimplemented:
+		return false
+	}
+	return true
+}
+
+// read runs call on the primary, or on the secondary when the primary is
+// down or fails. Errors caused by ctx itself never fail over.
+func (f *FailoverDB) read(ctx context.Context, op string, call func(VectorDB) error) error {
+	if f.primaryDown() {
+		return call(f.secondary)
+	}
+
+	err := call(f.primary)
+	if err == nil || ctx.Err() != nil || !failsOver(err) {
+		return err
+	}
+	f.markDown(op, err)
+
+	if secondaryErr := call(f.secondary); secondaryErr != nil {
+		f.logger.Warn("Secondary vector database failed too", zap.String("operation", op), zap.Error(secondaryErr))
+		return err
+	}
+	return nil
+}
+
+// write runs call on the primary and mirrors it to the secondary. Only the
+// primary's error is returned; mirror failures are logged.
+func (f *FailoverDB) write(ctx context.Context, op string, call func(VectorDB) error) error {
+	err := call(f.primary)
+	if err != nil && ctx.Err() == nil && failsOver(err) {
+		f.markDown(op, err)
+	}
+
+	if mirrorErr := call(f.secondary); mirrorErr != nil {
+		// Creating an existing collection fails on every startup
+		log := f.logger.Warn
+		if op == "create_collection" {
+			log = f.logger.Debug
+		}
+		log("Failed to mirror write to secondary vector database", zap.String("operation", op), zap.Error(mirrorErr))
+	}
+	return err
+}
+
+func (f *FailoverDB) CreateCollection(ctx context.Context, name string, dimension int) error {
+	return f.write(ctx, "create_collection", func(db VectorDB) error {
+		return db.CreateCollection(ctx, name, dimension)
+	})
+}
+
+func (f *FailoverDB) DeleteCollection(ctx context.Context, name string) error {
+	return f.write(ctx, "delete_collection", func(db VectorDB) error {
+		return db.DeleteCollection(ctx, name)
+	})
+}
+
+func (f *FailoverDB) Upsert(ctx context.Context, collection string, points []Point) error {
+	return f.write(ctx, "upsert", func(db VectorDB) error {
+		return db.Upsert(ctx, collection, points)
+	})
+}
+
+func (f *FailoverDB) Search(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error) {
+	var results []SearchResult
+	err := f.read(ctx, "search", func(db VectorDB) error {
+		var err error
+		results, err = db.Search(ctx, collection, filter, vector, limit, offset, minScore, opts)
+		return err
+	})
+	return results, err
+}
+
+// HybridSearch runs the hybrid search of the database serving reads, which
+// must implement HybridSearcher
+func (f *FailoverDB) HybridSearch(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, sparse SparseVector, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error) {
+	var results []SearchResult
+	err := f.read(ctx, "hybrid_search", func(db VectorDB) error {
+		hybrid, ok := db.(HybridSearcher)
+		if !ok {
+			return fmt.Errorf("vector database does not support hybrid search")
+		}
+		var err error
+		results, err = hybrid.HybridSearch(ctx, collection, filter, vector, sparse, limit, offset, minScore, opts)
+		return err
+	})
+	return results, err
+}
+
+// ReadPoints reads from the database serving reads, which must implement
+// PointReader
+func (f *FailoverDB) ReadPoints(ctx context.Context, collection string, filter map[string]interface{}) ([]Point, error) {
+	var points []Point
+	err := f.read(ctx, "read_points", func(db VectorDB) error {
+		reader, ok := db.(PointReader)
+		if !ok {
+			return fmt.Errorf("vector database cannot read points back")
+		}
+		var err error
+		points, err = reader.ReadPoints(ctx, collection, filter)
+		return err
+	})
+	return points, err
+}
+
+func (f *FailoverDB) Delete(ctx context.Context, collection string, filter map[string]interface{}) error {
+	return f.write(ctx, "delete", func(db VectorDB) error {
+		return db.Delete(ctx, collection, filter)
+	})
+}
+
+func (f *FailoverDB) DeleteIDs(ctx context.Context, collection string, ids []string) error {
+	return f.write(ctx, "delete_ids", func(db VectorDB) error {
+		return db.DeleteIDs(ctx, collection, ids)
+	})
+}
+
+// Scroll fails over only when the primary errors before yielding a point,
+// so fn never sees a point twice
+func (f *FailoverDB) Scroll(ctx context.Context, collection string, filter map[string]interface{}, fields []string, fn func(StoredPoint) error) error {
+	yielded := false
+	counted := func(p StoredPoint) error {
+		yielded = true
+		return fn(p)
+	}
+
+	if f.primaryDown() {
+		return f.secondary.Scroll(ctx, collection, filter, fields, fn)
+	}
+
+	err := f.primary.Scroll(ctx, collection, filter, fields, counted)
+	if err == nil || yielded || ctx.Err() != nil || !failsOver(err) {
+		return err
+	}
+	f.markDown("scroll", err)
+
+	if secondaryErr := f.secondary.Scroll(ctx, collection, filter, fields, fn); secondaryErr != nil {
+		f.logger.Warn("Secondary vector database failed too", zap.String("operation", "scroll"), zap.Error(secondaryErr))
+		return err
+	}
+	return nil
+}
+
+func (f *FailoverDB) Count(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	var count int64
+	err := f.read(ctx, "count", func(db VectorDB) error {
+		var err error
+		count, err = db.Count(ctx, collection, filter)
+		return err
+	})
+	return count, err
+}
+
+func (f *FailoverDB) SetPayload(ctx context.Context, collection string, filter map[string]interface{}, payload map[string]interface{}) error {
+	return f.write(ctx, "set_payload", func(db VectorDB) error {
+		return db.SetPayload(ctx, collection, filter, payload)
+	})
+}
+
+func (f *FailoverDB) DeletePayload(ctx context.Context, collection string, filter map[string]interface{}, keys []string) error {
+	return f.write(ctx, "delete_payload", func(db VectorDB) error {
+		return db.DeletePayload(ctx, collection, filter, keys)
+	})
+}
+
+func (f *FailoverDB) GetCollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error) {
+	var info *CollectionInfo
+	err := f.read(ctx, "get_collection_info", func(db VectorDB) error {
+		var err error
+		info, err = db.GetCollectionInfo(ctx, collection)
+		return err
+	})
+	return info, err
+}
+
+// Close closes both databases
+func (f *FailoverDB) Close() error {
+	err := f.primary.Close()
+	if secondaryErr := f.secondary.Close(); err == nil {
+		err = secondaryErr
+	}
+	return err
+}
@@ -0,0 +1,209 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrInjectedFault marks failures produced by a FaultInjector
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultConfig sets how often injected failures happen. Rates are
+// probabilities between 0 and 1, drawn independently for each call.
+type FaultConfig struct {
+	Seed                int64         // Random seed; 0 picks one from the clock
+	EmbedderTimeoutRate float64       // Embed/EmbedBatch calls that hang then time out
+	EmbedderTimeout     time.Duration // How long a timed-out call hangs (bounded by its context)
+	VectorDBErrorRate   float64       // Vector database data calls that fail outright
+	PartialBatchRate    float64       // Upserts that store only part of their points, then fail
+}
+
+// FaultInjector wraps an embedder and a vector database so they fail at
+// random, to exercise retries, degraded search and resumable indexing.
+// Schema calls (CreateCollection, DeleteCollection) and Close are not affected.
+type FaultInjector struct {
+	config FaultConfig
+	logger *zap.Logger
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewFaultInjector creates a fault injector
+func NewFaultInjector(config FaultConfig, logger *zap.Logger) *FaultInjector {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if config.EmbedderTimeout <= 0 {
+		config.EmbedderTimeout = 5 * time.Second
+	}
+	return &FaultInjector{
+		config: config,
+		logger: logger,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// roll reports whether a fault with the given rate happens on this call
+func (f *FaultInjector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Float64() < rate
+}
+
+// intn returns a random number in [0, n)
+func (f *FaultInjector) intn(n int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Intn(n)
+}
+
+func (f *FaultInjector) vectorDBFault(op string) error {
+	if !f.roll(f.config.VectorDBErrorRate) {
+		return nil
+	}
+	f.logger.Debug("Injecting vector database error", zap.String("op", op))
+	return fmt.Errorf("%w: vector database %s failed: unavailable", ErrInjectedFault, op)
+}
+
+// Embedder wraps an embedder with injected timeouts
+func (f *FaultInjector) Embedder(embedder Embedder) Embedder {
+	return &faultyEmbedder{Embedder: embedder, faults: f}
+}
+
+// VectorDB wraps a vector database with injected errors and partial upserts
+func (f *FaultInjector) VectorDB(db VectorDB) VectorDB {
+	return &faultyVectorDB{VectorDB: db, faults: f}
+}
+
+type faultyEmbedder struct {
+	Embedder
+	faults *FaultInjector
+}
+
+// timeout hangs like an unresponsive embedding server, then fails
+func (e *faultyEmbedder) timeout(ctx context.Context) error {
+	if !e.faults.roll(e.faults.config.EmbedderTimeoutRate) {
+		return nil
+	}
+	e.faults.logger.Debug("Injecting embedder timeout", zap.Duration("after", e.faults.config.EmbedderTimeout))
+
+	timer := time.NewTimer(e.faults.config.EmbedderTimeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return fmt.Errorf("%w: embedding request: %w", ErrInjectedFault, context.DeadlineExceeded)
+}
+
+func (e *faultyEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := e.timeout(ctx); err != nil {
+		return nil, err
+	}
+	return e.Embedder.Embed(ctx, text)
+}
+
+func (e *faultyEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.timeout(ctx); err != nil {
+		return nil, err
+	}
+	return e.Embedder.EmbedBatch(ctx, texts)
+}
+
+type faultyVectorDB struct {
+	VectorDB
+	faults *FaultInjector
+}
+
+func (db *faultyVectorDB) Upsert(ctx context.Context, collection string, points []Point) error {
+	if err := db.faults.vectorDBFault("upsert"); err != nil {
+		return err
+	}
+	if len(points) > 1 && db.faults.roll(db.faults.config.PartialBatchRate) {
+		stored := 1 + db.faults.intn(len(points)-1)
+		db.faults.logger.Debug("Injecting partial upsert", zap.Int("stored", stored), zap.Int("points", len(points)))
+		if err := db.VectorDB.Upsert(ctx, collection, points[:stored]); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: upsert stored %d of %d points", ErrInjectedFault, stored, len(points))
+	}
+	return db.VectorDB.Upsert(ctx, collection, points)
+}
+
+func (db *faultyVectorDB) Search(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error) {
+	if err := db.faults.vectorDBFault("search"); err != nil {
+		return nil, err
+	}
+	return db.VectorDB.Search(ctx, collection, filter, vector, limit, offset, minScore, opts)
+}
+
+func (db *faultyVectorDB) Delete(ctx context.Context, collection string, filter map[string]interface{}) error {
+	if err := db.faults.vectorDBFault("delete"); err != nil {
+		return err
+	}
+	return db.VectorDB.Delete(ctx, collection, filter)
+}
+
+func (db *faultyVectorDB) DeleteIDs(ctx context.Context, collection string, ids []string) error {
+	if err := db.faults.vectorDBFault("delete"); err != nil {
+		return err
+	}
+	return db.VectorDB.DeleteIDs(ctx, collection, ids)
+}
+
+func (db *faultyVectorDB) Scroll(ctx context.Context, collection string, filter map[string]interface{}, fields []string, fn func(StoredPoint) error) error {
+	if err := db.faults.vectorDBFault("scroll"); err != nil {
+		return err
+	}
+	return db.VectorDB.Scroll(ctx, collection, filter, fields, fn)
+}
+
+func (db *faultyVectorDB) ReadPoints(ctx context.Context, collection string, filter map[string]interface{}) ([]Point, error) {
+	if err := db.faults.vectorDBFault("scroll"); err != nil {
+		return nil, err
+	}
+	reader, ok := db.VectorDB.(PointReader)
+	if !ok {
+		return nil, fmt.Errorf("vector database cannot read points back")
+	}
+	return reader.ReadPoints(ctx, collection, filter)
+}
+
+func (db *faultyVectorDB) Count(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	if err := db.faults.vectorDBFault("count"); err != nil {
+		return 0, err
+	}
+	return db.VectorDB.Count(ctx, collection, filter)
+}
+
+func (db *faultyVectorDB) SetPayload(ctx context.Context, collection string, filter map[string]interface{}, payload map[string]interface{}) error {
+	if err := db.faults.vectorDBFault("set payload"); err != nil {
+		return err
+	}
+	return db.VectorDB.SetPayload(ctx, collection, filter, payload)
+}
+
+func (db *faultyVectorDB) DeletePayload(ctx context.Context, collection string, filter map[string]interface{}, keys []string) error {
+	if err := db.faults.vectorDBFault("delete payload"); err != nil {
+		return err
+	}
+	return db.VectorDB.DeletePayload(ctx, collection, filter, keys)
+}
+
+func (db *faultyVectorDB) GetCollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error) {
+	if err := db.faults.vectorDBFault("collection info"); err != nil {
+		return nil, err
+	}
+	return db.VectorDB.GetCollectionInfo(ctx, collection)
+}
@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// FileChunks returns the indexed chunks of a file in line order, and the
+// symbols they define. Lines repeated by overlapping chunks are removed from
+// the later chunk, so the chunks read as the file did when it was indexed.
+// A file that is not indexed has no chunks.
+func FileChunks(ctx context.Context, db VectorDB, collection, filePath string) ([]SearchResult, []Symbol, error) {
+	var chunks []SearchResult
+	var symbols []Symbol
+
+	filter := map[string]interface{}{"file_path": filePath}
+	fields := append([]string{"language", "chunker_version", "symbol_defs"}, contentFields...)
+	disk := newDiskLines()
+	err := db.Scroll(ctx, collection, filter, fields, func(point StoredPoint) error {
+		chunks = append(chunks, storedPointToResult(point, disk))
+		for _, sym := range symbolsFromPayload(point.Payload) {
+			if !containsSymbol(symbols, sym) {
+				symbols = append(symbols, sym)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chunks = preferNewestChunks(chunks)
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].LineStart < chunks[j].LineStart })
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Line < symbols[j].Line })
+
+	trimmed := chunks[:0]
+	lastLine := 0
+	for _, chunk := range chunks {
+		if chunk.LineEnd <= lastLine {
+			continue // Entirely covered by earlier chunks
+		}
+		if overlap := lastLine - chunk.LineStart + 1; overlap > 0 {
+			lines := strings.Split(chunk.Content, "\n")
+			if overlap < len(lines) {
+				chunk.Content = strings.Join(lines[overlap:], "\n")
+				chunk.LineStart = lastLine + 1
+			}
+		}
+		trimmed = append(trimmed, chunk)
+		lastLine = chunk.LineEnd
+	}
+
+	return trimmed, symbols, nil
+}
@@ -0,0 +1,91 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadLines returns lines start..end (1-based, inclusive) of a file and the
+// file's total line count. end is clamped to the end of the file.
+func ReadLines(filePath string, start, end int) ([]string, int, error) {
+	if start < 1 {
+		return nil, 0, fmt.Errorf("start line must be >= 1")
+	}
+	if end < start {
+		return nil, 0, fmt.Errorf("end line must be >= start line")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	return lineRange(SplitLines(content), len(content) == 0, start, end)
+}
+
+// lineRange returns lines start..end of a file split into fileLines, and
+// its line count
+func lineRange(fileLines []string, empty bool, start, end int) ([]string, int, error) {
+	lineNum := len(fileLines)
+	if empty {
+		lineNum = 0
+	}
+
+	if lineNum < start {
+		return nil, lineNum, fmt.Errorf("start line %d is past the end of the file (%d lines)", start, lineNum)
+	}
+
+	// Capped so appending never overwrites lines shared with a cache
+	last := min(end, lineNum)
+	return fileLines[start-1 : last : last], lineNum, nil
+}
+
+// ExpandResult widens a search result by n lines on each side, reading the
+// current file from disk. The result is returned unchanged if the file
+// cannot be read.
+func ExpandResult(result SearchResult, n int) SearchResult {
+	return expandResult(result, n, ReadLines)
+}
+
+// expandResult is ExpandResult reading lines with readLines
+func expandResult(result SearchResult, n int, readLines func(filePath string, start, end int) ([]string, int, error)) SearchResult {
+	if n <= 0 || result.LineStart < 1 {
+		return result
+	}
+
+	start := max(1, result.LineStart-n)
+	lines, total, err := readLines(result.FilePath, start, result.LineEnd+n)
+	if err != nil || len(lines) == 0 {
+		return result
+	}
+
+	result.LineStart = start
+	result.LineEnd = min(result.LineEnd+n, total)
+	result.Content = strings.Join(lines, "\n")
+	return result
+}
+
+// ParentResult replaces a search result by its parent read from disk: the
+// whole enclosing definition instead of the matched fragment. Results without
+// a stored parent, or whose file cannot be read, are returned unchanged.
+func ParentResult(result SearchResult) SearchResult {
+	return parentResult(result, ReadLines)
+}
+
+// parentResult is ParentResult reading lines with readLines
+func parentResult(result SearchResult, readLines func(filePath string, start, end int) ([]string, int, error)) SearchResult {
+	parent := result.Parent
+	if parent.LineEnd == 0 || (parent.LineStart == result.LineStart && parent.LineEnd == result.LineEnd) {
+		return result
+	}
+
+	lines, total, err := readLines(result.FilePath, parent.LineStart, parent.LineEnd)
+	if err != nil || len(lines) == 0 {
+		return result
+	}
+
+	result.LineStart = parent.LineStart
+	result.LineEnd = min(parent.LineEnd, total)
+	result.Content = strings.Join(lines, "\n")
+	return result
+}
@@ -0,0 +1,224 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// FilesSuffix names the collection of file-level summaries used by two-tier
+// retrieval, next to the collection of chunks
+const FilesSuffix = "_files"
+
+// FilesCollection returns the file-level collection of a collection
+func FilesCollection(collection string) string {
+	return collection + FilesSuffix
+}
+
+const (
+	// maxSummaryImports caps the imports listed in a file summary
+	maxSummaryImports = 30
+
+	// maxSummarySymbols caps the symbols listed in a file summary
+	maxSummarySymbols = 60
+
+	// maxSummaryDocLines caps the lines of the doc comment in a file summary
+	maxSummaryDocLines = 8
+)
+
+// importLine matches import statements of the supported languages
+var importLine = regexp.MustCompile(`^\s*(import\b|from\s+\S+\s+import\b|use\s|using\s|require\b|#include\b|@import\b)`)
+
+// goImportSpec matches a package path inside a Go import block
+var goImportSpec = regexp.MustCompile(`^\s*(\w+\s+|\.\s+|_\s+)?"[^"]+"\s*$`)
+
+// SetFileSummaries enables embedding a summary of each indexed file into the
+// file-level collection, for two-tier retrieval
+func (idx *Indexer) SetFileSummaries(enabled bool) {
+	idx.fileSummaries = enabled
+}
+
+// FileSummary describes a file for the file-level collection: its path,
+// language, first doc comment, imports and exported symbols
+func FileSummary(filePath, language string, lines []string, symbols []Symbol) string {
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("File: %s\nLanguage: %s\n", filePath, language))
+
+	if doc := firstDocComment(lines); doc != "" {
+		summary.WriteString("Doc: " + doc + "\n")
+	}
+
+	if imports := fileImports(language, lines); len(imports) > 0 {
+		summary.WriteString("Imports: " + strings.Join(imports, "; ") + "\n")
+	}
+
+	var exported []string
+	for _, sym := range symbols {
+		if isExported(language, sym.Name) {
+			exported = append(exported, sym.Kind+" "+sym.Name)
+		}
+		if len(exported) == maxSummarySymbols {
+			break
+		}
+	}
+	if len(exported) > 0 {
+		summary.WriteString("Symbols: " + strings.Join(exported, ", ") + "\n")
+	}
+
+	return summary.String()
+}
+
+// fileImports returns the import statements of a file, up to
+// maxSummaryImports
+func fileImports(language string, lines []string) []string {
+	var imports []string
+	inGoBlock := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case language == "go" && trimmed == "import (":
+			inGoBlock = true
+		case inGoBlock && trimmed == ")":
+			inGoBlock = false
+		case inGoBlock && goImportSpec.MatchString(line):
+			imports = append(imports, trimmed)
+		case !inGoBlock && importLine.MatchString(line):
+			imports = append(imports, strings.TrimSuffix(trimmed, ";"))
+		}
+		if len(imports) == maxSummaryImports {
+			break
+		}
+	}
+	return imports
+}
+
+// firstDocComment returns the first comment block of a file, joined on one
+// line, skipping shebangs, build constraints and copyright lines
+func firstDocComment(lines []string) string {
+	var doc []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#!") || strings.HasPrefix(trimmed, "//go:build") || strings.HasPrefix(trimmed, "// +build") {
+			continue
+		}
+		if isCommentLine(line) || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, `"""`) {
+			text := strings.TrimSpace(strings.Trim(trimmed, "/#*\""))
+			if text != "" && !strings.HasPrefix(strings.ToLower(text), "copyright") {
+				doc = append(doc, text)
+			}
+			if len(doc) == maxSummaryDocLines {
+				break
+			}
+			continue
+		}
+		if trimmed == "" && len(doc) == 0 {
+			continue
+		}
+		break
+	}
+	return strings.Join(doc, " ")
+}
+
+// isExported reports whether a symbol is visible outside its file: Go names
+// starting with an upper-case letter, other names not starting with "_"
+func isExported(language, name string) bool {
+	if name == "" {
+		return false
+	}
+	if language == "go" {
+		return name[0] >= 'A' && name[0] <= 'Z'
+	}
+	return !strings.HasPrefix(name, "_")
+}
+
+// indexFileSummaries embeds the summaries set on chunks and stores them in the
+// file-level collection of collectionName. Failures are logged: chunks stay
+// searchable without them.
+func (idx *Indexer) indexFileSummaries(ctx context.Context, chunks []CodeChunk, collectionName string) {
+	var summarized []CodeChunk
+	for _, chunk := range chunks {
+		if chunk.FileSummary != "" && !chunk.Dirty {
+			summarized = append(summarized, chunk)
+		}
+	}
+	if len(summarized) == 0 {
+		return
+	}
+
+	texts := make([]string, len(summarized))
+	for i, chunk := range summarized {
+		texts[i] = chunk.FileSummary
+	}
+	embeddings, err := idx.embedBatch(ctx, texts)
+	if err != nil {
+		idx.logger.Warn("Failed to embed file summaries", zap.Int("files", len(summarized)), zap.Error(err))
+		return
+	}
+
+	points := make([]Point, len(summarized))
+	for i, chunk := range summarized {
+		points[i] = Point{
+			ID:     ChunkPointID(chunk.FilePath, 0),
+			Vector: embeddings[i],
+			Payload: map[string]interface{}{
+				"file_path":       chunk.FilePath,
+				"language":        chunk.Language,
+				"chunker_version": ChunkerVersion,
+				"file_hash":       chunk.FileHash,
+			},
+		}
+		if tags := idx.TagsFor(chunk.FilePath); len(tags) > 0 {
+			points[i].Payload["tags"] = tags
+		}
+		if root := idx.RootFor(chunk.FilePath); root != "" {
+			points[i].Payload["root"] = root
+		}
+		idx.storeContentPayload(points[i].Payload, chunk.FileSummary)
+	}
+
+	files := FilesCollection(collectionName)
+	if err := idx.vectorDB.CreateCollection(ctx, files, idx.embedder.Dimension()); err != nil {
+		idx.logger.Debug("File-level collection might already exist", zap.Error(err))
+	}
+	if err := idx.vectorDB.Upsert(ctx, files, points); err != nil {
+		idx.logger.Warn("Failed to store file summaries", zap.Int("files", len(points)), zap.Error(err))
+	}
+}
+
+// dropFileSummaries deletes the summaries of files removed from the index.
+// The file-level collection may not exist, so failures are only logged.
+func (idx *Indexer) dropFileSummaries(ctx context.Context, collectionName string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	if err := idx.vectorDB.Delete(ctx, FilesCollection(collectionName), map[string]interface{}{"file_path": files}); err != nil {
+		idx.logger.Debug("No file summaries dropped", zap.Error(err))
+	}
+}
+
+// TwoTierSearch searches coarse to fine: the files whose summaries best match
+// vector first, then the chunks of those files only. It returns the candidate
+// files too; none when no file summary is indexed. Both tiers keep matches
+// scoring at least minScore. filter and opts apply to both tiers, and the
+// SearchStats of ctx only count the chunk search.
+func TwoTierSearch(ctx context.Context, db VectorDB, collection string, filter map[string]interface{}, vector []float32, files, limit int, minScore float32, opts SearchOptions) ([]SearchResult, []string, error) {
+	coarse, err := db.Search(WithSearchTrace(WithSearchStats(ctx, nil), nil), FilesCollection(collection), filter, vector, files, 0, minScore, opts)
+	if err != nil || len(coarse) == 0 {
+		return nil, nil, err
+	}
+
+	candidates := make([]string, 0, len(coarse))
+	for _, result := range coarse {
+		candidates = append(candidates, result.FilePath)
+	}
+
+	fileFilter := map[string]interface{}{"file_path": candidates}
+	for key, value := range filter {
+		fileFilter[key] = value
+	}
+	results, err := db.Search(ctx, collection, fileFilter, vector, limit, 0, minScore, opts)
+	return results, candidates, err
+}
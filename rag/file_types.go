@@ -0,0 +1,83 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// filenameLanguages maps well-known extensionless file names to language
+// tags. "Dockerfile.prod" style variants match their base name.
+var filenameLanguages = map[string]string{
+	"Dockerfile":    "dockerfile",
+	"Containerfile": "dockerfile",
+	"Makefile":      "makefile",
+	"makefile":      "makefile",
+	"GNUmakefile":   "makefile",
+	"Jenkinsfile":   "groovy",
+	"Vagrantfile":   "ruby",
+	"Gemfile":       "ruby",
+	"Rakefile":      "ruby",
+	"Brewfile":      "ruby",
+	"Procfile":      "yaml",
+}
+
+// shebangExtensions maps shebang languages to the extension that enables
+// them: an extensionless python script is indexed when ".py" is
+var shebangExtensions = map[string]string{
+	"python": ".py", "bash": ".sh", "javascript": ".js", "typescript": ".ts",
+	"ruby": ".rb", "perl": ".pl", "php": ".php", "lua": ".lua", "r": ".r",
+}
+
+// FilenameLanguage returns the language of a well-known file name such as
+// Dockerfile, Makefile or Jenkinsfile, or ""
+func FilenameLanguage(filePath string) string {
+	base := filepath.Base(filePath)
+	if language, ok := filenameLanguages[base]; ok {
+		return language
+	}
+	if name, _, found := strings.Cut(base, "."); found {
+		return filenameLanguages[name]
+	}
+	return ""
+}
+
+// MatchesFileType reports whether a file should be indexed for the
+// configured file types. Entries starting with "." match extensions; other
+// entries match base names ("Dockerfile" also matches "Dockerfile.prod").
+// Files without an extension are also matched by their shebang, when the
+// extension of the script language is configured.
+func MatchesFileType(filePath string, fileTypes []string) bool {
+	ext := filepath.Ext(filePath)
+	base := filepath.Base(filePath)
+	for _, t := range fileTypes {
+		if strings.HasPrefix(t, ".") {
+			if ext == t {
+				return true
+			}
+			continue
+		}
+		if base == t || strings.HasPrefix(base, t+".") {
+			return true
+		}
+	}
+
+	if ext != "" {
+		return false
+	}
+	language := fileShebangLanguage(filePath)
+	return language != "" && contains(fileTypes, shebangExtensions[language])
+}
+
+// fileShebangLanguage reads the first line of a file for a shebang
+func fileShebangLanguage(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 256)
+	n, _ := file.Read(head)
+	return ShebangLanguage(head[:n])
+}
@@ -0,0 +1,106 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Generator answers chat conversations, e.g. a question with retrieved code
+type Generator interface {
+	Generate(ctx context.Context, messages []ChatMessage) (string, error)
+}
+
+// ChatMessage is one message of a conversation
+type ChatMessage struct {
+	Role    string // "system", "user" or "assistant"
+	Content string
+}
+
+// GeneratorConfig holds the settings of a chat backend
+type GeneratorConfig struct {
+	Type        string // "openai", "ollama" or "lmstudio"
+	Model       string
+	BaseURL     string // Default: the type's usual endpoint
+	APIKey      string
+	MaxTokens   int // Longest answer (0 = backend default)
+	Temperature float32
+}
+
+// generatorBaseURLs are the default OpenAI-compatible endpoints per type
+var generatorBaseURLs = map[string]string{
+	"openai":   "https://api.openai.com/v1",
+	"ollama":   "http://localhost:11434/v1",
+	"lmstudio": "http://localhost:1234/v1",
+}
+
+// ChatGenerator calls an OpenAI-compatible chat completions endpoint,
+// which OpenAI, Ollama and LM Studio all serve
+type ChatGenerator struct {
+	client *openai.Client
+	cfg    GeneratorConfig
+}
+
+// NewGenerator creates the chat backend of cfg
+func NewGenerator(cfg GeneratorConfig) (Generator, error) {
+	baseURL, ok := generatorBaseURLs[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown generation type %q (use openai, ollama or lmstudio)", cfg.Type)
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("generation model required")
+	}
+	if cfg.Type == "openai" && cfg.APIKey == "" {
+		return nil, fmt.Errorf("API key required")
+	}
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+
+	clientConfig := openai.DefaultConfig(cfg.APIKey)
+	clientConfig.BaseURL = strings.TrimSuffix(baseURL, "/")
+	return &ChatGenerator{client: openai.NewClientWithConfig(clientConfig), cfg: cfg}, nil
+}
+
+func (g *ChatGenerator) Generate(ctx context.Context, messages []ChatMessage) (string, error) {
+	request := openai.ChatCompletionRequest{
+		Model:       g.cfg.Model,
+		MaxTokens:   g.cfg.MaxTokens,
+		Temperature: g.cfg.Temperature,
+	}
+	for _, m := range messages {
+		request.Messages = append(request.Messages, openai.ChatCompletionMessage{Role: m.Role, Content: m.Content})
+	}
+
+	resp, err := g.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no answer returned")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// answerInstructions is the system prompt of AnswerMessages
+const answerInstructions = `You answer questions about a codebase using only the numbered code excerpts provided.
+- Cite every claim with the excerpt's file:line range in backticks, e.g. ` + "`/src/auth.go:10-42`" + `
+- Quote code only when it helps, and keep quotes short
+- If the excerpts do not answer the question, say so and name what is missing instead of guessing`
+
+// AnswerMessages builds the conversation asking a generator to answer
+// question from excerpts, citing them by file:line
+func AnswerMessages(question string, excerpts []SearchResult) []ChatMessage {
+	var sources strings.Builder
+	for i, excerpt := range excerpts {
+		sources.WriteString(fmt.Sprintf("[%d] %s:%d-%d\n```%s\n%s\n```\n\n",
+			i+1, excerpt.FilePath, excerpt.LineStart, excerpt.LineEnd, excerpt.Language, excerpt.Content))
+	}
+
+	return []ChatMessage{
+		{Role: "system", Content: answerInstructions},
+		{Role: "user", Content: fmt.Sprintf("Code excerpts:\n\n%sQuestion: %s", sources.String(), question)},
+	}
+}
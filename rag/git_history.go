@@ -0,0 +1,87 @@
+package rag
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Commit is one entry of the git log
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    string
+	Subject string
+	Body    string
+}
+
+// RecentCommits returns up to limit commits of repoDir, most recent first.
+// When paths are given, only commits touching them are returned.
+// Commits are read from git directly; they are not part of the vector index.
+func RecentCommits(ctx context.Context, repoDir string, paths []string, limit int) ([]Commit, error) {
+	args := []string{"log", "--no-color", "--date=short",
+		"--format=%h%x1f%an%x1f%ad%x1f%s%x1f%b%x1e", "-n", strconv.Itoa(limit), "--"}
+	args = append(args, paths...)
+
+	out, err := runGit(ctx, repoDir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) < 5 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Date:    fields[2],
+			Subject: fields[3],
+			Body:    strings.TrimSpace(fields[4]),
+		})
+	}
+	return commits, nil
+}
+
+// commitPrefixPattern matches conventional subject prefixes: "feat:", "fix(api):", "[ABC-12]"
+var commitPrefixPattern = regexp.MustCompile(`^(\[[^\]]+\]|[a-z]+(\([^)]*\))?!?:)`)
+
+// SubjectConvention is a subject prefix style and how many commits use it
+type SubjectConvention struct {
+	Pattern string
+	Count   int
+}
+
+// CommitConventions summarizes the subject prefix styles of commits, most common first.
+// Bracketed prefixes and scopes are generalized ("[ABC-12]" -> "[...]", "fix(api):" -> "fix(...):").
+func CommitConventions(commits []Commit) []SubjectConvention {
+	counts := make(map[string]int)
+	for _, c := range commits {
+		prefix := commitPrefixPattern.FindString(c.Subject)
+		switch {
+		case prefix == "":
+			prefix = "(no prefix)"
+		case strings.HasPrefix(prefix, "["):
+			prefix = "[...]"
+		case strings.Contains(prefix, "("):
+			prefix = prefix[:strings.Index(prefix, "(")] + "(...):"
+		}
+		counts[prefix]++
+	}
+
+	conventions := make([]SubjectConvention, 0, len(counts))
+	for pattern, count := range counts {
+		conventions = append(conventions, SubjectConvention{Pattern: pattern, Count: count})
+	}
+	sort.Slice(conventions, func(i, j int) bool {
+		if conventions[i].Count != conventions[j].Count {
+			return conventions[i].Count > conventions[j].Count
+		}
+		return conventions[i].Pattern < conventions[j].Pattern
+	})
+	return conventions
+}
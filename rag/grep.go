@@ -0,0 +1,72 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GrepMatch is an indexed line matching a pattern
+type GrepMatch struct {
+	FilePath string
+	Line     int
+	Text     string
+	Language string
+}
+
+// GrepIndex returns the indexed lines matching pattern, sorted by file and
+// line, and how many lines matched in total. It scans the stored content of
+// the chunks matching filter (nil for all), so it needs no embeddings and
+// sees exactly what searches see; a limit of 0 returns every match.
+func GrepIndex(ctx context.Context, db VectorDB, collection string, pattern *regexp.Regexp, filter map[string]interface{}, limit int) ([]GrepMatch, int, error) {
+	seen := make(map[string]bool)
+	var matches []GrepMatch
+
+	fields := append([]string{"language"}, contentFields...)
+	disk := newDiskLines()
+	err := db.Scroll(ctx, collection, filter, fields, func(point StoredPoint) error {
+		content := payloadContent(point.Payload, disk)
+		if !pattern.MatchString(content) {
+			return nil
+		}
+		filePath, _ := point.Payload["file_path"].(string)
+		language, _ := point.Payload["language"].(string)
+		lineStart := payloadInt(point.Payload["line_start"])
+
+		for i, line := range strings.Split(content, "\n") {
+			if !pattern.MatchString(line) {
+				continue
+			}
+			key := fmt.Sprintf("%s:%d", filePath, lineStart+i)
+			if seen[key] {
+				continue // Overlapping chunks share lines
+			}
+			seen[key] = true
+			matches = append(matches, GrepMatch{
+				FilePath: filePath,
+				Line:     lineStart + i,
+				Text:     strings.TrimSpace(line),
+				Language: language,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].FilePath != matches[j].FilePath {
+			return matches[i].FilePath < matches[j].FilePath
+		}
+		return matches[i].Line < matches[j].Line
+	})
+
+	total := len(matches)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, total, nil
+}
@@ -0,0 +1,90 @@
+package rag
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// hclChunk is a window of lines with the address of the block it holds, if any
+type hclChunk struct {
+	start, end int // Lines [start, end)
+	address    string
+}
+
+// HCLBlockAddress returns the Terraform address of a top-level block:
+// aws_vpc.main, data.aws_ami.ubuntu, module.network, var.region, output.id
+func HCLBlockAddress(blockType string, labels []string) string {
+	switch blockType {
+	case "resource":
+		return strings.Join(labels, ".")
+	case "data":
+		return strings.Join(append([]string{"data"}, labels...), ".")
+	case "variable":
+		return strings.Join(append([]string{"var"}, labels...), ".")
+	default:
+		return strings.Join(append([]string{blockType}, labels...), ".")
+	}
+}
+
+// chunkHCL chunks a Terraform file with one chunk per top-level block,
+// comments directly above a block included. Lines outside blocks (top-level
+// attributes, stray comments) are chunked as usual, and a block larger than
+// size is split with its address on every part. Returns false when the file
+// does not parse, so the caller falls back to plain windows.
+func chunkHCL(filePath string, lines []string, size, overlap int) ([]hclChunk, bool) {
+	file, diags := hclsyntax.ParseConfig([]byte(strings.Join(lines, "\n")), filePath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+
+	var chunks []hclChunk
+	addLoose := func(start, end int) {
+		for _, w := range chunkLines(lines[start:end], size, overlap) {
+			chunks = append(chunks, hclChunk{start: start + w[0], end: start + w[1]})
+		}
+	}
+
+	covered := 0
+	for _, block := range body.Blocks {
+		r := block.Range()
+		start, end := r.Start.Line-1, r.End.Line
+		if start < covered {
+			continue // Another block on the same line
+		}
+
+		// Attach the comment lines directly above the block
+		for start > covered && isHCLComment(lines[start-1]) {
+			start--
+		}
+		if start > covered {
+			addLoose(covered, start)
+		}
+
+		address := HCLBlockAddress(block.Type, block.Labels)
+		if linesChars(lines[start:end]) <= size {
+			chunks = append(chunks, hclChunk{start: start, end: end, address: address})
+		} else {
+			for _, w := range chunkLines(lines[start:end], size, overlap) {
+				chunks = append(chunks, hclChunk{start: start + w[0], end: start + w[1], address: address})
+			}
+		}
+		covered = end
+	}
+	if covered < len(lines) {
+		addLoose(covered, len(lines))
+	}
+	return chunks, true
+}
+
+// isHCLComment reports whether a line is a whole-line comment
+func isHCLComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") ||
+		strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*")
+}
@@ -0,0 +1,178 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// hotFileDecay is how much of its hit count a file keeps at each Prefetch,
+// so files stop being hot once agents stop asking about them
+const hotFileDecay = 0.5
+
+// cachedFile is the content of a hot file as of its modification time
+type cachedFile struct {
+	lines   []string
+	empty   bool
+	modTime time.Time
+	size    int64
+}
+
+// HotFiles logs which files searches return and keeps the lines of the most
+// returned ones in memory, so expanding matches into function bodies and
+// reading ranges of them skip the disk. Cached files are checked against
+// their modification time and size on every read, so edits are never
+// served stale.
+type HotFiles struct {
+	capacity int
+
+	mu     sync.Mutex
+	hits   map[string]float64 // Decayed number of times each file was returned
+	cached map[string]*cachedFile
+}
+
+// NewHotFiles creates a cache of up to capacity files
+func NewHotFiles(capacity int) *HotFiles {
+	return &HotFiles{
+		capacity: capacity,
+		hits:     make(map[string]float64),
+		cached:   make(map[string]*cachedFile),
+	}
+}
+
+// Record logs the files of search results
+func (h *HotFiles) Record(results []SearchResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, result := range results {
+		h.hits[result.FilePath]++
+	}
+}
+
+// Hot returns up to n files by decreasing hit count
+func (h *HotFiles) Hot(n int) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hotLocked(n)
+}
+
+func (h *HotFiles) hotLocked(n int) []string {
+	files := make([]string, 0, len(h.hits))
+	for file := range h.hits {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if h.hits[files[i]] != h.hits[files[j]] {
+			return h.hits[files[i]] > h.hits[files[j]]
+		}
+		return files[i] < files[j]
+	})
+	if len(files) > n {
+		files = files[:n]
+	}
+	return files
+}
+
+// Prefetch loads the hottest files into the cache and evicts the others,
+// then decays the hit counts. It returns the number of files cached.
+func (h *HotFiles) Prefetch() int {
+	h.mu.Lock()
+	hot := h.hotLocked(h.capacity)
+	for file, hits := range h.hits {
+		if hits *= hotFileDecay; hits < 0.5 {
+			delete(h.hits, file)
+		} else {
+			h.hits[file] = hits
+		}
+	}
+	h.mu.Unlock()
+
+	// Read outside the lock: searches keep being served meanwhile
+	cached := make(map[string]*cachedFile, len(hot))
+	for _, file := range hot {
+		if entry, err := h.fresh(file); err == nil {
+			cached[file] = entry
+		} else if entry, err := loadCachedFile(file); err == nil {
+			cached[file] = entry
+		}
+	}
+
+	h.mu.Lock()
+	h.cached = cached
+	h.mu.Unlock()
+	return len(cached)
+}
+
+// Cached returns the number of files in the cache
+func (h *HotFiles) Cached() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.cached)
+}
+
+// ReadLines is ReadLines served from the cache when filePath is hot and
+// unchanged on disk
+func (h *HotFiles) ReadLines(filePath string, start, end int) ([]string, int, error) {
+	if start < 1 {
+		return nil, 0, fmt.Errorf("start line must be >= 1")
+	}
+	if end < start {
+		return nil, 0, fmt.Errorf("end line must be >= start line")
+	}
+
+	entry, err := h.fresh(filePath)
+	if err != nil {
+		return ReadLines(filePath, start, end)
+	}
+	return lineRange(entry.lines, entry.empty, start, end)
+}
+
+// ExpandResult is ExpandResult reading hot files from the cache
+func (h *HotFiles) ExpandResult(result SearchResult, n int) SearchResult {
+	return expandResult(result, n, h.ReadLines)
+}
+
+// ParentResult is ParentResult reading hot files from the cache
+func (h *HotFiles) ParentResult(result SearchResult) SearchResult {
+	return parentResult(result, h.ReadLines)
+}
+
+// fresh returns the cached content of filePath if it is cached and its
+// modification time and size did not change
+func (h *HotFiles) fresh(filePath string) (*cachedFile, error) {
+	h.mu.Lock()
+	entry, ok := h.cached[filePath]
+	h.mu.Unlock()
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.ModTime().Equal(entry.modTime) || info.Size() != entry.size {
+		return nil, fmt.Errorf("%s changed since it was cached", filePath)
+	}
+	return entry, nil
+}
+
+// loadCachedFile reads a file for the cache
+func loadCachedFile(filePath string) (*cachedFile, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedFile{
+		lines:   SplitLines(content),
+		empty:   len(content) == 0,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package rag
+
+import "sort"
+
+// rrfK is the reciprocal rank fusion damping constant
+const rrfK = 60
+
+// FuseResults merges vector and lexical rankings with reciprocal rank fusion,
+// deduplicated with dedup. Scores are normalized so the best fused result
+// scores 1.0.
+func FuseResults(vector, lexical []SearchResult, limit int, dedup DedupOptions) []SearchResult {
+	type fused struct {
+		result SearchResult
+		score  float64
+	}
+
+	byID := make(map[string]*fused)
+	var order []string
+
+	add := func(results []SearchResult) {
+		for rank, r := range results {
+			f, ok := byID[r.ID]
+			if !ok {
+				f = &fused{result: r}
+				byID[r.ID] = f
+				order = append(order, r.ID)
+			}
+			f.score += 1.0 / float64(rrfK+rank+1)
+		}
+	}
+	add(vector)
+	add(lexical)
+
+	merged := make([]fused, 0, len(order))
+	for _, id := range order {
+		merged = append(merged, *byID[id])
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].score > merged[j].score
+	})
+
+	results := make([]SearchResult, 0, len(merged))
+	for _, f := range merged {
+		r := f.result
+		r.Score = float32(f.score / merged[0].score)
+		results = append(results, r)
+	}
+
+	results = dedup.deduplicate(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
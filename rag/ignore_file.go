@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the per-project list of paths never indexed, in gitignore syntax
+const IgnoreFileName = ".code-ragignore"
+
+// IgnoreRules are the patterns of an ignore file, in file order
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern string
+	negate  bool // "!pattern" re-includes paths ignored by earlier rules
+}
+
+// LoadIgnoreFile reads the .code-ragignore file at root.
+// It returns nil rules and no error when there is none.
+func LoadIgnoreFile(root string) (*IgnoreRules, error) {
+	file, err := os.Open(filepath.Join(root, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules := &IgnoreRules{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		// "\#" and "\!" escape a leading special character
+		line = strings.TrimPrefix(line, "\\")
+		rule.pattern = line
+
+		if err := ValidatePathPatterns([]string{line}); err != nil {
+			return nil, err
+		}
+		rules.rules = append(rules.rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// Ignored reports whether a path relative to the ignore file's directory is
+// ignored. As in gitignore, the last matching rule wins, and files below an
+// ignored directory cannot be re-included.
+func (r *IgnoreRules) Ignored(relPath string, isDir bool) bool {
+	if r == nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	// An ignored parent directory wins over any rule about the path itself
+	if parent := path.Dir(relPath); parent != "." && r.Ignored(parent, true) {
+		return true
+	}
+
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		if matchPathPattern(r.rules[i].pattern, relPath, isDir) {
+			return !r.rules[i].negate
+		}
+	}
+	return false
+}
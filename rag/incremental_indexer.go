@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -23,14 +24,25 @@ type IncrementalIndexer struct {
 	*Indexer
 	state     *IndexingState
 	statePath string
+	retry     RetryPolicy // Retries of failed files at the end of a run
+
+	control   sync.Mutex
+	cancelRun context.CancelFunc // Cancels the running IndexDirectoryIncremental, if any
+	resumed   chan struct{}      // Non-nil while paused; closed on resume
+
+	commitsMu  sync.Mutex // Guards the indexed commits file
+	tagsFileMu sync.Mutex // Guards the path tags file
 }
 
 // NewIncrementalIndexer creates a new incremental indexer
 func NewIncrementalIndexer(indexer *Indexer, workDir string) *IncrementalIndexer {
-	return &IncrementalIndexer{
+	idx := &IncrementalIndexer{
 		Indexer:   indexer,
 		statePath: filepath.Join(workDir, StateFileName),
+		retry:     DefaultRetryPolicy,
 	}
+	idx.loadPathTags()
+	return idx
 }
 
 // IndexDirectoryIncremental indexes a directory with resume capability
@@ -40,11 +52,23 @@ func (idx *IncrementalIndexer) IndexDirectoryIncremental(
 	extensions []string,
 	collectionName string,
 ) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idx.control.Lock()
+	idx.cancelRun = cancel
+	idx.control.Unlock()
+	defer func() {
+		idx.control.Lock()
+		idx.cancelRun = nil
+		idx.control.Unlock()
+	}()
+
 	// Try to load existing state
 	state, err := LoadIndexingState(idx.statePath)
 	if err != nil || state.RootPath != path || state.Status == "completed" {
 		// Start fresh
 		state = NewIndexingState(path)
+		state.Commit, _ = HeadCommit(ctx, path) // Empty outside git repositories
 		idx.logger.Info("Starting new indexing session", zap.String("path", path))
 	} else {
 		idx.logger.Info("Resuming indexing session",
@@ -78,18 +102,18 @@ func (idx *IncrementalIndexer) IndexDirectoryIncremental(
 	if len(filesToProcess) == 0 {
 		state.SetStatus("completed")
 		state.Save(idx.statePath)
+		idx.recordIndexedCommit(path, state.Commit)
 		idx.logger.Info("Indexing already complete")
 		return nil
 	}
 
 	// Process files in batches
 	for i := 0; i < len(filesToProcess); i += FileBatchSize {
-		select {
-		case <-ctx.Done():
+		if err := idx.waitWhilePaused(ctx); err != nil {
 			idx.logger.Info("Indexing cancelled, saving state...")
+			state.SetStatus("cancelled")
 			state.Save(idx.statePath)
-			return ctx.Err()
-		default:
+			return err
 		}
 
 		end := i + FileBatchSize
@@ -119,8 +143,18 @@ func (idx *IncrementalIndexer) IndexDirectoryIncremental(
 		)
 	}
 
+	// Transient embedding failures must not leave holes in the index
+	if err := idx.retryWithBackoff(ctx, collectionName); err != nil {
+		idx.logger.Info("Indexing cancelled while retrying failed files, saving state...")
+		state.SetStatus("cancelled")
+		state.Save(idx.statePath)
+		return err
+	}
+
 	state.SetStatus("completed")
 	state.Save(idx.statePath)
+	idx.recordIndexedCommit(path, state.Commit)
+	idx.recordFullIndex(ctx, collectionName, path)
 
 	idx.logger.Info("Indexing complete",
 		zap.Int("total_files", state.IndexedFiles),
@@ -132,7 +166,7 @@ func (idx *IncrementalIndexer) IndexDirectoryIncremental(
 }
 
 // collectFiles walks the directory and collects files with priority
-func (idx *IncrementalIndexer) collectFiles(rootPath string, extensions []string) ([]string, error) {
+func (idx *Indexer) collectFiles(rootPath string, extensions []string) ([]string, error) {
 	type fileWithPriority struct {
 		path     string
 		priority int
@@ -178,6 +212,8 @@ func (idx *IncrementalIndexer) collectFiles(rootPath string, extensions []string
 		"bin":          true,
 	}
 
+	matcher := idx.newPathMatcher(rootPath, PathFilter{})
+
 	err := filepath.Walk(rootPath, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -187,20 +223,23 @@ func (idx *IncrementalIndexer) collectFiles(rootPath string, extensions []string
 			dirName := filepath.Base(filePath)
 
 			// Skip certain directories
-			if skipDirs[dirName] || strings.HasPrefix(dirName, ".") {
+			if skipDirs[dirName] || strings.HasPrefix(dirName, ".") || matcher.skip(filePath, true) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		// Check extension
-		ext := filepath.Ext(filePath)
-		if !contains(extensions, ext) {
+		if matcher.skip(filePath, false) {
+			return nil
+		}
+
+		// Check extension, file name or shebang
+		if !MatchesFileType(filePath, extensions) {
 			return nil
 		}
 
 		// Skip large files
-		if info.Size() > 1024*1024 { // 1MB
+		if info.Size() > idx.chunking.MaxFileSize {
 			idx.logger.Debug("Skipping large file", zap.String("file", filePath))
 			return nil
 		}
@@ -249,6 +288,9 @@ func (idx *IncrementalIndexer) collectFiles(rootPath string, extensions []string
 // processBatch processes a batch of files
 func (idx *IncrementalIndexer) processBatch(ctx context.Context, files []string, collectionName string) error {
 	var allChunks []CodeChunk
+	var chunked []string // Files with chunks, marked processed once embedded
+	var emptied []string // Files without chunks, whose previous chunks go now
+	chunkCounts := make(map[string]int)
 
 	for _, filePath := range files {
 		chunks, err := idx.chunkFile(filePath)
@@ -260,6 +302,7 @@ func (idx *IncrementalIndexer) processBatch(ctx context.Context, files []string,
 
 		if len(chunks) == 0 {
 			idx.state.MarkFileProcessed(filePath, 0)
+			emptied = append(emptied, filePath)
 			continue
 		}
 
@@ -269,9 +312,40 @@ func (idx *IncrementalIndexer) processBatch(ctx context.Context, files []string,
 		}
 
 		allChunks = append(allChunks, chunks...)
-		idx.state.MarkFileProcessed(filePath, len(chunks))
+		chunked = append(chunked, filePath)
+		chunkCounts[filePath] = len(chunks)
 	}
 
+	// Chunks left by a previous run are replaced file by file, once the
+	// file's new points are stored, so a failed batch keeps them searchable
+	oldIDs := make(map[string][]string)
+	err := idx.vectorDB.Scroll(ctx, collectionName, map[string]interface{}{"file_path": files}, []string{"file_path"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		oldIDs[filePath] = append(oldIDs[filePath], point.ID)
+		return nil
+	})
+	if err != nil {
+		idx.logger.Warn("Failed to list previous chunks", zap.Error(err))
+	}
+	stored := make(map[string]bool)
+	replaceChunks := func(filePath string) {
+		var stale []string
+		for _, id := range oldIDs[filePath] {
+			if !stored[id] {
+				stale = append(stale, id)
+			}
+		}
+		if len(stale) == 0 {
+			return
+		}
+		if err := idx.vectorDB.DeleteIDs(ctx, collectionName, stale); err != nil {
+			idx.logger.Warn("Failed to delete previous chunks", zap.String("file", filePath), zap.Error(err))
+		}
+	}
+
+	for _, filePath := range emptied {
+		replaceChunks(filePath)
+	}
 	if len(allChunks) == 0 {
 		return nil
 	}
@@ -284,7 +358,27 @@ func (idx *IncrementalIndexer) processBatch(ctx context.Context, files []string,
 		}
 
 		chunkBatch := allChunks[i:end]
-		if err := idx.indexBatch(ctx, chunkBatch, collectionName); err != nil {
+		ids, err := idx.upsertChunks(ctx, chunkBatch, collectionName)
+		for _, id := range ids {
+			stored[id] = true
+		}
+		if err != nil {
+			// Files with chunks left to embed are not processed: canceled ones
+			// wait for a resume, failed ones are retried at the end of the
+			// run or with RetryFailedFiles
+			pending := make(map[string]bool)
+			for _, chunk := range allChunks[i:] {
+				pending[chunk.FilePath] = true
+			}
+			for _, filePath := range chunked {
+				switch {
+				case !pending[filePath]:
+					replaceChunks(filePath)
+					idx.state.MarkFileProcessed(filePath, chunkCounts[filePath])
+				case ctx.Err() == nil:
+					idx.state.MarkFileFailed(filePath, err.Error())
+				}
+			}
 			return fmt.Errorf("failed to index chunk batch: %w", err)
 		}
 
@@ -292,15 +386,135 @@ func (idx *IncrementalIndexer) processBatch(ctx context.Context, files []string,
 		time.Sleep(100 * time.Millisecond)
 	}
 
+	for _, filePath := range chunked {
+		replaceChunks(filePath)
+		idx.state.MarkFileProcessed(filePath, chunkCounts[filePath])
+	}
 	return nil
 }
 
+// Cancel stops the running IndexDirectoryIncremental after its current
+// batch, keeping its progress for a later resume. It reports whether a run
+// was active.
+func (idx *IncrementalIndexer) Cancel() bool {
+	idx.control.Lock()
+	defer idx.control.Unlock()
+
+	if idx.cancelRun == nil {
+		return false
+	}
+	idx.cancelRun()
+	return true
+}
+
+// Running reports whether IndexDirectoryIncremental is running
+func (idx *IncrementalIndexer) Running() bool {
+	idx.control.Lock()
+	defer idx.control.Unlock()
+	return idx.cancelRun != nil
+}
+
+// Pause holds indexing before its next batch until Resume, freeing the
+// embedding server. It reports false if indexing was already paused.
+func (idx *IncrementalIndexer) Pause() bool {
+	idx.control.Lock()
+	defer idx.control.Unlock()
+
+	if idx.resumed != nil {
+		return false
+	}
+	idx.resumed = make(chan struct{})
+	return true
+}
+
+// Resume continues paused indexing. It reports false if it was not paused.
+func (idx *IncrementalIndexer) Resume() bool {
+	idx.control.Lock()
+	defer idx.control.Unlock()
+
+	if idx.resumed == nil {
+		return false
+	}
+	close(idx.resumed)
+	idx.resumed = nil
+	return true
+}
+
+// Paused reports whether indexing is paused
+func (idx *IncrementalIndexer) Paused() bool {
+	idx.control.Lock()
+	defer idx.control.Unlock()
+	return idx.resumed != nil
+}
+
+// waitWhilePaused blocks while indexing is paused, returning ctx's error if
+// it is cancelled first
+func (idx *IncrementalIndexer) waitWhilePaused(ctx context.Context) error {
+	idx.control.Lock()
+	resumed := idx.resumed
+	idx.control.Unlock()
+
+	if resumed != nil {
+		idx.logger.Info("Indexing paused")
+		idx.state.SetStatus("paused")
+		idx.state.Save(idx.statePath)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resumed:
+		}
+
+		idx.logger.Info("Indexing resumed")
+		idx.state.SetStatus("in_progress")
+	}
+
+	return ctx.Err()
+}
+
 // GetState returns the current indexing state
 func (idx *IncrementalIndexer) GetState() *IndexingState {
 	return idx.state
 }
 
+// WorkDir returns the directory holding the indexing state file
+func (idx *IncrementalIndexer) WorkDir() string {
+	return filepath.Dir(idx.statePath)
+}
+
 // ResetState removes the state file to start fresh
 func (idx *IncrementalIndexer) ResetState() error {
-	return os.Remove(idx.statePath)
+	idx.state = nil
+	if err := os.Remove(idx.statePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ReindexAll forces a full re-index of paths, ignoring any saved progress.
+// With recreate, the collection is dropped and created again with dimension
+// first, which also applies a changed embedding dimension.
+func (idx *IncrementalIndexer) ReindexAll(ctx context.Context, paths, extensions []string, collectionName string, recreate bool, dimension int) error {
+	if recreate {
+		idx.logger.Info("Recreating collection", zap.String("collection", collectionName), zap.Int("dimension", dimension))
+		if err := idx.vectorDB.DeleteCollection(ctx, collectionName); err != nil {
+			return err
+		}
+		// The next full index writes a manifest for the new setup
+		idx.vectorDB.DeleteCollection(ctx, ManifestCollection(collectionName))
+		if err := idx.vectorDB.CreateCollection(ctx, collectionName, dimension); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range paths {
+		if err := idx.ResetState(); err != nil {
+			return fmt.Errorf("failed to reset indexing state: %w", err)
+		}
+		if err := idx.IndexDirectoryIncremental(ctx, path, extensions, collectionName); err != nil {
+			return fmt.Errorf("failed to index %s: %w", path, err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,84 @@
+package rag
+
+import (
+	"context"
+	"sort"
+)
+
+// BreakdownEntry counts the chunks and files of one language or directory
+type BreakdownEntry struct {
+	Name   string
+	Files  int
+	Chunks int
+}
+
+// IndexBreakdown is what a collection holds, by language and by top-level
+// directory, largest first
+type IndexBreakdown struct {
+	Files       int
+	Chunks      int
+	Languages   []BreakdownEntry
+	Directories []BreakdownEntry // One level below the code path containing each file
+}
+
+// BreakdownIndex scrolls collection and counts its chunks and files by
+// language and by top-level directory of roots (files outside roots are
+// counted under their own directory)
+func BreakdownIndex(ctx context.Context, db VectorDB, collection string, roots []string) (*IndexBreakdown, error) {
+	languages := make(map[string]*BreakdownEntry)
+	dirs := make(map[string]*BreakdownEntry)
+	files := make(map[string]bool)
+	breakdown := &IndexBreakdown{}
+
+	count := func(entries map[string]*BreakdownEntry, name string, newFile bool) {
+		entry := entries[name]
+		if entry == nil {
+			entry = &BreakdownEntry{Name: name}
+			entries[name] = entry
+		}
+		entry.Chunks++
+		if newFile {
+			entry.Files++
+		}
+	}
+
+	err := db.Scroll(ctx, collection, nil, []string{"file_path", "language"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		language, _ := point.Payload["language"].(string)
+		if language == "" {
+			language = "unknown"
+		}
+		newFile := !files[filePath]
+		files[filePath] = true
+
+		breakdown.Chunks++
+		if newFile {
+			breakdown.Files++
+		}
+		count(languages, language, newFile)
+		count(dirs, directoryAtDepth(filePath, rootOf(filePath, roots), 1), newFile)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown.Languages = sortedEntries(languages)
+	breakdown.Directories = sortedEntries(dirs)
+	return breakdown, nil
+}
+
+// sortedEntries returns entries by decreasing chunks, then name
+func sortedEntries(entries map[string]*BreakdownEntry) []BreakdownEntry {
+	sorted := make([]BreakdownEntry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, *entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Chunks != sorted[j].Chunks {
+			return sorted[i].Chunks > sorted[j].Chunks
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
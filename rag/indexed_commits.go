@@ -0,0 +1,176 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// IndexedCommitsFileName stores, per indexed directory, the git commit the
+// index reflects
+const IndexedCommitsFileName = ".indexed_commits.json"
+
+// ErrNoIndexedCommit is returned by ReindexChanged for a directory indexed
+// before commits were recorded, or never indexed
+var ErrNoIndexedCommit = errors.New("no indexed commit recorded; index the directory first")
+
+// HeadCommit returns the commit checked out in the repository containing dir
+func HeadCommit(ctx context.Context, dir string) (string, error) {
+	out, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ChangedFilesSince lists the files under dir (absolute paths) that differ
+// between commit and HEAD, deleted files included. Renamed files are listed
+// under both names so the old one can be removed.
+func ChangedFilesSince(ctx context.Context, dir, commit string) ([]string, error) {
+	if commit == "" || strings.HasPrefix(commit, "-") {
+		return nil, fmt.Errorf("invalid commit: %q", commit)
+	}
+
+	top, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top = strings.TrimSpace(top)
+
+	// Paths are relative to the top-level; the "." pathspec limits them to dir
+	out, err := runGit(ctx, dir, "diff", "--name-only", "--no-renames", "-z", commit, "HEAD", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, filepath.Join(top, filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
+
+// IndexedCommit returns the commit recorded for path, or "" if none was
+func (idx *IncrementalIndexer) IndexedCommit(path string) string {
+	idx.commitsMu.Lock()
+	defer idx.commitsMu.Unlock()
+	return idx.loadIndexedCommits()[filepath.Clean(path)]
+}
+
+// SetIndexedCommit records that the index of path reflects commit
+func (idx *IncrementalIndexer) SetIndexedCommit(path, commit string) error {
+	idx.commitsMu.Lock()
+	defer idx.commitsMu.Unlock()
+
+	commits := idx.loadIndexedCommits()
+	commits[filepath.Clean(path)] = commit
+
+	data, err := json.MarshalIndent(commits, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(idx.WorkDir(), IndexedCommitsFileName), data, 0644)
+}
+
+// recordIndexedCommit records commit for path if path is in a repository,
+// logging failures: the index itself is fine without it
+func (idx *IncrementalIndexer) recordIndexedCommit(path, commit string) {
+	if commit == "" {
+		return
+	}
+	if err := idx.SetIndexedCommit(path, commit); err != nil {
+		idx.logger.Warn("Failed to record indexed commit", zap.String("path", path), zap.Error(err))
+	}
+}
+
+func (idx *IncrementalIndexer) loadIndexedCommits() map[string]string {
+	commits := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(idx.WorkDir(), IndexedCommitsFileName))
+	if err == nil {
+		if err := json.Unmarshal(data, &commits); err != nil {
+			idx.logger.Warn("Ignoring unreadable indexed commits file", zap.Error(err))
+			commits = make(map[string]string)
+		}
+	}
+	return commits
+}
+
+// ChangedReport lists what ReindexChanged re-indexed
+type ChangedReport struct {
+	From  string   // Commit the index reflected
+	To    string   // Commit it reflects now (HEAD)
+	Files []string // Changed and deleted files, re-indexed or removed
+}
+
+// ReindexChanged re-indexes exactly the files under path changed between the
+// commit recorded when path was last indexed and HEAD, removing deleted ones,
+// then records HEAD. Uncommitted changes are not included.
+func (idx *IncrementalIndexer) ReindexChanged(ctx context.Context, path string, extensions []string, collectionName string) (*ChangedReport, error) {
+	from := idx.IndexedCommit(path)
+	if from == "" {
+		return nil, ErrNoIndexedCommit
+	}
+	to, err := HeadCommit(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ChangedReport{From: from, To: to}
+	if from == to {
+		return report, nil
+	}
+
+	changed, err := ChangedFilesSince(ctx, path, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..HEAD (run reindex_all if history was rewritten): %w", ShortCommit(from), err)
+	}
+
+	matcher := idx.newPathMatcher(path, PathFilter{})
+	for _, filePath := range changed {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			// Deleted: drop its chunks, whatever its type
+			report.Files = append(report.Files, filePath)
+			continue
+		}
+		if matcher.skip(filePath, false) || !MatchesFileType(filePath, extensions) {
+			continue
+		}
+		report.Files = append(report.Files, filePath)
+	}
+
+	for i := 0; i < len(report.Files); i += FileBatchSize {
+		end := i + FileBatchSize
+		if end > len(report.Files) {
+			end = len(report.Files)
+		}
+		if err := idx.ReindexFiles(ctx, report.Files[i:end], collectionName); err != nil {
+			return report, fmt.Errorf("failed to re-index changed files: %w", err)
+		}
+	}
+
+	idx.recordIndexedCommit(path, to)
+	idx.logger.Info("Re-indexed changes since last indexed commit",
+		zap.String("path", path),
+		zap.String("from", ShortCommit(from)),
+		zap.String("to", ShortCommit(to)),
+		zap.Int("files", len(report.Files)),
+	)
+
+	return report, nil
+}
+
+// ShortCommit abbreviates a commit hash for display
+func ShortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
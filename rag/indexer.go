@@ -2,10 +2,14 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"text/template"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -15,6 +19,92 @@ type Indexer struct {
 	embedder Embedder
 	vectorDB VectorDB
 	logger   *zap.Logger
+	filter   PathFilter // Configured include/exclude globs
+	chunking ChunkingConfig
+	hooks    []ChunkHook // Run on each chunk before embedding
+	dedup    bool        // Skip chunks whose content is indexed from another file
+
+	maxEmbedTokens int               // Texts are cut to this many tokens before embedding (0 = no limit)
+	prefixes       EmbeddingPrefixes // Prepended to documents and queries of asymmetric models
+
+	fileSummaries bool // Embed a summary of each file for two-tier retrieval
+
+	scrubber   *SecretScrubber // Masks secrets before embedding (nil = off)
+	sensitive  []string        // File name globs never indexed
+	extensions []string        // File types ReindexFiles accepts (nil = all)
+
+	storeContent    bool // Store chunk content in payloads, or read it from disk
+	compressContent bool // Store it zstd-compressed
+
+	embeddingModel string // Recorded in the index manifest
+	distance       string // Distance metric of the collection, recorded in the index manifest
+
+	sparse SparseEncoder // Encodes chunks into sparse vectors too (nil = dense only)
+
+	enrichment *template.Template // Renders chunks into the text embedded for them
+	repos      sync.Map           // Directory -> Git repository containing it, for enrichment
+
+	rootsMu sync.RWMutex
+	roots   []string // Code paths feeding the collection, innermost first
+
+	tagsMu sync.RWMutex
+	tags   map[string][]string // Tags of the files under each path
+}
+
+// ChunkingConfig controls how files are split into chunks. Sizes are in
+// characters; chunks always end on line boundaries.
+type ChunkingConfig struct {
+	ChunkSize    int   // Maximum characters per chunk (a longer single line forms its own chunk)
+	ChunkOverlap int   // Characters of trailing lines repeated at the start of the next chunk
+	MaxFileSize  int64 // Files larger than this (bytes) are skipped
+
+	// Languages overrides ChunkSize/ChunkOverlap per language tag (e.g. "terraform").
+	// Zero fields fall back to the global values.
+	Languages map[string]LanguageChunking
+}
+
+// LanguageChunking is a per-language chunk size override, in characters
+type LanguageChunking struct {
+	ChunkSize    int
+	ChunkOverlap int
+}
+
+// DefaultChunking matches the config defaults
+var DefaultChunking = ChunkingConfig{ChunkSize: 1000, ChunkOverlap: 200, MaxFileSize: 1024 * 1024}
+
+// normalized replaces invalid values by defaults and keeps the overlap below the chunk size
+func (c ChunkingConfig) normalized() ChunkingConfig {
+	if c.ChunkSize <= 0 {
+		c.ChunkSize = DefaultChunking.ChunkSize
+	}
+	if c.ChunkOverlap < 0 {
+		c.ChunkOverlap = 0
+	}
+	if c.ChunkOverlap >= c.ChunkSize {
+		c.ChunkOverlap = c.ChunkSize / 2
+	}
+	if c.MaxFileSize <= 0 {
+		c.MaxFileSize = DefaultChunking.MaxFileSize
+	}
+	return c
+}
+
+// forLanguage returns the chunk size and overlap used for a language
+func (c ChunkingConfig) forLanguage(language string) (size, overlap int) {
+	override, ok := c.Languages[language]
+	if !ok {
+		return c.ChunkSize, c.ChunkOverlap
+	}
+
+	resolved := ChunkingConfig{ChunkSize: c.ChunkSize, ChunkOverlap: c.ChunkOverlap, MaxFileSize: c.MaxFileSize}
+	if override.ChunkSize > 0 {
+		resolved.ChunkSize = override.ChunkSize
+	}
+	if override.ChunkOverlap > 0 {
+		resolved.ChunkOverlap = override.ChunkOverlap
+	}
+	resolved = resolved.normalized()
+	return resolved.ChunkSize, resolved.ChunkOverlap
 }
 
 type CodeChunk struct {
@@ -23,6 +113,19 @@ type CodeChunk struct {
 	LineStart int
 	LineEnd   int
 	Language  string
+	Symbols   []Symbol    // Definitions starting inside this chunk
+	FileHash  string      // SHA-256 of the whole file, to detect stale chunks
+	Dirty     bool        // Uncommitted content, stored in the overlay collection
+	Headings  []string    // Markdown heading hierarchy of the chunk, outermost first
+	Address   string      // Terraform address of the block in the chunk, e.g. aws_vpc.main
+	Parent    ChunkParent // Enclosing definition, or window of lines around the chunk
+	Imports   []string    // Import statements of the file, shared by its chunks
+
+	// FileSummary is set on a file's first chunk when file summaries are indexed
+	FileSummary string
+
+	// Metadata is stored as the "metadata" payload; set by chunk hooks
+	Metadata map[string]string
 }
 
 func NewIndexer(embedder Embedder, vectorDB VectorDB, logger *zap.Logger) *Indexer {
@@ -30,42 +133,197 @@ func NewIndexer(embedder Embedder, vectorDB VectorDB, logger *zap.Logger) *Index
 		embedder: embedder,
 		vectorDB: vectorDB,
 		logger:   logger,
+		chunking: DefaultChunking,
+		dedup:    true,
+
+		sensitive: DefaultSensitiveFiles,
+
+		storeContent: true,
+
+		enrichment: defaultEnrichment,
+	}
+}
+
+// SetChunking sets the chunk sizes and file size limit used for indexing
+func (idx *Indexer) SetChunking(chunking ChunkingConfig) {
+	idx.chunking = chunking.normalized()
+}
+
+// SetMaxEmbedTokens sets the token limit of the embedding model (default: 0,
+// no limit). Longer texts are truncated before embedding instead of failing
+// their batch. Call it before indexing starts.
+func (idx *Indexer) SetMaxEmbedTokens(maxTokens int) {
+	idx.maxEmbedTokens = maxTokens
+}
+
+// embedBatch embeds texts as documents, with the document prefix and cut to
+// the embedding model's token limit, waiting out embedder outages
+func (idx *Indexer) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	texts = idx.documentTexts(texts)
+	truncated := 0
+	for i, text := range texts {
+		if cut, ok := TruncateForEmbedding(text, idx.maxEmbedTokens); ok {
+			texts[i] = cut
+			truncated++
+		}
+	}
+	if truncated > 0 {
+		idx.logger.Warn("Truncated texts longer than the embedding model accepts",
+			zap.Int("texts", truncated), zap.Int("max_tokens", idx.maxEmbedTokens))
+	}
+	return idx.embedder.EmbedBatch(waitForEmbedder(ctx), texts)
+}
+
+// SetPathFilter sets the include/exclude globs applied to every indexed directory
+func (idx *Indexer) SetPathFilter(filter PathFilter) {
+	idx.filter = filter
+}
+
+// SetSensitiveFiles sets the file name globs never indexed (default:
+// DefaultSensitiveFiles). Call it before indexing starts.
+func (idx *Indexer) SetSensitiveFiles(patterns []string) {
+	idx.sensitive = patterns
+}
+
+// IsSensitive reports whether filePath matches the sensitive file deny list,
+// so it is never indexed
+func (idx *Indexer) IsSensitive(filePath string) bool {
+	return IsSensitiveFile(idx.sensitive, filePath)
+}
+
+// SetFileExtensions sets the file types ReindexFiles indexes, like the
+// extensions given to directory walks (default: all)
+func (idx *Indexer) SetFileExtensions(extensions []string) {
+	idx.extensions = extensions
+}
+
+// skippedDirs are directory names never walked into, like hidden directories
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+// pathMatcher decides which paths under a root are indexed, combining the
+// configured filter, per-call patterns, the root's .code-ragignore file and
+// the sensitive file deny list
+type pathMatcher struct {
+	root      string
+	filter    PathFilter
+	ignore    *IgnoreRules
+	sensitive []string
+}
+
+func (idx *Indexer) newPathMatcher(root string, extra PathFilter) *pathMatcher {
+	ignore, err := LoadIgnoreFile(root)
+	if err != nil {
+		idx.logger.Warn("Failed to read ignore file", zap.String("root", root), zap.Error(err))
+	}
+	return &pathMatcher{root: root, filter: idx.filter.With(extra), ignore: ignore, sensitive: idx.sensitive}
+}
+
+// skip reports whether a file or directory is left out of indexing
+func (m *pathMatcher) skip(filePath string, isDir bool) bool {
+	relPath, err := filepath.Rel(m.root, filePath)
+	if err != nil || relPath == "." {
+		return false
+	}
+	if m.ignore.Ignored(relPath, isDir) {
+		return true
+	}
+	if isDir {
+		return m.filter.SkipDir(relPath)
 	}
+	return IsSensitiveFile(m.sensitive, filePath) || !m.filter.Allows(relPath)
 }
 
-func (idx *Indexer) IndexDirectory(ctx context.Context, path string, extensions []string, collectionName string) error {
+// excludes reports whether a walk of the root leaves filePath out, because of
+// the file itself or of a directory on its way
+func (m *pathMatcher) excludes(filePath string) bool {
+	relPath, err := filepath.Rel(m.root, filePath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return false
+	}
+	dir := m.root
+	for _, name := range strings.Split(filepath.Dir(relPath), string(filepath.Separator)) {
+		if name == "." {
+			continue
+		}
+		dir = filepath.Join(dir, name)
+		if skippedDirs[name] || strings.HasPrefix(name, ".") || m.skip(dir, true) {
+			return true
+		}
+	}
+	return m.skip(filePath, false)
+}
+
+// indexable reports whether walking the code path of filePath would index it:
+// its type is indexed and neither the filters, the ignore file nor the
+// sensitive list exclude it. Outside the code paths only the sensitive list
+// applies. matchers caches the matcher of each root.
+func (idx *Indexer) indexable(filePath string, matchers map[string]*pathMatcher) bool {
+	if len(idx.extensions) > 0 && !MatchesFileType(filePath, idx.extensions) {
+		return false
+	}
+	root := idx.RootFor(filePath)
+	if root == "" {
+		return !IsSensitiveFile(idx.sensitive, filePath)
+	}
+	matcher, ok := matchers[root]
+	if !ok {
+		matcher = idx.newPathMatcher(root, PathFilter{})
+		matchers[root] = matcher
+	}
+	return !matcher.excludes(filePath)
+}
+
+// IndexDirectory indexes a directory. The patterns of filter are applied on
+// top of the configured ones.
+func (idx *Indexer) IndexDirectory(ctx context.Context, path string, extensions []string, filter PathFilter, collectionName string) error {
+	return idx.IndexDirectoryProgress(ctx, path, extensions, filter, collectionName, nil)
+}
+
+// IndexProgress is called after each embedded batch with the number of
+// chunks indexed so far and the total to index
+type IndexProgress func(done, total int)
+
+// IndexDirectoryProgress is IndexDirectory reporting progress. It stops with
+// ctx's error when ctx is cancelled.
+func (idx *Indexer) IndexDirectoryProgress(ctx context.Context, path string, extensions []string, filter PathFilter, collectionName string, progress IndexProgress) error {
 	idx.logger.Info("Starting indexing", zap.String("path", path))
 
 	var chunks []CodeChunk
+	matcher := idx.newPathMatcher(path, filter)
 
 	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		if info.IsDir() {
 			// Skip certain directories
 			dirName := filepath.Base(filePath)
-			if dirName == "node_modules" ||
-				dirName == ".git" ||
-				dirName == "vendor" ||
-				dirName == "__pycache__" ||
-				dirName == ".venv" ||
-				dirName == "venv" ||
-				strings.HasPrefix(dirName, ".") {
+			if skippedDirs[dirName] || strings.HasPrefix(dirName, ".") || matcher.skip(filePath, true) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		// Check extension
-		ext := filepath.Ext(filePath)
-		if !contains(extensions, ext) {
+		if matcher.skip(filePath, false) {
+			return nil
+		}
+
+		// Check extension, file name or shebang
+		if !MatchesFileType(filePath, extensions) {
 			return nil
 		}
 
 		// Skip large files
-		if info.Size() > 1024*1024 { // 1MB
+		if info.Size() > idx.chunking.MaxFileSize {
 			idx.logger.Debug("Skipping large file", zap.String("file", filePath), zap.Int64("size", info.Size()))
 			return nil
 		}
@@ -82,10 +340,16 @@ func (idx *Indexer) IndexDirectory(ctx context.Context, path string, extensions
 	})
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return fmt.Errorf("failed to walk directory: %w", err)
 	}
 
 	idx.logger.Info("Chunked files", zap.Int("chunks", len(chunks)))
+	if progress != nil {
+		progress(0, len(chunks))
+	}
 
 	if len(chunks) == 0 {
 		return fmt.Errorf("no files found to index")
@@ -101,13 +365,23 @@ func (idx *Indexer) IndexDirectory(ctx context.Context, path string, extensions
 
 		batch := chunks[i:end]
 		if err := idx.indexBatch(ctx, batch, collectionName); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			return fmt.Errorf("failed to index batch: %w", err)
 		}
 
 		idx.logger.Info("Indexed batch", zap.Int("start", i), zap.Int("end", end), zap.Int("total", len(chunks)))
+		if progress != nil {
+			progress(end, len(chunks))
+		}
 	}
 
 	idx.logger.Info("Indexing complete", zap.Int("total_chunks", len(chunks)))
+	// Filters narrower than the configuration leave files out
+	if len(filter.Exclude) == 0 && len(filter.Include) == 0 {
+		idx.recordFullIndex(ctx, collectionName, path)
+	}
 	return nil
 }
 
@@ -116,22 +390,54 @@ func (idx *Indexer) chunkFile(filePath string) ([]CodeChunk, error) {
 	if err != nil {
 		return nil, err
 	}
+	if int64(len(content)) > idx.chunking.MaxFileSize {
+		return nil, fmt.Errorf("file exceeds max_file_size (%d > %d bytes)", len(content), idx.chunking.MaxFileSize)
+	}
+
+	lines := SplitLines(content)
 
-	text := string(content)
-	lines := strings.Split(text, "\n")
+	language := DetectLanguageFromContent(filePath, content)
+	symbols := ExtractSymbols(language, lines)
+	fileHash := HashContent(content)
 
-	// Simple chunking by line count
-	chunkSize := 50
-	overlap := 10
+	chunkSize, chunkOverlap := idx.chunking.forLanguage(language)
 
 	var chunks []CodeChunk
-	for i := 0; i < len(lines); i += chunkSize - overlap {
-		end := i + chunkSize
-		if end > len(lines) {
-			end = len(lines)
+	if language == "markdown" {
+		chunks = markdownCodeChunks(filePath, lines, chunkSize, symbols, fileHash)
+	} else {
+		chunks = lineChunks(filePath, language, lines, chunkSize, chunkOverlap, symbols, fileHash)
+	}
+
+	imports := fileImports(language, lines)
+	for i := range chunks {
+		chunks[i].Parent = chunkParent(symbols, chunks[i].LineStart, chunks[i].LineEnd, len(lines))
+		chunks[i].Imports = imports
+	}
+	if idx.fileSummaries && len(chunks) > 0 {
+		chunks[0].FileSummary = FileSummary(filePath, language, lines, symbols)
+	}
+	return chunks, nil
+}
+
+// lineChunks splits a file into windows of lines, following Terraform blocks
+// when the file is Terraform
+func lineChunks(filePath, language string, lines []string, chunkSize, chunkOverlap int, symbols []Symbol, fileHash string) []CodeChunk {
+	var windows []hclChunk
+	if language == "terraform" {
+		windows, _ = chunkHCL(filePath, lines, chunkSize, chunkOverlap)
+	}
+	if windows == nil {
+		for _, w := range chunkLines(lines, chunkSize, chunkOverlap) {
+			windows = append(windows, hclChunk{start: w[0], end: w[1]})
 		}
+	}
 
-		chunkText := strings.Join(lines[i:end], "\n")
+	var chunks []CodeChunk
+	for _, window := range windows {
+		start, end := window.start, window.end
+
+		chunkText := strings.Join(lines[start:end], "\n")
 		if strings.TrimSpace(chunkText) == "" {
 			continue
 		}
@@ -139,67 +445,218 @@ func (idx *Indexer) chunkFile(filePath string) ([]CodeChunk, error) {
 		chunks = append(chunks, CodeChunk{
 			FilePath:  filePath,
 			Content:   chunkText,
-			LineStart: i + 1,
+			LineStart: start + 1,
 			LineEnd:   end,
-			Language:  detectLanguage(filePath),
+			Language:  language,
+			Symbols:   symbolsInRange(symbols, start+1, end),
+			FileHash:  fileHash,
+			Address:   window.address,
 		})
-
-		if end == len(lines) {
-			break
-		}
 	}
 
-	return chunks, nil
+	return chunks
+}
+
+// pointIDNamespace scopes the UUIDv5 point IDs of indexed chunks
+var pointIDNamespace = uuid.MustParse("0f7468e8-e6c6-4a3b-a580-77ebb47c71bd")
+
+// ChunkPointID derives the point ID of a chunk from its file, first line and
+// the chunker version, so re-indexing the same chunk overwrites it in place
+// even when deleting the file's previous chunks failed.
+func ChunkPointID(filePath string, lineStart int) string {
+	name := fmt.Sprintf("%s\x00%d\x00%d", filePath, lineStart, ChunkerVersion)
+	return uuid.NewSHA1(pointIDNamespace, []byte(name)).String()
 }
 
 func (idx *Indexer) indexBatch(ctx context.Context, chunks []CodeChunk, collectionName string) error {
+	_, err := idx.upsertChunks(ctx, chunks, collectionName)
+	return err
+}
+
+// upsertChunks embeds and stores chunks, returning the IDs of the points
+// stored: chunks dropped as duplicates or with invalid embeddings have none
+func (idx *Indexer) upsertChunks(ctx context.Context, chunks []CodeChunk, collectionName string) ([]string, error) {
+	// Secrets never reach the embedder, payloads or file summaries
+	chunks = idx.scrubSecrets(chunks)
+
+	// Before hooks and dedup, which may drop a file's first chunk
+	idx.indexFileSummaries(ctx, chunks, collectionName)
+
+	chunks = idx.applyChunkHooks(chunks)
+	chunks = idx.dropDuplicateChunks(ctx, chunks, collectionName)
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
 	// Extract texts for embedding
 	texts := make([]string, len(chunks))
 	for i, chunk := range chunks {
 		// Enhance text with context for better embeddings
-		texts[i] = fmt.Sprintf("File: %s\nLanguage: %s\nCode:\n%s",
-			filepath.Base(chunk.FilePath),
-			chunk.Language,
-			chunk.Content,
-		)
+		text, err := idx.enrich(chunk)
+		if err != nil {
+			return nil, err
+		}
+		texts[i] = text
 	}
 
 	// Generate embeddings
-	embeddings, err := idx.embedder.EmbedBatch(ctx, texts)
-	if err != nil {
-		return err
+	embeddings, err := idx.embedBatch(ctx, texts)
+	var invalid *InvalidEmbeddingsError
+	if errors.As(err, &invalid) && len(invalid.Indexes) < len(chunks) {
+		// Skip only the chunks without a valid vector
+		for _, i := range invalid.Indexes {
+			idx.logger.Warn("Skipping chunk with invalid embedding",
+				zap.String("file", chunks[i].FilePath), zap.Int("line_start", chunks[i].LineStart), zap.Error(invalid.Last))
+		}
+		chunks, embeddings = dropInvalidEmbeddings(chunks, embeddings, invalid.Indexes)
+	} else if err != nil {
+		return nil, err
 	}
 
 	// Create points
 	points := make([]Point, len(chunks))
 	for i, chunk := range chunks {
+		symbolNames, symbolDefs := symbolsPayload(chunk.Symbols)
 		points[i] = Point{
-			ID:     uuid.New().String(),
+			ID:     ChunkPointID(chunk.FilePath, chunk.LineStart),
 			Vector: embeddings[i],
 			Payload: map[string]interface{}{
-				"file_path":  chunk.FilePath,
-				"content":    chunk.Content,
-				"line_start": chunk.LineStart,
-				"line_end":   chunk.LineEnd,
-				"language":   chunk.Language,
+				"file_path":       chunk.FilePath,
+				"line_start":      chunk.LineStart,
+				"line_end":        chunk.LineEnd,
+				"language":        chunk.Language,
+				"symbols":         symbolNames,
+				"symbol_defs":     symbolDefs,
+				"identifiers":     identifiersPayload(ExtractIdentifiers(chunk.Content)),
+				"chunker_version": ChunkerVersion,
+				"file_hash":       chunk.FileHash,
+				"content_hash":    ContentHash(chunk.Content),
 			},
 		}
+		if idx.sparse != nil {
+			sparse := idx.sparse.EncodeDocument(texts[i])
+			points[i].Sparse = &sparse
+		}
+		if chunk.Dirty {
+			points[i].Payload["dirty"] = true
+		}
+		if tags := idx.TagsFor(chunk.FilePath); len(tags) > 0 {
+			points[i].Payload["tags"] = tags
+		}
+		if root := idx.RootFor(chunk.FilePath); root != "" {
+			points[i].Payload["root"] = root
+		}
+		if len(chunk.Headings) > 0 {
+			points[i].Payload["headings"] = chunk.Headings
+		}
+		if chunk.Address != "" {
+			points[i].Payload["address"] = chunk.Address
+		}
+		parentPayload(points[i].Payload, chunk.Parent)
+		idx.storeContentPayload(points[i].Payload, chunk.Content)
+		if len(chunk.Metadata) > 0 {
+			metadata := make(map[string]interface{}, len(chunk.Metadata))
+			for k, v := range chunk.Metadata {
+				metadata[k] = v
+			}
+			points[i].Payload["metadata"] = metadata
+		}
 	}
 
 	// Upsert to vector DB
-	return idx.vectorDB.Upsert(ctx, collectionName, points)
+	if err := idx.vectorDB.Upsert(ctx, collectionName, points); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(points))
+	for i, point := range points {
+		ids[i] = point.ID
+	}
+	return ids, nil
+}
+
+// dropInvalidEmbeddings removes the chunks at indexes (sorted) and their
+// embeddings
+func dropInvalidEmbeddings(chunks []CodeChunk, embeddings [][]float32, indexes []int) ([]CodeChunk, [][]float32) {
+	keptChunks := make([]CodeChunk, 0, len(chunks)-len(indexes))
+	keptEmbeddings := make([][]float32, 0, len(chunks)-len(indexes))
+	next := 0
+	for i := range chunks {
+		if next < len(indexes) && indexes[next] == i {
+			next++
+			continue
+		}
+		keptChunks = append(keptChunks, chunks[i])
+		keptEmbeddings = append(keptEmbeddings, embeddings[i])
+	}
+	return keptChunks, keptEmbeddings
+}
+
+// chunkLines splits lines into windows [start, end) of at most size characters,
+// newlines included, ending on line boundaries. Consecutive windows share
+// trailing lines totalling at most overlap characters.
+func chunkLines(lines []string, size, overlap int) [][2]int {
+	var windows [][2]int
+	for start := 0; start < len(lines); {
+		end, chars := start, 0
+		for end < len(lines) {
+			lineChars := utf8.RuneCountInString(lines[end]) + 1
+			if end > start && chars+lineChars > size {
+				break
+			}
+			chars += lineChars
+			end++
+		}
+		windows = append(windows, [2]int{start, end})
+		if end == len(lines) {
+			break
+		}
+
+		// Step back over the overlap, always moving forward by at least one line
+		// and leaving room for the line that did not fit
+		next, overlapChars := end, 0
+		nextLineChars := utf8.RuneCountInString(lines[end]) + 1
+		for next-1 > start {
+			lineChars := utf8.RuneCountInString(lines[next-1]) + 1
+			if overlapChars+lineChars > overlap || overlapChars+lineChars+nextLineChars > size {
+				break
+			}
+			overlapChars += lineChars
+			next--
+		}
+		start = next
+	}
+	return windows
+}
+
+// indexChunks embeds and stores chunks in ChunkBatchSize batches
+func (idx *Indexer) indexChunks(ctx context.Context, chunks []CodeChunk, collectionName string) error {
+	for i := 0; i < len(chunks); i += ChunkBatchSize {
+		end := i + ChunkBatchSize
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+
+		if err := idx.indexBatch(ctx, chunks[i:end], collectionName); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func detectLanguage(filePath string) string {
+// DetectLanguage returns the language tag for a file based on its extension,
+// or its name for extensionless files such as Dockerfile. Other extensions
+// are looked up in go-enry when they map to a single language; ambiguous
+// ones (".m") stay "unknown" until DetectLanguageFromContent sees the content.
+func DetectLanguage(filePath string) string {
 	ext := filepath.Ext(filePath)
 	switch ext {
 	case ".go":
 		return "go"
 	case ".py":
 		return "python"
-	case ".js":
+	case ".js", ".jsx", ".mjs", ".cjs":
 		return "javascript"
-	case ".ts":
+	case ".ts", ".tsx", ".mts", ".cts":
 		return "typescript"
 	case ".tf":
 		return "terraform"
@@ -219,7 +676,37 @@ func detectLanguage(filePath string) string {
 		return "c"
 	case ".cpp", ".hpp", ".cc":
 		return "cpp"
+	case ".rb":
+		return "ruby"
+	case ".php":
+		return "php"
+	case ".kt", ".kts":
+		return "kotlin"
+	case ".swift":
+		return "swift"
+	case ".scala", ".sc":
+		return "scala"
+	case ".cs":
+		return "csharp"
+	case ".sql":
+		return "sql"
+	case ".proto":
+		return "protobuf"
+	case ".graphql", ".gql":
+		return "graphql"
+	case ".vue":
+		return "vue"
+	case ".svelte":
+		return "svelte"
+	case ".dart":
+		return "dart"
 	default:
+		if language := FilenameLanguage(filePath); language != "" {
+			return language
+		}
+		if language := enryLanguageByName(filePath); language != "" {
+			return language
+		}
 		return "unknown"
 	}
 }
@@ -233,15 +720,41 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-// ReindexFiles re-indexes specific files (used by git hooks)
+// ReindexFiles re-indexes specific files (used by git hooks). A deleted file
+// whose indexed content now lives at another of filePaths is renamed in the
+// index instead of being re-embedded. Files a directory walk would leave out
+// (file type, filters, .code-ragignore, sensitive list) lose their chunks
+// and are not indexed.
 func (idx *Indexer) ReindexFiles(ctx context.Context, filePaths []string, collectionName string) error {
 	idx.logger.Info("Re-indexing files", zap.Int("count", len(filePaths)), zap.Strings("files", filePaths))
 
+	matchers := make(map[string]*pathMatcher)
+	var removed, present []string
+	for _, filePath := range filePaths {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			removed = append(removed, filePath)
+		} else if idx.indexable(filePath, matchers) {
+			present = append(present, filePath)
+		}
+	}
+	renamed := make(map[string]bool)
+	for _, rename := range idx.renameFiles(ctx, removed, present, collectionName) {
+		renamed[rename.From] = true
+		renamed[rename.To] = true
+	}
+
 	var allChunks []CodeChunk
 	deletedCount := 0
 	indexedCount := 0
 
 	for _, filePath := range filePaths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if renamed[filePath] {
+			continue
+		}
+
 		// Delete old chunks for this file
 		err := idx.vectorDB.Delete(ctx, collectionName, map[string]interface{}{
 			"file_path": filePath,
@@ -257,6 +770,12 @@ func (idx *Indexer) ReindexFiles(ctx context.Context, filePaths []string, collec
 			idx.logger.Info("File deleted, skipping re-indexing", zap.String("file", filePath))
 			continue
 		}
+		// Its old chunks are gone, which also purges files indexed before
+		// they were excluded
+		if !idx.indexable(filePath, matchers) {
+			idx.logger.Info("Excluded file, skipping re-indexing", zap.String("file", filePath))
+			continue
+		}
 
 		// Re-chunk and prepare for indexing
 		chunks, err := idx.chunkFile(filePath)
@@ -287,6 +806,7 @@ func (idx *Indexer) ReindexFiles(ctx context.Context, filePaths []string, collec
 	idx.logger.Info("Re-indexing complete",
 		zap.Int("files_processed", indexedCount),
 		zap.Int("files_deleted", deletedCount),
+		zap.Int("files_renamed", len(renamed)/2),
 		zap.Int("total_chunks", len(allChunks)),
 	)
 
@@ -11,13 +11,14 @@ import (
 type IndexingState struct {
 	mu             sync.RWMutex
 	RootPath       string            `json:"root_path"`
+	Commit         string            `json:"commit,omitempty"` // HEAD when the session started, if RootPath is in a git repository
 	TotalFiles     int               `json:"total_files"`
 	IndexedFiles   int               `json:"indexed_files"`
 	TotalChunks    int               `json:"total_chunks"`
 	ProcessedFiles map[string]bool   `json:"processed_files"`
 	FailedFiles    map[string]string `json:"failed_files"` // file -> error message
 	LastUpdate     time.Time         `json:"last_update"`
-	Status         string            `json:"status"` // "in_progress", "completed", "failed"
+	Status         string            `json:"status"` // "in_progress", "paused", "cancelled", "completed", "failed"
 	StartTime      time.Time         `json:"start_time"`
 	CompletionTime *time.Time        `json:"completion_time,omitempty"`
 }
@@ -45,6 +46,12 @@ func LoadIndexingState(path string) (*IndexingState, error) {
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, err
 	}
+	if state.ProcessedFiles == nil {
+		state.ProcessedFiles = make(map[string]bool)
+	}
+	if state.FailedFiles == nil {
+		state.FailedFiles = make(map[string]string)
+	}
 
 	return &state, nil
 }
@@ -68,6 +75,7 @@ func (s *IndexingState) MarkFileProcessed(filePath string, chunksCount int) {
 	defer s.mu.Unlock()
 
 	s.ProcessedFiles[filePath] = true
+	delete(s.FailedFiles, filePath)
 	s.IndexedFiles++
 	s.TotalChunks += chunksCount
 	s.LastUpdate = time.Now()
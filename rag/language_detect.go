@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-enry/go-enry/v2"
+)
+
+// languageSniffBytes is how much of a file content detection looks at
+const languageSniffBytes = 8 * 1024
+
+var (
+	// cppHeaderPattern matches constructs only valid in C++ headers
+	cppHeaderPattern = regexp.MustCompile(`(?m)^\s*(class\s+\w+|namespace\s+\w*|template\s*<|(public|private|protected)\s*:)|std::|#include\s*<(iostream|string|vector|memory|map|unordered_map|algorithm)>`)
+
+	// objcPattern matches Objective-C declarations
+	objcPattern = regexp.MustCompile(`(?m)^\s*(@interface|@implementation|@protocol|#import\s)`)
+
+	// versionSuffixPattern strips interpreter versions: python3.11 -> python
+	versionSuffixPattern = regexp.MustCompile(`[0-9.]+$`)
+)
+
+// shebangInterpreters maps script interpreters to language tags
+var shebangInterpreters = map[string]string{
+	"python": "python", "pypy": "python",
+	"bash": "bash", "sh": "bash", "zsh": "bash", "ksh": "bash", "dash": "bash",
+	"node": "javascript", "nodejs": "javascript", "deno": "typescript", "bun": "javascript", "ts-node": "typescript",
+	"ruby": "ruby", "perl": "perl", "php": "php", "lua": "lua", "Rscript": "r",
+}
+
+// enryLanguages maps go-enry (Linguist) language names to this repo's tags
+// where they differ from the lowercased name
+var enryLanguages = map[string]string{
+	"C++":              "cpp",
+	"C#":               "csharp",
+	"F#":               "fsharp",
+	"Objective-C":      "objective-c",
+	"Objective-C++":    "objective-cpp",
+	"Shell":            "bash",
+	"TSX":              "typescript",
+	"Vim Script":       "vim",
+	"Jupyter Notebook": "jupyter",
+	"Text":             "unknown",
+}
+
+// enryTag converts a go-enry language name to a language tag
+func enryTag(name string) string {
+	if tag, ok := enryLanguages[name]; ok {
+		return tag
+	}
+	return strings.ReplaceAll(strings.ToLower(name), " ", "-")
+}
+
+// DetectLanguageFromContent refines DetectLanguage with the file content:
+// ".h" headers are told apart as C, C++ or Objective-C, ".ts" Qt translation
+// files are tagged xml, and other files without a known language are
+// recognized by their shebang, then by go-enry's content heuristics and
+// classifier (".m" Objective-C vs MATLAB, for example).
+func DetectLanguageFromContent(filePath string, content []byte) string {
+	language := DetectLanguage(filePath)
+	if len(content) > languageSniffBytes {
+		content = content[:languageSniffBytes]
+	}
+
+	switch {
+	case filepath.Ext(filePath) == ".h":
+		if objcPattern.Match(content) {
+			return "objective-c"
+		}
+		if cppHeaderPattern.Match(content) {
+			return "cpp"
+		}
+	case language == "typescript":
+		trimmed := bytes.TrimSpace(content)
+		if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.Contains(trimmed, []byte("<!DOCTYPE TS>")) || bytes.Contains(trimmed, []byte("<TS ")) {
+			return "xml"
+		}
+	case language == "unknown":
+		if shebang := ShebangLanguage(content); shebang != "" {
+			return shebang
+		}
+		if candidates := enry.GetLanguagesByContent(filePath, content, nil); len(candidates) == 1 {
+			return enryTag(candidates[0])
+		}
+		if name := enry.GetLanguage(filePath, content); name != "" {
+			return enryTag(name)
+		}
+	}
+	return language
+}
+
+// enryLanguageByName returns the language of a file name or extension when
+// go-enry knows exactly one candidate, or ""
+func enryLanguageByName(filePath string) string {
+	if candidates := enry.GetLanguagesByFilename(filePath, nil, nil); len(candidates) == 1 {
+		return enryTag(candidates[0])
+	}
+	if candidates := enry.GetLanguagesByExtension(filePath, nil, nil); len(candidates) == 1 {
+		return enryTag(candidates[0])
+	}
+	return ""
+}
+
+// DetectFileLanguage detects the language of a file on disk, reading its
+// beginning only when the extension is ambiguous or unknown
+func DetectFileLanguage(filePath string) string {
+	language := DetectLanguage(filePath)
+	if language != "unknown" && language != "typescript" && filepath.Ext(filePath) != ".h" {
+		return language
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return language
+	}
+	defer file.Close()
+
+	head := make([]byte, languageSniffBytes)
+	n, _ := file.Read(head)
+	return DetectLanguageFromContent(filePath, head[:n])
+}
+
+// ShebangLanguage returns the language named by a "#!" first line, or ""
+func ShebangLanguage(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(content[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// "#!/usr/bin/env -S python3 -u" names the interpreter after env and its flags
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	if language, ok := shebangInterpreters[interpreter]; ok {
+		return language
+	}
+	return shebangInterpreters[versionSuffixPattern.ReplaceAllString(interpreter, "")]
+}
@@ -0,0 +1,197 @@
+package rag
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// LeaseCollectionSuffix names the collection holding the leader lease,
+	// next to the collection it guards
+	LeaseCollectionSuffix = "_leader"
+
+	// leasePointID is the single point storing the lease
+	leasePointID = "6c1f0f4e-8d0a-4b7e-9a55-0e0c8b8f2d31"
+
+	// leaseSettleDelay lets concurrent takeover attempts land before the
+	// winner is read back
+	leaseSettleDelay = time.Second
+)
+
+// Lease elects one indexing leader among instances sharing a vector
+// database. The lease is a point holding its holder and expiry; the holder
+// renews it every third of its TTL and others take it over once it expires.
+// Qdrant has no compare-and-swap, so a contested takeover is settled by
+// reading the lease back after leaseSettleDelay: the last writer wins.
+type Lease struct {
+	db         VectorDB
+	collection string
+	holder     string
+	ttl        time.Duration
+	logger     *zap.Logger
+	held       atomic.Bool
+	resigned   atomic.Bool // Stop competing for the lease
+	mu         sync.Mutex  // Serializes writes of the lease point
+}
+
+// NewLease creates a lease stored in collection, acquired as holder
+func NewLease(db VectorDB, collection, holder string, ttl time.Duration, logger *zap.Logger) *Lease {
+	return &Lease{
+		db:         db,
+		collection: collection,
+		holder:     holder,
+		ttl:        ttl,
+		logger:     logger,
+	}
+}
+
+// Held reports whether this instance currently holds the lease
+func (l *Lease) Held() bool {
+	return l.held.Load()
+}
+
+// Holder returns the identity this instance acquires the lease as
+func (l *Lease) Holder() string {
+	return l.holder
+}
+
+// Run competes for the lease until ctx is done. Each time this instance is
+// elected, onElected runs in its own goroutine with a context cancelled when
+// leadership is lost. The lease is released when ctx is done.
+func (l *Lease) Run(ctx context.Context, onElected func(context.Context)) {
+	// The collection usually exists already
+	l.db.CreateCollection(ctx, l.collection, 1)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	var stopTerm context.CancelFunc
+	leading := false
+	for {
+		elected, err := l.acquire(ctx)
+		if err != nil {
+			// Without Qdrant the lease cannot be renewed; assume it is lost
+			l.logger.Warn("Failed to renew leader lease", zap.Error(err))
+		}
+		l.held.Store(elected)
+
+		switch {
+		case elected && !leading:
+			leading = true
+			l.logger.Info("Elected indexing leader", zap.String("instance", l.holder))
+			var termCtx context.Context
+			termCtx, stopTerm = context.WithCancel(ctx)
+			go onElected(termCtx)
+		case !elected && leading:
+			leading = false
+			l.logger.Warn("Lost indexing leadership", zap.String("instance", l.holder))
+			stopTerm()
+		}
+
+		select {
+		case <-ctx.Done():
+			if stopTerm != nil {
+				stopTerm()
+			}
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := l.Release(releaseCtx); err != nil {
+				l.logger.Warn("Failed to release leader lease", zap.Error(err))
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Release gives up the lease so another instance can take over without
+// waiting for it to expire
+func (l *Lease) Release(ctx context.Context) error {
+	if !l.held.Swap(false) {
+		return nil
+	}
+
+	holder, _, err := l.read(ctx)
+	if err != nil || holder != l.holder {
+		return err
+	}
+	return l.write(ctx, "", time.Time{})
+}
+
+// Resign releases the lease and stops competing for it, e.g. before the
+// instance shuts down
+func (l *Lease) Resign(ctx context.Context) error {
+	l.resigned.Store(true)
+	return l.Release(ctx)
+}
+
+// acquire takes or renews the lease, reporting whether this instance holds it
+func (l *Lease) acquire(ctx context.Context) (bool, error) {
+	if l.resigned.Load() {
+		return false, nil
+	}
+
+	holder, expiresAt, err := l.read(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if holder != "" && holder != l.holder && now.Before(expiresAt) {
+		return false, nil
+	}
+
+	if err := l.write(ctx, l.holder, now.Add(l.ttl)); err != nil {
+		return false, err
+	}
+	if holder == l.holder {
+		return true, nil
+	}
+
+	// Taking over: other instances may have written the lease concurrently
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-time.After(leaseSettleDelay):
+	}
+
+	holder, _, err = l.read(ctx)
+	if err != nil {
+		return false, err
+	}
+	return holder == l.holder, nil
+}
+
+// read returns the current holder and expiry of the lease, if any
+func (l *Lease) read(ctx context.Context) (string, time.Time, error) {
+	var holder string
+	var expiresAt time.Time
+	err := l.db.Scroll(ctx, l.collection, nil, []string{"holder", "expires_at"}, func(p StoredPoint) error {
+		if p.ID != leasePointID {
+			return nil
+		}
+		holder, _ = p.Payload["holder"].(string)
+		expiresAt = time.UnixMilli(int64(payloadInt(p.Payload["expires_at"])))
+		return nil
+	})
+	return holder, expiresAt, err
+}
+
+// write stores the lease point
+func (l *Lease) write(ctx context.Context, holder string, expiresAt time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.db.Upsert(ctx, l.collection, []Point{{
+		ID:     leasePointID,
+		Vector: []float32{1},
+		Payload: map[string]interface{}{
+			"holder":     holder,
+			"expires_at": expiresAt.UnixMilli(),
+		},
+	}})
+}
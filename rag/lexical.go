@@ -0,0 +1,174 @@
+package rag
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// lexicalStopwords are query words that carry no signal for code search
+var lexicalStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "how": true, "does": true,
+	"where": true, "what": true, "is": true, "are": true, "to": true, "of": true,
+	"in": true, "a": true, "an": true, "find": true, "show": true, "me": true,
+	"code": true, "that": true, "this": true, "all": true,
+}
+
+// Tokenize splits text into lowercase terms, breaking identifiers on
+// punctuation, underscores and camelCase boundaries.
+func Tokenize(text string) []string {
+	var terms []string
+	var current []rune
+
+	flush := func() {
+		if len(current) >= 2 {
+			terms = append(terms, strings.ToLower(string(current)))
+		}
+		current = current[:0]
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// camelCase boundary: lower -> Upper
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) {
+			flush()
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return terms
+}
+
+// lexicalCandidate is a chunk containing at least one query term
+type lexicalCandidate struct {
+	result    SearchResult
+	termFreqs map[string]int
+	length    int
+}
+
+// LexicalSearch ranks stored chunks against query with BM25 over their
+// content. It needs no embeddings, so it keeps search usable when the
+// embedding service is down. Only chunks matching filter (nil for all) are
+// ranked, and matches are deduplicated with dedup. Scores are normalized to 0-1.
+func LexicalSearch(ctx context.Context, db VectorDB, collection string, filter map[string]interface{}, query string, limit int, dedup DedupOptions) ([]SearchResult, error) {
+	queryTerms := make(map[string]bool)
+	for _, term := range Tokenize(query) {
+		if !lexicalStopwords[term] {
+			queryTerms[term] = true
+		}
+	}
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+
+	var candidates []lexicalCandidate
+	docFreq := make(map[string]int)
+	totalDocs := 0
+	totalLength := 0
+
+	fields := append([]string{"language", "chunker_version"}, contentFields...)
+	disk := newDiskLines()
+	err := db.Scroll(ctx, collection, filter, fields, func(point StoredPoint) error {
+		content := payloadContent(point.Payload, disk)
+		terms := Tokenize(content)
+		totalDocs++
+		totalLength += len(terms)
+
+		var freqs map[string]int
+		for _, term := range terms {
+			if !queryTerms[term] {
+				continue
+			}
+			if freqs == nil {
+				freqs = make(map[string]int)
+			}
+			freqs[term]++
+		}
+		if freqs == nil {
+			return nil
+		}
+
+		for term := range freqs {
+			docFreq[term]++
+		}
+
+		candidates = append(candidates, lexicalCandidate{
+			result:    storedPointToResult(point, disk),
+			termFreqs: freqs,
+			length:    len(terms),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	avgLength := float64(totalLength) / float64(totalDocs)
+	maxScore := 0.0
+	scores := make([]float64, len(candidates))
+
+	for i, c := range candidates {
+		score := 0.0
+		for term, tf := range c.termFreqs {
+			df := float64(docFreq[term])
+			idf := math.Log(1 + (float64(totalDocs)-df+0.5)/(df+0.5))
+			norm := float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*(1-bm25B+bm25B*float64(c.length)/avgLength))
+			score += idf * norm
+		}
+		scores[i] = score
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+
+	results := make([]SearchResult, len(candidates))
+	for i, c := range candidates {
+		results[i] = c.result
+		results[i].Score = float32(scores[i] / maxScore)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	results = dedup.deduplicate(preferNewestChunks(results))
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// storedPointToResult converts a scrolled point into an unscored search
+// result, reading content indexed without it from disk
+func storedPointToResult(point StoredPoint, disk *diskLines) SearchResult {
+	result := SearchResult{
+		ID:             point.ID,
+		LineStart:      payloadInt(point.Payload["line_start"]),
+		LineEnd:        payloadInt(point.Payload["line_end"]),
+		ChunkerVersion: payloadInt(point.Payload["chunker_version"]),
+		Parent:         parentFromPayload(point.Payload),
+	}
+	result.FilePath, _ = point.Payload["file_path"].(string)
+	result.Content = payloadContent(point.Payload, disk)
+	result.Language, _ = point.Payload["language"].(string)
+	result.Root, _ = point.Payload["root"].(string)
+	return result
+}
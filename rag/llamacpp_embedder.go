@@ -0,0 +1,197 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// llamaCppBatchSize is the number of texts per /embedding request. The server
+// splits them over its slots, so larger batches only queue.
+const llamaCppBatchSize = 16
+
+// LlamaCppEmbedder embeds with the native /embedding API of a llama.cpp
+// server (llama-server --embedding). Its responses differ from the OpenAI
+// API and between llama.cpp versions; all known shapes are read.
+type LlamaCppEmbedder struct {
+	baseURL    string
+	dim        int
+	httpClient *http.Client
+}
+
+// llamaCppRequest is the body of a /embedding request
+type llamaCppRequest struct {
+	Content []string `json:"content"`
+}
+
+// llamaCppEmbedding is one embedding of a /embedding response: a vector, or
+// one vector per token when the server runs without pooling
+type llamaCppEmbedding struct {
+	Index     int             `json:"index"`
+	Embedding json.RawMessage `json:"embedding"`
+}
+
+// NewLlamaCppEmbedder creates an embedder for the llama.cpp server at
+// baseURL (default http://localhost:8080, without /v1)
+func NewLlamaCppEmbedder(baseURL string, dim int) (Embedder, error) {
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	embedder := &LlamaCppEmbedder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		dim:     dim,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := embedder.testConnection(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to llama.cpp: %w", err)
+	}
+
+	return embedder, nil
+}
+
+func (e *LlamaCppEmbedder) Dimension() int {
+	return e.dim
+}
+
+func (e *LlamaCppEmbedder) testConnection(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", e.baseURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("llama.cpp returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (e *LlamaCppEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *LlamaCppEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += llamaCppBatchSize {
+		end := min(i+llamaCppBatchSize, len(texts))
+		batch, err := e.embedBatchDirect(ctx, texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sub-batch [%d:%d]: %w", i, end, err)
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+// embedBatchDirect sends a single batch request to the server
+func (e *LlamaCppEmbedder) embedBatchDirect(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(llamaCppRequest{Content: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.baseURL+"/embedding", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("llama.cpp returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	embeddings, err := parseLlamaCppEmbeddings(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("llama.cpp returned %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	return embeddings, nil
+}
+
+// parseLlamaCppEmbeddings reads the embeddings of a /embedding response:
+// [{"index": 0, "embedding": [[...]]}, ...] from current servers,
+// {"results": [{"embedding": [...]}, ...]} or {"embedding": [...]} from older
+// ones
+func parseLlamaCppEmbeddings(body []byte) ([][]float32, error) {
+	var items []llamaCppEmbedding
+	if err := json.Unmarshal(body, &items); err != nil {
+		var legacy struct {
+			Results   []llamaCppEmbedding `json:"results"`
+			Embedding json.RawMessage     `json:"embedding"`
+		}
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil, err
+		}
+		items = legacy.Results
+		if len(items) == 0 && legacy.Embedding != nil {
+			items = []llamaCppEmbedding{{Embedding: legacy.Embedding}}
+		}
+	}
+
+	embeddings := make([][]float32, len(items))
+	for i, item := range items {
+		vector, err := pooledVector(item.Embedding)
+		if err != nil {
+			return nil, err
+		}
+		index := item.Index
+		if index < 0 || index >= len(items) || embeddings[index] != nil {
+			index = i
+		}
+		embeddings[index] = vector
+	}
+	return embeddings, nil
+}
+
+// pooledVector reads an embedding that is a vector or a single-row matrix.
+// Several rows mean the server returns token embeddings: it must run with
+// --pooling (mean, cls or last) to embed texts.
+func pooledVector(raw json.RawMessage) ([]float32, error) {
+	var vector []float32
+	if err := json.Unmarshal(raw, &vector); err == nil {
+		return vector, nil
+	}
+
+	var rows [][]float32
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) != 1 {
+		return nil, fmt.Errorf("llama.cpp returned %d token embeddings per text: start llama-server with --pooling mean", len(rows))
+	}
+	return rows[0], nil
+}
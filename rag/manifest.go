@@ -0,0 +1,246 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ManifestSuffix names the collection holding the manifest of a collection
+	ManifestSuffix = "_manifest"
+
+	// manifestPointID is the single point storing the manifest
+	manifestPointID = "2f6e4c1a-93b7-4d0e-8c5a-7d1b0f3e9a64"
+)
+
+// ManifestCollection returns the collection holding the manifest of collection
+func ManifestCollection(collection string) string {
+	return collection + ManifestSuffix
+}
+
+// IndexManifest records how a collection was built, so a server started with
+// another embedding model, dimension, distance metric, sparse vector setting
+// or chunker notices instead of mixing incompatible vectors and chunks in one
+// collection
+type IndexManifest struct {
+	EmbeddingModel string
+	Dimension      int
+	ChunkerVersion int
+	Distance       string    // Empty in manifests written before it was recorded, SparseVectors is then unknown too
+	SparseVectors  bool      // Chunks carry sparse vectors for hybrid search in the vector database
+	LastFullIndex  time.Time // Zero until a directory is fully indexed
+	SourceRoots    []string  // Directories fully indexed into the collection
+}
+
+// SetEmbeddingModel names the embedding model recorded in the manifest
+func (idx *Indexer) SetEmbeddingModel(model string) {
+	idx.embeddingModel = model
+}
+
+// SetDistance names the distance metric of the collection recorded in the
+// manifest (default: DistanceCosine)
+func (idx *Indexer) SetDistance(distance string) {
+	idx.distance = distance
+}
+
+// Setup describes how idx embeds and chunks, as the manifest of a collection
+// it fully indexed would
+func (idx *Indexer) Setup() IndexManifest {
+	distance := idx.distance
+	if distance == "" {
+		distance = DistanceCosine
+	}
+	return IndexManifest{
+		EmbeddingModel: idx.embeddingModel,
+		Dimension:      idx.embedder.Dimension(),
+		ChunkerVersion: ChunkerVersion,
+		Distance:       distance,
+		SparseVectors:  idx.sparse != nil,
+	}
+}
+
+// ReadManifest returns the manifest of collection, or nil if it has none
+// (collections indexed before manifests were written)
+func ReadManifest(ctx context.Context, db VectorDB, collection string) (*IndexManifest, error) {
+	var manifest *IndexManifest
+	fields := []string{"embedding_model", "dimension", "chunker_version", "distance", "sparse_vectors", "last_full_index", "source_roots"}
+	err := db.Scroll(ctx, ManifestCollection(collection), nil, fields, func(p StoredPoint) error {
+		if p.ID != manifestPointID {
+			return nil
+		}
+		manifest = &IndexManifest{
+			Dimension:      payloadInt(p.Payload["dimension"]),
+			ChunkerVersion: payloadInt(p.Payload["chunker_version"]),
+		}
+		manifest.EmbeddingModel, _ = p.Payload["embedding_model"].(string)
+		manifest.Distance, _ = p.Payload["distance"].(string)
+		manifest.SparseVectors, _ = p.Payload["sparse_vectors"].(bool)
+		if ms := payloadInt(p.Payload["last_full_index"]); ms > 0 {
+			manifest.LastFullIndex = time.UnixMilli(int64(ms))
+		}
+		manifest.SourceRoots = payloadStrings(p.Payload["source_roots"])
+		return nil
+	})
+	if err != nil {
+		// A missing manifest collection is a collection without manifest
+		if _, infoErr := db.GetCollectionInfo(ctx, ManifestCollection(collection)); infoErr != nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// WriteManifest stores the manifest of collection
+func WriteManifest(ctx context.Context, db VectorDB, collection string, manifest IndexManifest) error {
+	// The collection usually exists already
+	db.CreateCollection(ctx, ManifestCollection(collection), 1)
+
+	roots := make([]interface{}, len(manifest.SourceRoots))
+	for i, root := range manifest.SourceRoots {
+		roots[i] = root
+	}
+	payload := map[string]interface{}{
+		"embedding_model": manifest.EmbeddingModel,
+		"dimension":       manifest.Dimension,
+		"chunker_version": manifest.ChunkerVersion,
+		"distance":        manifest.Distance,
+		"sparse_vectors":  manifest.SparseVectors,
+		"source_roots":    roots,
+	}
+	if !manifest.LastFullIndex.IsZero() {
+		payload["last_full_index"] = manifest.LastFullIndex.UnixMilli()
+	}
+	return db.Upsert(ctx, ManifestCollection(collection), []Point{{
+		ID:      manifestPointID,
+		Vector:  []float32{1},
+		Payload: payload,
+	}})
+}
+
+// Problems lists how the manifest disagrees with the setup of a server (see
+// Indexer.Setup); the chunker version is compared with ChunkerVersion
+func (m *IndexManifest) Problems(setup IndexManifest) []string {
+	var problems []string
+	if m.EmbeddingModel != "" && setup.EmbeddingModel != "" && m.EmbeddingModel != setup.EmbeddingModel {
+		problems = append(problems, fmt.Sprintf("collection was embedded with model %q, the server embeds with %q: searches compare incompatible vectors until reindex_all with recreate_collection", m.EmbeddingModel, setup.EmbeddingModel))
+	}
+	if m.Dimension > 0 && setup.Dimension > 0 && m.Dimension != setup.Dimension {
+		problems = append(problems, fmt.Sprintf("collection holds %d-dimension vectors, the embedder produces %d: run reindex_all with recreate_collection", m.Dimension, setup.Dimension))
+	}
+	if m.Distance != "" && setup.Distance != "" {
+		if m.Distance != setup.Distance {
+			problems = append(problems, fmt.Sprintf("collection compares vectors by %s distance, the server is configured for %s: scores and min_score follow the collection until reindex_all with recreate_collection", m.Distance, setup.Distance))
+		}
+		switch {
+		case setup.SparseVectors && !m.SparseVectors:
+			problems = append(problems, "qdrant.sparse_vectors is on but the collection has no sparse vectors: hybrid searches fail until reindex_all with recreate_collection")
+		case !setup.SparseVectors && m.SparseVectors:
+			problems = append(problems, "collection has sparse vectors but qdrant.sparse_vectors is off: chunks indexed now get none, so hybrid search misses them once it is turned back on")
+		}
+	}
+	switch {
+	case m.ChunkerVersion > ChunkerVersion:
+		problems = append(problems, fmt.Sprintf("collection was chunked by a newer server (chunker version %d, this server uses %d)", m.ChunkerVersion, ChunkerVersion))
+	case m.ChunkerVersion > 0 && m.ChunkerVersion < ChunkerVersion:
+		problems = append(problems, fmt.Sprintf("collection was chunked with chunker version %d, this server uses %d: enable auto_migrate_chunks or re-index", m.ChunkerVersion, ChunkerVersion))
+	}
+	return problems
+}
+
+// recordFullIndex updates the manifest of collection once root was fully
+// indexed. The embedding model and dimension of an existing manifest are
+// kept while other roots may still hold vectors of it, so a mismatch stays
+// reported until every root is re-indexed or the collection recreated. Its
+// distance and sparse vector setting are always kept: they are set when the
+// collection is created, which drops the manifest.
+func (idx *Indexer) recordFullIndex(ctx context.Context, collection, root string) {
+	manifest, err := ReadManifest(ctx, idx.vectorDB, collection)
+	if err != nil {
+		idx.logger.Warn("Failed to read index manifest", zap.Error(err))
+		return
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	current := idx.Setup()
+	if manifest == nil {
+		manifest = &current
+	} else if coversRoots(root, manifest.SourceRoots) {
+		manifest.EmbeddingModel = current.EmbeddingModel
+		manifest.Dimension = current.Dimension
+		manifest.ChunkerVersion = current.ChunkerVersion
+	} else if problems := manifest.Problems(current); len(problems) > 0 {
+		idx.logger.Error("Collection mixes indexing setups", zap.String("collection", collection), zap.Strings("problems", problems))
+	}
+	if manifest.Distance == "" {
+		// Recorded by an older server: assume the collection matches
+		manifest.Distance, manifest.SparseVectors = current.Distance, current.SparseVectors
+	}
+
+	manifest.LastFullIndex = time.Now()
+	manifest.SourceRoots = addRoot(manifest.SourceRoots, root)
+	if err := WriteManifest(ctx, idx.vectorDB, collection, *manifest); err != nil {
+		idx.logger.Warn("Failed to write index manifest", zap.Error(err))
+	}
+}
+
+// recordChunkerVersion records in the manifest of collection that every
+// chunk was produced by the current chunker
+func (idx *Indexer) recordChunkerVersion(ctx context.Context, collection string) {
+	manifest, err := ReadManifest(ctx, idx.vectorDB, collection)
+	if err != nil || manifest == nil || manifest.ChunkerVersion >= ChunkerVersion {
+		return
+	}
+	manifest.ChunkerVersion = ChunkerVersion
+	if err := WriteManifest(ctx, idx.vectorDB, collection, *manifest); err != nil {
+		idx.logger.Warn("Failed to write index manifest", zap.Error(err))
+	}
+}
+
+// coversRoots reports whether root contains every one of roots
+func coversRoots(root string, roots []string) bool {
+	for _, r := range roots {
+		if r != root && !strings.HasPrefix(r, root+string(filepath.Separator)) {
+			return false
+		}
+	}
+	return true
+}
+
+// addRoot adds root to roots, dropping the roots it contains
+func addRoot(roots []string, root string) []string {
+	kept := []string{root}
+	for _, r := range roots {
+		if !coversRoots(root, []string{r}) {
+			kept = append(kept, r)
+		}
+	}
+	sort.Strings(kept)
+	return kept
+}
+
+// payloadStrings reads a list of strings from a payload value
+func payloadStrings(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return append([]string(nil), list...)
+	case []interface{}:
+		var values []string
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,194 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// markdownHeadingPattern matches ATX headings: "## Title ##"
+	markdownHeadingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+	// markdownFencePattern matches the opening or closing line of a fenced code block
+	markdownFencePattern = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+)
+
+// markdownSection is a heading and the lines under it, up to the next heading
+type markdownSection struct {
+	start, end int      // Lines [start, end)
+	level      int      // Heading level, 0 for text before the first heading
+	headings   []string // Heading hierarchy, outermost first
+}
+
+// markdownChunk is a window of lines with the heading hierarchy it falls under
+type markdownChunk struct {
+	start, end int
+	headings   []string
+}
+
+// markdownSections splits a document on ATX headings, ignoring "#" lines
+// inside fenced code blocks
+func markdownSections(lines []string) []markdownSection {
+	var sections []markdownSection
+	var hierarchy []string
+	var levels []int
+	current := markdownSection{}
+	fence := ""
+
+	for i, line := range lines {
+		if m := markdownFencePattern.FindStringSubmatch(line); m != nil {
+			marker := m[1][:3]
+			if fence == "" {
+				fence = marker
+			} else if marker == fence {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		m := markdownHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		level := len(m[1])
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			levels = levels[:len(levels)-1]
+			hierarchy = hierarchy[:len(hierarchy)-1]
+		}
+		levels = append(levels, level)
+		hierarchy = append(hierarchy, strings.TrimSpace(m[2]))
+
+		current.end = i
+		if current.end > current.start {
+			sections = append(sections, current)
+		}
+		current = markdownSection{start: i, level: level, headings: append([]string(nil), hierarchy...)}
+	}
+
+	current.end = len(lines)
+	if current.end > current.start {
+		sections = append(sections, current)
+	}
+	return sections
+}
+
+// chunkMarkdown chunks a document on heading boundaries. A section is kept
+// whole when it fits in size characters, and followed by its subsections
+// while they fit. Larger sections are split between blocks (paragraphs,
+// tables, code blocks) so a table is only cut when it alone exceeds size.
+func chunkMarkdown(lines []string, size int) []markdownChunk {
+	var chunks []markdownChunk
+	open := -1 // Index of the chunk that may still take subsections
+	openLevel, openChars := 0, 0
+
+	for _, section := range markdownSections(lines) {
+		chars := linesChars(lines[section.start:section.end])
+
+		if open >= 0 && section.level > openLevel && openChars+chars <= size {
+			chunks[open].end = section.end
+			openChars += chars
+			continue
+		}
+
+		if chars <= size {
+			chunks = append(chunks, markdownChunk{start: section.start, end: section.end, headings: section.headings})
+			open, openLevel, openChars = len(chunks)-1, section.level, chars
+			if section.level == 0 {
+				// Text before the first heading does not own the sections after it
+				open = -1
+			}
+			continue
+		}
+
+		for _, window := range markdownBlockWindows(lines, section.start, section.end, size) {
+			chunks = append(chunks, markdownChunk{start: window[0], end: window[1], headings: section.headings})
+		}
+		open = -1
+	}
+	return chunks
+}
+
+// markdownBlockWindows packs the blocks of lines [start, end) into windows
+// of at most size characters. Blocks are separated by blank lines outside
+// fenced code; a block larger than size is split by chunkLines.
+func markdownBlockWindows(lines []string, start, end, size int) [][2]int {
+	var blocks [][2]int
+	blockStart := start
+	fence := ""
+	for i := start; i < end; i++ {
+		if m := markdownFencePattern.FindStringSubmatch(lines[i]); m != nil {
+			marker := m[1][:3]
+			if fence == "" {
+				fence = marker
+			} else if marker == fence {
+				fence = ""
+			}
+		}
+		if fence == "" && strings.TrimSpace(lines[i]) == "" {
+			blocks = append(blocks, [2]int{blockStart, i + 1})
+			blockStart = i + 1
+		}
+	}
+	if blockStart < end {
+		blocks = append(blocks, [2]int{blockStart, end})
+	}
+
+	var windows [][2]int
+	windowStart, windowChars := start, 0
+	for _, block := range blocks {
+		chars := linesChars(lines[block[0]:block[1]])
+		if windowChars > 0 && windowChars+chars > size {
+			windows = append(windows, [2]int{windowStart, block[0]})
+			windowStart, windowChars = block[0], 0
+		}
+		if chars > size {
+			for _, w := range chunkLines(lines[block[0]:block[1]], size, 0) {
+				windows = append(windows, [2]int{block[0] + w[0], block[0] + w[1]})
+			}
+			windowStart = block[1]
+			continue
+		}
+		windowChars += chars
+	}
+	if windowStart < end {
+		windows = append(windows, [2]int{windowStart, end})
+	}
+	return windows
+}
+
+// linesChars counts characters of lines, newlines included, as chunkLines does
+func linesChars(lines []string) int {
+	chars := 0
+	for _, line := range lines {
+		chars += utf8.RuneCountInString(line) + 1
+	}
+	return chars
+}
+
+// markdownCodeChunks builds the chunks of a markdown file. Overlap does not
+// apply: chunks end on section or block boundaries, and each carries its
+// heading hierarchy instead.
+func markdownCodeChunks(filePath string, lines []string, size int, symbols []Symbol, fileHash string) []CodeChunk {
+	var chunks []CodeChunk
+	for _, c := range chunkMarkdown(lines, size) {
+		content := strings.Join(lines[c.start:c.end], "\n")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		chunks = append(chunks, CodeChunk{
+			FilePath:  filePath,
+			Content:   content,
+			LineStart: c.start + 1,
+			LineEnd:   c.end,
+			Language:  "markdown",
+			Symbols:   symbolsInRange(symbols, c.start+1, c.end),
+			FileHash:  fileHash,
+			Headings:  c.headings,
+		})
+	}
+	return chunks
+}
@@ -0,0 +1,456 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryDB is an in-process VectorDB with the filtering and trash semantics
+// of QdrantDB. It is meant for tests and small experiments: search is a
+// linear scan and nothing is persisted.
+type MemoryDB struct {
+	mu          sync.RWMutex
+	collections map[string]*memoryCollection
+}
+
+type memoryCollection struct {
+	dimension int
+	points    map[string]Point
+	updatedAt time.Time
+}
+
+// NewMemoryDB creates an empty in-memory vector database
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{collections: make(map[string]*memoryCollection)}
+}
+
+func (m *MemoryDB) collection(name string) (*memoryCollection, error) {
+	c, ok := m.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("collection %s not found", name)
+	}
+	return c, nil
+}
+
+func (m *MemoryDB) CreateCollection(ctx context.Context, name string, dimension int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[name]; ok {
+		return fmt.Errorf("collection %s already exists", name)
+	}
+	m.collections[name] = &memoryCollection{dimension: dimension, points: make(map[string]Point), updatedAt: time.Now()}
+	return nil
+}
+
+func (m *MemoryDB) DeleteCollection(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.collection(name); err != nil {
+		return err
+	}
+	delete(m.collections, name)
+	return nil
+}
+
+func (m *MemoryDB) Upsert(ctx context.Context, collection string, points []Point) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return err
+	}
+	for _, p := range points {
+		if len(p.Vector) != c.dimension {
+			return fmt.Errorf("wrong vector dimension for point %s: expected %d, got %d", p.ID, c.dimension, len(p.Vector))
+		}
+		c.points[p.ID] = Point{
+			ID:      p.ID,
+			Vector:  append([]float32(nil), p.Vector...),
+			Payload: normalizePayload(p.Payload),
+		}
+	}
+	c.updatedAt = time.Now()
+	return nil
+}
+
+// Search ranks live points matching filter by cosine similarity, like a
+// Qdrant cosine collection
+func (m *MemoryDB) Search(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, p := range c.points {
+		if !matchesReadFilter(p.Payload, filter) {
+			continue
+		}
+		score := cosineSimilarity(vector, p.Vector)
+		if score < minScore {
+			continue
+		}
+		filePath, _ := p.Payload["file_path"].(string)
+		content := payloadContent(p.Payload, nil)
+		language, _ := p.Payload["language"].(string)
+		root, _ := p.Payload["root"].(string)
+		results = append(results, SearchResult{
+			ID:             p.ID,
+			Score:          score,
+			FilePath:       filePath,
+			Content:        content,
+			LineStart:      payloadInt(p.Payload["line_start"]),
+			LineEnd:        payloadInt(p.Payload["line_end"]),
+			Language:       language,
+			ChunkerVersion: payloadInt(p.Payload["chunker_version"]),
+			Parent:         parentFromPayload(p.Payload),
+			Root:           root,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if offset >= len(results) {
+		return nil, nil
+	}
+	results = results[offset:]
+	if fetch := opts.fetchLimit(limit); fetch > 0 && len(results) > fetch {
+		results = results[:fetch]
+	}
+
+	return finishSearch(ctx, results, limit, opts.Dedup), nil
+}
+
+// Delete removes the points matching filter, sparing trashed points as
+// QdrantDB.Delete does
+func (m *MemoryDB) Delete(ctx context.Context, collection string, filter map[string]interface{}) error {
+	if len(filter) == 0 {
+		return fmt.Errorf("delete filter required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return err
+	}
+	for id, p := range c.points {
+		if matchesReadFilter(p.Payload, filter) {
+			delete(c.points, id)
+		}
+	}
+	c.updatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryDB) DeleteIDs(ctx context.Context, collection string, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		delete(c.points, id)
+	}
+	c.updatedAt = time.Now()
+	return nil
+}
+
+// ReadPoints returns copies of the points matching filter, in ID order
+func (m *MemoryDB) ReadPoints(ctx context.Context, collection string, filter map[string]interface{}) ([]Point, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, err := m.collection(collection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read points: %w", err)
+	}
+
+	var points []Point
+	for _, p := range c.points {
+		if matchesReadFilter(p.Payload, filter) {
+			point := p
+			point.Vector = append([]float32(nil), p.Vector...)
+			point.Payload = selectFields(p.Payload, nil)
+			points = append(points, point)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].ID < points[j].ID })
+	return points, nil
+}
+
+// Scroll visits matching points in ID order, so iteration is deterministic
+func (m *MemoryDB) Scroll(ctx context.Context, collection string, filter map[string]interface{}, fields []string, fn func(StoredPoint) error) error {
+	m.mu.RLock()
+	c, err := m.collection(collection)
+	if err != nil {
+		m.mu.RUnlock()
+		return fmt.Errorf("failed to scroll collection: %w", err)
+	}
+
+	// Copy the matches so fn may write to the database
+	var matches []StoredPoint
+	for _, p := range c.points {
+		if matchesReadFilter(p.Payload, filter) {
+			matches = append(matches, StoredPoint{ID: p.ID, Payload: selectFields(p.Payload, fields)})
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	for _, point := range matches {
+		if err := fn(point); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryDB) Count(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	var count int64
+	for _, p := range c.points {
+		if matchesReadFilter(p.Payload, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryDB) SetPayload(ctx context.Context, collection string, filter map[string]interface{}, payload map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return err
+	}
+	values := normalizePayload(payload)
+	for id, p := range c.points {
+		if !matchesReadFilter(p.Payload, filter) {
+			continue
+		}
+		for k, v := range values {
+			p.Payload[k] = v
+		}
+		c.points[id] = p
+	}
+	c.updatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryDB) DeletePayload(ctx context.Context, collection string, filter map[string]interface{}, keys []string) error {
+	if len(filter) == 0 {
+		return fmt.Errorf("delete payload filter required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return err
+	}
+	for _, p := range c.points {
+		if !matchesFilter(p.Payload, filter) {
+			continue
+		}
+		for _, k := range keys {
+			delete(p.Payload, k)
+		}
+	}
+	c.updatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryDB) GetCollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, err := m.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+	count := int64(len(c.points))
+	return &CollectionInfo{
+		PointsCount: count,
+		VectorDim:   c.dimension,
+		UpdatedAt:   c.updatedAt,
+		Summary:     fmt.Sprintf("Collection ready with %d chunks", count),
+	}, nil
+}
+
+func (m *MemoryDB) Close() error {
+	return nil
+}
+
+// matchesReadFilter applies filter and, like readFilter, hides trashed
+// points unless the filter targets the trash
+func matchesReadFilter(payload, filter map[string]interface{}) bool {
+	if _, ok := filter[TrashIDField]; !ok && !payloadEmpty(payload[TrashIDField]) {
+		return false
+	}
+	return matchesFilter(payload, filter)
+}
+
+// matchesFilter mirrors buildFilter: every entry must match; strings, booleans
+// and ints match exactly, string slices match any of their values. A list
+// payload matches when any of its items does.
+func matchesFilter(payload, filter map[string]interface{}) bool {
+	for key, want := range filter {
+		if !payloadMatches(payload[key], want) {
+			return false
+		}
+	}
+	return true
+}
+
+func payloadMatches(value, want interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if payloadMatches(item, want) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch w := want.(type) {
+	case string:
+		v, ok := value.(string)
+		return ok && v == w
+	case []string:
+		v, ok := value.(string)
+		if !ok {
+			return false
+		}
+		for _, candidate := range w {
+			if v == candidate {
+				return true
+			}
+		}
+		return false
+	case bool:
+		v, ok := value.(bool)
+		return ok && v == w
+	case int:
+		v, ok := value.(int64)
+		return ok && v == int64(w)
+	case int64:
+		v, ok := value.(int64)
+		return ok && v == w
+	default:
+		// Unsupported filter values are ignored, as in buildFilter
+		return true
+	}
+}
+
+// payloadEmpty matches Qdrant's IsEmpty: missing, null or an empty list
+func payloadEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	list, ok := value.([]interface{})
+	return ok && len(list) == 0
+}
+
+func selectFields(payload map[string]interface{}, fields []string) map[string]interface{} {
+	selected := make(map[string]interface{}, len(payload))
+	if len(fields) == 0 {
+		for k, v := range payload {
+			selected[k] = v
+		}
+		return selected
+	}
+	for _, f := range fields {
+		if v, ok := payload[f]; ok {
+			selected[f] = v
+		}
+	}
+	return selected
+}
+
+// normalizePayload converts values to the types QdrantDB reads back
+// (int64, float64, []interface{}, map[string]interface{}), so code behaves
+// the same against both databases
+func normalizePayload(payload map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		normalized[k] = normalizeValue(reflect.ValueOf(v))
+	}
+	return normalized
+}
+
+func normalizeValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return normalizeValue(v.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = normalizeValue(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = normalizeValue(iter.Value())
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
+
+func cosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
@@ -0,0 +1,128 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// discoveryTimeout bounds listing the server's models; probeTimeout bounds
+// the probe embedding, which can wait for the server to load the model
+const (
+	discoveryTimeout = 5 * time.Second
+	probeTimeout     = 60 * time.Second
+)
+
+// dimensionProbe is embedded at startup to measure the model's dimension
+const dimensionProbe = "func main() {}"
+
+// serverModel is an entry of an OpenAI-compatible /models listing. LM Studio
+// adds the model type ("embeddings", "llm", "vlm").
+type serverModel struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// isEmbedding reports whether the model embeds text: by type when the server
+// tells it, else by name
+func (m serverModel) isEmbedding() bool {
+	if m.Type != "" {
+		return m.Type == "embeddings"
+	}
+	return strings.Contains(strings.ToLower(m.ID), "embed")
+}
+
+// discover checks the server serves the configured model, picking the only
+// embedding model it serves when it does not, then measures the model's
+// dimension with a probe embedding. Mis-typed model names and dimensions
+// fail here instead of mid-index.
+func (e *LocalEmbedder) discover() error {
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	models, err := e.listModels(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", e.name, err)
+	}
+
+	model, err := e.chooseModel(models)
+	if err != nil {
+		return err
+	}
+	e.model = model
+
+	ctx, cancel = context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	embeddings, err := e.embedBatchDirect(ctx, []string{dimensionProbe})
+	if err != nil {
+		return fmt.Errorf("%s failed to embed with model %q: %w", e.name, e.model, err)
+	}
+	if len(embeddings) != 1 || len(embeddings[0]) == 0 {
+		return fmt.Errorf("%s returned no embedding for model %q", e.name, e.model)
+	}
+
+	dim := len(embeddings[0])
+	if e.dim > 0 && e.dim != dim {
+		return fmt.Errorf("embedding_dim is %d but %s model %q returns %d-dimensional vectors: set embedding_dim to %d", e.dim, e.name, e.model, dim, dim)
+	}
+	e.dim = dim
+	return nil
+}
+
+// chooseModel returns the configured model when the server lists it (or
+// lists nothing to check against), else the only embedding model listed
+func (e *LocalEmbedder) chooseModel(models []serverModel) (string, error) {
+	if len(models) == 0 {
+		return e.model, nil
+	}
+
+	var embedding []string
+	for _, m := range models {
+		if m.ID == e.model && e.model != "" {
+			return e.model, nil
+		}
+		if m.isEmbedding() {
+			embedding = append(embedding, m.ID)
+		}
+	}
+	sort.Strings(embedding)
+
+	switch {
+	case len(embedding) == 1:
+		return embedding[0], nil
+	case len(embedding) == 0:
+		return "", fmt.Errorf("%s serves no embedding model: load one (configured: %q)", e.name, e.model)
+	default:
+		return "", fmt.Errorf("%s does not serve embedding model %q; set embedding_model to one of: %s", e.name, e.model, strings.Join(embedding, ", "))
+	}
+}
+
+// listModels returns the models of the server's /models listing
+func (e *LocalEmbedder) listModels(ctx context.Context) ([]serverModel, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", e.baseURL+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	e.authorize(req)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", e.name, resp.StatusCode)
+	}
+
+	var listing struct {
+		Data []serverModel `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+	return listing.Data, nil
+}
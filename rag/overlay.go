@@ -0,0 +1,202 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// OverlaySuffix names the collection holding chunks of uncommitted files,
+// next to the collection of committed code
+const OverlaySuffix = "_overlay"
+
+// OverlayCollection returns the overlay collection of a collection
+func OverlayCollection(collection string) string {
+	return collection + OverlaySuffix
+}
+
+// WorkingTreeChanges lists the uncommitted files of a repository (absolute paths)
+type WorkingTreeChanges struct {
+	Root     string   // Repository top-level directory
+	Modified []string // Modified, added, renamed or untracked files
+	Deleted  []string // Deleted files, and old names of renamed files
+}
+
+// WorkingTreeStatus reads the uncommitted changes of the repository containing repoDir
+func WorkingTreeStatus(ctx context.Context, repoDir string) (*WorkingTreeChanges, error) {
+	top, err := runGit(ctx, repoDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top = strings.TrimSpace(top)
+
+	out, err := runGit(ctx, repoDir, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &WorkingTreeChanges{Root: top}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		status, path := entry[:2], filepath.Join(top, filepath.FromSlash(entry[3:]))
+
+		// Renames and copies are followed by the original path
+		if status[0] == 'R' || status[0] == 'C' {
+			if i+1 < len(entries) && status[0] == 'R' {
+				changes.Deleted = append(changes.Deleted, filepath.Join(top, filepath.FromSlash(entries[i+1])))
+			}
+			i++
+		}
+
+		if status[0] == 'D' || status[1] == 'D' {
+			changes.Deleted = append(changes.Deleted, path)
+		} else {
+			changes.Modified = append(changes.Modified, path)
+		}
+	}
+	return changes, nil
+}
+
+// OverlayReport summarizes an overlay refresh
+type OverlayReport struct {
+	Indexed   []string // Dirty files (re-)indexed into the overlay
+	Unchanged int      // Dirty files already up to date in the overlay
+	Removed   []string // Files no longer dirty, dropped from the overlay
+	Deleted   []string // Files deleted in the working tree
+}
+
+// RefreshOverlay indexes the uncommitted files of the repository at repoDir into
+// the overlay collection of collection, so searches can reflect code being
+// edited rather than the last indexed commit. Files whose content did not change
+// since the last refresh are skipped; files no longer dirty are dropped.
+func (idx *Indexer) RefreshOverlay(ctx context.Context, repoDir, collection string, extensions []string, dimension int) (*OverlayReport, error) {
+	changes, err := WorkingTreeStatus(ctx, repoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := OverlayCollection(collection)
+	if err := idx.vectorDB.CreateCollection(ctx, overlay, dimension); err != nil {
+		idx.logger.Debug("Overlay collection might already exist", zap.Error(err))
+	}
+
+	indexedHashes := make(map[string]string)
+	err = idx.vectorDB.Scroll(ctx, overlay, nil, []string{"file_path", "file_hash"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		indexedHashes[filePath], _ = point.Payload["file_hash"].(string)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan overlay: %w", err)
+	}
+
+	report := &OverlayReport{Deleted: changes.Deleted}
+
+	matcher := idx.newPathMatcher(repoDir, PathFilter{})
+	dirty := make(map[string]bool)
+	for _, filePath := range changes.Modified {
+		if !MatchesFileType(filePath, extensions) || matcher.skip(filePath, false) {
+			continue
+		}
+		info, err := os.Stat(filePath)
+		if err != nil || info.IsDir() || info.Size() > idx.chunking.MaxFileSize {
+			continue
+		}
+		dirty[filePath] = true
+	}
+
+	for filePath := range indexedHashes {
+		// The overlay is shared by all repositories; only this one's files are refreshed
+		if dirty[filePath] || !strings.HasPrefix(filePath, changes.Root+string(filepath.Separator)) {
+			continue
+		}
+		if err := idx.vectorDB.Delete(ctx, overlay, map[string]interface{}{"file_path": filePath}); err != nil {
+			return report, fmt.Errorf("failed to drop %s from overlay: %w", filePath, err)
+		}
+		report.Removed = append(report.Removed, filePath)
+	}
+
+	var chunks []CodeChunk
+	for filePath := range dirty {
+		fileChunks, err := idx.chunkFile(filePath)
+		if err != nil {
+			idx.logger.Warn("Failed to chunk dirty file", zap.String("file", filePath), zap.Error(err))
+			continue
+		}
+		if len(fileChunks) > 0 && fileChunks[0].FileHash == indexedHashes[filePath] {
+			report.Unchanged++
+			continue
+		}
+
+		if err := idx.vectorDB.Delete(ctx, overlay, map[string]interface{}{"file_path": filePath}); err != nil {
+			return report, fmt.Errorf("failed to clear %s in overlay: %w", filePath, err)
+		}
+		for i := range fileChunks {
+			fileChunks[i].Dirty = true
+		}
+		chunks = append(chunks, fileChunks...)
+		report.Indexed = append(report.Indexed, filePath)
+	}
+
+	if err := idx.indexChunks(ctx, chunks, overlay); err != nil {
+		return report, fmt.Errorf("failed to index dirty files: %w", err)
+	}
+
+	sort.Strings(report.Indexed)
+	sort.Strings(report.Removed)
+
+	idx.logger.Info("Overlay refreshed",
+		zap.String("repo", repoDir),
+		zap.Int("indexed", len(report.Indexed)),
+		zap.Int("unchanged", report.Unchanged),
+		zap.Int("removed", len(report.Removed)),
+		zap.Int("deleted", len(report.Deleted)),
+	)
+
+	return report, nil
+}
+
+// OverlayFiles returns the files held by the overlay collection of collection
+func OverlayFiles(ctx context.Context, db VectorDB, collection string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := db.Scroll(ctx, OverlayCollection(collection), nil, []string{"file_path"}, func(point StoredPoint) error {
+		if filePath, ok := point.Payload["file_path"].(string); ok {
+			files[filePath] = true
+		}
+		return nil
+	})
+	return files, err
+}
+
+// MergeOverlay merges overlay matches over committed ones: committed chunks of
+// files present in the overlay are dropped, since their content is stale, and
+// overlay matches are added in score order. limit caps the merged results.
+func MergeOverlay(committed, overlay []SearchResult, overlayFiles map[string]bool, limit int) []SearchResult {
+	merged := make([]SearchResult, 0, len(committed)+len(overlay))
+	for _, result := range committed {
+		if !overlayFiles[result.FilePath] {
+			merged = append(merged, result)
+		}
+	}
+	for _, result := range overlay {
+		result.Dirty = true
+		merged = append(merged, result)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
@@ -0,0 +1,159 @@
+package rag
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// codeOwnersLocations are the places GitHub and GitLab look for a CODEOWNERS file
+var codeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwnersRule is one line of a CODEOWNERS file
+type CodeOwnersRule struct {
+	Pattern string
+	Owners  []string
+}
+
+// Reviewer sources
+const (
+	ReviewerSourceCodeOwners = "codeowners"
+	ReviewerSourceAuthor     = "author"
+)
+
+// ReviewerSuggestion is a reviewer and the similar files backing the suggestion
+type ReviewerSuggestion struct {
+	Reviewer string
+	Sources  []string // codeowners and/or author
+	Score    float32  // Sum of match similarity, weighted by ownership share
+	Files    []string // Similar files the reviewer owns or authored
+}
+
+// LoadCodeOwners reads the CODEOWNERS file of repoDir.
+// It returns no rules and no error when the repository has none.
+func LoadCodeOwners(repoDir string) ([]CodeOwnersRule, error) {
+	for _, location := range codeOwnersLocations {
+		file, err := os.Open(filepath.Join(repoDir, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		var rules []CodeOwnersRule
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if i := strings.Index(line, " #"); i >= 0 {
+				line = line[:i]
+			}
+			fields := strings.Fields(line)
+			rules = append(rules, CodeOwnersRule{Pattern: fields[0], Owners: fields[1:]})
+		}
+		return rules, scanner.Err()
+	}
+	return nil, nil
+}
+
+// OwnersOf returns the owners of a repository-relative path. As in GitHub,
+// the last matching rule wins.
+func OwnersOf(rules []CodeOwnersRule, relPath string) []string {
+	relPath = filepath.ToSlash(relPath)
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matchPathPattern(rules[i].Pattern, relPath, false) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// SuggestReviewers aggregates the CODEOWNERS entries and recent git authors of
+// the files behind the given matches. Each match contributes its similarity:
+// fully to its code owners, and split by commit share among its authors.
+// authorsPerFile is the number of recent commits read per file (0 skips git).
+func SuggestReviewers(ctx context.Context, repoDir string, results []SearchResult, authorsPerFile int, exclude []string) ([]ReviewerSuggestion, error) {
+	rules, err := LoadCodeOwners(repoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		excluded[strings.ToLower(name)] = true
+	}
+
+	type tally struct {
+		score   float32
+		sources map[string]bool
+		files   map[string]bool
+	}
+	tallies := make(map[string]*tally)
+	credit := func(reviewer, source, file string, score float32) {
+		if excluded[strings.ToLower(reviewer)] || excluded[strings.ToLower(strings.TrimPrefix(reviewer, "@"))] {
+			return
+		}
+		t, ok := tallies[reviewer]
+		if !ok {
+			t = &tally{sources: make(map[string]bool), files: make(map[string]bool)}
+			tallies[reviewer] = t
+		}
+		t.score += score
+		t.sources[source] = true
+		t.files[file] = true
+	}
+
+	// Files often match through several chunks; only their best match counts
+	best := make(map[string]float32)
+	for _, result := range results {
+		if result.Score > best[result.FilePath] {
+			best[result.FilePath] = result.Score
+		}
+	}
+
+	for filePath, score := range best {
+		rel, err := filepath.Rel(repoDir, filePath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // Indexed from another repository
+		}
+
+		for _, owner := range OwnersOf(rules, rel) {
+			credit(owner, ReviewerSourceCodeOwners, rel, score)
+		}
+
+		if authorsPerFile <= 0 {
+			continue
+		}
+		commits, err := RecentCommits(ctx, repoDir, []string{rel}, authorsPerFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range commits {
+			credit(commit.Author, ReviewerSourceAuthor, rel, score/float32(len(commits)))
+		}
+	}
+
+	suggestions := make([]ReviewerSuggestion, 0, len(tallies))
+	for reviewer, t := range tallies {
+		suggestions = append(suggestions, ReviewerSuggestion{
+			Reviewer: reviewer,
+			Sources:  sortedKeys(t.sources),
+			Score:    t.score,
+			Files:    sortedKeys(t.files),
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Reviewer < suggestions[j].Reviewer
+	})
+	return suggestions, nil
+}
@@ -0,0 +1,83 @@
+package rag
+
+import "github.com/qdrant/go-client/qdrant"
+
+// maxParentLines caps the definition stored as a chunk's parent; chunks of
+// longer definitions get a window of lines around them instead
+const maxParentLines = 300
+
+// ChunkParent is the enclosing unit of a chunk: the definition the chunk
+// belongs to, or a window of lines around it when no definition encloses it.
+// It is zero for chunks indexed before parents were stored.
+type ChunkParent struct {
+	LineStart int
+	LineEnd   int
+	Symbol    string // Enclosing definition ("" for a window)
+}
+
+// chunkParent returns the parent of the chunk at lines start-end of a file of
+// total lines: the last definition starting at or before the chunk, up to the
+// next definition, widened to cover the whole chunk. Without one, or when it is
+// longer than maxParentLines, it is the chunk widened by its own length on
+// each side.
+func chunkParent(symbols []Symbol, start, end, total int) ChunkParent {
+	var owner *Symbol
+	for i := range symbols {
+		if symbols[i].Line <= start && (owner == nil || symbols[i].Line >= owner.Line) {
+			owner = &symbols[i]
+		}
+	}
+
+	if owner != nil {
+		parentEnd := total
+		for _, sym := range symbols {
+			if sym.Line > owner.Line && sym.Line-1 < parentEnd {
+				parentEnd = sym.Line - 1
+			}
+		}
+		parentEnd = max(parentEnd, end)
+		if parentEnd-owner.Line+1 <= maxParentLines {
+			return ChunkParent{LineStart: owner.Line, LineEnd: parentEnd, Symbol: owner.Name}
+		}
+	}
+
+	pad := end - start + 1
+	return ChunkParent{LineStart: max(1, start-pad), LineEnd: min(total, end+pad)}
+}
+
+// parentPayload adds the parent of a chunk to its payload
+func parentPayload(payload map[string]interface{}, parent ChunkParent) {
+	if parent.LineEnd == 0 {
+		return
+	}
+	payload["parent_start"] = parent.LineStart
+	payload["parent_end"] = parent.LineEnd
+	if parent.Symbol != "" {
+		payload["parent_symbol"] = parent.Symbol
+	}
+}
+
+// parentFromPayload reads the parent stored with a chunk
+func parentFromPayload(payload map[string]interface{}) ChunkParent {
+	parent := ChunkParent{
+		LineStart: payloadInt(payload["parent_start"]),
+		LineEnd:   payloadInt(payload["parent_end"]),
+	}
+	parent.Symbol, _ = payload["parent_symbol"].(string)
+	return parent
+}
+
+// parentFromQdrant reads the parent stored with a Qdrant point
+func parentFromQdrant(payload map[string]*qdrant.Value) ChunkParent {
+	var parent ChunkParent
+	if v := payload["parent_start"]; v != nil {
+		parent.LineStart = int(v.GetIntegerValue())
+	}
+	if v := payload["parent_end"]; v != nil {
+		parent.LineEnd = int(v.GetIntegerValue())
+	}
+	if v := payload["parent_symbol"]; v != nil {
+		parent.Symbol = v.GetStringValue()
+	}
+	return parent
+}
@@ -0,0 +1,157 @@
+package rag
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathFilter selects the files of a directory to index. Patterns are globs
+// matched against paths relative to the indexed root.
+type PathFilter struct {
+	Exclude []string // Never indexed, e.g. "**/generated/**", "*.pb.go"
+	Include []string // When set, only matching files are indexed, e.g. "src/**"
+}
+
+// Validate checks the patterns of the filter
+func (f PathFilter) Validate() error {
+	if err := ValidatePathPatterns(f.Exclude); err != nil {
+		return err
+	}
+	return ValidatePathPatterns(f.Include)
+}
+
+// With returns the filter extended with the patterns of other
+func (f PathFilter) With(other PathFilter) PathFilter {
+	return PathFilter{
+		Exclude: append(append([]string{}, f.Exclude...), other.Exclude...),
+		Include: append(append([]string{}, f.Include...), other.Include...),
+	}
+}
+
+// SkipDir reports whether a directory, and everything below it, is excluded.
+// Include patterns never skip directories since files below may still match.
+func (f PathFilter) SkipDir(relPath string) bool {
+	return MatchesAnyPattern(f.Exclude, relPath, true)
+}
+
+// Allows reports whether a file is indexed: it matches no exclude pattern and,
+// when include patterns are set, at least one of them
+func (f PathFilter) Allows(relPath string) bool {
+	if MatchesAnyPattern(f.Exclude, relPath, false) {
+		return false
+	}
+	return len(f.Include) == 0 || MatchesAnyPattern(f.Include, relPath, false)
+}
+
+// DefaultSensitiveFiles are file name globs never indexed, whatever their
+// extension: environment files, keys and certificates, credential stores and
+// Terraform state, which hold secrets in clear
+var DefaultSensitiveFiles = []string{
+	".env", ".env.*", "*.env",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore", "*.ppk", "*.asc", "*.gpg",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", "id_rsa.*", "id_dsa.*", "id_ecdsa.*", "id_ed25519.*",
+	"credentials", "credentials.json", "credentials.yaml", "credentials.yml", "client_secret*.json", "service-account*.json",
+	"secrets.json", "secrets.yaml", "secrets.yml", "*.secret", "*.secrets",
+	".npmrc", ".pypirc", ".netrc", ".pgpass", ".htpasswd", ".dockercfg", "kubeconfig",
+	"*.tfstate", "*.tfstate.backup", "*.tfstate.*.backup",
+}
+
+// sensitiveFileTemplates are names matching DefaultSensitiveFiles that only
+// document the variables to set, and are indexed
+var sensitiveFileTemplates = map[string]bool{
+	".env.example": true, ".env.sample": true, ".env.template": true, ".env.dist": true,
+}
+
+// IsSensitiveFile reports whether the name of filePath matches one of the
+// sensitive file globs (templates such as .env.example excepted)
+func IsSensitiveFile(patterns []string, filePath string) bool {
+	name := filepath.Base(filePath)
+	return !sensitiveFileTemplates[name] && MatchesAnyPattern(patterns, name, false)
+}
+
+// ValidatePathPatterns checks glob patterns before they are used for matching
+func ValidatePathPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		for _, part := range strings.Split(pattern, "**") {
+			if _, err := path.Match(strings.Trim(part, "/"), ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchesAnyPattern reports whether a path relative to the indexed root matches
+// one of the glob patterns
+func MatchesAnyPattern(patterns []string, relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range patterns {
+		if matchPathPattern(pattern, relPath, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathPattern implements the gitignore-style subset of glob patterns shared
+// by CODEOWNERS and the indexing filters: anchored ("/docs/") and floating
+// ("*.pb.go", "generated/") patterns, directories matching everything below
+// them, and "**" matching any number of directories
+func matchPathPattern(pattern, relPath string, isDir bool) bool {
+	if pattern == "*" || pattern == "**" {
+		return true
+	}
+
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	segments := strings.Split(relPath, "/")
+
+	// Candidates are the path itself and each parent directory; floating
+	// patterns may also start at any depth
+	for start := 0; start < len(segments); start++ {
+		if anchored && start > 0 {
+			break
+		}
+		for end := len(segments); end > start; end-- {
+			if dirOnly && end == len(segments) && !isDir {
+				continue
+			}
+			if globMatch(pattern, strings.Join(segments[start:end], "/")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// globMatch matches path.Match patterns extended with "**" for any number of directories
+func globMatch(pattern, name string) bool {
+	if !strings.Contains(pattern, "**") {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}
+	prefix, suffix, _ := strings.Cut(pattern, "**")
+	prefix = strings.TrimSuffix(prefix, "/")
+	suffix = strings.TrimPrefix(suffix, "/")
+
+	// "**" spans parts[i:j]; the prefix matches what precedes it, the suffix what follows
+	parts := strings.Split(name, "/")
+	for i := 0; i <= len(parts); i++ {
+		head := strings.Join(parts[:i], "/")
+		if (prefix == "" && i > 0) || (prefix != "" && !globMatch(prefix, head)) {
+			continue
+		}
+		for j := i; j <= len(parts); j++ {
+			tail := strings.Join(parts[j:], "/")
+			if (suffix == "" && j == len(parts)) || (suffix != "" && globMatch(suffix, tail)) {
+				return true
+			}
+		}
+	}
+	return false
+}
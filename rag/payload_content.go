@@ -0,0 +1,127 @@
+package rag
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// SetStoreContent sets whether chunk content is stored in payloads (default:
+// true). Without it only paths, line ranges and symbols are stored, and
+// content is read from the files on disk when searched. Call it before
+// indexing starts.
+func (idx *Indexer) SetStoreContent(enabled bool) {
+	idx.storeContent = enabled
+}
+
+// diskLines reads the content of chunks indexed without it from the files on
+// disk, keeping the lines of each file read for one search or scan. A nil
+// diskLines reads nothing.
+type diskLines struct {
+	files map[string][]string
+}
+
+// contentFields are the payload fields payloadContent reads
+var contentFields = []string{"file_path", "content", compressedContentField, "line_start", "line_end"}
+
+func newDiskLines() *diskLines {
+	return &diskLines{files: make(map[string][]string)}
+}
+
+// read returns lines start..end of filePath, "" when the file cannot be read
+// or is now shorter
+func (d *diskLines) read(filePath string, start, end int) string {
+	if d == nil {
+		return ""
+	}
+	lines, ok := d.files[filePath]
+	if !ok {
+		if content, err := os.ReadFile(filePath); err == nil {
+			lines = SplitLines(content)
+		}
+		d.files[filePath] = lines
+	}
+	if start < 1 || start > len(lines) || end < start {
+		return ""
+	}
+	return strings.Join(lines[start-1:min(end, len(lines))], "\n")
+}
+
+// payloadContent returns the content of a stored chunk: from its payload,
+// decompressed if needed, or read from disk when it was indexed without
+// content. Scans must include contentFields in their fields.
+func payloadContent(payload map[string]interface{}, disk *diskLines) string {
+	if content, ok := payload["content"].(string); ok {
+		return content
+	}
+	if encoded, ok := payload[compressedContentField].(string); ok {
+		if content, err := decompressContent(encoded); err == nil {
+			return content
+		}
+	}
+	filePath, _ := payload["file_path"].(string)
+	return disk.read(filePath, payloadInt(payload["line_start"]), payloadInt(payload["line_end"]))
+}
+
+// fillContentFromDisk sets the content of search results indexed without it
+func fillContentFromDisk(results []SearchResult) {
+	var disk *diskLines
+	for i := range results {
+		if results[i].Content != "" || results[i].LineStart < 1 {
+			continue
+		}
+		if disk == nil {
+			disk = newDiskLines()
+		}
+		results[i].Content = disk.read(results[i].FilePath, results[i].LineStart, results[i].LineEnd)
+	}
+}
+
+// compressedContentField holds the zstd-compressed, base64-encoded content
+// of chunks indexed with content compression, instead of "content"
+const compressedContentField = "content_zstd"
+
+// EncodeAll and DecodeAll are safe for concurrent use
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// SetCompressContent sets whether chunk content is stored zstd-compressed
+// (default: false). Searches read both forms, so a collection can mix them.
+// Call it before indexing starts.
+func (idx *Indexer) SetCompressContent(enabled bool) {
+	idx.compressContent = enabled
+}
+
+// compressContent encodes content for the compressedContentField payload
+func compressContent(content string) string {
+	return base64.StdEncoding.EncodeToString(zstdEncoder.EncodeAll([]byte(content), nil))
+}
+
+// decompressContent decodes a compressedContentField payload
+func decompressContent(encoded string) (string, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	content, err := zstdDecoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// storeContentPayload sets the content fields of a chunk payload following
+// the store_content and compress_content settings
+func (idx *Indexer) storeContentPayload(payload map[string]interface{}, content string) {
+	switch {
+	case !idx.storeContent:
+	case idx.compressContent:
+		payload[compressedContentField] = compressContent(content)
+	default:
+		payload["content"] = content
+	}
+}
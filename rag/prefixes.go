@@ -0,0 +1,76 @@
+package rag
+
+import "strings"
+
+// AutoPrefix selects the prefix an embedding model was trained with
+const AutoPrefix = "auto"
+
+// EmbeddingPrefixes are prepended to the texts an asymmetric embedding model
+// embeds: nomic-embed and e5 models were trained with different prefixes for
+// search queries and the documents they match, and retrieve noticeably worse
+// without them
+type EmbeddingPrefixes struct {
+	Query    string // Prepended to search queries
+	Document string // Prepended to indexed chunks and summaries
+}
+
+// modelPrefixes are the prefixes of model families, by model name fragment,
+// the first matching fragment winning
+var modelPrefixes = []struct {
+	fragment string
+	prefixes EmbeddingPrefixes
+}{
+	{"nomic-embed-code", EmbeddingPrefixes{Query: "Represent this query for searching relevant code: "}},
+	{"nomic-embed", EmbeddingPrefixes{Query: "search_query: ", Document: "search_document: "}},
+	{"e5-", EmbeddingPrefixes{Query: "query: ", Document: "passage: "}},
+}
+
+// DefaultPrefixes returns the prefixes model was trained with, none for
+// symmetric models
+func DefaultPrefixes(model string) EmbeddingPrefixes {
+	model = strings.ToLower(model)
+	for _, family := range modelPrefixes {
+		if strings.Contains(model, family.fragment) {
+			return family.prefixes
+		}
+	}
+	return EmbeddingPrefixes{}
+}
+
+// Resolve replaces AutoPrefix values by the default prefixes of model
+func (p EmbeddingPrefixes) Resolve(model string) EmbeddingPrefixes {
+	defaults := DefaultPrefixes(model)
+	if p.Query == AutoPrefix {
+		p.Query = defaults.Query
+	}
+	if p.Document == AutoPrefix {
+		p.Document = defaults.Document
+	}
+	return p
+}
+
+// SetEmbeddingPrefixes sets the prefixes of the embedding model (default:
+// none). Changing them changes every vector: reindex after. Call it before
+// indexing starts.
+func (idx *Indexer) SetEmbeddingPrefixes(prefixes EmbeddingPrefixes) {
+	idx.prefixes = prefixes
+}
+
+// QueryText returns query with the query prefix, as searches of the index
+// must embed it
+func (idx *Indexer) QueryText(query string) string {
+	return idx.prefixes.Query + query
+}
+
+// documentTexts returns texts with the document prefix, copied so callers
+// keep the originals
+func (idx *Indexer) documentTexts(texts []string) []string {
+	if idx.prefixes.Document == "" {
+		return texts
+	}
+	prefixed := make([]string, len(texts))
+	for i, text := range texts {
+		prefixed[i] = idx.prefixes.Document + text
+	}
+	return prefixed
+}
@@ -0,0 +1,161 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// HashContent returns the hex SHA-256 of a file's content
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// PruneReport lists the stale files found by PruneIndex
+type PruneReport struct {
+	FilesChecked     int
+	Missing          []string    // Deleted or renamed files whose chunks were moved to the trash
+	Modified         []string    // Files changed since indexing, re-indexed
+	UnavailableRoots []string    // Roots missing or empty on disk, whose files were left alone
+	Trashed          *TrashEntry // Trash entry holding the chunks of the missing files
+	Failed           map[string]string
+}
+
+// PruneIndex moves chunks of files that no longer exist to the trash and
+// re-indexes files whose content no longer matches the indexed hash. Chunks
+// indexed before hashes were stored are only checked for existence. Files
+// under a root that is missing or empty on disk (e.g. an unmounted volume)
+// are skipped rather than reported missing. With dryRun, nothing is changed
+// and the report lists what would be.
+func (idx *Indexer) PruneIndex(ctx context.Context, collection string, trash *Trash, dryRun bool) (*PruneReport, error) {
+	indexedHashes := make(map[string]string)
+
+	err := idx.vectorDB.Scroll(ctx, collection, nil, []string{"file_path", "file_hash"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		hash, _ := point.Payload["file_hash"].(string)
+		if _, seen := indexedHashes[filePath]; !seen || indexedHashes[filePath] == "" {
+			indexedHashes[filePath] = hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan index: %w", err)
+	}
+
+	report := &PruneReport{FilesChecked: len(indexedHashes), Failed: make(map[string]string)}
+	rootAvailable := make(map[string]bool)
+
+	for filePath, indexedHash := range indexedHashes {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		root := idx.RootFor(filePath)
+		available, checked := rootAvailable[root]
+		if !checked {
+			available = root == "" || rootOnDisk(root)
+			rootAvailable[root] = available
+			if !available {
+				report.UnavailableRoots = append(report.UnavailableRoots, root)
+			}
+		}
+		if !available {
+			continue
+		}
+
+		content, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			report.Missing = append(report.Missing, filePath)
+			continue
+		}
+		if err != nil {
+			report.Failed[filePath] = err.Error()
+			continue
+		}
+		if indexedHash != "" && HashContent(content) != indexedHash {
+			report.Modified = append(report.Modified, filePath)
+		}
+	}
+
+	sort.Strings(report.Missing)
+	sort.Strings(report.Modified)
+	sort.Strings(report.UnavailableRoots)
+	for _, root := range report.UnavailableRoots {
+		idx.logger.Warn("Code path missing or empty, not pruning its files", zap.String("root", root))
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if len(report.Missing) > 0 {
+		entry, err := trash.SoftDelete(ctx, collection, map[string]interface{}{"file_path": report.Missing},
+			fmt.Sprintf("prune_index (%d files)", len(report.Missing)))
+		if err != nil {
+			return report, fmt.Errorf("failed to move chunks of missing files to trash: %w", err)
+		}
+		report.Trashed = entry
+		idx.dropFileSummaries(ctx, collection, report.Missing)
+	}
+
+	if len(report.Modified) > 0 {
+		if err := idx.ReindexFiles(ctx, report.Modified, collection); err != nil {
+			return report, fmt.Errorf("failed to re-index modified files: %w", err)
+		}
+	}
+
+	idx.logger.Info("Index pruned",
+		zap.Int("files_checked", report.FilesChecked),
+		zap.Int("missing", len(report.Missing)),
+		zap.Int("modified", len(report.Modified)),
+		zap.Int("unavailable_roots", len(report.UnavailableRoots)),
+		zap.Int("failed", len(report.Failed)),
+	)
+
+	return report, nil
+}
+
+// rootOnDisk reports whether root is a directory with entries. A missing or
+// empty root usually means an unmounted volume or checkout, not deleted code.
+func rootOnDisk(root string) bool {
+	dir, err := os.Open(root)
+	if err != nil {
+		return false
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(1)
+	return err == nil && len(names) > 0
+}
+
+// FileUnchanged reports whether filePath is indexed in collection with its
+// current content, so a change notification for it can be skipped. Missing
+// files and chunks indexed without a hash count as changed.
+func (idx *Indexer) FileUnchanged(ctx context.Context, collection, filePath string) (bool, error) {
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Every chunk of a file stores the same hash: stop at the first one
+	var indexedHash string
+	errFound := errors.New("found")
+	err = idx.vectorDB.Scroll(ctx, collection, map[string]interface{}{"file_path": filePath}, []string{"file_hash"}, func(point StoredPoint) error {
+		indexedHash, _ = point.Payload["file_hash"].(string)
+		return errFound
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return false, err
+	}
+
+	return indexedHash != "" && indexedHash == HashContent(content), nil
+}
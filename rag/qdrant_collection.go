@@ -0,0 +1,119 @@
+package rag
+
+import (
+	"fmt"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// Distance metrics of vector collections
+const (
+	DistanceCosine    = "cosine"
+	DistanceDot       = "dot"
+	DistanceEuclidean = "euclidean"
+)
+
+// CollectionOptions tune the collections a QdrantDB creates. They apply to
+// new collections only: changing them requires recreating the collection.
+type CollectionOptions struct {
+	Distance             string // DistanceCosine (default), DistanceDot or DistanceEuclidean
+	OnDiskPayload        bool   // Keep payloads on disk instead of in RAM
+	IndexingThreshold    int    // KB of vectors a segment holds before it is HNSW-indexed (0: Qdrant default)
+	MemmapThreshold      int    // KB of vectors above which a segment is memory-mapped (0: Qdrant default)
+	DefaultSegmentNumber int    // Segments the optimizer aims for (0: Qdrant default)
+
+	SparseVectors bool // Store sparse (lexical) vectors next to dense ones, for HybridSearch
+}
+
+// Validate checks the distance metric and thresholds
+func (o CollectionOptions) Validate() error {
+	switch o.Distance {
+	case "", DistanceCosine, DistanceDot, DistanceEuclidean:
+	default:
+		return fmt.Errorf("unknown distance %q (use %s, %s or %s)", o.Distance, DistanceCosine, DistanceDot, DistanceEuclidean)
+	}
+	if o.IndexingThreshold < 0 || o.MemmapThreshold < 0 || o.DefaultSegmentNumber < 0 {
+		return fmt.Errorf("optimizer thresholds and segment number must not be negative")
+	}
+	return nil
+}
+
+// SetCollectionOptions sets how new collections are created and how their
+// scores are read
+func (q *QdrantDB) SetCollectionOptions(opts CollectionOptions) {
+	q.options = opts
+}
+
+// qdrantDistance returns the Qdrant metric of the options
+func (o CollectionOptions) qdrantDistance() qdrant.Distance {
+	switch o.Distance {
+	case DistanceDot:
+		return qdrant.Distance_Dot
+	case DistanceEuclidean:
+		return qdrant.Distance_Euclid
+	default:
+		return qdrant.Distance_Cosine
+	}
+}
+
+// optimizersConfig returns the optimizer settings, or nil for Qdrant's
+func (o CollectionOptions) optimizersConfig() *qdrant.OptimizersConfigDiff {
+	if o.IndexingThreshold == 0 && o.MemmapThreshold == 0 && o.DefaultSegmentNumber == 0 {
+		return nil
+	}
+	optimizers := &qdrant.OptimizersConfigDiff{}
+	if o.IndexingThreshold > 0 {
+		optimizers.IndexingThreshold = qdrant.PtrOf(uint64(o.IndexingThreshold))
+	}
+	if o.MemmapThreshold > 0 {
+		optimizers.MemmapThreshold = qdrant.PtrOf(uint64(o.MemmapThreshold))
+	}
+	if o.DefaultSegmentNumber > 0 {
+		optimizers.DefaultSegmentNumber = qdrant.PtrOf(uint64(o.DefaultSegmentNumber))
+	}
+	return optimizers
+}
+
+// sparseVectorsConfig returns the sparse vector of new collections, nil
+// without sparse vectors. Qdrant weighs its terms by IDF.
+func (o CollectionOptions) sparseVectorsConfig() *qdrant.SparseVectorConfig {
+	if !o.SparseVectors {
+		return nil
+	}
+	return qdrant.NewSparseVectorsConfig(map[string]*qdrant.SparseVectorParams{
+		SparseVectorName: {Modifier: qdrant.Modifier_Idf.Enum()},
+	})
+}
+
+// vectors returns the vectors stored for point: its dense vector, and its
+// sparse vector when the collection has one
+func (o CollectionOptions) vectors(point Point) *qdrant.Vectors {
+	if !o.SparseVectors || point.Sparse == nil {
+		return qdrant.NewVectors(point.Vector...)
+	}
+	return qdrant.NewVectorsMap(map[string]*qdrant.Vector{
+		"":               qdrant.NewVectorDense(point.Vector),
+		SparseVectorName: qdrant.NewVectorSparse(point.Sparse.Indices, point.Sparse.Values),
+	})
+}
+
+// similarity converts a Qdrant score to one where higher is closer, as
+// min_score and ranking expect: a euclidean distance d becomes 1/(1+d)
+func (o CollectionOptions) similarity(score float32) float32 {
+	if o.Distance == DistanceEuclidean {
+		return 1 / (1 + score)
+	}
+	return score
+}
+
+// scoreThreshold converts a minimum similarity to Qdrant's score threshold,
+// a maximum distance for euclidean collections
+func (o CollectionOptions) scoreThreshold(minScore float32) *float32 {
+	if o.Distance != DistanceEuclidean {
+		return qdrant.PtrOf(minScore)
+	}
+	if minScore <= 0 {
+		return nil
+	}
+	return qdrant.PtrOf(1/minScore - 1)
+}
@@ -0,0 +1,125 @@
+package rag
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RankingConfig weighs signals besides similarity when ordering results
+type RankingConfig struct {
+	PathBoost       float32       // Added to the score when all query terms appear in the file's directories, pro rata
+	FilenameBoost   float32       // Added to the score when all query terms appear in the file name, pro rata
+	RecencyWeight   float32       // Largest share of the score removed from files untouched for long
+	RecencyHalfLife time.Duration // Age at which half of RecencyWeight applies (0 disables the decay)
+}
+
+// Ranker re-ranks search results with the boosts of its config
+type Ranker struct {
+	Config RankingConfig
+
+	// Roots are the code paths: only directories below them count as path terms
+	Roots []string
+
+	// ModTime returns when a file was last modified (default: os.Stat)
+	ModTime func(filePath string) (time.Time, error)
+}
+
+// Rerank adjusts the scores of results for query and sorts them again. Scores
+// stay at most 1. Results are re-ranked within the page they were fetched for.
+func (r *Ranker) Rerank(query string, results []SearchResult) []SearchResult {
+	if r == nil || len(results) == 0 {
+		return results
+	}
+
+	terms := queryTerms(query)
+	now := time.Now()
+	for i := range results {
+		score := results[i].Score
+		if len(terms) > 0 {
+			dir, name := r.pathTerms(results[i].FilePath)
+			score += r.Config.PathBoost * termCoverage(terms, dir)
+			score += r.Config.FilenameBoost * termCoverage(terms, name)
+		}
+		if r.Config.RecencyWeight > 0 && r.Config.RecencyHalfLife > 0 && !results[i].Dirty {
+			if modTime, err := r.modTime(results[i].FilePath); err == nil && modTime.Before(now) {
+				halfLives := float64(now.Sub(modTime)) / float64(r.Config.RecencyHalfLife)
+				score *= 1 - r.Config.RecencyWeight*float32(1-math.Pow(0.5, halfLives))
+			}
+		}
+		results[i].Score = float32(math.Min(float64(score), 1))
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+func (r *Ranker) modTime(filePath string) (time.Time, error) {
+	if r.ModTime != nil {
+		return r.ModTime(filePath)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// pathTerms returns the terms of a file's directories below its code path,
+// and of its name without extension
+func (r *Ranker) pathTerms(filePath string) (dir, name []string) {
+	rel := filePath
+	for _, root := range r.Roots {
+		if p, err := filepath.Rel(root, filePath); err == nil && !strings.HasPrefix(p, "..") {
+			rel = p
+			break
+		}
+	}
+	base := filepath.Base(rel)
+	return Tokenize(filepath.Dir(rel)), Tokenize(strings.TrimSuffix(base, filepath.Ext(base)))
+}
+
+// queryTerms returns the distinct meaningful terms of a query
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range Tokenize(query) {
+		if !lexicalStopwords[term] && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// termCoverage returns the share of terms matched by candidates. Terms match
+// when equal or when one starts with the other and both have 4+ letters,
+// so "payment" matches "payments" and "auth" matches "authentication".
+func termCoverage(terms, candidates []string) float32 {
+	if len(terms) == 0 || len(candidates) == 0 {
+		return 0
+	}
+	matched := 0
+	for _, term := range terms {
+		for _, candidate := range candidates {
+			if termsMatch(term, candidate) {
+				matched++
+				break
+			}
+		}
+	}
+	return float32(matched) / float32(len(terms))
+}
+
+func termsMatch(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < 4 || len(b) < 4 {
+		return false
+	}
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
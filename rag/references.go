@@ -0,0 +1,142 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Reference is a line where an identifier is used
+type Reference struct {
+	FilePath     string
+	Line         int
+	Text         string
+	Language     string
+	IsDefinition bool
+}
+
+// maxIdentifiersPerChunk caps the identifier payload of a single chunk
+const maxIdentifiersPerChunk = 512
+
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// commonKeywords are language keywords that carry no reference information
+var commonKeywords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "return": true, "func": true,
+	"function": true, "def": true, "class": true, "import": true, "from": true, "package": true,
+	"var": true, "let": true, "const": true, "type": true, "struct": true, "interface": true,
+	"true": true, "false": true, "nil": true, "null": true, "None": true, "True": true,
+	"False": true, "self": true, "this": true, "new": true, "in": true, "of": true,
+	"and": true, "or": true, "not": true, "is": true, "as": true, "do": true, "end": true,
+	"switch": true, "case": true, "default": true, "break": true, "continue": true,
+	"go": true, "defer": true, "range": true, "map": true, "chan": true, "select": true,
+	"pub": true, "fn": true, "impl": true, "mut": true, "use": true, "mod": true,
+	"public": true, "private": true, "protected": true, "static": true, "void": true,
+	"int": true, "string": true, "bool": true, "error": true, "try": true, "catch": true,
+	"async": true, "await": true, "export": true, "with": true, "pass": true, "raise": true,
+}
+
+// ExtractIdentifiers returns the distinct identifiers used in a chunk,
+// skipping keywords and single-character names.
+func ExtractIdentifiers(content string) []string {
+	seen := make(map[string]bool)
+	var identifiers []string
+
+	for _, ident := range identifierPattern.FindAllString(content, -1) {
+		if len(ident) < 2 || commonKeywords[ident] || seen[ident] {
+			continue
+		}
+		seen[ident] = true
+		identifiers = append(identifiers, ident)
+		if len(identifiers) >= maxIdentifiersPerChunk {
+			break
+		}
+	}
+
+	sort.Strings(identifiers)
+	return identifiers
+}
+
+// identifiersPayload converts identifiers to a payload list
+func identifiersPayload(identifiers []string) []interface{} {
+	list := make([]interface{}, len(identifiers))
+	for i, ident := range identifiers {
+		list[i] = ident
+	}
+	return list
+}
+
+// FindReferences returns every indexed line where symbol is used as a whole word.
+// Definition lines are included only when includeDefinitions is set.
+func FindReferences(ctx context.Context, db VectorDB, collection, symbol string, includeDefinitions bool, limit int) ([]Reference, error) {
+	wordPattern, err := regexp.Compile(`\b` + regexp.QuoteMeta(symbol) + `\b`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid symbol: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var refs []Reference
+
+	filter := map[string]interface{}{"identifiers": symbol}
+	fields := append([]string{"language", "symbol_defs"}, contentFields...)
+	disk := newDiskLines()
+
+	err = db.Scroll(ctx, collection, filter, fields, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		language, _ := point.Payload["language"].(string)
+		content := payloadContent(point.Payload, disk)
+		lineStart := payloadInt(point.Payload["line_start"])
+
+		definitionLines := make(map[int]bool)
+		for _, sym := range symbolsFromPayload(point.Payload) {
+			if sym.Name == symbol {
+				definitionLines[sym.Line] = true
+			}
+		}
+
+		for i, line := range strings.Split(content, "\n") {
+			if !wordPattern.MatchString(line) {
+				continue
+			}
+
+			lineNum := lineStart + i
+			key := fmt.Sprintf("%s:%d", filePath, lineNum)
+			if seen[key] {
+				continue // Overlapping chunks share lines
+			}
+			seen[key] = true
+
+			isDefinition := definitionLines[lineNum]
+			if isDefinition && !includeDefinitions {
+				continue
+			}
+
+			refs = append(refs, Reference{
+				FilePath:     filePath,
+				Line:         lineNum,
+				Text:         strings.TrimSpace(line),
+				Language:     language,
+				IsDefinition: isDefinition,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].FilePath != refs[j].FilePath {
+			return refs[i].FilePath < refs[j].FilePath
+		}
+		return refs[i].Line < refs[j].Line
+	})
+
+	if limit > 0 && len(refs) > limit {
+		refs = refs[:limit]
+	}
+
+	return refs, nil
+}
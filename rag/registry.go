@@ -0,0 +1,156 @@
+package rag
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// EmbedderConfig holds the settings passed to embedder factories
+type EmbedderConfig struct {
+	Model     string
+	APIKey    string
+	BaseURL   string
+	Dimension int
+	Options   map[string]interface{} // Backend-specific settings (embedding_options)
+	Batch     BatchOptions           // Request sizing of LM Studio and vLLM (embedding_batch block)
+}
+
+// EmbedderFactory creates an embedder from its configuration
+type EmbedderFactory func(cfg EmbedderConfig) (Embedder, error)
+
+// VectorDBConfig holds the settings passed to vector database factories
+type VectorDBConfig struct {
+	URL        string // host:port (qdrant_url)
+	APIKey     string
+	Options    map[string]interface{} // Backend-specific settings (vectordb_options)
+	Collection CollectionOptions      // How Qdrant collections are created (qdrant block)
+}
+
+// VectorDBFactory creates a vector database from its configuration
+type VectorDBFactory func(cfg VectorDBConfig) (VectorDB, error)
+
+var (
+	registryMu        sync.RWMutex
+	embedderFactories = make(map[string]EmbedderFactory)
+	vectorDBFactories = make(map[string]VectorDBFactory)
+)
+
+func init() {
+	local := func(cfg EmbedderConfig) (Embedder, error) {
+		return NewLocalEmbedder(cfg.BaseURL, cfg.Model, cfg.Dimension, cfg.Batch)
+	}
+	RegisterEmbedder("local", local)
+	RegisterEmbedder("lmstudio", local)
+	RegisterEmbedder("openai", func(cfg EmbedderConfig) (Embedder, error) {
+		return NewOpenAIEmbedder(cfg.Model, cfg.APIKey, cfg.Dimension)
+	})
+	RegisterEmbedder("vllm", func(cfg EmbedderConfig) (Embedder, error) {
+		return NewVLLMEmbedder(cfg.BaseURL, cfg.Model, cfg.APIKey, cfg.Dimension, cfg.Batch)
+	})
+	RegisterEmbedder("llamacpp", func(cfg EmbedderConfig) (Embedder, error) {
+		return NewLlamaCppEmbedder(cfg.BaseURL, cfg.Dimension)
+	})
+
+	RegisterVectorDB("qdrant", func(cfg VectorDBConfig) (VectorDB, error) {
+		host, portStr, err := net.SplitHostPort(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Qdrant URL: %w", err)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Qdrant port: %w", err)
+		}
+		if err := cfg.Collection.Validate(); err != nil {
+			return nil, err
+		}
+		db, err := NewQdrantDB(host, port, cfg.APIKey)
+		if err != nil {
+			return nil, err
+		}
+		db.SetCollectionOptions(cfg.Collection)
+		return db, nil
+	})
+	RegisterVectorDB("memory", func(cfg VectorDBConfig) (VectorDB, error) {
+		return NewMemoryDB(), nil
+	})
+}
+
+// RegisterEmbedder makes an embedder type available to NewEmbedder (and the
+// embedding_type setting). Call it from an init function; it panics if the
+// name is already registered or the factory is nil.
+func RegisterEmbedder(name string, factory EmbedderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("rag: RegisterEmbedder factory is nil")
+	}
+	if _, dup := embedderFactories[name]; dup {
+		panic("rag: RegisterEmbedder called twice for " + name)
+	}
+	embedderFactories[name] = factory
+}
+
+// RegisterVectorDB makes a vector database type available to NewVectorDB
+// (and the vectordb_type setting). Call it from an init function; it panics
+// if the name is already registered or the factory is nil.
+func RegisterVectorDB(name string, factory VectorDBFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("rag: RegisterVectorDB factory is nil")
+	}
+	if _, dup := vectorDBFactories[name]; dup {
+		panic("rag: RegisterVectorDB called twice for " + name)
+	}
+	vectorDBFactories[name] = factory
+}
+
+// NewEmbedderFromConfig creates an embedder of a registered type
+func NewEmbedderFromConfig(embedType string, cfg EmbedderConfig) (Embedder, error) {
+	registryMu.RLock()
+	factory, ok := embedderFactories[embedType]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding type: %s (available: %v)", embedType, EmbedderTypes())
+	}
+	return factory(cfg)
+}
+
+// NewVectorDB creates a vector database of a registered type
+func NewVectorDB(dbType string, cfg VectorDBConfig) (VectorDB, error) {
+	registryMu.RLock()
+	factory, ok := vectorDBFactories[dbType]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown vector database type: %s (available: %v)", dbType, VectorDBTypes())
+	}
+	return factory(cfg)
+}
+
+// EmbedderTypes returns the registered embedder types, sorted
+func EmbedderTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registeredTypes(embedderFactories)
+}
+
+// VectorDBTypes returns the registered vector database types, sorted
+func VectorDBTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registeredTypes(vectorDBFactories)
+}
+
+func registeredTypes[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,160 @@
+package rag
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// FileRename is a file moved from one path to another
+type FileRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RenameFile moves the chunks indexed for from to the path to, without
+// re-embedding them, when to holds exactly the content indexed for from
+// with the same language and chunker version. It reports whether it did;
+// otherwise nothing changed and to must be indexed as a new file. Moved
+// chunks get the point IDs, root and tags of to, as if indexed there, so a
+// file later indexed at from cannot overwrite them. Vector databases that
+// cannot read vectors back (no PointReader) never rename.
+func (idx *Indexer) RenameFile(ctx context.Context, from, to, collectionName string) (bool, error) {
+	reader, ok := idx.vectorDB.(PointReader)
+	if from == to || !ok {
+		return false, nil
+	}
+	content, err := os.ReadFile(to)
+	if err != nil {
+		return false, nil
+	}
+	hash := HashContent(content)
+	language := DetectLanguageFromContent(to, content)
+
+	points, err := reader.ReadPoints(ctx, collectionName, map[string]interface{}{"file_path": from})
+	if err != nil {
+		return false, err
+	}
+	if len(points) == 0 {
+		return false, nil
+	}
+	for _, point := range points {
+		fileHash, _ := point.Payload["file_hash"].(string)
+		chunkLanguage, _ := point.Payload["language"].(string)
+		if fileHash != hash || chunkLanguage != language || payloadInt(point.Payload["chunker_version"]) != ChunkerVersion {
+			return false, nil
+		}
+	}
+
+	// Chunks left at the destination by an earlier index would be duplicates
+	if err := idx.vectorDB.Delete(ctx, collectionName, map[string]interface{}{"file_path": to}); err != nil {
+		return false, err
+	}
+	if err := idx.movePoints(ctx, collectionName, points, to); err != nil {
+		return false, err
+	}
+	idx.moveFileSummary(ctx, collectionName, from, to)
+
+	idx.logger.Info("Renamed file in index without re-embedding",
+		zap.String("from", from), zap.String("to", to), zap.Int("chunks", len(points)))
+	return true, nil
+}
+
+// movePoints stores points under the IDs of the file to, with its path, root
+// and tags, then deletes them from their old IDs
+func (idx *Indexer) movePoints(ctx context.Context, collectionName string, points []Point, to string) error {
+	tags, root := idx.TagsFor(to), idx.RootFor(to)
+	oldIDs := make([]string, len(points))
+	moved := make([]Point, len(points))
+	for i, point := range points {
+		oldIDs[i] = point.ID
+		point.ID = ChunkPointID(to, payloadInt(point.Payload["line_start"]))
+		point.Payload["file_path"] = to
+		delete(point.Payload, "tags")
+		if len(tags) > 0 {
+			point.Payload["tags"] = tags
+		}
+		delete(point.Payload, "root")
+		if root != "" {
+			point.Payload["root"] = root
+		}
+		moved[i] = point
+	}
+
+	if err := idx.vectorDB.Upsert(ctx, collectionName, moved); err != nil {
+		return err
+	}
+	return idx.vectorDB.DeleteIDs(ctx, collectionName, oldIDs)
+}
+
+// moveFileSummary moves the file-level summary of from to to, when two-tier
+// retrieval keeps one. Failures are only logged: the next index of to
+// rebuilds it.
+func (idx *Indexer) moveFileSummary(ctx context.Context, collectionName, from, to string) {
+	reader, ok := idx.vectorDB.(PointReader)
+	if !idx.fileSummaries || !ok {
+		return
+	}
+	files := FilesCollection(collectionName)
+	summaries, err := reader.ReadPoints(ctx, files, map[string]interface{}{"file_path": from})
+	if err == nil && len(summaries) > 0 {
+		err = idx.movePoints(ctx, files, summaries, to)
+	}
+	if err != nil {
+		idx.logger.Debug("Failed to move file summary", zap.String("from", from), zap.String("to", to), zap.Error(err))
+	}
+}
+
+// renameFiles pairs removed files with added files whose content is the one
+// indexed for them and moves their chunks, returning the renames done
+func (idx *Indexer) renameFiles(ctx context.Context, removed, added []string, collectionName string) []FileRename {
+	if len(removed) == 0 || len(added) == 0 {
+		return nil
+	}
+
+	removedByHash := make(map[string][]string)
+	seen := make(map[string]bool)
+	err := idx.vectorDB.Scroll(ctx, collectionName, map[string]interface{}{"file_path": removed},
+		[]string{"file_path", "file_hash"}, func(point StoredPoint) error {
+			filePath, _ := point.Payload["file_path"].(string)
+			hash, _ := point.Payload["file_hash"].(string)
+			if hash != "" && !seen[filePath] {
+				seen[filePath] = true
+				removedByHash[hash] = append(removedByHash[hash], filePath)
+			}
+			return nil
+		})
+	if err != nil {
+		idx.logger.Warn("Rename detection failed", zap.Error(err))
+		return nil
+	}
+	if len(removedByHash) == 0 {
+		return nil
+	}
+
+	var renames []FileRename
+	for _, to := range added {
+		content, err := os.ReadFile(to)
+		if err != nil {
+			continue
+		}
+		hash := HashContent(content)
+		candidates := removedByHash[hash]
+		if len(candidates) == 0 {
+			continue
+		}
+
+		from := candidates[0]
+		ok, err := idx.RenameFile(ctx, from, to, collectionName)
+		if err != nil {
+			idx.logger.Warn("Failed to rename file in index", zap.String("from", from), zap.String("to", to), zap.Error(err))
+			continue
+		}
+		if ok {
+			removedByHash[hash] = candidates[1:]
+			renames = append(renames, FileRename{From: from, To: to})
+		}
+	}
+	return renames
+}
@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RetryPolicy sets how an indexing run retries its failed files before
+// completing
+type RetryPolicy struct {
+	Attempts int           // Retries of the failed files (0 = none)
+	Backoff  time.Duration // Wait before the first retry, doubled for each next one
+}
+
+// DefaultRetryPolicy matches the config defaults
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Backoff: 30 * time.Second}
+
+// ErrIndexingRunning is returned when failed files are retried while an
+// indexing run is active: the run retries them itself before completing
+var ErrIndexingRunning = errors.New("indexing is running")
+
+// RetryReport lists the outcome of retrying failed files
+type RetryReport struct {
+	Retried   int
+	Recovered []string          // Files indexed on retry
+	Failed    map[string]string // Files still failing, with their last error
+}
+
+// SetRetryPolicy sets how failed files are retried at the end of an indexing
+// run (default: DefaultRetryPolicy). Call it before indexing starts.
+func (idx *IncrementalIndexer) SetRetryPolicy(policy RetryPolicy) {
+	idx.retry = policy
+}
+
+// RetryFailedFiles indexes again the files that failed in the last indexing
+// session, recorded in its state, and saves the state with the outcome. Like
+// an indexing run, it can be canceled with Cancel.
+func (idx *IncrementalIndexer) RetryFailedFiles(ctx context.Context, collectionName string) (*RetryReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idx.control.Lock()
+	if idx.cancelRun != nil {
+		idx.control.Unlock()
+		return nil, ErrIndexingRunning
+	}
+	idx.cancelRun = cancel
+	idx.control.Unlock()
+	defer func() {
+		idx.control.Lock()
+		idx.cancelRun = nil
+		idx.control.Unlock()
+	}()
+
+	if idx.state == nil {
+		state, err := LoadIndexingState(idx.statePath)
+		if err != nil {
+			// No session yet: nothing failed
+			return &RetryReport{Failed: make(map[string]string)}, nil
+		}
+		idx.state = state
+	}
+
+	report, err := idx.retryFailed(ctx, collectionName)
+	if saveErr := idx.state.Save(idx.statePath); saveErr != nil {
+		idx.logger.Warn("Failed to save state", zap.Error(saveErr))
+	}
+	return report, err
+}
+
+// retryFailed processes the failed files of the current state again
+func (idx *IncrementalIndexer) retryFailed(ctx context.Context, collectionName string) (*RetryReport, error) {
+	files := idx.state.failedFiles()
+	report := &RetryReport{Retried: len(files), Failed: make(map[string]string)}
+
+	for i := 0; i < len(files); i += FileBatchSize {
+		end := min(i+FileBatchSize, len(files))
+		if err := idx.processBatch(ctx, files[i:end], collectionName); err != nil {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			idx.logger.Warn("Retrying failed files failed", zap.Int("files", end-i), zap.Error(err))
+		}
+	}
+
+	failed := idx.state.failedErrors()
+	for _, file := range files {
+		if msg, ok := failed[file]; ok {
+			report.Failed[file] = msg
+		} else {
+			report.Recovered = append(report.Recovered, file)
+		}
+	}
+	return report, nil
+}
+
+// retryWithBackoff retries the failed files of the current state up to
+// RetryPolicy.Attempts times, waiting Backoff, then twice as long each time
+func (idx *IncrementalIndexer) retryWithBackoff(ctx context.Context, collectionName string) error {
+	backoff := idx.retry.Backoff
+	for attempt := 1; attempt <= idx.retry.Attempts; attempt++ {
+		pending := len(idx.state.failedFiles())
+		if pending == 0 {
+			return nil
+		}
+		idx.logger.Info("Retrying failed files",
+			zap.Int("files", pending), zap.Int("attempt", attempt), zap.Duration("backoff", backoff))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		report, err := idx.retryFailed(ctx, collectionName)
+		if err != nil {
+			return err
+		}
+		idx.logger.Info("Retried failed files",
+			zap.Int("recovered", len(report.Recovered)), zap.Int("still_failing", len(report.Failed)))
+		backoff *= 2
+	}
+	return nil
+}
+
+// failedFiles returns the failed files, sorted
+func (s *IndexingState) failedFiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files := make([]string, 0, len(s.FailedFiles))
+	for file := range s.FailedFiles {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// failedErrors returns a copy of the failed files and their errors
+func (s *IndexingState) failedErrors() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	failed := make(map[string]string, len(s.FailedFiles))
+	for file, msg := range s.FailedFiles {
+		failed[file] = msg
+	}
+	return failed
+}
@@ -0,0 +1,117 @@
+package rag
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// SetRoots sets the code paths feeding the collection. Chunks store the one
+// containing their file in the "root" payload, so searches can be scoped to
+// a root and paths shown relative to it. Call it before indexing starts.
+func (idx *Indexer) SetRoots(roots []string) {
+	idx.rootsMu.Lock()
+	defer idx.rootsMu.Unlock()
+
+	idx.roots = nil
+	for _, root := range roots {
+		if abs, err := filepath.Abs(root); err == nil {
+			idx.roots = append(idx.roots, abs)
+		}
+	}
+	sortRoots(idx.roots)
+}
+
+// AddRoot adds a root while the indexer runs, e.g. a project opened in an
+// MCP client outside the code paths
+func (idx *Indexer) AddRoot(root string) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return
+	}
+
+	idx.rootsMu.Lock()
+	defer idx.rootsMu.Unlock()
+	if slices.Contains(idx.roots, abs) {
+		return
+	}
+	idx.roots = append(idx.roots, abs)
+	sortRoots(idx.roots)
+}
+
+// RootFor returns the root containing filePath, "" outside all roots
+func (idx *Indexer) RootFor(filePath string) string {
+	idx.rootsMu.RLock()
+	defer idx.rootsMu.RUnlock()
+	return rootOf(filePath, idx.roots)
+}
+
+// sortRoots orders roots innermost first, so nested roots win
+func sortRoots(roots []string) {
+	sort.Slice(roots, func(i, j int) bool { return len(roots[i]) > len(roots[j]) })
+}
+
+// RelativePath returns filePath relative to its root, prefixed with the
+// root's directory name ("api/internal/auth.go"), or filePath outside roots
+func RelativePath(root, filePath string) string {
+	if root == "" {
+		return filePath
+	}
+	rel, err := filepath.Rel(root, filePath)
+	if err != nil {
+		return filePath
+	}
+	return filepath.Join(filepath.Base(root), rel)
+}
+
+// RecordRoots sets the "root" payload of the chunks whose stored root is
+// missing or outdated (indexed before roots were stored, or roots edited),
+// without re-embedding them. It returns the number of files updated.
+func (idx *Indexer) RecordRoots(ctx context.Context, collectionName string) (int, error) {
+	stored := make(map[string]string)
+	err := idx.vectorDB.Scroll(ctx, collectionName, nil, []string{"file_path", "root"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		root, _ := point.Payload["root"].(string)
+		// A file is updated when any of its chunks has another root
+		if current, seen := stored[filePath]; !seen || current == idx.RootFor(filePath) {
+			stored[filePath] = root
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	byRoot := make(map[string][]string)
+	for filePath, root := range stored {
+		if want := idx.RootFor(filePath); root != want {
+			byRoot[want] = append(byRoot[want], filePath)
+		}
+	}
+
+	updated := 0
+	for root, files := range byRoot {
+		sort.Strings(files)
+		for i := 0; i < len(files); i += scrollPageSize {
+			end := min(i+scrollPageSize, len(files))
+			filter := map[string]interface{}{"file_path": files[i:end]}
+			if root == "" {
+				err = idx.vectorDB.DeletePayload(ctx, collectionName, filter, []string{"root"})
+			} else {
+				err = idx.vectorDB.SetPayload(ctx, collectionName, filter, map[string]interface{}{"root": root})
+			}
+			if err != nil {
+				return updated, err
+			}
+		}
+		updated += len(files)
+	}
+
+	if updated > 0 {
+		idx.logger.Info("Updated roots of indexed files", zap.Int("files", updated))
+	}
+	return updated, nil
+}
@@ -0,0 +1,325 @@
+package rag
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SearchLogFileName stores one JSON line per search
+const SearchLogFileName = ".code-rag-searches.jsonl"
+
+const (
+	// searchLogTopResults is the number of results recorded per search
+	searchLogTopResults = 5
+
+	// searchLogCompactInterval is how often Record drops expired entries
+	searchLogCompactInterval = time.Hour
+
+	// searchLogScanWindow is the part of the log read line by line once
+	// Entries has bisected it to the first entry asked for
+	searchLogScanWindow = 64 * 1024
+)
+
+// SearchLogEntry records one search: what was asked, how, and what came back
+type SearchLogEntry struct {
+	Time      time.Time `json:"time"`
+	Tool      string    `json:"tool"`
+	Query     string    `json:"query"`
+	Limit     int       `json:"limit,omitempty"`
+	Offset    int       `json:"offset,omitempty"`
+	MinScore  float32   `json:"min_score"`
+	Tags      []string  `json:"tags,omitempty"`
+	Hybrid    bool      `json:"hybrid,omitempty"`
+	Results   int       `json:"results"`
+	Top       []string  `json:"top,omitempty"` // Best results as file:start-end
+	Degraded  bool      `json:"degraded,omitempty"`
+	Lexical   bool      `json:"lexical,omitempty"` // Results are the lexical fallback: no semantic match
+	Error     string    `json:"error,omitempty"`
+	LatencyMs float64   `json:"latency_ms"`
+}
+
+// TopResults returns the file:start-end references of the best results
+func TopResults(results []SearchResult) []string {
+	top := make([]string, 0, min(len(results), searchLogTopResults))
+	for _, result := range results {
+		if len(top) == searchLogTopResults {
+			break
+		}
+		top = append(top, fmt.Sprintf("%s:%d-%d", result.FilePath, result.LineStart, result.LineEnd))
+	}
+	return top
+}
+
+// SearchLog is an append-only local store of searches, for analytics.
+// Entries older than the retention are dropped when the log is opened, then
+// hourly as entries are recorded.
+type SearchLog struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration
+	compacted time.Time // Last time expired entries were dropped
+}
+
+// NewSearchLog opens the search log of workDir, dropping entries older than
+// retention (0 keeps everything)
+func NewSearchLog(workDir string, retention time.Duration) (*SearchLog, error) {
+	l := &SearchLog{path: filepath.Join(workDir, SearchLogFileName), retention: retention}
+	if retention > 0 {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if err := l.compact(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Record appends an entry, dropping expired entries when they were last
+// dropped searchLogCompactInterval ago
+func (l *SearchLog) Record(entry SearchLogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if l.retention > 0 && time.Since(l.compacted) >= searchLogCompactInterval {
+		return l.compact()
+	}
+	return nil
+}
+
+// Entries returns the entries recorded since the given time, and within the
+// retention, oldest first. Unreadable lines are skipped.
+func (l *SearchLog) Entries(since time.Time) ([]SearchLogEntry, error) {
+	if l.retention > 0 {
+		if cutoff := time.Now().Add(-l.retention); since.Before(cutoff) {
+			since = cutoff
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.read(since)
+}
+
+// read returns the entries recorded since the given time. Entries are
+// appended in time order, so older ones are skipped by bisecting the file.
+func (l *SearchLog) read(since time.Time) ([]SearchLogEntry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	start, err := seekSince(f, since)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var entries []SearchLogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry SearchLogEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// seekSince returns the offset of a line of the log at or before the first
+// entry recorded since the given time, within searchLogScanWindow of it
+func seekSince(f *os.File, since time.Time) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// The line starting at lo is older than since; the first newer one
+	// starts before hi
+	lo, hi := int64(0), info.Size()
+	for hi-lo > searchLogScanWindow {
+		mid := lo + (hi-lo)/2
+		if _, err := f.Seek(mid, io.SeekStart); err != nil {
+			return 0, err
+		}
+		reader := bufio.NewReader(f)
+		partial, err := reader.ReadBytes('\n') // Rest of the line holding mid
+		if err != nil {
+			break
+		}
+		line, err := reader.ReadBytes('\n')
+		var entry SearchLogEntry
+		if err != nil || json.Unmarshal(line, &entry) != nil {
+			break // Scan the rest line by line
+		}
+		next := mid + int64(len(partial))
+		if next >= hi {
+			break
+		}
+		if entry.Time.Before(since) {
+			lo = next
+		} else {
+			hi = next
+		}
+	}
+	return lo, nil
+}
+
+// compact rewrites the log without the entries older than the retention.
+// l.mu must be held.
+func (l *SearchLog) compact() error {
+	l.compacted = time.Now()
+	entries, err := l.read(l.compacted.Add(-l.retention))
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// QueryStats aggregates the searches of one normalized query
+type QueryStats struct {
+	Query        string
+	Searches     int
+	ZeroResults  int
+	AvgResults   float64
+	AvgLatencyMs float64
+	Last         time.Time
+}
+
+// SearchAnalytics summarizes the search log
+type SearchAnalytics struct {
+	Searches          int
+	DistinctQueries   int
+	ZeroResults       int // Searches without semantic results
+	Degraded          int
+	Errors            int
+	AvgLatencyMs      float64
+	P95LatencyMs      float64
+	ByTool            map[string]int
+	TopQueries        []QueryStats // Most frequent first
+	ZeroResultQueries []QueryStats // Queries that returned nothing, most often empty first
+}
+
+// Analyze aggregates entries, keeping the n most frequent queries of each list
+func Analyze(entries []SearchLogEntry, n int) SearchAnalytics {
+	analytics := SearchAnalytics{Searches: len(entries), ByTool: make(map[string]int)}
+	if len(entries) == 0 {
+		return analytics
+	}
+
+	byQuery := make(map[string]*QueryStats)
+	latencies := make([]float64, 0, len(entries))
+	var totalLatency float64
+	for _, entry := range entries {
+		analytics.ByTool[entry.Tool]++
+		latencies = append(latencies, entry.LatencyMs)
+		totalLatency += entry.LatencyMs
+		// Lexical fallback results mean the index had no semantic answer
+		empty := entry.Error == "" && (entry.Results == 0 || entry.Lexical)
+		if entry.Error != "" {
+			analytics.Errors++
+		} else if empty {
+			analytics.ZeroResults++
+		}
+		if entry.Degraded {
+			analytics.Degraded++
+		}
+
+		key := normalizeQuery(entry.Query)
+		stats, ok := byQuery[key]
+		if !ok {
+			stats = &QueryStats{Query: key}
+			byQuery[key] = stats
+		}
+		stats.Searches++
+		if empty {
+			stats.ZeroResults++
+		}
+		stats.AvgResults += float64(entry.Results)
+		stats.AvgLatencyMs += entry.LatencyMs
+		if entry.Time.After(stats.Last) {
+			stats.Last = entry.Time
+		}
+	}
+
+	analytics.DistinctQueries = len(byQuery)
+	analytics.AvgLatencyMs = totalLatency / float64(len(entries))
+	sort.Float64s(latencies)
+	analytics.P95LatencyMs = latencies[(len(latencies)*95-1)/100]
+
+	all := make([]QueryStats, 0, len(byQuery))
+	for _, stats := range byQuery {
+		stats.AvgResults /= float64(stats.Searches)
+		stats.AvgLatencyMs /= float64(stats.Searches)
+		all = append(all, *stats)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Searches != all[j].Searches {
+			return all[i].Searches > all[j].Searches
+		}
+		return all[i].Last.After(all[j].Last)
+	})
+
+	var zero []QueryStats
+	for _, stats := range all {
+		if len(analytics.TopQueries) < n {
+			analytics.TopQueries = append(analytics.TopQueries, stats)
+		}
+		if stats.ZeroResults > 0 {
+			zero = append(zero, stats)
+		}
+	}
+	sort.SliceStable(zero, func(i, j int) bool { return zero[i].ZeroResults > zero[j].ZeroResults })
+	analytics.ZeroResultQueries = zero[:min(n, len(zero))]
+	return analytics
+}
+
+// normalizeQuery groups queries differing only by case and spacing
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
@@ -0,0 +1,177 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+)
+
+// SearchStats counts what a vector search did with the points it matched
+// before returning its results
+type SearchStats struct {
+	Candidates   int // Points matched for the requested page
+	Superseded   int // Hidden because a newer chunker version of their file matched
+	Deduplicated int // Merged into an adjacent match of the same file, or dropped as overlapping a better one
+	Consumed     int // Leading points the results come from: the next page starts this far after the offset
+}
+
+type searchStatsKey struct{}
+
+// WithSearchStats returns a context in which VectorDB.Search fills stats.
+// Backends that do not support it leave stats unchanged.
+func WithSearchStats(ctx context.Context, stats *SearchStats) context.Context {
+	return context.WithValue(ctx, searchStatsKey{}, stats)
+}
+
+// SearchTrace records every point a vector search matched and what became of
+// it, to explain why some code was not returned
+type SearchTrace struct {
+	Candidates []TracedResult
+}
+
+// TracedResult is a matched point and why the search dropped it ("" when
+// it was returned)
+type TracedResult struct {
+	SearchResult
+	Dropped string
+}
+
+type searchTraceKey struct{}
+
+// WithSearchTrace returns a context in which VectorDB.Search appends the
+// points it matched to trace. Backends that do not support it leave trace
+// unchanged.
+func WithSearchTrace(ctx context.Context, trace *SearchTrace) context.Context {
+	return context.WithValue(ctx, searchTraceKey{}, trace)
+}
+
+// DedupOptions controls how a vector search deduplicates the chunks it
+// matches in the same file
+type DedupOptions struct {
+	Disabled  bool    // Return every matched chunk, even overlapping ones
+	Threshold float64 // Share of either chunk's lines an overlap must exceed for the worse chunk to be dropped (0: 0.5)
+	OverFetch int     // Match limit×OverFetch points so limit results remain after deduplication (0: 1)
+}
+
+// DefaultDedup is how searches deduplicate with zero DedupOptions
+var DefaultDedup = DedupOptions{Threshold: 0.5, OverFetch: 1}
+
+// withDefaults fills the unset fields of o from DefaultDedup
+func (o DedupOptions) withDefaults() DedupOptions {
+	if o.Threshold <= 0 {
+		o.Threshold = DefaultDedup.Threshold
+	}
+	if o.OverFetch < 1 || o.Disabled {
+		o.OverFetch = 1
+	}
+	return o
+}
+
+// deduplicate merges and drops overlapping results of the same file, unless
+// deduplication is disabled
+func (o DedupOptions) deduplicate(results []SearchResult) []SearchResult {
+	if o.Disabled {
+		return results
+	}
+	return deduplicateResults(results, o.withDefaults().Threshold)
+}
+
+// SearchOptions tune how VectorDB.Search matches points. The zero value
+// searches with the defaults.
+type SearchOptions struct {
+	Dedup  DedupOptions // How matches of the same file are deduplicated
+	Params SearchParams // HNSW settings; exact backends, like MemoryDB, ignore them
+}
+
+// fetchLimit returns how many points a search returning limit results
+// matches before deduplication
+func (o SearchOptions) fetchLimit(limit int) int {
+	if limit <= 0 {
+		return limit
+	}
+	return limit * o.Dedup.withDefaults().OverFetch
+}
+
+// finishSearch hides superseded chunks and duplicates from the points a
+// search matched, keeps the first limit results, and records what it dropped
+// in the context's SearchStats and SearchTrace
+func finishSearch(ctx context.Context, results []SearchResult, limit int, dedup DedupOptions) []SearchResult {
+	candidates := len(results)
+	fillContentFromDisk(results)
+	raw := append([]SearchResult(nil), results...)
+	results = preferNewestChunks(results)
+	newest := append([]SearchResult(nil), results...)
+	results = dedup.deduplicate(results)
+	deduplicated := len(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	if stats, ok := ctx.Value(searchStatsKey{}).(*SearchStats); ok && stats != nil {
+		stats.Candidates += candidates
+		stats.Superseded += candidates - len(newest)
+		stats.Deduplicated += len(newest) - deduplicated
+		stats.Consumed += consumedPoints(raw, results, deduplicated > len(results))
+	}
+	if trace, ok := ctx.Value(searchTraceKey{}).(*SearchTrace); ok && trace != nil {
+		trace.add(raw, newest, results, deduplicated > len(results))
+	}
+	return results
+}
+
+// consumedPoints returns how many of the raw points, best first, come before
+// the last result. Without truncated results, every point was used.
+func consumedPoints(raw, results []SearchResult, truncated bool) int {
+	if !truncated {
+		return len(raw)
+	}
+	position := make(map[string]int, len(raw))
+	for i, r := range raw {
+		position[r.ID] = i
+	}
+	consumed := 0
+	for _, r := range results {
+		consumed = max(consumed, position[r.ID]+1)
+	}
+	return consumed
+}
+
+// add records raw candidates, explaining the ones missing from newest
+// (superseded) and from results (deduplicated, or past the limit when
+// results were truncated)
+func (t *SearchTrace) add(raw, newest, results []SearchResult, truncated bool) {
+	kept := make(map[string]bool, len(newest))
+	for _, r := range newest {
+		kept[r.ID] = true
+	}
+	returned := make(map[string]bool, len(results))
+	for _, r := range results {
+		returned[r.ID] = true
+	}
+	consumed := consumedPoints(raw, results, truncated)
+
+	for i, candidate := range raw {
+		traced := TracedResult{SearchResult: candidate}
+		switch {
+		case !kept[candidate.ID]:
+			traced.Dropped = "superseded by a newer chunker version of the file"
+		case !returned[candidate.ID]:
+			traced.Dropped = "overlaps a better match of the file"
+			for _, r := range results {
+				if r.FilePath == candidate.FilePath && r.LineStart <= candidate.LineStart && r.LineEnd >= candidate.LineEnd {
+					traced.Dropped = fmt.Sprintf("merged into lines %d-%d", r.LineStart, r.LineEnd)
+					break
+				}
+			}
+			if traced.Dropped == "overlaps a better match of the file" && i >= consumed {
+				traced.Dropped = "over-fetched, past the limit"
+			}
+		}
+		t.Candidates = append(t.Candidates, traced)
+	}
+}
+
+// SearchParams trade latency for recall in approximate (HNSW) vector searches
+type SearchParams struct {
+	HnswEf int  // Candidates explored per search (0: collection default)
+	Exact  bool // Compare the query with every vector instead of using the index
+}
@@ -0,0 +1,122 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// SecretDetector finds one kind of secret in chunk content. When Pattern has
+// a group named "secret", only that group is masked (e.g. the value of an
+// assignment), otherwise the whole match is.
+type SecretDetector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// NewSecretDetector compiles a detector, e.g. from configuration
+func NewSecretDetector(name, pattern string) (SecretDetector, error) {
+	if name == "" {
+		return SecretDetector{}, fmt.Errorf("secret detector requires a name")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return SecretDetector{}, fmt.Errorf("secret detector %s: %w", name, err)
+	}
+	return SecretDetector{Name: name, Pattern: re}, nil
+}
+
+// DefaultSecretDetectors returns the built-in detectors: cloud and SaaS keys,
+// bearer tokens, private key blocks and quoted credentials assigned to
+// password/secret/token-like names
+func DefaultSecretDetectors() []SecretDetector {
+	return []SecretDetector{
+		{Name: "private_key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+		{Name: "aws_access_key_id", Pattern: regexp.MustCompile(`\b(AKIA|ASIA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+		{Name: "aws_secret_access_key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?(access_?)?key\W{0,4}[:=]\s*["']?(?P<secret>[A-Za-z0-9/+]{40})\b`)},
+		{Name: "github_token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+		{Name: "slack_token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+		{Name: "stripe_key", Pattern: regexp.MustCompile(`\b(sk|rk)_live_[A-Za-z0-9]{20,}\b`)},
+		{Name: "google_api_key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+		{Name: "bearer_token", Pattern: regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]{20,}=*)`)},
+		{Name: "credential", Pattern: regexp.MustCompile(`(?i)(password|passwd|pwd|secret|api_?key|access_?token|auth_?token|client_?secret)["']?\s*[:=]\s*["'](?P<secret>[^"'\s]{8,})["']`)},
+	}
+}
+
+// SecretScrubber masks secrets in chunks before they are embedded and stored,
+// so neither vectors nor payloads carry them. Masks keep line breaks, so
+// line numbers still match the file.
+type SecretScrubber struct {
+	detectors []SecretDetector
+}
+
+// NewSecretScrubber creates a scrubber running detectors in order
+func NewSecretScrubber(detectors ...SecretDetector) *SecretScrubber {
+	return &SecretScrubber{detectors: detectors}
+}
+
+// Scrub masks the secrets of text as [REDACTED:<detector>] and returns the
+// names of the detectors that matched
+func (s *SecretScrubber) Scrub(text string) (string, []string) {
+	var found []string
+	for _, detector := range s.detectors {
+		matches := detector.Pattern.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		group := 0
+		if i := detector.Pattern.SubexpIndex("secret"); i > 0 {
+			group = i
+		}
+
+		var scrubbed strings.Builder
+		last := 0
+		for _, match := range matches {
+			start, end := match[2*group], match[2*group+1]
+			if start < 0 {
+				continue
+			}
+			scrubbed.WriteString(text[last:start])
+			scrubbed.WriteString("[REDACTED:" + detector.Name + "]")
+			scrubbed.WriteString(strings.Repeat("\n", strings.Count(text[start:end], "\n")))
+			last = end
+		}
+		scrubbed.WriteString(text[last:])
+		text = scrubbed.String()
+		found = append(found, detector.Name)
+	}
+	return text, found
+}
+
+// SetSecretScrubber masks secrets in every chunk indexed from now on (nil
+// disables it). Call it before indexing starts.
+func (idx *Indexer) SetSecretScrubber(scrubber *SecretScrubber) {
+	idx.scrubber = scrubber
+}
+
+// scrubSecrets masks the secrets of chunks and of their file summaries
+func (idx *Indexer) scrubSecrets(chunks []CodeChunk) []CodeChunk {
+	if idx.scrubber == nil {
+		return chunks
+	}
+
+	for i := range chunks {
+		var found, inSummary []string
+		chunks[i].Content, found = idx.scrubber.Scrub(chunks[i].Content)
+		if chunks[i].FileSummary != "" {
+			chunks[i].FileSummary, inSummary = idx.scrubber.Scrub(chunks[i].FileSummary)
+			found = append(found, inSummary...)
+		}
+		if len(found) > 0 {
+			idx.logger.Info("Secrets redacted before indexing",
+				zap.String("file", chunks[i].FilePath),
+				zap.Int("line_start", chunks[i].LineStart),
+				zap.Strings("detectors", found),
+			)
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,106 @@
+package rag
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+)
+
+// SparseVectorName is the named sparse vector of collections created with
+// CollectionOptions.SparseVectors
+const SparseVectorName = "lexical"
+
+// bm25AvgLength is the chunk length, in terms, that document term weights
+// are normalized against. Chunks are bounded by the chunk size, so a fixed
+// average avoids keeping corpus statistics; Qdrant applies the IDF.
+const bm25AvgLength = 150
+
+// SparseVector is a sparse (lexical) vector: weights of the terms present,
+// keyed by term index
+type SparseVector struct {
+	Indices []uint32
+	Values  []float32
+}
+
+// SparseEncoder turns text into sparse vectors. BM25Encoder needs no model;
+// learned encoders (BM42, SPLADE) can be plugged in with Indexer.SetSparseEncoder.
+type SparseEncoder interface {
+	EncodeDocument(text string) SparseVector
+	EncodeQuery(text string) SparseVector
+}
+
+// HybridSearcher is implemented by vector databases that fuse dense and
+// sparse rankings themselves
+type HybridSearcher interface {
+	// HybridSearch fuses the dense matches of vector scoring at least minScore
+	// with those of sparse, after offset. A point ranked first by both scores 1.0.
+	HybridSearch(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, sparse SparseVector, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error)
+}
+
+// SetSparseEncoder sets the encoder of the sparse vectors stored with chunks
+// (default: nil, dense vectors only). The collection must have been created
+// with CollectionOptions.SparseVectors. Call it before indexing starts.
+func (idx *Indexer) SetSparseEncoder(encoder SparseEncoder) {
+	idx.sparse = encoder
+}
+
+// SparseEncoder returns the encoder set with SetSparseEncoder, nil without one
+func (idx *Indexer) SparseEncoder() SparseEncoder {
+	return idx.sparse
+}
+
+// BM25Encoder encodes text as hashed terms weighted like BM25: document
+// weights saturate with term frequency and are normalized by chunk length,
+// queries weigh each term once. The IDF part is left to the database
+// (Qdrant's IDF modifier), so the encoder keeps no state.
+type BM25Encoder struct{}
+
+// EncodeDocument returns the BM25 term weights of a chunk
+func (BM25Encoder) EncodeDocument(text string) SparseVector {
+	terms := Tokenize(text)
+	freqs := make(map[uint32]float64)
+	for _, term := range terms {
+		freqs[termIndex(term)]++
+	}
+
+	norm := bm25K1 * (1 - bm25B + bm25B*float64(len(terms))/bm25AvgLength)
+	weights := make(map[uint32]float32, len(freqs))
+	for index, tf := range freqs {
+		weights[index] = float32(tf * (bm25K1 + 1) / (tf + norm))
+	}
+	return newSparseVector(weights)
+}
+
+// EncodeQuery returns the terms of a query, stopwords excluded
+func (BM25Encoder) EncodeQuery(text string) SparseVector {
+	weights := make(map[uint32]float32)
+	for _, term := range Tokenize(text) {
+		if !lexicalStopwords[term] {
+			weights[termIndex(term)] = 1
+		}
+	}
+	return newSparseVector(weights)
+}
+
+// termIndex hashes a term to its sparse vector index
+func termIndex(term string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(term))
+	return h.Sum32()
+}
+
+// newSparseVector builds a sparse vector from weights, by increasing index
+func newSparseVector(weights map[uint32]float32) SparseVector {
+	v := SparseVector{
+		Indices: make([]uint32, 0, len(weights)),
+		Values:  make([]float32, 0, len(weights)),
+	}
+	for index := range weights {
+		v.Indices = append(v.Indices, index)
+	}
+	sort.Slice(v.Indices, func(i, j int) bool { return v.Indices[i] < v.Indices[j] })
+	for _, index := range v.Indices {
+		v.Values = append(v.Values, weights[index])
+	}
+	return v
+}
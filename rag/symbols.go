@@ -0,0 +1,342 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Symbol is a named definition (function, type, class...) found in a file
+type Symbol struct {
+	Name string
+	Kind string
+	Line int // 1-based line of the definition
+}
+
+// SymbolMatch is a symbol found by SearchSymbols
+type SymbolMatch struct {
+	Symbol
+	FilePath string
+	Language string
+	Score    float64
+}
+
+// Symbol match modes for SearchSymbols
+const (
+	SymbolMatchExact  = "exact"
+	SymbolMatchPrefix = "prefix"
+	SymbolMatchFuzzy  = "fuzzy"
+)
+
+type symbolPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// symbolPatterns holds per-language definition patterns.
+// The first capture group is the symbol name.
+var symbolPatterns = map[string][]symbolPattern{
+	"go": {
+		{"method", regexp.MustCompile(`^func\s+\([^)]*\)\s*([A-Za-z_]\w*)`)},
+		{"function", regexp.MustCompile(`^func\s+([A-Za-z_]\w*)`)},
+		{"type", regexp.MustCompile(`^type\s+([A-Za-z_]\w*)`)},
+		{"type", regexp.MustCompile(`^\s+([A-Z]\w*)\s+(?:struct|interface)\s*\{`)},
+	},
+	"python": {
+		{"class", regexp.MustCompile(`^\s*class\s+([A-Za-z_]\w*)`)},
+		{"function", regexp.MustCompile(`^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)`)},
+	},
+	"javascript": {
+		{"class", regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?class\s+([A-Za-z_$][\w$]*)`)},
+		{"function", regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`)},
+		{"function", regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s+)?(?:function|\([^)]*\)\s*=>|[A-Za-z_$][\w$]*\s*=>)`)},
+	},
+	"typescript": {
+		{"class", regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`)},
+		{"interface", regexp.MustCompile(`^\s*(?:export\s+)?interface\s+([A-Za-z_$][\w$]*)`)},
+		{"type", regexp.MustCompile(`^\s*(?:export\s+)?type\s+([A-Za-z_$][\w$]*)\s*(?:<[^=]*>)?\s*=`)},
+		{"enum", regexp.MustCompile(`^\s*(?:export\s+)?(?:const\s+)?enum\s+([A-Za-z_$][\w$]*)`)},
+		{"function", regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`)},
+		{"function", regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)`)},
+	},
+	"rust": {
+		{"function", regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+([A-Za-z_]\w*)`)},
+		{"struct", regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?struct\s+([A-Za-z_]\w*)`)},
+		{"enum", regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?enum\s+([A-Za-z_]\w*)`)},
+		{"trait", regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?trait\s+([A-Za-z_]\w*)`)},
+		{"type", regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?type\s+([A-Za-z_]\w*)`)},
+	},
+	"java": {
+		{"class", regexp.MustCompile(`^\s*(?:(?:public|protected|private|abstract|final|static)\s+)*(?:class|interface|enum|record)\s+([A-Za-z_]\w*)`)},
+		{"method", regexp.MustCompile(`^\s*(?:(?:public|protected|private|abstract|final|static|synchronized)\s+)+[\w<>\[\],\s]+?\s+([a-z_]\w*)\s*\(`)},
+	},
+	"c": {
+		{"function", regexp.MustCompile(`^[A-Za-z_][\w\s\*]*?\b([A-Za-z_]\w*)\s*\([^;]*$`)},
+		{"struct", regexp.MustCompile(`^\s*(?:typedef\s+)?struct\s+([A-Za-z_]\w*)`)},
+	},
+	"cpp": {
+		{"class", regexp.MustCompile(`^\s*(?:class|struct)\s+([A-Za-z_]\w*)`)},
+		{"function", regexp.MustCompile(`^[A-Za-z_][\w\s\*&:<>,]*?\b([A-Za-z_][\w:]*)\s*\([^;]*$`)},
+	},
+	"terraform": {
+		{"resource", regexp.MustCompile(`^\s*(?:resource|data)\s+"([^"]+"\s+"[^"]+)"`)},
+		{"module", regexp.MustCompile(`^\s*module\s+"([^"]+)"`)},
+		{"variable", regexp.MustCompile(`^\s*variable\s+"([^"]+)"`)},
+		{"output", regexp.MustCompile(`^\s*output\s+"([^"]+)"`)},
+	},
+	"makefile": {
+		{"target", regexp.MustCompile(`^([A-Za-z0-9_][A-Za-z0-9_./%-]*)\s*::?(?:[^=]|$)`)},
+	},
+	"dockerfile": {
+		{"stage", regexp.MustCompile(`(?i)^\s*FROM\s+\S+\s+AS\s+(\S+)`)},
+	},
+	"bash": {
+		{"function", regexp.MustCompile(`^\s*(?:function\s+)?([A-Za-z_][\w-]*)\s*\(\)\s*\{?`)},
+		{"function", regexp.MustCompile(`^\s*function\s+([A-Za-z_][\w-]*)`)},
+	},
+}
+
+// terraformAddressSep joins resource type and name into an address
+var terraformAddressSep = regexp.MustCompile(`"\s+"`)
+
+// symbolKeywords are captures that patterns can accidentally match (mostly C-like control flow)
+var symbolKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true,
+	"else": true, "sizeof": true, "catch": true, "new": true,
+}
+
+// ExtractSymbols finds symbol definitions in a file's lines using
+// lightweight per-language patterns. Only the first matching pattern
+// per line is kept.
+func ExtractSymbols(language string, lines []string) []Symbol {
+	patterns, ok := symbolPatterns[language]
+	if !ok {
+		return nil
+	}
+
+	var symbols []Symbol
+	for i, line := range lines {
+		for _, p := range patterns {
+			m := p.re.FindStringSubmatch(line)
+			if m == nil || symbolKeywords[m[1]] {
+				continue
+			}
+
+			name := m[1]
+			if language == "terraform" && p.kind == "resource" {
+				// resource "aws_vpc" "main" -> aws_vpc.main
+				name = terraformAddressSep.ReplaceAllString(name, ".")
+			}
+
+			symbols = append(symbols, Symbol{Name: name, Kind: p.kind, Line: i + 1})
+			break
+		}
+	}
+
+	return symbols
+}
+
+// symbolsInRange returns the symbols defined between lineStart and lineEnd (inclusive)
+func symbolsInRange(symbols []Symbol, lineStart, lineEnd int) []Symbol {
+	var result []Symbol
+	for _, sym := range symbols {
+		if sym.Line >= lineStart && sym.Line <= lineEnd {
+			result = append(result, sym)
+		}
+	}
+	return result
+}
+
+// symbolsPayload converts symbols to the payload fields stored with each chunk.
+// "symbols" holds bare names (usable in keyword filters) and "symbol_defs"
+// keeps kind and line for each definition.
+func symbolsPayload(symbols []Symbol) (names []interface{}, defs []interface{}) {
+	names = make([]interface{}, 0, len(symbols))
+	defs = make([]interface{}, 0, len(symbols))
+	for _, sym := range symbols {
+		names = append(names, sym.Name)
+		defs = append(defs, map[string]interface{}{
+			"name": sym.Name,
+			"kind": sym.Kind,
+			"line": sym.Line,
+		})
+	}
+	return names, defs
+}
+
+// symbolsFromPayload reads the "symbol_defs" payload field back into symbols
+func symbolsFromPayload(payload map[string]interface{}) []Symbol {
+	defs, ok := payload["symbol_defs"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	symbols := make([]Symbol, 0, len(defs))
+	for _, d := range defs {
+		def, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sym := Symbol{}
+		sym.Name, _ = def["name"].(string)
+		sym.Kind, _ = def["kind"].(string)
+		sym.Line = payloadInt(def["line"])
+		if sym.Name != "" {
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols
+}
+
+// MatchSymbol scores how well a symbol name matches a query.
+// Exact matching is case-sensitive; prefix and fuzzy matching are not.
+// Fuzzy matching accepts names containing the query characters in order.
+func MatchSymbol(name, query, mode string) (float64, bool) {
+	switch mode {
+	case SymbolMatchExact:
+		if name == query {
+			return 1.0, true
+		}
+		return 0, false
+	case SymbolMatchPrefix:
+		lowerName, lowerQuery := strings.ToLower(name), strings.ToLower(query)
+		if !strings.HasPrefix(lowerName, lowerQuery) {
+			return 0, false
+		}
+		return float64(len(query)) / float64(len(name)), true
+	default:
+		lowerName, lowerQuery := strings.ToLower(name), strings.ToLower(query)
+		if lowerName == lowerQuery {
+			return 1.0, true
+		}
+		if strings.Contains(lowerName, lowerQuery) {
+			score := 0.5 + 0.4*float64(len(query))/float64(len(name))
+			if strings.HasPrefix(lowerName, lowerQuery) {
+				score += 0.05
+			}
+			return score, true
+		}
+
+		// Subsequence match: every query character appears in order
+		qi := 0
+		for i := 0; i < len(lowerName) && qi < len(lowerQuery); i++ {
+			if lowerName[i] == lowerQuery[qi] {
+				qi++
+			}
+		}
+		if qi < len(lowerQuery) {
+			return 0, false
+		}
+		return 0.4 * float64(len(query)) / float64(len(name)), true
+	}
+}
+
+// SearchSymbols scans indexed chunk payloads for symbol definitions matching query.
+// kind optionally restricts results to one symbol kind (e.g. "function").
+func SearchSymbols(ctx context.Context, db VectorDB, collection, query, mode, kind string, limit int) ([]SymbolMatch, error) {
+	var filter map[string]interface{}
+	if mode == SymbolMatchExact {
+		filter = map[string]interface{}{"symbols": query}
+	}
+
+	seen := make(map[string]bool)
+	var matches []SymbolMatch
+
+	err := db.Scroll(ctx, collection, filter, []string{"file_path", "language", "symbol_defs"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		language, _ := point.Payload["language"].(string)
+
+		for _, sym := range symbolsFromPayload(point.Payload) {
+			if kind != "" && sym.Kind != kind {
+				continue
+			}
+			score, ok := MatchSymbol(sym.Name, query, mode)
+			if !ok {
+				continue
+			}
+
+			// Overlapping chunks carry the same definition; keep it once
+			key := fmt.Sprintf("%s:%s:%d", filePath, sym.Name, sym.Line)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			matches = append(matches, SymbolMatch{
+				Symbol:   sym,
+				FilePath: filePath,
+				Language: language,
+				Score:    score,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].FilePath != matches[j].FilePath {
+			return matches[i].FilePath < matches[j].FilePath
+		}
+		return matches[i].Line < matches[j].Line
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// DefinitionsOf looks up the definitions of several symbol names in one scan.
+// Returns at most one definition per name (the first by file path); test files
+// are skipped when skipTests is set.
+func DefinitionsOf(ctx context.Context, db VectorDB, collection string, names []string, skipTests bool) ([]SymbolMatch, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	found := make(map[string]SymbolMatch)
+	filter := map[string]interface{}{"symbols": names}
+
+	err := db.Scroll(ctx, collection, filter, []string{"file_path", "language", "symbol_defs"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		if skipTests && IsTestFile(filePath) {
+			return nil
+		}
+		language, _ := point.Payload["language"].(string)
+
+		for _, sym := range symbolsFromPayload(point.Payload) {
+			if !wanted[sym.Name] {
+				continue
+			}
+			if existing, ok := found[sym.Name]; ok && existing.FilePath <= filePath {
+				continue
+			}
+			found[sym.Name] = SymbolMatch{Symbol: sym, FilePath: filePath, Language: language, Score: 1.0}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]SymbolMatch, 0, len(found))
+	for _, name := range names {
+		if m, ok := found[name]; ok {
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,151 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// SyncReport lists what SyncDirectory changed in the index
+type SyncReport struct {
+	Added     []string     // Files not indexed before
+	Modified  []string     // Files whose content changed since indexing
+	Removed   []string     // Indexed files that no longer exist
+	Renamed   []FileRename // Removed files found unchanged at an added path, moved without re-embedding
+	Unchanged int
+	Failed    map[string]string
+}
+
+// SyncDirectory brings the chunks of files under root up to date with the
+// disk: new and changed files are re-indexed, chunks of deleted files are
+// removed, and unchanged files are left alone. Unlike
+// IndexDirectoryIncremental, it never re-embeds a file whose hash matches.
+// Cancel stops it like an incremental run.
+func (idx *IncrementalIndexer) SyncDirectory(ctx context.Context, root string, extensions []string, collectionName string) (*SyncReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idx.control.Lock()
+	idx.cancelRun = cancel
+	idx.control.Unlock()
+	defer func() {
+		idx.control.Lock()
+		idx.cancelRun = nil
+		idx.control.Unlock()
+	}()
+
+	prefix := filepath.Clean(root) + string(os.PathSeparator)
+	head, _ := HeadCommit(ctx, root) // Empty outside git repositories
+
+	indexedHashes := make(map[string]string)
+	err := idx.vectorDB.Scroll(ctx, collectionName, nil, []string{"file_path", "file_hash"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		if !strings.HasPrefix(filePath, prefix) {
+			return nil
+		}
+		hash, _ := point.Payload["file_hash"].(string)
+		if indexedHashes[filePath] == "" {
+			indexedHashes[filePath] = hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan index: %w", err)
+	}
+
+	files, err := idx.collectFiles(root, extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect files: %w", err)
+	}
+
+	report := &SyncReport{Failed: make(map[string]string)}
+	onDisk := make(map[string]bool, len(files))
+	for _, filePath := range files {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		onDisk[filePath] = true
+
+		indexedHash, indexed := indexedHashes[filePath]
+		if !indexed {
+			report.Added = append(report.Added, filePath)
+			continue
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			report.Failed[filePath] = err.Error()
+			continue
+		}
+		if indexedHash == "" || HashContent(content) != indexedHash {
+			report.Modified = append(report.Modified, filePath)
+			continue
+		}
+		report.Unchanged++
+	}
+
+	for filePath := range indexedHashes {
+		if !onDisk[filePath] {
+			report.Removed = append(report.Removed, filePath)
+		}
+	}
+
+	if report.Renamed = idx.renameFiles(ctx, report.Removed, report.Added, collectionName); len(report.Renamed) > 0 {
+		moved := make(map[string]bool, 2*len(report.Renamed))
+		for _, rename := range report.Renamed {
+			moved[rename.From] = true
+			moved[rename.To] = true
+		}
+		report.Removed = withoutPaths(report.Removed, moved)
+		report.Added = withoutPaths(report.Added, moved)
+	}
+
+	if len(report.Removed) > 0 {
+		if err := idx.vectorDB.Delete(ctx, collectionName, map[string]interface{}{"file_path": report.Removed}); err != nil {
+			return report, fmt.Errorf("failed to delete removed files: %w", err)
+		}
+		idx.dropFileSummaries(ctx, collectionName, report.Removed)
+	}
+
+	changed := append(append([]string{}, report.Added...), report.Modified...)
+	for i := 0; i < len(changed); i += FileBatchSize {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		end := i + FileBatchSize
+		if end > len(changed) {
+			end = len(changed)
+		}
+		if err := idx.ReindexFiles(ctx, changed[i:end], collectionName); err != nil {
+			return report, fmt.Errorf("failed to re-index changed files: %w", err)
+		}
+	}
+
+	idx.recordIndexedCommit(root, head)
+	idx.logger.Info("Index synced",
+		zap.String("path", root),
+		zap.Int("added", len(report.Added)),
+		zap.Int("modified", len(report.Modified)),
+		zap.Int("removed", len(report.Removed)),
+		zap.Int("renamed", len(report.Renamed)),
+		zap.Int("unchanged", report.Unchanged),
+		zap.Int("failed", len(report.Failed)),
+	)
+
+	return report, nil
+}
+
+// withoutPaths returns the paths not in drop
+func withoutPaths(paths []string, drop map[string]bool) []string {
+	kept := paths[:0:0]
+	for _, path := range paths {
+		if !drop[path] {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PathTagsFileName stores the tags assigned to directories at indexing
+// time, so files re-indexed later keep them
+const PathTagsFileName = ".path_tags.json"
+
+// SetPathTags assigns tags (e.g. "team:payments", "tier:critical") to the
+// files under path. They are stored in the "tags" payload of chunks indexed
+// from now on; RetagPath updates chunks already indexed. No tags removes
+// the assignment.
+func (idx *Indexer) SetPathTags(path string, tags []string) {
+	idx.tagsMu.Lock()
+	defer idx.tagsMu.Unlock()
+
+	path = filepath.Clean(path)
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		delete(idx.tags, path)
+		return
+	}
+	if idx.tags == nil {
+		idx.tags = make(map[string][]string)
+	}
+	idx.tags[path] = tags
+}
+
+// PathTags returns the tags assigned to each path
+func (idx *Indexer) PathTags() map[string][]string {
+	idx.tagsMu.RLock()
+	defer idx.tagsMu.RUnlock()
+
+	tags := make(map[string][]string, len(idx.tags))
+	for path, pathTags := range idx.tags {
+		tags[path] = append([]string(nil), pathTags...)
+	}
+	return tags
+}
+
+// TagsFor returns the tags of filePath: those of every tagged path
+// containing it, so a repository inherits the tags of its parent directory
+func (idx *Indexer) TagsFor(filePath string) []string {
+	idx.tagsMu.RLock()
+	defer idx.tagsMu.RUnlock()
+
+	var tags []string
+	for path, pathTags := range idx.tags {
+		if filePath == path || strings.HasPrefix(filePath, path+string(os.PathSeparator)) {
+			tags = append(tags, pathTags...)
+		}
+	}
+	return normalizeTags(tags)
+}
+
+// RetagPath rewrites the "tags" payload of the chunks indexed under path
+// with their current tags, without re-embedding them. It returns the number
+// of files updated.
+func (idx *Indexer) RetagPath(ctx context.Context, path, collectionName string) (int, error) {
+	files, err := FilesUnderPath(ctx, idx.vectorDB, collectionName, path)
+	if err != nil {
+		return 0, err
+	}
+
+	// Files under nested tagged paths have more tags: update by tag set
+	byTags := make(map[string][]string)
+	for _, file := range files {
+		key := strings.Join(idx.TagsFor(file), "\x00")
+		byTags[key] = append(byTags[key], file)
+	}
+
+	for key, group := range byTags {
+		for i := 0; i < len(group); i += scrollPageSize {
+			end := min(i+scrollPageSize, len(group))
+			filter := map[string]interface{}{"file_path": group[i:end]}
+			if key == "" {
+				err = idx.vectorDB.DeletePayload(ctx, collectionName, filter, []string{"tags"})
+			} else {
+				err = idx.vectorDB.SetPayload(ctx, collectionName, filter, map[string]interface{}{"tags": strings.Split(key, "\x00")})
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	idx.logger.Info("Updated tags of indexed files", zap.String("path", path), zap.Int("files", len(files)))
+	return len(files), nil
+}
+
+// TagPath assigns tags to path like SetPathTags and records them in the work
+// directory, so they are restored after a restart
+func (idx *IncrementalIndexer) TagPath(path string, tags []string) error {
+	idx.tagsFileMu.Lock()
+	defer idx.tagsFileMu.Unlock()
+
+	idx.SetPathTags(path, tags)
+
+	recorded := idx.readPathTags()
+	if tags = normalizeTags(tags); len(tags) > 0 {
+		recorded[filepath.Clean(path)] = tags
+	} else {
+		delete(recorded, filepath.Clean(path))
+	}
+
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(idx.WorkDir(), PathTagsFileName), data, 0644)
+}
+
+// loadPathTags restores the tags recorded by TagPath
+func (idx *IncrementalIndexer) loadPathTags() {
+	for path, tags := range idx.readPathTags() {
+		idx.SetPathTags(path, tags)
+	}
+}
+
+func (idx *IncrementalIndexer) readPathTags() map[string][]string {
+	tags := make(map[string][]string)
+	data, err := os.ReadFile(filepath.Join(idx.WorkDir(), PathTagsFileName))
+	if err == nil {
+		if err := json.Unmarshal(data, &tags); err != nil {
+			idx.logger.Warn("Ignoring unreadable path tags file", zap.Error(err))
+			tags = make(map[string][]string)
+		}
+	}
+	return tags
+}
+
+// normalizeTags trims, sorts and deduplicates tags, dropping empty ones
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
@@ -0,0 +1,27 @@
+package rag
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// IsTestFile reports whether a path follows a common test file naming convention
+func IsTestFile(filePath string) bool {
+	base := strings.ToLower(filepath.Base(filePath))
+
+	switch {
+	case strings.HasSuffix(base, "_test.go"),
+		strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py"),
+		strings.HasSuffix(base, "_test.py"),
+		strings.Contains(base, ".test."),
+		strings.Contains(base, ".spec."),
+		strings.HasSuffix(base, "test.java"),
+		strings.HasSuffix(base, "tests.java"),
+		strings.HasSuffix(base, "_spec.rb"),
+		strings.HasSuffix(base, ".tftest.hcl"):
+		return true
+	}
+
+	dir := filepath.ToSlash(filepath.Dir(filePath)) + "/"
+	return strings.Contains(dir, "/tests/") || strings.Contains(dir, "/__tests__/")
+}
@@ -0,0 +1,52 @@
+package rag
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// DecodeText returns file content as UTF-8 text: a UTF-8 byte order mark is
+// dropped and UTF-16 content (detected by its BOM) is decoded
+func DecodeText(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, utf8BOM):
+		return string(content[len(utf8BOM):])
+	case bytes.HasPrefix(content, utf16LEBOM), bytes.HasPrefix(content, utf16BEBOM):
+		bigEndian := content[0] == 0xFE
+		content = content[2:]
+		units := make([]uint16, len(content)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = uint16(content[2*i])<<8 | uint16(content[2*i+1])
+			} else {
+				units[i] = uint16(content[2*i+1])<<8 | uint16(content[2*i])
+			}
+		}
+		return string(utf16.Decode(units))
+	default:
+		return string(content)
+	}
+}
+
+// SplitLines splits file content into lines numbered the way editors number
+// them: "\r\n", "\n" and a lone "\r" all end a line, and a final line ending
+// does not start an extra empty line. See DecodeText for encodings.
+func SplitLines(content []byte) []string {
+	text := DecodeText(content)
+	if !strings.Contains(text, "\r") {
+		text = strings.TrimSuffix(text, "\n")
+		return strings.Split(text, "\n")
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	return strings.Split(text, "\n")
+}
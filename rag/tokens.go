@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+var (
+	tokenCodecOnce sync.Once
+	tokenCodec     tokenizer.Codec
+)
+
+// codec lazily loads the cl100k_base encoding, a close match for the
+// tokenizers of current chat models. Returns nil if it cannot be loaded.
+func codec() tokenizer.Codec {
+	tokenCodecOnce.Do(func() {
+		c, err := tokenizer.Get(tokenizer.Cl100kBase)
+		if err == nil {
+			tokenCodec = c
+		}
+	})
+	return tokenCodec
+}
+
+// CountTokens returns the number of tokens in text.
+// Falls back to a chars/4 estimate if the tokenizer is unavailable.
+func CountTokens(text string) int {
+	if c := codec(); c != nil {
+		if n, err := c.Count(text); err == nil {
+			return n
+		}
+	}
+	return (len(text) + 3) / 4
+}
+
+// TruncateToTokens keeps the longest run of leading lines of text that fits
+// in maxTokens. Returns the kept text and the number of lines dropped.
+func TruncateToTokens(text string, maxTokens int) (string, int) {
+	if CountTokens(text) <= maxTokens {
+		return text, 0
+	}
+
+	lines := strings.Split(text, "\n")
+
+	// Binary search the number of lines that fit
+	lo, hi := 0, len(lines)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if CountTokens(strings.Join(lines[:mid], "\n")) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	return strings.Join(lines[:lo], "\n"), len(lines) - lo
+}
+
+// TruncateForEmbedding cuts text to maxTokens for an embedding model: whole
+// leading lines when at least one fits, else the leading tokens of the first
+// line (minified code). It reports whether text was cut.
+func TruncateForEmbedding(text string, maxTokens int) (string, bool) {
+	if maxTokens <= 0 {
+		return text, false
+	}
+	kept, dropped := TruncateToTokens(text, maxTokens)
+	if dropped == 0 {
+		return text, false
+	}
+	if kept != "" {
+		return kept, true
+	}
+
+	// Binary search the runes of the first line that fit
+	runes := []rune(strings.SplitN(text, "\n", 2)[0])
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if CountTokens(string(runes[:mid])) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo]), true
+}
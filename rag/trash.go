@@ -0,0 +1,316 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TrashFileName = ".code-rag-trash.json"
+
+	// TrashIDField tags soft-deleted points with the trash entry they belong to.
+	// Tagged points are hidden from searches and scrolls.
+	TrashIDField = "trash_id"
+
+	// trashPointIDField keeps the ID a trashed point had before it was moved
+	// to the trash, so Restore can put it back
+	trashPointIDField = "trash_point_id"
+
+	// trashMoveBatchSize is the number of points written per Upsert when
+	// moving points in or out of the trash
+	trashMoveBatchSize = 256
+)
+
+// trashIDNamespace scopes the point IDs of trashed points
+var trashIDNamespace = uuid.MustParse("5d1f0b6e-3c2a-4f8e-9b7d-2e6a1c4f8d90")
+
+// TrashEntry describes one soft-delete operation that can be restored
+type TrashEntry struct {
+	ID          string    `json:"id"`
+	Collection  string    `json:"collection"`
+	Description string    `json:"description"`
+	Points      int64     `json:"points"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Trash soft-deletes points by tagging them instead of removing them,
+// so destructive operations can be undone until the retention expires.
+type Trash struct {
+	mu        sync.Mutex
+	db        VectorDB
+	path      string
+	retention time.Duration
+	entries   []TrashEntry
+}
+
+// NewTrash creates a trash manager persisting its entries in workDir
+func NewTrash(db VectorDB, workDir string, retention time.Duration) *Trash {
+	t := &Trash{
+		db:        db,
+		path:      filepath.Join(workDir, TrashFileName),
+		retention: retention,
+	}
+
+	if data, err := os.ReadFile(t.path); err == nil {
+		json.Unmarshal(data, &t.entries)
+	}
+
+	return t
+}
+
+// SoftDelete moves every live point matching filter to the trash.
+// An empty filter trashes the whole collection. Trashed points get IDs of
+// their own, so re-indexing their files cannot overwrite them; vector
+// databases that cannot read vectors back (no PointReader) tag them in place.
+func (t *Trash) SoftDelete(ctx context.Context, collection string, filter map[string]interface{}, description string) (*TrashEntry, error) {
+	count, err := t.db.Count(ctx, collection, filter)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("nothing to delete")
+	}
+
+	now := time.Now()
+	entry := TrashEntry{
+		ID:          strings.Split(uuid.New().String(), "-")[0],
+		Collection:  collection,
+		Description: description,
+		Points:      count,
+		DeletedAt:   now,
+		ExpiresAt:   now.Add(t.retention),
+	}
+
+	if reader, ok := t.db.(PointReader); ok {
+		err = t.moveToTrash(ctx, reader, collection, filter, entry.ID)
+	} else {
+		err = t.db.SetPayload(ctx, collection, filter, map[string]interface{}{
+			TrashIDField: entry.ID,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to move points to trash: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries = append(t.entries, entry)
+	if err := t.save(); err != nil {
+		return &entry, fmt.Errorf("points moved to trash but manifest not saved: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// Restore brings the points of a trash entry back into search results.
+// Chunks indexed for the same files since the delete are replaced, so each
+// file comes back as it was when it was deleted.
+func (t *Trash) Restore(ctx context.Context, id string) (*TrashEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pos := t.find(id)
+	if pos < 0 {
+		return nil, fmt.Errorf("trash entry not found: %s", id)
+	}
+	entry := t.entries[pos]
+
+	var err error
+	if reader, ok := t.db.(PointReader); ok {
+		err = t.moveFromTrash(ctx, reader, entry)
+	} else {
+		err = t.db.DeletePayload(ctx, entry.Collection, map[string]interface{}{
+			TrashIDField: entry.ID,
+		}, []string{TrashIDField})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore points: %w", err)
+	}
+
+	t.entries = append(t.entries[:pos], t.entries[pos+1:]...)
+	return &entry, t.save()
+}
+
+// List returns the current trash entries, most recent first
+func (t *Trash) List() []TrashEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]TrashEntry, len(t.entries))
+	copy(entries, t.entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries
+}
+
+// PurgeExpired permanently deletes trash entries past their retention.
+// Returns the number of entries purged.
+func (t *Trash) PurgeExpired(ctx context.Context) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	kept := t.entries[:0]
+	purged := 0
+	var firstErr error
+
+	for _, entry := range t.entries {
+		if now.Before(entry.ExpiresAt) {
+			kept = append(kept, entry)
+			continue
+		}
+
+		if err := t.db.Delete(ctx, entry.Collection, map[string]interface{}{
+			TrashIDField: entry.ID,
+		}); err != nil {
+			// Keep the entry so the purge is retried later
+			kept = append(kept, entry)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		purged++
+	}
+
+	t.entries = kept
+	if purged > 0 {
+		if err := t.save(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return purged, firstErr
+}
+
+// moveToTrash stores the points matching filter under trash IDs, tagged
+// with the trash entry id, then deletes them from their old IDs
+func (t *Trash) moveToTrash(ctx context.Context, reader PointReader, collection string, filter map[string]interface{}, id string) error {
+	points, err := reader.ReadPoints(ctx, collection, filter)
+	if err != nil {
+		return err
+	}
+
+	oldIDs := make([]string, len(points))
+	newIDs := make([]string, len(points))
+	for i := range points {
+		oldIDs[i] = points[i].ID
+		newIDs[i] = trashPointID(id, points[i].ID)
+		points[i].Payload[TrashIDField] = id
+		points[i].Payload[trashPointIDField] = points[i].ID
+		points[i].ID = newIDs[i]
+	}
+
+	if err := t.upsertBatches(ctx, collection, points); err != nil {
+		// Drop the copies written so far: the originals are still live
+		t.db.DeleteIDs(ctx, collection, newIDs)
+		return err
+	}
+	return t.db.DeleteIDs(ctx, collection, oldIDs)
+}
+
+// moveFromTrash puts the points of entry back under the IDs they had before
+// it, replacing the live points of their files
+func (t *Trash) moveFromTrash(ctx context.Context, reader PointReader, entry TrashEntry) error {
+	points, err := reader.ReadPoints(ctx, entry.Collection, map[string]interface{}{
+		TrashIDField: entry.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var trashIDs, files []string
+	seen := make(map[string]bool)
+	for i := range points {
+		if oldID, _ := points[i].Payload[trashPointIDField].(string); oldID != "" {
+			trashIDs = append(trashIDs, points[i].ID)
+			points[i].ID = oldID
+		}
+		delete(points[i].Payload, TrashIDField)
+		delete(points[i].Payload, trashPointIDField)
+		if filePath, _ := points[i].Payload["file_path"].(string); filePath != "" && !seen[filePath] {
+			seen[filePath] = true
+			files = append(files, filePath)
+		}
+	}
+
+	if len(files) > 0 {
+		if err := t.db.Delete(ctx, entry.Collection, map[string]interface{}{"file_path": files}); err != nil {
+			return err
+		}
+	}
+	if err := t.upsertBatches(ctx, entry.Collection, points); err != nil {
+		return err
+	}
+	return t.db.DeleteIDs(ctx, entry.Collection, trashIDs)
+}
+
+func (t *Trash) upsertBatches(ctx context.Context, collection string, points []Point) error {
+	for start := 0; start < len(points); start += trashMoveBatchSize {
+		end := min(start+trashMoveBatchSize, len(points))
+		if err := t.db.Upsert(ctx, collection, points[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashPointID derives the ID of a point moved to the trash entry id
+func trashPointID(id, pointID string) string {
+	return uuid.NewSHA1(trashIDNamespace, []byte(id+"\x00"+pointID)).String()
+}
+
+func (t *Trash) find(id string) int {
+	for i, entry := range t.entries {
+		if entry.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// save persists the entries; callers must hold t.mu
+func (t *Trash) save() error {
+	data, err := json.MarshalIndent(t.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, data, 0644)
+}
+
+// FilesUnderPath returns the distinct indexed file paths located under root
+func FilesUnderPath(ctx context.Context, db VectorDB, collection, root string) ([]string, error) {
+	root = filepath.Clean(root)
+	seen := make(map[string]bool)
+	var files []string
+
+	err := db.Scroll(ctx, collection, nil, []string{"file_path"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		if seen[filePath] {
+			return nil
+		}
+		if filePath == root || strings.HasPrefix(filePath, root+string(os.PathSeparator)) {
+			seen[filePath] = true
+			files = append(files, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,75 @@
+package rag_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/Mirrdhyn/code-rag-mcp/ragtest"
+)
+
+func TestTrashSurvivesReindex(t *testing.T) {
+	ctx := context.Background()
+	h := ragtest.New(t)
+	h.WriteFiles(t, map[string]string{
+		"auth.go": "package auth\n\nfunc ValidateToken(token string) bool {\n\treturn token != \"\"\n}\n",
+	})
+	if err := h.Index(ctx); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	path := h.Path("auth.go")
+	collection := h.Config.CollectionName
+	indexed := readContents(t, h.DB, collection, map[string]interface{}{"file_path": path})
+
+	trash := rag.NewTrash(h.DB, t.TempDir(), time.Hour)
+	entry, err := trash.SoftDelete(ctx, collection, map[string]interface{}{"file_path": path}, "delete_path")
+	if err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+
+	// Re-indexing the path must leave the trashed chunks alone
+	h.WriteFiles(t, map[string]string{
+		"auth.go": "package auth\n\nfunc ValidateToken(token string) bool {\n\treturn len(token) > 8\n}\n",
+	})
+	if err := h.Indexer.ReindexFiles(ctx, []string{path}, collection); err != nil {
+		t.Fatalf("reindex: %v", err)
+	}
+	trashed, err := h.DB.Count(ctx, collection, map[string]interface{}{rag.TrashIDField: entry.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trashed != entry.Points {
+		t.Fatalf("trash holds %d chunks after re-index, want %d", trashed, entry.Points)
+	}
+
+	if _, err := trash.Restore(ctx, entry.ID); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	restored := readContents(t, h.DB, collection, map[string]interface{}{"file_path": path})
+	if len(restored) != len(indexed) {
+		t.Fatalf("restored %d chunks, want %d", len(restored), len(indexed))
+	}
+	for i := range indexed {
+		if restored[i] != indexed[i] {
+			t.Errorf("restored chunk %d = %q, want %q", i, restored[i], indexed[i])
+		}
+	}
+	if trashed, _ := h.DB.Count(ctx, collection, map[string]interface{}{rag.TrashIDField: entry.ID}); trashed != 0 {
+		t.Errorf("%d chunks left in the trash after restore", trashed)
+	}
+}
+
+// readContents returns the content of the points matching filter, in ID order
+func readContents(t *testing.T, db *rag.MemoryDB, collection string, filter map[string]interface{}) []string {
+	t.Helper()
+	points, err := db.ReadPoints(context.Background(), collection, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make([]string, len(points))
+	for i, point := range points {
+		contents[i], _ = point.Payload["content"].(string)
+	}
+	return contents
+}
@@ -3,7 +3,7 @@ package rag
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,13 +11,17 @@ import (
 )
 
 type SearchResult struct {
-	ID        string
-	Score     float32
-	FilePath  string
-	Content   string
-	LineStart int
-	LineEnd   int
-	Language  string
+	ID             string
+	Score          float32
+	FilePath       string
+	Content        string
+	LineStart      int
+	LineEnd        int
+	Language       string
+	ChunkerVersion int
+	Dirty          bool        // From the uncommitted changes overlay
+	Parent         ChunkParent // Enclosing definition or window of the chunk
+	Root           string      // Code path the file was indexed from ("" when unknown)
 }
 
 type CollectionInfo struct {
@@ -29,9 +33,15 @@ type CollectionInfo struct {
 
 type VectorDB interface {
 	CreateCollection(ctx context.Context, name string, dimension int) error
+	DeleteCollection(ctx context.Context, name string) error
 	Upsert(ctx context.Context, collection string, points []Point) error
-	Search(ctx context.Context, collection string, vector []float32, limit int, minScore float32) ([]SearchResult, error)
+	Search(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error)
 	Delete(ctx context.Context, collection string, filter map[string]interface{}) error
+	DeleteIDs(ctx context.Context, collection string, ids []string) error
+	Scroll(ctx context.Context, collection string, filter map[string]interface{}, fields []string, fn func(StoredPoint) error) error
+	Count(ctx context.Context, collection string, filter map[string]interface{}) (int64, error)
+	SetPayload(ctx context.Context, collection string, filter map[string]interface{}, payload map[string]interface{}) error
+	DeletePayload(ctx context.Context, collection string, filter map[string]interface{}, keys []string) error
 	GetCollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error)
 	Close() error
 }
@@ -39,11 +49,28 @@ type VectorDB interface {
 type Point struct {
 	ID      string
 	Vector  []float32
+	Sparse  *SparseVector // Lexical vector, stored when the collection has sparse vectors
 	Payload map[string]interface{}
 }
 
+// StoredPoint is a point read back from a collection (without its vector)
+type StoredPoint struct {
+	ID      string
+	Payload map[string]interface{}
+}
+
+// PointReader is implemented by vector databases that can read points back
+// with their vectors, so they can be moved to other IDs without re-embedding
+type PointReader interface {
+	ReadPoints(ctx context.Context, collection string, filter map[string]interface{}) ([]Point, error)
+}
+
+// scrollPageSize is the number of points fetched per Scroll request
+const scrollPageSize = 256
+
 type QdrantDB struct {
-	client *qdrant.Client
+	client  *qdrant.Client
+	options CollectionOptions
 }
 
 func NewQdrantDB(host string, port int, apiKey string) (*QdrantDB, error) {
@@ -73,8 +100,11 @@ func (q *QdrantDB) CreateCollection(ctx context.Context, name string, dimension
 		CollectionName: name,
 		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
 			Size:     uint64(dimension),
-			Distance: qdrant.Distance_Cosine,
+			Distance: q.options.qdrantDistance(),
 		}),
+		SparseVectorsConfig: q.options.sparseVectorsConfig(),
+		OnDiskPayload:       qdrant.PtrOf(q.options.OnDiskPayload),
+		OptimizersConfig:    q.options.optimizersConfig(),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create collection: %w", err)
@@ -83,6 +113,14 @@ func (q *QdrantDB) CreateCollection(ctx context.Context, name string, dimension
 	return nil
 }
 
+// DeleteCollection drops a collection and all its points
+func (q *QdrantDB) DeleteCollection(ctx context.Context, name string) error {
+	if err := q.client.DeleteCollection(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return nil
+}
+
 func (q *QdrantDB) Upsert(ctx context.Context, collection string, points []Point) error {
 	qdrantPoints := make([]*qdrant.PointStruct, len(points))
 
@@ -96,7 +134,7 @@ func (q *QdrantDB) Upsert(ctx context.Context, collection string, points []Point
 
 		qdrantPoints[i] = &qdrant.PointStruct{
 			Id:      qdrant.NewIDUUID(point.ID),
-			Vectors: qdrant.NewVectors(point.Vector...),
+			Vectors: q.options.vectors(point),
 			Payload: qdrant.NewValueMap(payload),
 		}
 	}
@@ -109,18 +147,31 @@ func (q *QdrantDB) Upsert(ctx context.Context, collection string, points []Point
 	return err
 }
 
-func (q *QdrantDB) Search(ctx context.Context, collection string, vector []float32, limit int, minScore float32) ([]SearchResult, error) {
+// Search returns up to limit points nearest to vector matching filter, after offset.
+// Deduplicated pages can hold fewer; SearchStats.Consumed tells where the next starts.
+func (q *QdrantDB) Search(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error) {
 	resp, err := q.client.Query(ctx, &qdrant.QueryPoints{
 		CollectionName: collection,
 		Query:          qdrant.NewQuery(vector...),
-		Limit:          qdrant.PtrOf(uint64(limit)),
-		ScoreThreshold: qdrant.PtrOf(minScore),
+		Filter:         readFilter(filter),
+		Limit:          qdrant.PtrOf(uint64(opts.fetchLimit(limit))),
+		Offset:         qdrant.PtrOf(uint64(offset)),
+		ScoreThreshold: q.options.scoreThreshold(minScore),
+		Params:         qdrantSearchParams(opts.Params),
 		WithPayload:    qdrant.NewWithPayload(true),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	// Hide chunks superseded by a newer chunker while a migration is running,
+	// and deduplicate results by file path and overlapping line ranges
+	return finishSearch(ctx, searchResults(resp, q.options.similarity), limit, opts.Dedup), nil
+}
+
+// searchResults converts scored points to results, their scores converted
+// by score
+func searchResults(resp []*qdrant.ScoredPoint, score func(float32) float32) []SearchResult {
 	results := make([]SearchResult, len(resp))
 	for i, point := range resp {
 		lineStart := 0
@@ -141,6 +192,8 @@ func (q *QdrantDB) Search(ctx context.Context, collection string, vector []float
 		content := ""
 		if c := point.Payload["content"]; c != nil {
 			content = c.GetStringValue()
+		} else if c := point.Payload[compressedContentField]; c != nil {
+			content, _ = decompressContent(c.GetStringValue())
 		}
 
 		language := ""
@@ -148,110 +201,198 @@ func (q *QdrantDB) Search(ctx context.Context, collection string, vector []float
 			language = l.GetStringValue()
 		}
 
+		chunkerVersion := 0
+		if cv := point.Payload["chunker_version"]; cv != nil {
+			chunkerVersion = int(cv.GetIntegerValue())
+		}
+
+		root := ""
+		if r := point.Payload["root"]; r != nil {
+			root = r.GetStringValue()
+		}
+
 		results[i] = SearchResult{
-			ID:        point.Id.GetUuid(),
-			Score:     point.Score,
-			FilePath:  filePath,
-			Content:   content,
-			Language:  language,
-			LineStart: lineStart,
-			LineEnd:   lineEnd,
+			ID:             point.Id.GetUuid(),
+			Score:          score(point.Score),
+			FilePath:       filePath,
+			Content:        content,
+			Language:       language,
+			LineStart:      lineStart,
+			LineEnd:        lineEnd,
+			ChunkerVersion: chunkerVersion,
+			Parent:         parentFromQdrant(point.Payload),
+			Root:           root,
 		}
 	}
 
-	// Deduplicate results by file path and overlapping line ranges
-	deduped := deduplicateResults(results)
-
-	return deduped, nil
+	return results
 }
 
-// deduplicateResults removes duplicate chunks that represent the same code
-func deduplicateResults(results []SearchResult) []SearchResult {
-	if len(results) == 0 {
-		return results
+// HybridSearch fuses dense and sparse matches of filter in Qdrant (RRF). Needs
+// sparse vectors; unlike FuseResults, fused rankings page consistently.
+func (q *QdrantDB) HybridSearch(ctx context.Context, collection string, filter map[string]interface{}, vector []float32, sparse SparseVector, limit, offset int, minScore float32, opts SearchOptions) ([]SearchResult, error) {
+	if !q.options.SparseVectors {
+		return nil, fmt.Errorf("hybrid search needs a collection created with qdrant.sparse_vectors")
 	}
 
-	seen := make(map[string]bool)
-	unique := []SearchResult{}
-
-	for _, result := range results {
-		// Create a key based on file + line range
-		// Consider chunks overlapping if they share >50% of lines
-		key := fmt.Sprintf("%s:%d-%d", result.FilePath, result.LineStart, result.LineEnd)
-
-		// Check if we've seen an overlapping chunk from this file
-		isDuplicate := false
-		for existingKey := range seen {
-			if isOverlapping(existingKey, key) {
-				isDuplicate = true
-				break
-			}
-		}
+	// Each ranking must cover the requested page to fuse it
+	candidates := qdrant.PtrOf(uint64(offset + opts.fetchLimit(limit)))
+	resp, err := q.client.Query(ctx, &qdrant.QueryPoints{
+		CollectionName: collection,
+		Prefetch: []*qdrant.PrefetchQuery{
+			{
+				Query:          qdrant.NewQueryDense(vector),
+				Filter:         readFilter(filter),
+				Limit:          candidates,
+				ScoreThreshold: q.options.scoreThreshold(minScore),
+				Params:         qdrantSearchParams(opts.Params),
+			},
+			{
+				Query:  qdrant.NewQuerySparse(sparse.Indices, sparse.Values),
+				Using:  qdrant.PtrOf(SparseVectorName),
+				Filter: readFilter(filter),
+				Limit:  candidates,
+			},
+		},
+		Query:       qdrant.NewQueryRRF(&qdrant.Rrf{K: qdrant.PtrOf(uint32(rrfK))}),
+		Limit:       qdrant.PtrOf(uint64(opts.fetchLimit(limit))),
+		Offset:      qdrant.PtrOf(uint64(offset)),
+		WithPayload: qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if !isDuplicate {
-			seen[key] = true
-			unique = append(unique, result)
+	// A point ranked first by both rankings scores 2/(rrfK+1)
+	best := float32(2) / float32(rrfK+1)
+	normalize := func(score float32) float32 {
+		if score >= best {
+			return 1
 		}
+		return score / best
 	}
-
-	return unique
+	return finishSearch(ctx, searchResults(resp, normalize), limit, opts.Dedup), nil
 }
 
-// isOverlapping checks if two file:line-range keys represent overlapping chunks
-func isOverlapping(key1, key2 string) bool {
-	// Parse keys: "file:start-end"
-	parts1 := parseRangeKey(key1)
-	parts2 := parseRangeKey(key2)
-
-	if parts1 == nil || parts2 == nil {
-		return false
+// qdrantSearchParams converts params, or returns nil to use the collection's
+// defaults
+func qdrantSearchParams(params SearchParams) *qdrant.SearchParams {
+	if params.HnswEf <= 0 && !params.Exact {
+		return nil
 	}
-
-	// Different files = not overlapping
-	if parts1[0] != parts2[0] {
-		return false
+	qp := &qdrant.SearchParams{}
+	if params.HnswEf > 0 {
+		qp.HnswEf = qdrant.PtrOf(uint64(params.HnswEf))
 	}
+	if params.Exact {
+		qp.Exact = qdrant.PtrOf(true)
+	}
+	return qp
+}
 
-	// Same file, check if ranges overlap significantly
-	start1, end1 := parts1[1], parts1[2]
-	start2, end2 := parts2[1], parts2[2]
+// maxMergedLines caps the line range of a result merged from adjacent chunks
+const maxMergedLines = 200
 
-	// Calculate overlap
-	overlapStart := max(start1, start2)
-	overlapEnd := min(end1, end2)
+// deduplicateResults merges results of the same file whose line ranges overlap
+// or touch into one excerpt spanning them all, scored as its best chunk, so
+// chunks extending a match add context instead of being dropped. Chunks that
+// cannot be joined (content not matching its lines, or merged ranges longer
+// than maxMergedLines) are dropped when they overlap a better match by more
+// than threshold of either's lines. Results keep the order of their best chunk.
+func deduplicateResults(results []SearchResult, threshold float64) []SearchResult {
+	if len(results) == 0 {
+		return results
+	}
 
-	if overlapStart >= overlapEnd {
-		return false // No overlap
+	// A group is a merged excerpt and the position of its best chunk
+	type group struct {
+		result SearchResult
+		first  int
+	}
+	var groups []group
+
+	byFile := make(map[string][]int)
+	var files []string
+	for i, result := range results {
+		if result.LineStart < 1 {
+			groups = append(groups, group{result: result, first: i})
+			continue
+		}
+		if byFile[result.FilePath] == nil {
+			files = append(files, result.FilePath)
+		}
+		byFile[result.FilePath] = append(byFile[result.FilePath], i)
 	}
 
-	overlap := overlapEnd - overlapStart
-	range1 := end1 - start1
-	range2 := end2 - start2
+	for _, file := range files {
+		indices := byFile[file]
+		sort.SliceStable(indices, func(a, b int) bool {
+			return results[indices[a]].LineStart < results[indices[b]].LineStart
+		})
+
+		var current *group
+		for _, i := range indices {
+			result := results[i]
+			if current != nil && result.LineStart <= current.result.LineEnd+1 {
+				if joined, ok := joinResults(current.result, result); ok {
+					current.result = joined
+					current.first = min(current.first, i)
+					continue
+				}
+				if overlapsMostly(current.result, result, threshold) {
+					if i < current.first {
+						*current = group{result: result, first: i}
+					}
+					continue
+				}
+			}
+			groups = append(groups, group{result: result, first: i})
+			current = &groups[len(groups)-1]
+		}
+	}
 
-	// Consider duplicate if overlap > 50% of either range
-	return float64(overlap) > float64(range1)*0.5 || float64(overlap) > float64(range2)*0.5
+	sort.SliceStable(groups, func(a, b int) bool { return groups[a].first < groups[b].first })
+	unique := make([]SearchResult, len(groups))
+	for i, g := range groups {
+		unique[i] = g.result
+	}
+	return unique
 }
 
-func parseRangeKey(key string) []int {
-	// Parse "file:start-end"
-	parts := strings.Split(key, ":")
-	if len(parts) != 2 {
-		return nil
+// joinResults merges b, starting within or right after a, into one result
+// with the lines of both, identified and scored as the better of the two
+func joinResults(a, b SearchResult) (SearchResult, bool) {
+	end := max(a.LineEnd, b.LineEnd)
+	if end-a.LineStart+1 > maxMergedLines {
+		return a, false
 	}
 
-	rangeParts := strings.Split(parts[1], "-")
-	if len(rangeParts) != 2 {
-		return nil
+	aLines := strings.Split(a.Content, "\n")
+	bLines := strings.Split(b.Content, "\n")
+	if len(aLines) != a.LineEnd-a.LineStart+1 || len(bLines) != b.LineEnd-b.LineStart+1 {
+		return a, false
 	}
 
-	start, err1 := strconv.Atoi(rangeParts[0])
-	end, err2 := strconv.Atoi(rangeParts[1])
-
-	if err1 != nil || err2 != nil {
-		return nil
+	joined := a
+	if b.Score > a.Score {
+		joined = b
+	}
+	joined.LineStart, joined.LineEnd = a.LineStart, end
+	joined.Content = a.Content
+	if b.LineEnd > a.LineEnd {
+		joined.Content += "\n" + strings.Join(bLines[a.LineEnd-b.LineStart+1:], "\n")
 	}
+	return joined, true
+}
 
-	return []int{0, start, end} // 0 is placeholder for file (we compare separately)
+// overlapsMostly reports whether the line ranges of a and b share more than
+// threshold of either
+func overlapsMostly(a, b SearchResult, threshold float64) bool {
+	overlap := min(a.LineEnd, b.LineEnd) - max(a.LineStart, b.LineStart)
+	if overlap <= 0 {
+		return false
+	}
+	return float64(overlap) > float64(a.LineEnd-a.LineStart)*threshold || float64(overlap) > float64(b.LineEnd-b.LineStart)*threshold
 }
 
 func max(a, b int) int {
@@ -268,36 +409,20 @@ func min(a, b int) int {
 	return b
 }
 
+// Delete removes the points matching filter. Trashed points are left alone
+// unless filter targets the trash, so only a purge can drop them.
 func (q *QdrantDB) Delete(ctx context.Context, collection string, filter map[string]interface{}) error {
-	// Build Qdrant filter from map
-	// For now, we only support filtering by file_path
-	filePath, ok := filter["file_path"].(string)
-	if !ok {
-		return fmt.Errorf("file_path filter required")
+	// Never issue an unfiltered delete: it would wipe the whole collection
+	if len(filter) == 0 {
+		return fmt.Errorf("delete filter required")
 	}
 
-	// Delete points matching the file_path
 	_, err := q.client.Delete(ctx, &qdrant.DeletePoints{
 		CollectionName: collection,
 		Wait:           qdrant.PtrOf(true),
 		Points: &qdrant.PointsSelector{
 			PointsSelectorOneOf: &qdrant.PointsSelector_Filter{
-				Filter: &qdrant.Filter{
-					Must: []*qdrant.Condition{
-						{
-							ConditionOneOf: &qdrant.Condition_Field{
-								Field: &qdrant.FieldCondition{
-									Key: "file_path",
-									Match: &qdrant.Match{
-										MatchValue: &qdrant.Match_Keyword{
-											Keyword: filePath,
-										},
-									},
-								},
-							},
-						},
-					},
-				},
+				Filter: readFilter(filter),
 			},
 		},
 	})
@@ -305,6 +430,251 @@ func (q *QdrantDB) Delete(ctx context.Context, collection string, filter map[str
 	return err
 }
 
+// DeleteIDs deletes the points with the given IDs
+func (q *QdrantDB) DeleteIDs(ctx context.Context, collection string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	pointIDs := make([]*qdrant.PointId, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = qdrant.NewIDUUID(id)
+	}
+
+	_, err := q.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: collection,
+		Wait:           qdrant.PtrOf(true),
+		Points:         qdrant.NewPointsSelector(pointIDs...),
+	})
+
+	return err
+}
+
+// Scroll iterates over all points matching filter and calls fn for each one.
+// fields restricts the returned payload keys (nil returns the full payload).
+// Iteration stops at the first error returned by fn.
+func (q *QdrantDB) Scroll(ctx context.Context, collection string, filter map[string]interface{}, fields []string, fn func(StoredPoint) error) error {
+	withPayload := qdrant.NewWithPayload(true)
+	if len(fields) > 0 {
+		withPayload = qdrant.NewWithPayloadInclude(fields...)
+	}
+
+	var offset *qdrant.PointId
+	for {
+		points, next, err := q.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: collection,
+			Filter:         readFilter(filter),
+			Offset:         offset,
+			Limit:          qdrant.PtrOf(uint32(scrollPageSize)),
+			WithPayload:    withPayload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scroll collection: %w", err)
+		}
+
+		for _, point := range points {
+			if err := fn(StoredPoint{
+				ID:      point.Id.GetUuid(),
+				Payload: payloadFromQdrant(point.Payload),
+			}); err != nil {
+				return err
+			}
+		}
+
+		if next == nil || len(points) == 0 {
+			return nil
+		}
+		offset = next
+	}
+}
+
+// ReadPoints returns the live points matching filter with their vectors
+func (q *QdrantDB) ReadPoints(ctx context.Context, collection string, filter map[string]interface{}) ([]Point, error) {
+	var points []Point
+	var offset *qdrant.PointId
+	for {
+		page, next, err := q.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: collection,
+			Filter:         readFilter(filter),
+			Offset:         offset,
+			Limit:          qdrant.PtrOf(uint32(scrollPageSize)),
+			WithPayload:    qdrant.NewWithPayload(true),
+			WithVectors:    qdrant.NewWithVectors(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read points: %w", err)
+		}
+
+		for _, p := range page {
+			point := Point{ID: p.Id.GetUuid(), Payload: payloadFromQdrant(p.Payload)}
+			delete(point.Payload, "_indexed_at")
+			vectors := p.GetVectors()
+			dense := vectors.GetVector()
+			if named := vectors.GetVectors().GetVectors(); named != nil {
+				dense = named[""]
+				if sparse := named[SparseVectorName].GetSparse(); sparse != nil {
+					point.Sparse = &SparseVector{Indices: sparse.GetIndices(), Values: sparse.GetValues()}
+				}
+			}
+			if d := dense.GetDense(); d != nil {
+				point.Vector = d.GetData()
+			} else {
+				point.Vector = dense.GetData() // Servers before the dense oneof
+			}
+			points = append(points, point)
+		}
+
+		if next == nil || len(page) == 0 {
+			return points, nil
+		}
+		offset = next
+	}
+}
+
+// Count returns the number of live points matching filter
+func (q *QdrantDB) Count(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	count, err := q.client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: collection,
+		Filter:         readFilter(filter),
+		Exact:          qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	return int64(count), nil
+}
+
+// SetPayload merges payload into every live point matching filter
+func (q *QdrantDB) SetPayload(ctx context.Context, collection string, filter map[string]interface{}, payload map[string]interface{}) error {
+	values, err := qdrant.TryValueMap(payload)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	_, err = q.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+		CollectionName: collection,
+		Wait:           qdrant.PtrOf(true),
+		Payload:        values,
+		PointsSelector: qdrant.NewPointsSelectorFilter(readFilter(filter)),
+	})
+	return err
+}
+
+// DeletePayload removes payload keys from every point matching filter
+func (q *QdrantDB) DeletePayload(ctx context.Context, collection string, filter map[string]interface{}, keys []string) error {
+	if len(filter) == 0 {
+		return fmt.Errorf("delete payload filter required")
+	}
+
+	_, err := q.client.DeletePayload(ctx, &qdrant.DeletePayloadPoints{
+		CollectionName: collection,
+		Wait:           qdrant.PtrOf(true),
+		Keys:           keys,
+		PointsSelector: qdrant.NewPointsSelectorFilter(buildFilter(filter)),
+	})
+	return err
+}
+
+// readFilter builds a filter for reads and deletes. Points in the trash
+// (tagged with TrashIDField) are hidden unless filter explicitly targets them.
+func readFilter(filter map[string]interface{}) *qdrant.Filter {
+	f := buildFilter(filter)
+	if _, ok := filter[TrashIDField]; ok {
+		return f
+	}
+
+	if f == nil {
+		f = &qdrant.Filter{}
+	}
+	f.Must = append(f.Must, qdrant.NewIsEmpty(TrashIDField))
+	return f
+}
+
+// buildFilter converts a simple field -> value map into a Qdrant filter.
+// Every entry must match: strings and booleans match exactly, ints match
+// integer fields, and string slices match any of the given keywords.
+func buildFilter(filter map[string]interface{}) *qdrant.Filter {
+	if len(filter) == 0 {
+		return nil
+	}
+
+	// Sort keys so the generated filter is deterministic
+	keys := make([]string, 0, len(filter))
+	for k := range filter {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]*qdrant.Condition, 0, len(filter))
+	for _, key := range keys {
+		switch v := filter[key].(type) {
+		case string:
+			conditions = append(conditions, qdrant.NewMatchKeyword(key, v))
+		case []string:
+			conditions = append(conditions, qdrant.NewMatchKeywords(key, v...))
+		case bool:
+			conditions = append(conditions, qdrant.NewMatchBool(key, v))
+		case int:
+			conditions = append(conditions, qdrant.NewMatchInt(key, int64(v)))
+		case int64:
+			conditions = append(conditions, qdrant.NewMatchInt(key, v))
+		}
+	}
+
+	return &qdrant.Filter{Must: conditions}
+}
+
+// payloadFromQdrant converts a Qdrant payload into plain Go values
+func payloadFromQdrant(payload map[string]*qdrant.Value) map[string]interface{} {
+	result := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		result[k] = valueFromQdrant(v)
+	}
+	return result
+}
+
+func valueFromQdrant(v *qdrant.Value) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	switch kind := v.Kind.(type) {
+	case *qdrant.Value_StringValue:
+		return kind.StringValue
+	case *qdrant.Value_IntegerValue:
+		return kind.IntegerValue
+	case *qdrant.Value_DoubleValue:
+		return kind.DoubleValue
+	case *qdrant.Value_BoolValue:
+		return kind.BoolValue
+	case *qdrant.Value_StructValue:
+		return payloadFromQdrant(kind.StructValue.GetFields())
+	case *qdrant.Value_ListValue:
+		values := kind.ListValue.GetValues()
+		list := make([]interface{}, len(values))
+		for i, item := range values {
+			list[i] = valueFromQdrant(item)
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// payloadInt reads a numeric payload value regardless of its stored type
+func payloadInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
+
 func (q *QdrantDB) GetCollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error) {
 	resp, err := q.client.GetCollectionInfo(ctx, collection)
 	if err != nil {
@@ -0,0 +1,128 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// VerifyReport lists how the index drifted from the files on disk
+type VerifyReport struct {
+	FilesOnDisk  int         // Files under the roots that indexing would pick
+	FilesIndexed int         // Indexed files under the roots
+	NotIndexed   []string    // On disk but without chunks
+	Stale        []string    // Indexed with a content hash the file no longer has
+	Deleted      []string    // Indexed but gone from disk, or no longer picked by the filters
+	Repaired     bool        // Drift was fixed: missing and stale files indexed, deleted ones trashed
+	Trashed      *TrashEntry // Trash entry holding the chunks of the deleted files
+	Failed       map[string]string
+}
+
+// Drifted reports whether the index and the disk disagree
+func (r *VerifyReport) Drifted() bool {
+	return len(r.NotIndexed) > 0 || len(r.Stale) > 0 || len(r.Deleted) > 0
+}
+
+// VerifyIndex compares the files indexing would pick under roots, with the
+// same extensions, filters and size limit, against the files indexed in
+// collection and their content hashes. With repair, files not indexed or
+// stale are (re-)indexed and the chunks of deleted files moved to trash, so
+// files dropped by a filter change can be restored. Files whose
+// chunks were all dropped as duplicates of another file, and empty files,
+// show as not indexed.
+func (idx *Indexer) VerifyIndex(ctx context.Context, roots, extensions []string, collection string, trash *Trash, repair bool) (*VerifyReport, error) {
+	onDisk := make(map[string]bool)
+	absRoots := make([]string, len(roots))
+	for i, root := range roots {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		absRoots[i] = root
+		files, err := idx.collectFiles(root, extensions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+		}
+		for _, file := range files {
+			onDisk[file] = true
+		}
+	}
+
+	indexedHashes := make(map[string]string)
+	err := idx.vectorDB.Scroll(ctx, collection, nil, []string{"file_path", "file_hash"}, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		if !underAny(filePath, absRoots) {
+			return nil
+		}
+		hash, _ := point.Payload["file_hash"].(string)
+		if indexedHashes[filePath] == "" {
+			indexedHashes[filePath] = hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan index: %w", err)
+	}
+
+	report := &VerifyReport{FilesOnDisk: len(onDisk), FilesIndexed: len(indexedHashes), Failed: make(map[string]string)}
+	for filePath := range onDisk {
+		if _, indexed := indexedHashes[filePath]; !indexed {
+			report.NotIndexed = append(report.NotIndexed, filePath)
+		}
+	}
+	for filePath, indexedHash := range indexedHashes {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if !onDisk[filePath] {
+			report.Deleted = append(report.Deleted, filePath)
+			continue
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			report.Failed[filePath] = err.Error()
+			continue
+		}
+		// Chunks indexed before hashes were stored are only checked for existence
+		if indexedHash != "" && HashContent(content) != indexedHash {
+			report.Stale = append(report.Stale, filePath)
+		}
+	}
+
+	sort.Strings(report.NotIndexed)
+	sort.Strings(report.Stale)
+	sort.Strings(report.Deleted)
+
+	if !repair || !report.Drifted() {
+		return report, nil
+	}
+
+	if len(report.Deleted) > 0 {
+		entry, err := trash.SoftDelete(ctx, collection, map[string]interface{}{"file_path": report.Deleted},
+			fmt.Sprintf("verify_index repair (%d files)", len(report.Deleted)))
+		if err != nil {
+			return report, fmt.Errorf("failed to move chunks of deleted files to trash: %w", err)
+		}
+		report.Trashed = entry
+		idx.dropFileSummaries(ctx, collection, report.Deleted)
+	}
+
+	if outdated := append(append([]string{}, report.NotIndexed...), report.Stale...); len(outdated) > 0 {
+		if err := idx.ReindexFiles(ctx, outdated, collection); err != nil {
+			return report, fmt.Errorf("failed to index drifted files: %w", err)
+		}
+	}
+	report.Repaired = true
+
+	idx.logger.Info("Index drift repaired",
+		zap.Int("not_indexed", len(report.NotIndexed)),
+		zap.Int("stale", len(report.Stale)),
+		zap.Int("deleted", len(report.Deleted)),
+		zap.Int("failed", len(report.Failed)),
+	)
+	return report, nil
+}
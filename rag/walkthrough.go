@@ -0,0 +1,190 @@
+package rag
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Walkthrough stages, in reading order
+const (
+	StageEntryPoint = "entry point"
+	StageCore       = "core logic"
+	StageHelper     = "helper"
+)
+
+// FileOutline summarizes an indexed file from its chunk payloads
+type FileOutline struct {
+	FilePath    string
+	Language    string
+	Symbols     []Symbol
+	Identifiers map[string]bool
+}
+
+// WalkthroughStep is one file of a reading list
+type WalkthroughStep struct {
+	FilePath string
+	Language string
+	Stage    string
+	Symbols  []Symbol
+	Uses     []string // Files of the walkthrough this file depends on
+	UsedBy   []string // Files of the walkthrough depending on this file
+}
+
+// entryPointNames are file names that conventionally start a program or package
+var entryPointNames = map[string]bool{
+	"main.go": true, "main.py": true, "__main__.py": true, "app.py": true, "cli.py": true,
+	"index.js": true, "index.ts": true, "main.js": true, "main.ts": true, "server.js": true,
+	"main.rs": true, "lib.rs": true, "main.tf": true, "main.c": true, "main.cpp": true,
+}
+
+// FileOutlines reads the symbols and identifiers of the given indexed files
+func FileOutlines(ctx context.Context, db VectorDB, collection string, files []string) (map[string]*FileOutline, error) {
+	outlines := make(map[string]*FileOutline, len(files))
+	if len(files) == 0 {
+		return outlines, nil
+	}
+
+	filter := map[string]interface{}{"file_path": files}
+	fields := []string{"file_path", "language", "symbol_defs", "identifiers"}
+
+	err := db.Scroll(ctx, collection, filter, fields, func(point StoredPoint) error {
+		filePath, _ := point.Payload["file_path"].(string)
+		outline, ok := outlines[filePath]
+		if !ok {
+			outline = &FileOutline{FilePath: filePath, Identifiers: make(map[string]bool)}
+			outline.Language, _ = point.Payload["language"].(string)
+			outlines[filePath] = outline
+		}
+
+		for _, sym := range symbolsFromPayload(point.Payload) {
+			if !containsSymbol(outline.Symbols, sym) {
+				outline.Symbols = append(outline.Symbols, sym)
+			}
+		}
+		if idents, ok := point.Payload["identifiers"].([]interface{}); ok {
+			for _, ident := range idents {
+				if name, ok := ident.(string); ok {
+					outline.Identifiers[name] = true
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, outline := range outlines {
+		sort.Slice(outline.Symbols, func(i, j int) bool {
+			return outline.Symbols[i].Line < outline.Symbols[j].Line
+		})
+	}
+
+	return outlines, nil
+}
+
+func containsSymbol(symbols []Symbol, sym Symbol) bool {
+	for _, s := range symbols {
+		if s.Name == sym.Name && s.Line == sym.Line {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildWalkthrough orders files for reading: entry points first, then core
+// logic, then helpers. A file depends on another when it uses an identifier
+// the other defines. Entry points are conventional main files or files nothing
+// else in the set uses; helpers are used by others but use nothing themselves.
+func BuildWalkthrough(outlines map[string]*FileOutline) []WalkthroughStep {
+	// Map each defined symbol to the files defining it
+	definedIn := make(map[string][]string)
+	for path, outline := range outlines {
+		for _, sym := range outline.Symbols {
+			definedIn[sym.Name] = append(definedIn[sym.Name], path)
+		}
+	}
+
+	uses := make(map[string]map[string]bool)
+	usedBy := make(map[string]map[string]bool)
+	for path, outline := range outlines {
+		for ident := range outline.Identifiers {
+			for _, other := range definedIn[ident] {
+				if other == path {
+					continue
+				}
+				if uses[path] == nil {
+					uses[path] = make(map[string]bool)
+				}
+				if usedBy[other] == nil {
+					usedBy[other] = make(map[string]bool)
+				}
+				uses[path][other] = true
+				usedBy[other][path] = true
+			}
+		}
+	}
+
+	steps := make([]WalkthroughStep, 0, len(outlines))
+	for path, outline := range outlines {
+		step := WalkthroughStep{
+			FilePath: path,
+			Language: outline.Language,
+			Symbols:  outline.Symbols,
+			Uses:     sortedKeys(uses[path]),
+			UsedBy:   sortedKeys(usedBy[path]),
+		}
+
+		switch {
+		case isEntryPoint(outline) || (len(step.UsedBy) == 0 && len(step.Uses) > 0):
+			step.Stage = StageEntryPoint
+		case len(step.Uses) == 0 && len(step.UsedBy) > 0:
+			step.Stage = StageHelper
+		default:
+			step.Stage = StageCore
+		}
+		steps = append(steps, step)
+	}
+
+	stageOrder := map[string]int{StageEntryPoint: 0, StageCore: 1, StageHelper: 2}
+	sort.Slice(steps, func(i, j int) bool {
+		a, b := steps[i], steps[j]
+		if a.Stage != b.Stage {
+			return stageOrder[a.Stage] < stageOrder[b.Stage]
+		}
+		// Within a stage, read the files that pull in the most code first
+		if len(a.Uses) != len(b.Uses) {
+			return len(a.Uses) > len(b.Uses)
+		}
+		if len(a.UsedBy) != len(b.UsedBy) {
+			return len(a.UsedBy) > len(b.UsedBy)
+		}
+		return a.FilePath < b.FilePath
+	})
+
+	return steps
+}
+
+// isEntryPoint reports whether a file looks like a program or package entry point
+func isEntryPoint(outline *FileOutline) bool {
+	if entryPointNames[strings.ToLower(filepath.Base(outline.FilePath))] {
+		return true
+	}
+	for _, sym := range outline.Symbols {
+		if sym.Name == "main" && (sym.Kind == "function" || sym.Kind == "method") {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
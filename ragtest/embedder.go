@@ -0,0 +1,113 @@
+// Package ragtest provides an in-process test harness for code-rag-mcp: a
+// deterministic embedder, an in-memory vector database and an MCP server
+// wired to both, so tools can be exercised without Qdrant or an embedding
+// service.
+package ragtest
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// DefaultDimension is the vector size of embedders created with NewEmbedder(0)
+const DefaultDimension = 256
+
+var tokenPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*|[0-9]+`)
+
+// Embedder is a deterministic Embedder: each word and camelCase/snake_case
+// part is hashed into a dimension (the "hashing trick"), so texts sharing
+// vocabulary get similar vectors and the same text always gets the same one.
+type Embedder struct {
+	dimension int
+}
+
+// NewEmbedder creates a hash embedder producing vectors of the given size
+func NewEmbedder(dimension int) *Embedder {
+	if dimension <= 0 {
+		dimension = DefaultDimension
+	}
+	return &Embedder{dimension: dimension}
+}
+
+func (e *Embedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vector := make([]float32, e.dimension)
+	for _, token := range tokens(text) {
+		h := fnv.New32a()
+		h.Write([]byte(token))
+		sum := h.Sum32()
+
+		// The top bit picks the sign so unrelated tokens cancel out on average
+		sign := float32(1)
+		if sum&(1<<31) != 0 {
+			sign = -1
+		}
+		vector[int(sum%uint32(e.dimension))] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+	return vector, nil
+}
+
+func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+func (e *Embedder) Dimension() int {
+	return e.dimension
+}
+
+// tokens lowercases words and splits identifiers into their parts:
+// "parseHTTPRequest" -> parsehttprequest, parse, http, request
+func tokens(text string) []string {
+	var out []string
+	for _, word := range tokenPattern.FindAllString(text, -1) {
+		out = append(out, strings.ToLower(word))
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			out = append(out, parts...)
+		}
+	}
+	return out
+}
+
+func splitIdentifier(word string) []string {
+	var parts []string
+	start := 0
+	for i := 1; i < len(word); i++ {
+		prev, cur := word[i-1], word[i]
+		lowerToUpper := isLower(prev) && isUpper(cur)
+		acronymEnd := isUpper(prev) && isUpper(cur) && i+1 < len(word) && isLower(word[i+1])
+		if lowerToUpper || acronymEnd {
+			parts = append(parts, strings.ToLower(word[start:i]))
+			start = i
+		}
+	}
+	return append(parts, strings.ToLower(word[start:]))
+}
+
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+func isUpper(c byte) bool { return c >= 'A' && c <= 'Z' }
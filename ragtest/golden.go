@@ -0,0 +1,210 @@
+package ragtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv, when set to a non-empty value, makes golden assertions
+// rewrite their files instead of comparing, as the -update flag does:
+//
+//	RAGTEST_UPDATE_GOLDEN=1 go test ./...
+//	go test ./ragtest -update
+const UpdateGoldenEnv = "RAGTEST_UPDATE_GOLDEN"
+
+// update is the -update test flag
+var update = flag.Bool("update", false, "rewrite golden files instead of comparing with them")
+
+// GoldenDir holds golden files, relative to the package directory of the test
+const GoldenDir = "testdata/golden"
+
+var (
+	// timestampPattern matches the timestamps tools print
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?`)
+
+	// durationPattern matches Go-formatted durations such as 12ms or 1.5s
+	durationPattern = regexp.MustCompile(`\b\d+(\.\d+)?(ns|µs|us|ms|s|m\d+(\.\d+)?s|h\d+m\d+(\.\d+)?s)\b`)
+)
+
+// SampleFiles is a small multi-language repository for snapshot tests: it
+// gives every tool something representative to print
+var SampleFiles = map[string]string{
+	"auth/middleware.go": `package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrUnauthorized is returned when a request has no valid token
+var ErrUnauthorized = errors.New("unauthorized")
+
+// AuthMiddleware rejects requests without a valid bearer token
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ValidateToken(r.Header.Get("Authorization")); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ValidateToken checks a bearer token
+func ValidateToken(header string) error {
+	if header == "" {
+		return ErrUnauthorized
+	}
+	return nil
+}
+`,
+	"db/pool.py": `import sqlite3
+
+
+class ConnectionPool:
+    """Keeps a fixed number of database connections open."""
+
+    def __init__(self, path, size=4):
+        self.connections = [sqlite3.connect(path) for _ in range(size)]
+
+    def acquire(self):
+        return self.connections.pop()
+
+    def release(self, conn):
+        self.connections.append(conn)
+`,
+	"web/retry.ts": `export async function fetchWithRetry(url: string, attempts = 3): Promise<Response> {
+  let lastError: unknown;
+  for (let i = 0; i < attempts; i++) {
+    try {
+      return await fetch(url);
+    } catch (err) {
+      lastError = err;
+    }
+  }
+  throw lastError;
+}
+`,
+	"infra/main.tf": `resource "aws_s3_bucket" "artifacts" {
+  bucket = "build-artifacts"
+}
+`,
+}
+
+// Scrub replaces what changes between runs in tool output (the temporary
+// code directory, timestamps, durations) with stable placeholders
+func (h *Harness) Scrub(output string) string {
+	dirs := []string{h.Dir}
+	if resolved, err := filepath.EvalSymlinks(h.Dir); err == nil && resolved != h.Dir {
+		dirs = append(dirs, resolved)
+	}
+	for _, dir := range dirs {
+		output = strings.ReplaceAll(output, dir, "<root>")
+	}
+	output = timestampPattern.ReplaceAllString(output, "<time>")
+	return durationPattern.ReplaceAllString(output, "<duration>")
+}
+
+// AssertToolGolden calls a tool and compares its scrubbed text output with
+// the golden file name. Tool errors are snapshotted too, prefixed "ERROR: ".
+func (h *Harness) AssertToolGolden(ctx context.Context, tb testing.TB, name, tool string, arguments map[string]interface{}) {
+	tb.Helper()
+
+	result, err := h.CallTool(ctx, tool, arguments)
+	if err != nil {
+		tb.Fatalf("ragtest: %v", err)
+	}
+	output := h.Scrub(Text(result))
+	if result.IsError {
+		output = "ERROR: " + output
+	}
+	AssertGolden(tb, name, output)
+}
+
+// AssertHTTPGolden sends a request to the HTTP API and compares the status
+// line and the scrubbed, indented JSON response with the golden file name
+func (h *Harness) AssertHTTPGolden(ctx context.Context, tb testing.TB, name, method, path string, body interface{}) {
+	tb.Helper()
+
+	status, response, err := h.CallHTTP(ctx, method, path, body)
+	if err != nil {
+		tb.Fatalf("ragtest: %v", err)
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, response, "", "  ") != nil {
+		indented.Reset()
+		indented.Write(response)
+	}
+	AssertGolden(tb, name, fmt.Sprintf("%d %s\n%s", status, method+" "+path, h.Scrub(indented.String())))
+}
+
+// AssertGoldenJSON marshals v as indented JSON and compares it with the golden file name
+func AssertGoldenJSON(tb testing.TB, name string, v interface{}) {
+	tb.Helper()
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		tb.Fatalf("ragtest: marshal %s: %v", name, err)
+	}
+	AssertGolden(tb, name, string(data)+"\n")
+}
+
+// AssertGolden compares got with GoldenDir/<name>.golden, reporting the first
+// differing line. With -update or UpdateGoldenEnv set, or when the file does not exist
+// yet, the file is written instead (a missing file still fails the test, so
+// new snapshots are reviewed before they are committed).
+func AssertGolden(tb testing.TB, name, got string) {
+	tb.Helper()
+
+	path := filepath.Join(GoldenDir, filepath.FromSlash(name)+".golden")
+	want, err := os.ReadFile(path)
+	missing := os.IsNotExist(err)
+	if err != nil && !missing {
+		tb.Fatalf("ragtest: read golden file: %v", err)
+	}
+
+	updating := *update || os.Getenv(UpdateGoldenEnv) != ""
+	if updating || missing {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("ragtest: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			tb.Fatalf("ragtest: write golden file: %v", err)
+		}
+		if missing && !updating {
+			tb.Errorf("ragtest: created golden file %s, review and commit it", path)
+		}
+		return
+	}
+
+	if string(want) != got {
+		tb.Errorf("ragtest: output differs from %s (run with -update or %s=1 to update)\n%s", path, UpdateGoldenEnv, firstDifference(string(want), got))
+	}
+}
+
+// firstDifference describes the first line where want and got differ
+func firstDifference(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i >= len(wantLines) || i >= len(gotLines) || w != g {
+			return fmt.Sprintf("line %d:\n  want: %q\n  got:  %q\n(%d lines wanted, %d got)", i+1, w, g, len(wantLines), len(gotLines))
+		}
+	}
+	return ""
+}
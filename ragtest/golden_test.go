@@ -0,0 +1,34 @@
+package ragtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Mirrdhyn/code-rag-mcp/ragtest"
+)
+
+func TestToolOutputGolden(t *testing.T) {
+	ctx := context.Background()
+	h := ragtest.New(t)
+	h.WriteFiles(t, ragtest.SampleFiles)
+	if err := h.Index(ctx); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments map[string]interface{}
+	}{
+		{"search", "semantic_code_search", map[string]interface{}{"query": "validate the bearer token of a request"}},
+		{"search_full", "semantic_code_search", map[string]interface{}{"query": "retry a failed fetch", "compact": false}},
+		{"search_first_page", "semantic_code_search", map[string]interface{}{"query": "bearer token", "limit": 1}},
+		{"search_no_results", "semantic_code_search", map[string]interface{}{"query": "bearer token", "min_score": 0.99}},
+		{"index_stats", "get_index_stats", map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.AssertToolGolden(ctx, t, tt.name, tt.tool, tt.arguments)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package ragtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/Mirrdhyn/code-rag-mcp/server"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// Harness is an MCP server running in-process on a temporary code directory,
+// with a hash embedder and an in-memory vector database
+type Harness struct {
+	Dir                string // Code directory (also holds indexing state and trash)
+	Config             *config.Config
+	DB                 *rag.MemoryDB
+	Embedder           *Embedder
+	Indexer            *rag.Indexer
+	IncrementalIndexer *rag.IncrementalIndexer
+	Server             *server.RAGServer
+
+	nextID int
+}
+
+// New creates a harness in a temporary directory removed when tb ends.
+// The configuration has the usual defaults, no minimum search score and the
+// HTTP API disabled; adjust h.Config before calling tools if needed.
+func New(tb testing.TB) *Harness {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	cfg := &config.Config{
+		ServerName:     "code-rag-test",
+		ServerVersion:  "test",
+		CollectionName: "code_embeddings_test",
+		EmbeddingType:  "test",
+		EmbeddingModel: "hash",
+		EmbeddingDim:   DefaultDimension,
+		CodePaths:      []string{dir},
+		FileExtensions: []string{".go", ".py", ".js", ".ts", ".tf", ".yaml", ".yml", ".md", ".sh", "Dockerfile", "Makefile"},
+		MaxFileSize:    rag.DefaultChunking.MaxFileSize,
+		ChunkSize:      rag.DefaultChunking.ChunkSize,
+		ChunkOverlap:   rag.DefaultChunking.ChunkOverlap,
+		TopK:           5,
+		SearchTimeout:  5 * time.Second,
+		RequestTimeout: time.Minute,
+		ReindexTimeout: time.Minute,
+		TrashRetention: time.Hour,
+		SearchLog:      true,
+	}
+
+	embedder := NewEmbedder(DefaultDimension)
+	db := rag.NewMemoryDB()
+	if err := db.CreateCollection(context.Background(), cfg.CollectionName, embedder.Dimension()); err != nil {
+		tb.Fatalf("ragtest: create collection: %v", err)
+	}
+
+	logger := zap.NewNop()
+	indexer := rag.NewIndexer(embedder, db, logger)
+	indexer.SetEmbeddingModel(cfg.EmbeddingModel)
+	incrementalIndexer := rag.NewIncrementalIndexer(indexer, dir)
+
+	return &Harness{
+		Dir:                dir,
+		Config:             cfg,
+		DB:                 db,
+		Embedder:           embedder,
+		Indexer:            indexer,
+		IncrementalIndexer: incrementalIndexer,
+		Server:             server.NewRAGServer(indexer, incrementalIndexer, db, embedder, cfg, logger),
+	}
+}
+
+// WriteFiles writes files (relative path -> content) under the code directory
+func (h *Harness) WriteFiles(tb testing.TB, files map[string]string) {
+	tb.Helper()
+	for name, content := range files {
+		path := filepath.Join(h.Dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("ragtest: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			tb.Fatalf("ragtest: %v", err)
+		}
+	}
+}
+
+// Path returns the absolute path of a file of the code directory, as stored in the index
+func (h *Harness) Path(name string) string {
+	return filepath.Join(h.Dir, filepath.FromSlash(name))
+}
+
+// Index indexes the code directory with the configured extensions
+func (h *Harness) Index(ctx context.Context) error {
+	return h.Indexer.IndexDirectory(ctx, h.Dir, h.Config.FileExtensions, rag.PathFilter{}, h.Config.CollectionName)
+}
+
+// CallTool calls an MCP tool through the server's JSON-RPC handler, as a
+// client would. Protocol errors (unknown tool, handler error) are returned as
+// errors; tool errors are results with IsError set.
+func (h *Harness) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	h.nextID++
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      h.nextID,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      name,
+			"arguments": arguments,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch response := h.Server.MCPServer().HandleMessage(ctx, request).(type) {
+	case mcp.JSONRPCResponse:
+		result, ok := response.Result.(*mcp.CallToolResult)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result type %T", response.Result)
+		}
+		return result, nil
+	case mcp.JSONRPCError:
+		return nil, fmt.Errorf("tool %s failed: %s", name, response.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected response type %T", response)
+	}
+}
+
+// CallHTTP sends a request to the HTTP API routes in-process. body, when not
+// nil, is sent as JSON. Requests are signed when Config.WebhookSecret is set.
+// It returns the status code and the response body.
+func (h *Harness) CallHTTP(ctx context.Context, method, path string, body interface{}) (int, []byte, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if h.Config.WebhookSecret != "" {
+		server.SignRequest(request, data, h.Config.WebhookSecret)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.NewHTTPAPIServer(h.Server, 0, zap.NewNop()).Handler().ServeHTTP(recorder, request)
+	return recorder.Code, recorder.Body.Bytes(), nil
+}
+
+// Text concatenates the text contents of a tool result
+func Text(result *mcp.CallToolResult) string {
+	var text strings.Builder
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			text.WriteString(c.Text)
+		case *mcp.TextContent:
+			text.WriteString(c.Text)
+		}
+	}
+	return text.String()
+}
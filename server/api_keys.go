@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"go.uber.org/zap"
+)
+
+// apiKeyContextKey is the request context key of the API key a request
+// authenticated with
+type apiKeyContextKey struct{}
+
+// requestAPIKey returns the API key r authenticated with, or nil when no
+// api_keys are configured
+func requestAPIKey(ctx context.Context) *config.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*config.APIKey)
+	return key
+}
+
+// lookupAPIKey returns the configured key matching token. Every key is
+// compared in constant time, so timing does not reveal how much matched.
+func lookupAPIKey(keys []config.APIKey, token string) *config.APIKey {
+	sum := sha256.Sum256([]byte(token))
+	var found *config.APIKey
+	for i := range keys {
+		keySum := sha256.Sum256([]byte(keys[i].Key))
+		if subtle.ConstantTimeCompare(sum[:], keySum[:]) == 1 {
+			found = &keys[i]
+		}
+	}
+	return found
+}
+
+// requireAPIKey rejects requests without a configured bearer token, except
+// on probe endpoints, and records the key in the request context. Without
+// api_keys, every request is let through.
+func (h *HTTPAPIServer) requireAPIKey(next http.Handler) http.Handler {
+	keys := h.server.config.APIKeys
+	if len(keys) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probePaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing API key", http.StatusUnauthorized)
+			return
+		}
+		key := lookupAPIKey(keys, strings.TrimSpace(token))
+		if key == nil {
+			h.logger.Warn("Rejected request with unknown API key", zap.String("path", r.URL.Path), zap.String("remote", r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid API key", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// indexingKey answers 403 on indexing endpoints to API keys without
+// indexing permission
+func (h *HTTPAPIServer) indexingKey(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if key := requestAPIKey(r.Context()); key != nil && !key.Indexing {
+			h.logger.Warn("Rejected indexing request of API key without indexing permission", zap.String("key", key.Name), zap.String("path", r.URL.Path))
+			http.Error(w, "API key may not trigger indexing", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// fullAccessKey answers 403 to API keys with tags or without indexing
+// permission: MCP tools cannot see the key of a request, so they could neither
+// scope searches to its tags nor refuse indexing to it
+func (h *HTTPAPIServer) fullAccessKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := requestAPIKey(r.Context()); key != nil && (!key.Indexing || len(key.Tags) > 0) {
+			h.logger.Warn("Rejected MCP request of a restricted API key", zap.String("key", key.Name))
+			http.Error(w, "MCP over SSE needs an API key with indexing permission and no tags", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// scopedTags returns the tags a search of the request's API key is limited
+// to: the requested ones, which must all be allowed to the key, or else all
+// the key's tags. Keys without tags may search any code.
+func scopedTags(ctx context.Context, requested []string) ([]string, error) {
+	key := requestAPIKey(ctx)
+	if key == nil || len(key.Tags) == 0 {
+		return requested, nil
+	}
+	if len(requested) == 0 {
+		return key.Tags, nil
+	}
+
+	allowed := make(map[string]bool, len(key.Tags))
+	for _, tag := range key.Tags {
+		allowed[tag] = true
+	}
+	for _, tag := range requested {
+		if !allowed[tag] {
+			return nil, fmt.Errorf("API key %s may not search tag %q", key.Name, tag)
+		}
+	}
+	return requested, nil
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// clientRoots holds the workspace roots advertised by the MCP client
+type clientRoots struct {
+	mu        sync.RWMutex
+	supported bool     // The client declared the roots capability
+	active    string   // Code path searches default to, "" for all
+	added     []string // Client roots outside code_paths added as roots (client_roots.index)
+
+	refresh sync.Mutex // Serializes roots/list round trips, so the latest list wins
+}
+
+// observeClient follows the roots of the client on transport: listed once the
+// session is initialized, and again each time the client reports a change
+func (s *RAGServer) observeClient(transport *stdioTransport) func(method string, params json.RawMessage) {
+	return func(method string, params json.RawMessage) {
+		switch method {
+		case "initialize":
+			var request struct {
+				Capabilities mcp.ClientCapabilities `json:"capabilities"`
+			}
+			_ = json.Unmarshal(params, &request)
+			s.clientRoots.mu.Lock()
+			s.clientRoots.supported = request.Capabilities.Roots != nil
+			s.clientRoots.mu.Unlock()
+		case "notifications/initialized", "notifications/roots/list_changed":
+			s.clientRoots.mu.RLock()
+			supported := s.clientRoots.supported
+			s.clientRoots.mu.RUnlock()
+			if supported {
+				// The response arrives on the transport: never wait for it here
+				go s.refreshClientRoots(transport)
+			}
+		}
+	}
+}
+
+// refreshClientRoots asks the client for its roots and applies them
+func (s *RAGServer) refreshClientRoots(transport *stdioTransport) {
+	s.clientRoots.refresh.Lock()
+	defer s.clientRoots.refresh.Unlock()
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	raw, err := transport.request(ctx, "roots/list", nil)
+	if err != nil {
+		s.logger.Warn("Failed to list client roots", zap.Error(err))
+		return
+	}
+	var result mcp.ListRootsResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		s.logger.Warn("Malformed client roots", zap.Error(err))
+		return
+	}
+	s.applyClientRoots(result.Roots)
+}
+
+// applyClientRoots matches the client roots with the code paths. A single
+// match becomes the active root searches default to. Client roots outside
+// all code paths are synced into the collection with client_roots.index.
+func (s *RAGServer) applyClientRoots(roots []mcp.Root) {
+	var paths []string
+	for _, root := range roots {
+		if path, ok := fileURIPath(root.URI); ok {
+			paths = append(paths, path)
+		}
+	}
+
+	var matched, unknown []string
+	for _, path := range paths {
+		codePaths := s.codePathsFor(path)
+		if len(codePaths) == 0 {
+			unknown = append(unknown, path)
+		}
+		for _, codePath := range codePaths {
+			if !slices.Contains(matched, codePath) {
+				matched = append(matched, codePath)
+			}
+		}
+	}
+
+	if s.config.ClientRoots.Index {
+		for _, path := range unknown {
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				s.logger.Warn("Skipping client root", zap.String("path", path))
+				continue
+			}
+			s.addClientRoot(path)
+			matched = append(matched, path)
+		}
+	} else if len(unknown) > 0 {
+		s.logger.Info("Client roots outside code_paths are not searched (set client_roots.index to index them)",
+			zap.Strings("roots", unknown))
+	}
+
+	active := ""
+	if len(matched) == 1 {
+		active = matched[0]
+	}
+
+	s.clientRoots.mu.Lock()
+	s.clientRoots.active = active
+	s.clientRoots.mu.Unlock()
+
+	s.logger.Info("Client roots updated",
+		zap.Strings("client_roots", paths),
+		zap.Strings("code_paths", matched),
+		zap.String("active_root", active),
+	)
+}
+
+// codePathsFor returns the code paths a client root covers: the innermost one
+// containing it, or else those inside it
+func (s *RAGServer) codePathsFor(path string) []string {
+	var containing string
+	var inside []string
+	for _, codePath := range s.codePaths() {
+		switch {
+		case codePath == path || strings.HasPrefix(path, codePath+string(os.PathSeparator)):
+			if len(codePath) > len(containing) {
+				containing = codePath
+			}
+		case strings.HasPrefix(codePath, path+string(os.PathSeparator)):
+			inside = append(inside, codePath)
+		}
+	}
+	if containing != "" {
+		return []string{containing}
+	}
+	return inside
+}
+
+// addClientRoot makes path a root of the collection and syncs it in the
+// background, on the leader only
+func (s *RAGServer) addClientRoot(path string) {
+	s.clientRoots.mu.Lock()
+	known := slices.Contains(s.clientRoots.added, path)
+	if !known {
+		s.clientRoots.added = append(s.clientRoots.added, path)
+	}
+	s.clientRoots.mu.Unlock()
+	if known {
+		return
+	}
+
+	s.indexer.AddRoot(path)
+	if !s.isLeader() {
+		return
+	}
+	s.logger.Info("Indexing client root", zap.String("path", path))
+	s.jobs.start("index_client_root", path, func(ctx context.Context, progress func(done, total int)) error {
+		// A sync would take over the cancel slot of the running session
+		if s.incrementalIndexer.Running() {
+			s.logger.Warn("Indexing in progress, not indexing client root: use index_codebase once it completes",
+				zap.String("path", path))
+			return rag.ErrIndexingRunning
+		}
+		report, err := s.incrementalIndexer.SyncDirectory(ctx, path, s.config.FileExtensions, s.config.CollectionName)
+		if err != nil {
+			s.logger.Error("Indexing client root failed", zap.String("path", path), zap.Error(err))
+			return err
+		}
+		s.logger.Info("Client root indexed",
+			zap.String("path", path),
+			zap.Int("added", len(report.Added)),
+			zap.Int("modified", len(report.Modified)),
+			zap.Int("unchanged", report.Unchanged),
+		)
+		return nil
+	})
+}
+
+// codePaths returns the absolute code paths and the client roots added to them
+func (s *RAGServer) codePaths() []string {
+	var paths []string
+	for _, path := range s.config.CodePaths {
+		if abs, err := filepath.Abs(path); err == nil {
+			paths = append(paths, abs)
+		}
+	}
+
+	s.clientRoots.mu.RLock()
+	defer s.clientRoots.mu.RUnlock()
+	return append(paths, s.clientRoots.added...)
+}
+
+// activeRoot returns the code path of the project open in the client, ""
+// when unknown or when the client has several open
+func (s *RAGServer) activeRoot() string {
+	s.clientRoots.mu.RLock()
+	defer s.clientRoots.mu.RUnlock()
+	return s.clientRoots.active
+}
+
+// fileURIPath returns the path of a file:// root URI
+func fileURIPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", false
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path)), true
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// confirmationTTL is how long a destructive-operation token stays valid
+const confirmationTTL = 5 * time.Minute
+
+// pendingConfirmation is a destructive operation awaiting its second call
+type pendingConfirmation struct {
+	action    string
+	target    string
+	expiresAt time.Time
+}
+
+// confirmationStore issues single-use tokens that destructive tools require
+// on their second call, so a single mistaken call can never delete anything.
+type confirmationStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{
+		pending: make(map[string]pendingConfirmation),
+	}
+}
+
+// issue returns a token authorizing action on target once
+func (c *confirmationStore) issue(action, target string) string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired tokens while we hold the lock
+	now := time.Now()
+	for t, p := range c.pending {
+		if now.After(p.expiresAt) {
+			delete(c.pending, t)
+		}
+	}
+
+	c.pending[token] = pendingConfirmation{
+		action:    action,
+		target:    target,
+		expiresAt: now.Add(confirmationTTL),
+	}
+
+	return token
+}
+
+// consume validates and invalidates a token for action on target
+func (c *confirmationStore) consume(token, action, target string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[token]
+	if !ok {
+		return fmt.Errorf("unknown or already used confirmation token")
+	}
+	delete(c.pending, token)
+
+	if time.Now().After(p.expiresAt) {
+		return fmt.Errorf("confirmation token expired, call the tool again without a token")
+	}
+	if p.action != action || p.target != target {
+		return fmt.Errorf("confirmation token was issued for a different operation")
+	}
+
+	return nil
+}
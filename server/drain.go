@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// drainPollInterval is how often Drain checks whether indexing has stopped
+const drainPollInterval = 200 * time.Millisecond
+
+// Drain prepares the instance for shutdown: it reports not ready, refuses
+// new indexing, hands leadership over, and stops running indexing (saving
+// resumable progress). It returns once indexing has stopped, or ctx's error.
+// Searches keep working until the process exits.
+func (s *RAGServer) Drain(ctx context.Context) error {
+	if s.draining.Swap(true) {
+		s.logger.Info("Already draining")
+	} else {
+		s.logger.Info("Draining: stopping indexing before shutdown")
+	}
+
+	if s.leader != nil {
+		if err := s.leader.Resign(ctx); err != nil {
+			s.logger.Warn("Failed to release leader lease", zap.Error(err))
+		}
+	}
+	s.incrementalIndexer.Cancel()
+	s.jobs.cancelAll()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for s.incrementalIndexer.Running() || s.jobs.running() > 0 || s.reindexing.Load() {
+		select {
+		case <-ctx.Done():
+			s.logger.Warn("Drain timed out with indexing still running", zap.Int("jobs", s.jobs.running()))
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	s.logger.Info("Drained")
+	return nil
+}
+
+// Draining reports whether Drain was called
+func (s *RAGServer) Draining() bool {
+	return s.draining.Load()
+}
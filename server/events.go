@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// eventRetryMax caps the delay between attempts at a failing event
+	eventRetryMax = time.Minute
+
+	// eventMaxAttempts caps the attempts at an event retried in place, so a
+	// lasting vector database or embedder failure cannot stall the bus
+	eventMaxAttempts = 8
+
+	// eventLeaderPoll is how often a non-leader checks whether it was elected
+	eventLeaderPoll = 5 * time.Second
+)
+
+// busMessage is one message received from an event bus
+type busMessage struct {
+	data []byte
+	ack  func() error // Marks the message processed
+	nak  func() error // Asks the bus to redeliver it later; nil to retry in place
+}
+
+// eventSource receives file-change messages from a message bus
+type eventSource interface {
+	// next waits for a message; it returns nil without error when none
+	// arrived in time, so the caller can check for shutdown
+	next(ctx context.Context) (*busMessage, error)
+	Close() error
+}
+
+// newEventSource connects to the configured event bus
+func newEventSource(ctx context.Context, cfg config.EventBus) (eventSource, error) {
+	switch cfg.Type {
+	case "nats":
+		return newNATSSource(ctx, cfg)
+	case "kafka":
+		return newKafkaSource(cfg)
+	default:
+		return nil, fmt.Errorf("unknown event_bus type %q (nats or kafka)", cfg.Type)
+	}
+}
+
+// runEventBus re-indexes the files named by event bus messages until ctx is
+// done. Messages are acknowledged only once processed (at least once), and
+// files whose indexed content hash matches are skipped, so redelivered or
+// duplicate events cost nothing. Only the indexing leader consumes.
+func (s *RAGServer) runEventBus(ctx context.Context) {
+	source, err := newEventSource(ctx, s.config.EventBus)
+	if err != nil {
+		s.logger.Error("Failed to connect to event bus", zap.String("type", s.config.EventBus.Type), zap.Error(err))
+		return
+	}
+	defer source.Close()
+
+	s.logger.Info("Consuming file-change events",
+		zap.String("type", s.config.EventBus.Type),
+		zap.String("topic", s.config.EventBus.Topic),
+	)
+
+	for ctx.Err() == nil {
+		if !s.isLeader() {
+			sleepContext(ctx, eventLeaderPoll)
+			continue
+		}
+
+		msg, err := source.next(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Warn("Failed to receive event", zap.Error(err))
+				sleepContext(ctx, eventLeaderPoll)
+			}
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+
+		s.handleBusMessage(ctx, msg)
+	}
+}
+
+// handleBusMessage processes one message, retrying until it succeeds, the
+// bus takes it back, eventMaxAttempts attempts failed or ctx is done
+func (s *RAGServer) handleBusMessage(ctx context.Context, msg *busMessage) {
+	var event ReindexRequest
+	if err := json.Unmarshal(msg.data, &event); err != nil || len(event.Files) == 0 {
+		// Redelivering a malformed event would never succeed
+		s.logger.Warn("Dropping invalid file-change event", zap.ByteString("data", msg.data), zap.Error(err))
+		msg.ack()
+		return
+	}
+
+	delay := time.Second
+	for attempt := 1; ; attempt++ {
+		err := s.reindexChangedFiles(ctx, event.Files)
+		if err == nil {
+			if err := msg.ack(); err != nil {
+				s.logger.Warn("Failed to acknowledge event", zap.Error(err))
+			}
+			return
+		}
+
+		s.logger.Warn("Failed to process file-change event", zap.Strings("files", event.Files), zap.Error(err))
+		if msg.nak != nil {
+			msg.nak()
+			return
+		}
+		if attempt == eventMaxAttempts {
+			s.logger.Error("Dropping file-change event after repeated failures; verify_index finds the files it missed",
+				zap.Strings("files", event.Files), zap.Int("attempts", attempt))
+			if err := msg.ack(); err != nil {
+				s.logger.Warn("Failed to acknowledge event", zap.Error(err))
+			}
+			return
+		}
+		if !sleepContext(ctx, delay) {
+			return
+		}
+		delay = min(delay*2, eventRetryMax)
+	}
+}
+
+// reindexChangedFiles re-indexes files whose content differs from the index;
+// deleted files have their chunks removed. Files that cannot be read, such as
+// directories, are skipped: retrying would not help. The errors returned come
+// from the vector database or the embedder.
+func (s *RAGServer) reindexChangedFiles(ctx context.Context, files []string) error {
+	var changed []string
+	for _, filePath := range files {
+		filePath = strings.TrimSpace(filePath)
+		if filePath == "" {
+			continue
+		}
+
+		unchanged, err := s.indexer.FileUnchanged(ctx, s.config.CollectionName, filePath)
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			s.logger.Warn("Skipping unreadable file of file-change event", zap.String("file", filePath), zap.Error(err))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !unchanged {
+			changed = append(changed, filePath)
+		}
+	}
+
+	if len(changed) == 0 {
+		s.logger.Debug("File-change event already indexed", zap.Strings("files", files))
+		return nil
+	}
+	return s.incrementalIndexer.ReindexFiles(ctx, changed, s.config.CollectionName)
+}
+
+// sleepContext waits for d, returning false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
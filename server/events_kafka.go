@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaSource consumes a topic in a consumer group, committing offsets only
+// after messages are processed
+type kafkaSource struct {
+	reader *kafka.Reader
+}
+
+func newKafkaSource(cfg config.EventBus) (*kafkaSource, error) {
+	if len(cfg.Servers) == 0 {
+		return nil, errors.New("event_bus.servers is required for kafka")
+	}
+
+	return &kafkaSource{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: cfg.Servers,
+			GroupID: cfg.Group,
+			Topic:   cfg.Topic,
+		}),
+	}, nil
+}
+
+// next returns messages in partition order. Kafka cannot redeliver a single
+// message, so failures are retried in place (nak is nil).
+func (k *kafkaSource) next(ctx context.Context) (*busMessage, error) {
+	msg, err := k.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &busMessage{
+		data: msg.Value,
+		ack: func() error {
+			return k.reader.CommitMessages(context.Background(), msg)
+		},
+	}, nil
+}
+
+func (k *kafkaSource) Close() error {
+	return k.reader.Close()
+}
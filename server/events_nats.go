@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// natsFetchWait bounds how long one fetch waits for a message
+	natsFetchWait = 5 * time.Second
+
+	// natsRedeliveryDelay is how long a failed message waits before redelivery
+	natsRedeliveryDelay = 30 * time.Second
+)
+
+// natsSource consumes a subject of a JetStream stream through a durable
+// consumer, so unacknowledged messages are redelivered
+type natsSource struct {
+	conn     *nats.Conn
+	consumer jetstream.Consumer
+}
+
+func newNATSSource(ctx context.Context, cfg config.EventBus) (*natsSource, error) {
+	if cfg.Stream == "" {
+		return nil, errors.New("event_bus.stream is required for nats")
+	}
+
+	conn, err := nats.Connect(strings.Join(cfg.Servers, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       cfg.Group,
+		FilterSubject: cfg.Topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &natsSource{conn: conn, consumer: consumer}, nil
+}
+
+func (n *natsSource) next(ctx context.Context) (*busMessage, error) {
+	msg, err := n.consumer.Next(jetstream.FetchMaxWait(natsFetchWait))
+	if errors.Is(err, nats.ErrTimeout) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &busMessage{
+		data: msg.Data(),
+		ack:  msg.Ack,
+		nak:  func() error { return msg.NakWithDelay(natsRedeliveryDelay) },
+	}, nil
+}
+
+func (n *natsSource) Close() error {
+	n.conn.Close()
+	return nil
+}
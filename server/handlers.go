@@ -7,10 +7,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
 	"github.com/mark3labs/mcp-go/mcp"
 	"go.uber.org/zap"
 )
 
+// maxExpandContextLines caps expand_context to keep responses bounded
+const maxExpandContextLines = 100
+
 func (s *RAGServer) handleSemanticSearch(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
 	query, ok := arguments["query"].(string)
 	if !ok {
@@ -21,6 +25,9 @@ func (s *RAGServer) handleSemanticSearch(arguments map[string]interface{}) (*mcp
 	if l, ok := arguments["limit"].(float64); ok {
 		limit = int(l)
 	}
+	if limit < 1 || limit > maxSearchLimit {
+		return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)), nil
+	}
 
 	minScore := float32(0.15) // Lowered for high-dim embeddings (3584)
 	if ms, ok := arguments["min_score"].(float64); ok {
@@ -37,57 +44,149 @@ func (s *RAGServer) handleSemanticSearch(arguments map[string]interface{}) (*mcp
 		excerptLines = int(el)
 	}
 
-	ctx := context.Background()
+	maxTokens := 0 // 0 = no budget
+	if mt, ok := arguments["max_tokens"].(float64); ok {
+		maxTokens = int(mt)
+	}
+
+	offset := 0
+	if o, ok := arguments["offset"].(float64); ok && o > 0 {
+		offset = int(o)
+	}
+
+	expandLines := 0 // Lines read from disk around each match
+	if ec, ok := arguments["expand_context"].(float64); ok && ec > 0 {
+		expandLines = min(int(ec), maxExpandContextLines)
+	}
+
+	returnParent, _ := arguments["return_parent"].(bool)
+
+	hybrid := s.config.HybridSearch
+	if h, ok := arguments["hybrid"].(bool); ok {
+		hybrid = h
+	}
+
+	var timeout time.Duration // 0 = config default
+	if t, ok := arguments["timeout_ms"].(float64); ok && t > 0 {
+		timeout = time.Duration(t) * time.Millisecond
+	}
+
+	language, _ := arguments["language"].(string)
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "all" {
+		language = ""
+	}
+
+	tags := stringArgs(arguments["tags"])
+	debug, _ := arguments["debug"].(bool)
+	params, err := searchParamsArg(arguments)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	root, err := s.rootArg(arguments)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
 
 	s.logger.Info("Semantic search",
 		zap.String("query", query),
 		zap.Int("limit", limit),
+		zap.Int("offset", offset),
+		zap.Int("expand_context", expandLines),
+		zap.Bool("return_parent", returnParent),
 		zap.Float32("min_score", minScore),
 		zap.Bool("compact", compact),
 		zap.Int("excerpt_lines", excerptLines),
+		zap.Bool("hybrid", hybrid),
+		zap.Strings("tags", tags),
+		zap.String("root", root),
+		zap.String("language", language),
+		zap.Bool("debug", debug),
 	)
 
-	// Generate embedding for query
-	embedding, err := s.embedder.Embed(ctx, query)
-	if err != nil {
-		s.logger.Error("Failed to generate embedding", zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate embedding: %v", err)), nil
-	}
-
-	// Search vector DB
-	results, err := s.vectorDB.Search(ctx, s.config.CollectionName, embedding, limit, minScore)
+	// Search vector DB (lexical fallback when the embedder is down)
+	outcome, err := s.search(ctx, searchRequest{
+		Tool:     "semantic_code_search",
+		Query:    query,
+		Limit:    limit,
+		Offset:   offset,
+		MinScore: minScore,
+		Hybrid:   hybrid,
+		Timeout:  timeout,
+		Tags:     tags,
+		Root:     root,
+		Language: language,
+		Debug:    debug,
+		Params:   params,
+	})
 	if err != nil {
 		s.logger.Error("Search failed", zap.Error(err))
 		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
 	}
+	results, degraded := outcome.Results, outcome.Degraded
+
+	// Replace fragments by their enclosing definitions, once per definition
+	if returnParent {
+		results = s.parentResults(results)
+	}
+
+	// Widen matches so signatures and returns cut by chunk boundaries are included
+	for i := range results {
+		results[i] = s.expandResult(results[i], expandLines)
+	}
 
 	if len(results) == 0 {
-		return mcp.NewToolResultText(fmt.Sprintf("No results found for query: '%s'\n\nTry:\n- Lowering min_score to 0.5-0.6\n- Broader query terms\n- Check if codebase is indexed", query)), nil
+		if degraded {
+			return mcp.NewToolResultText(degradedBanner + fmt.Sprintf("No lexical matches found for query: '%s'\n\nTry exact identifiers or keywords from the code.", query) + outcome.debugReport(minScore)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("No results found for query: '%s'\n\n%s\n\n%s", query, outcome.Stats.emptyReason(minScore, offset), outcome.Stats.summary(minScore)) + outcome.debugReport(minScore)), nil
 	}
 
 	// Format results based on mode
 	var output strings.Builder
+	if degraded {
+		output.WriteString(degradedBanner)
+	}
+	output.WriteString(lexicalNotice(outcome, minScore))
+	output.WriteString(partialResultsNotice(outcome))
 	output.WriteString(fmt.Sprintf("# Semantic Search Results\n\n"))
 	output.WriteString(fmt.Sprintf("Query: **%s**\n", query))
-	output.WriteString(fmt.Sprintf("Found: **%d matches** (deduplicated)\n\n", len(results)))
+	output.WriteString(fmt.Sprintf("Found: **%d matches** (deduplicated)\n", len(results)))
+	if offset > 0 {
+		output.WriteString(fmt.Sprintf("Page: matches after the first %d\n", offset))
+	}
+	if outcome.Stats != nil {
+		output.WriteString(outcome.Stats.summary(minScore))
+	}
+	output.WriteString("\n")
+
+	budget := newTokenBudget(maxTokens)
+	budget.take(output.String())
 
 	if compact {
 		output.WriteString("💡 **Compact mode** - showing file:line references only\n\n")
 		output.WriteString("---\n\n")
 
 		for i, result := range results {
-			output.WriteString(fmt.Sprintf("%d. `%s:%d-%d` (Score: %.3f, %s)\n",
-				i+1, result.FilePath, result.LineStart, result.LineEnd, result.Score, result.Language))
+			line := fmt.Sprintf("%d. `%s:%d-%d` (Score: %.3f, %s%s)\n",
+				i+1, s.displayPath(result), result.LineStart, result.LineEnd, result.Score, result.Language, dirtyMarker(result))
+			if !budget.take(line) {
+				budget.dropRest(len(results) - i)
+				break
+			}
+			output.WriteString(line)
 		}
 
 		output.WriteString("\n💡 Use `compact: false` to see full code excerpts.\n")
 	} else {
 		output.WriteString("---\n\n")
 
+		dedup, showRepeats := s.sessionDedup(), showRepeatsArg(arguments)
+		repeats := 0
 		for i, result := range results {
-			output.WriteString(fmt.Sprintf("## %d. %s (Score: %.3f)\n\n", i+1, result.FilePath, result.Score))
-			output.WriteString(fmt.Sprintf("**Language:** %s | **Lines:** %d-%d\n\n", result.Language, result.LineStart, result.LineEnd))
-
 			// Truncate content if excerpt_lines is set
 			content := result.Content
 			if excerptLines > 0 {
@@ -98,16 +197,55 @@ func (s *RAGServer) handleSemanticSearch(arguments map[string]interface{}) (*mcp
 				}
 			}
 
-			output.WriteString("```" + result.Language + "\n")
-			output.WriteString(content)
-			output.WriteString("\n```\n\n")
+			heading := fmt.Sprintf("## %d. %s (Score: %.3f)\n\n", i+1, s.displayPath(result), result.Score) +
+				fmt.Sprintf("**Language:** %s | **Lines:** %d-%d%s", result.Language, result.LineStart, result.LineEnd, dirtyMarker(result))
+			if returnParent && result.Parent.Symbol != "" {
+				heading += fmt.Sprintf(" | **Parent:** `%s`", result.Parent.Symbol)
+			}
+			heading += "\n\n"
+
+			if dedup && !showRepeats {
+				if previous, ok := s.shown.lookup(result, content); ok {
+					block := heading + previous.reference()
+					if !budget.take(block) {
+						budget.dropRest(len(results) - i)
+						break
+					}
+					output.WriteString(block)
+					repeats++
+					continue
+				}
+			}
+
+			block := budget.fitBlock(content, func(content string) string {
+				return heading + "```" + result.Language + "\n" + content + "\n```\n\n"
+			})
+			if block == "" {
+				budget.dropRest(len(results) - i - 1)
+				break
+			}
+			output.WriteString(block)
+			if dedup {
+				s.shown.record(result, content, shownExcerpt{Tool: "semantic_code_search", Query: query, Result: i + 1})
+			}
+		}
+
+		if repeats > 0 {
+			output.WriteString(showRepeatsHint)
 		}
 
-		if excerptLines == 0 {
+		if excerptLines == 0 && budget.unlimited() {
 			output.WriteString("💡 **Tip:** Use `excerpt_lines: 15` to show only first 15 lines and save tokens.\n")
 		}
 	}
 
+	output.WriteString(budget.notice())
+	// A short page is the last one
+	if len(outcome.Results) == limit {
+		output.WriteString(fmt.Sprintf("\n➡️ More matches: repeat with `offset: %d`.\n", outcome.NextOffset))
+	}
+	output.WriteString(outcome.debugReport(minScore))
+
 	return mcp.NewToolResultText(output.String()), nil
 }
 
@@ -127,91 +265,61 @@ func (s *RAGServer) handleFindSimilarCode(arguments map[string]interface{}) (*mc
 		minScore = float32(ms)
 	}
 
-	ctx := context.Background()
-
-	s.logger.Info("Finding similar code", zap.Int("snippet_length", len(snippet)), zap.Int("limit", limit))
-
-	// Generate embedding for snippet
-	embedding, err := s.embedder.Embed(ctx, snippet)
+	tags := stringArgs(arguments["tags"])
+	root, err := s.rootArg(arguments)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate embedding: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Search
-	results, err := s.vectorDB.Search(ctx, s.config.CollectionName, embedding, limit, minScore)
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Finding similar code", zap.Int("snippet_length", len(snippet)), zap.Int("limit", limit))
+
+	// Search (lexical fallback when the embedder is down)
+	outcome, err := s.search(ctx, searchRequest{Tool: "find_similar_code", Query: snippet, Limit: limit, MinScore: minScore, Tags: tags, Root: root})
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
 	}
+	results, degraded := outcome.Results, outcome.Degraded
 
 	var output strings.Builder
+	if degraded {
+		output.WriteString(degradedBanner)
+	}
+	output.WriteString(lexicalNotice(outcome, minScore))
 	output.WriteString(fmt.Sprintf("# Similar Code Matches\n\n"))
-	output.WriteString(fmt.Sprintf("Found: **%d similar snippets**\n\n", len(results)))
-	output.WriteString("---\n\n")
+	output.WriteString(fmt.Sprintf("Found: **%d similar snippets**\n", len(results)))
+	if outcome.Stats != nil {
+		output.WriteString(outcome.Stats.summary(minScore))
+		if len(results) == 0 {
+			output.WriteString("\n" + outcome.Stats.emptyReason(minScore, 0) + "\n")
+		}
+	}
+	output.WriteString("\n---\n\n")
 
+	dedup, showRepeats := s.sessionDedup(), showRepeatsArg(arguments)
+	repeats := 0
 	for i, result := range results {
 		output.WriteString(fmt.Sprintf("## Match %d (Similarity: %.1f%%)\n\n", i+1, result.Score*100))
-		output.WriteString(fmt.Sprintf("**File:** %s | **Lines:** %d-%d\n\n", result.FilePath, result.LineStart, result.LineEnd))
+		output.WriteString(fmt.Sprintf("**File:** %s | **Lines:** %d-%d\n\n", s.displayPath(result), result.LineStart, result.LineEnd))
+		if dedup && !showRepeats {
+			if previous, ok := s.shown.lookup(result, result.Content); ok {
+				output.WriteString(previous.reference())
+				repeats++
+				continue
+			}
+		}
 		output.WriteString("```" + result.Language + "\n")
 		output.WriteString(result.Content)
 		output.WriteString("\n```\n\n")
-	}
-
-	return mcp.NewToolResultText(output.String()), nil
-}
-
-func (s *RAGServer) handleExplainCode(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	filePath, ok := arguments["file_path"].(string)
-	if !ok {
-		return mcp.NewToolResultError("file_path must be a string"), nil
-	}
-
-	focus := ""
-	if f, ok := arguments["focus"].(string); ok {
-		focus = f
-	}
-
-	ctx := context.Background()
-
-	s.logger.Info("Explaining code", zap.String("file", filePath), zap.String("focus", focus))
-
-	// Read the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to read file: %v", err)), nil
-	}
-
-	// Search for related code
-	query := fmt.Sprintf("code related to %s %s", filePath, focus)
-	embedding, err := s.embedder.Embed(ctx, query)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate embedding: %v", err)), nil
-	}
-
-	results, err := s.vectorDB.Search(ctx, s.config.CollectionName, embedding, 5, 0.6)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
-	}
-
-	var output strings.Builder
-	output.WriteString(fmt.Sprintf("# Code Explanation: %s\n\n", filePath))
-
-	output.WriteString("## Main Code\n\n")
-	output.WriteString("```\n")
-	output.WriteString(string(content))
-	output.WriteString("\n```\n\n")
-
-	if len(results) > 0 {
-		output.WriteString("## Related Context\n\n")
-		for i, result := range results {
-			if result.FilePath == filePath {
-				continue // Skip same file
-			}
-			output.WriteString(fmt.Sprintf("### %d. %s\n\n", i+1, result.FilePath))
-			output.WriteString("```" + result.Language + "\n")
-			output.WriteString(result.Content)
-			output.WriteString("\n```\n\n")
+		if dedup {
+			s.shown.record(result, result.Content, shownExcerpt{Tool: "find_similar_code", Query: snippet, Result: i + 1})
 		}
 	}
+	if repeats > 0 {
+		output.WriteString(showRepeatsHint)
+	}
 
 	return mcp.NewToolResultText(output.String()), nil
 }
@@ -230,26 +338,66 @@ func (s *RAGServer) handleIndexDirectory(arguments map[string]interface{}) (*mcp
 		}
 	}
 
-	ctx := context.Background()
-
-	s.logger.Info("Starting indexing", zap.String("path", path), zap.Strings("extensions", extensions))
+	filter := rag.PathFilter{
+		Exclude: stringArgs(arguments["exclude_patterns"]),
+		Include: stringArgs(arguments["include_patterns"]),
+	}
+	if err := filter.Validate(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Check if path exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return mcp.NewToolResultError(fmt.Sprintf("Path does not exist: %s", path)), nil
 	}
 
-	err := s.indexer.IndexDirectory(ctx, path, extensions, s.config.CollectionName)
-	if err != nil {
-		s.logger.Error("Indexing failed", zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Indexing failed: %v", err)), nil
+	// Tags apply to the chunks indexed below and to later re-indexes of path
+	if _, ok := arguments["tags"].([]interface{}); ok {
+		if err := s.incrementalIndexer.TagPath(path, stringArgs(arguments["tags"])); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to record tags: %v", err)), nil
+		}
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("✅ Successfully indexed directory: %s\n\nThe codebase is now ready for semantic search!", path)), nil
+	s.logger.Info("Starting indexing",
+		zap.String("path", path),
+		zap.Strings("extensions", extensions),
+		zap.Strings("exclude_patterns", filter.Exclude),
+		zap.Strings("include_patterns", filter.Include),
+		zap.Strings("tags", s.indexer.TagsFor(path)),
+	)
+
+	jobID := s.jobs.start("index_codebase", path, func(ctx context.Context, progress func(done, total int)) error {
+		head, _ := rag.HeadCommit(ctx, path)
+		err := s.indexer.IndexDirectoryProgress(ctx, path, extensions, filter, s.config.CollectionName, progress)
+		switch {
+		case ctx.Err() != nil:
+			s.logger.Info("Indexing cancelled", zap.String("path", path))
+		case err != nil:
+			s.logger.Error("Indexing failed", zap.String("path", path), zap.Error(err))
+		default:
+			s.logger.Info("Indexing complete", zap.String("path", path))
+			// Filters narrower than the configuration leave files out: only a
+			// full index is a valid base for reindex_changed
+			if head != "" && len(filter.Exclude) == 0 && len(filter.Include) == 0 {
+				if err := s.incrementalIndexer.SetIndexedCommit(path, head); err != nil {
+					s.logger.Warn("Failed to record indexed commit", zap.String("path", path), zap.Error(err))
+				}
+			}
+		}
+		return err
+	})
+
+	return mcp.NewToolResultText(fmt.Sprintf(`⏳ **Indexing started:** %s
+
+**Job ID:** `+"`%s`"+`
+
+💡 Call `+"`get_job_status`"+` with this job ID to follow progress, or `+"`cancel_job`"+` to stop it. Searches return results as chunks are indexed.
+`, path, jobID)), nil
 }
 
 func (s *RAGServer) handleGetStats(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	ctx := context.Background()
+	ctx, cancel := s.toolContext()
+	defer cancel()
 
 	// Get collection info from Qdrant
 	info, err := s.vectorDB.GetCollectionInfo(ctx, s.config.CollectionName)
@@ -264,15 +412,10 @@ func (s *RAGServer) handleGetStats(arguments map[string]interface{}) (*mcp.CallT
 **Vector Dimension:** %d
 **Embedding Model:** %s (%s)
 **Last Updated:** %s
-
-**Indexed Languages:**
-- Go, Python, JavaScript/TypeScript
-- Terraform, YAML, Markdown
-- And more...
-
+%s
 **Configuration:**
-- Chunk Size: %d lines
-- Chunk Overlap: %d lines
+- Chunk Size: %d characters
+- Chunk Overlap: %d characters
 - Min Score: %.2f
 
 💡 **The index is ready!** Use 'semantic_code_search' to find code by concept.
@@ -288,14 +431,93 @@ func (s *RAGServer) handleGetStats(arguments map[string]interface{}) (*mcp.CallT
 		s.config.EmbeddingModel,
 		s.config.EmbeddingType,
 		info.UpdatedAt.Format("2006-01-02 15:04:05"),
+		s.indexBreakdown(ctx),
 		s.config.ChunkSize,
 		s.config.ChunkOverlap,
 		s.config.MinScore,
 	)
 
+	output += s.manifestSummary(ctx)
+
+	if stats, ok := s.embeddingValidation(); ok && stats.Invalid() > 0 {
+		output += fmt.Sprintf(`
+⚠️ **Invalid embeddings:** %d received (%d NaN/infinite, %d zero, %d wrong dimension), %d recovered on retry, %d chunks or queries skipped. Check the embedding server.
+`, stats.Invalid(), stats.NonFinite, stats.ZeroNorm, stats.WrongDimension, stats.Recovered, stats.Failed)
+	}
+
 	return mcp.NewToolResultText(output), nil
 }
 
+// breakdownRows caps the languages and directories listed by get_index_stats
+const breakdownRows = 15
+
+// indexBreakdown lists the indexed files and chunks by language and
+// top-level directory, for get_index_stats
+func (s *RAGServer) indexBreakdown(ctx context.Context) string {
+	breakdown, err := rag.BreakdownIndex(ctx, s.vectorDB, s.config.CollectionName, s.config.CodePaths)
+	if err != nil {
+		return fmt.Sprintf("\n⚠️ **Breakdown unavailable:** %v\n", err)
+	}
+	if breakdown.Chunks == 0 {
+		return ""
+	}
+
+	output := fmt.Sprintf("**Indexed Files:** %d\n", breakdown.Files)
+	output += breakdownTable("Language", breakdown.Languages)
+	output += breakdownTable("Directory", breakdown.Directories)
+	return output
+}
+
+// breakdownTable formats the largest entries as a Markdown table
+func breakdownTable(title string, entries []rag.BreakdownEntry) string {
+	output := fmt.Sprintf("\n| %s | Files | Chunks |\n|---|---:|---:|\n", title)
+	for i, entry := range entries {
+		if i == breakdownRows {
+			output += fmt.Sprintf("| ... %d more | | |\n", len(entries)-i)
+			break
+		}
+		output += fmt.Sprintf("| %s | %d | %d |\n", entry.Name, entry.Files, entry.Chunks)
+	}
+	return output
+}
+
+// manifestSummary describes how the collection was built and how that
+// disagrees with this server, for get_index_stats
+func (s *RAGServer) manifestSummary(ctx context.Context) string {
+	manifest, err := rag.ReadManifest(ctx, s.vectorDB, s.config.CollectionName)
+	if err != nil {
+		return fmt.Sprintf("\n⚠️ **Index manifest unavailable:** %v\n", err)
+	}
+	if manifest == nil {
+		return "\n**Index manifest:** none yet (written after the first full index)\n"
+	}
+
+	lastFull := "never"
+	if !manifest.LastFullIndex.IsZero() {
+		lastFull = manifest.LastFullIndex.Format("2006-01-02 15:04:05")
+	}
+	distance, sparse := manifest.Distance, "no"
+	if manifest.SparseVectors {
+		sparse = "yes"
+	}
+	if distance == "" {
+		distance, sparse = "unknown", "unknown"
+	}
+	output := fmt.Sprintf(`
+**Index manifest:**
+- Embedding model: %s (%d dimensions)
+- Distance: %s, sparse vectors: %s
+- Chunker version: %d
+- Last full index: %s
+- Source roots: %s
+`, manifest.EmbeddingModel, manifest.Dimension, distance, sparse, manifest.ChunkerVersion, lastFull, strings.Join(manifest.SourceRoots, ", "))
+
+	for _, problem := range manifest.Problems(s.indexer.Setup()) {
+		output += fmt.Sprintf("\n⚠️ **Manifest mismatch:** %s\n", problem)
+	}
+	return output
+}
+
 func (s *RAGServer) handleReindexFiles(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
 	filePathsRaw, ok := arguments["file_paths"].([]interface{})
 	if !ok {
@@ -311,7 +533,8 @@ func (s *RAGServer) handleReindexFiles(arguments map[string]interface{}) (*mcp.C
 		return mcp.NewToolResultError("file_paths cannot be empty"), nil
 	}
 
-	ctx := context.Background()
+	ctx, cancel := s.indexingContext()
+	defer cancel()
 
 	s.logger.Info("Re-indexing files via MCP", zap.Strings("files", filePaths))
 
@@ -350,6 +573,10 @@ func (s *RAGServer) handleGetIndexingProgress(arguments map[string]interface{})
 	switch stats["status"].(string) {
 	case "in_progress":
 		statusEmoji = "⏳"
+	case "paused":
+		statusEmoji = "⏸️"
+	case "cancelled":
+		statusEmoji = "🛑"
 	case "completed":
 		statusEmoji = "✅"
 	case "failed":
@@ -387,7 +614,7 @@ func (s *RAGServer) handleGetIndexingProgress(arguments map[string]interface{})
 	}
 
 	if stats["failed_files"].(int) > 0 {
-		output += "\n⚠️ **Some files failed to index.** Check `.indexing_state.json` for details.\n"
+		output += "\n⚠️ **Some files failed to index.** Check `.indexing_state.json` for details, and call `retry_failed_files` once the cause is fixed.\n"
 	}
 
 	if stats["status"].(string) == "in_progress" {
@@ -398,3 +625,23 @@ func (s *RAGServer) handleGetIndexingProgress(arguments map[string]interface{})
 
 	return mcp.NewToolResultText(output), nil
 }
+
+// stringArgs converts an array argument to strings, ignoring other values
+func stringArgs(arg interface{}) []string {
+	items, _ := arg.([]interface{})
+	var values []string
+	for _, item := range items {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// dirtyMarker flags matches coming from uncommitted changes
+func dirtyMarker(result rag.SearchResult) string {
+	if result.Dirty {
+		return ", uncommitted"
+	}
+	return ""
+}
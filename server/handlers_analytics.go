@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// logSearch records a search in the search log, if enabled
+func (s *RAGServer) logSearch(req searchRequest, outcome *searchOutcome, err error, latency time.Duration) {
+	if s.searchLog == nil || req.Unlogged {
+		return
+	}
+
+	entry := rag.SearchLogEntry{
+		Time:      time.Now(),
+		Tool:      req.Tool,
+		Query:     req.Query,
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+		MinScore:  req.MinScore,
+		Tags:      req.Tags,
+		Hybrid:    req.Hybrid,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else if outcome != nil {
+		entry.Results = len(outcome.Results)
+		entry.Top = rag.TopResults(outcome.Results)
+		entry.Degraded = outcome.Degraded
+		entry.Lexical = outcome.Lexical
+	}
+
+	if err := s.searchLog.Record(entry); err != nil {
+		s.logger.Warn("Failed to record search", zap.Error(err))
+	}
+}
+
+func (s *RAGServer) handleSearchAnalytics(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if s.searchLog == nil {
+		return mcp.NewToolResultError("The search log is disabled: set search_log: true to record searches."), nil
+	}
+
+	var since time.Time
+	period := "all recorded searches"
+	if raw, ok := arguments["since"].(string); ok && raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("since must be a positive duration such as \"24h\" or \"168h\": %q", raw)), nil
+		}
+		since = time.Now().Add(-d)
+		period = "last " + raw
+	}
+
+	limit := 10
+	if l, ok := arguments["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+
+	entries, err := s.searchLog.Entries(since)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read search log: %v", err)), nil
+	}
+	if len(entries) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No searches recorded (%s).", period)), nil
+	}
+	analytics := rag.Analyze(entries, limit)
+
+	var output strings.Builder
+	output.WriteString("# Search Analytics\n\n")
+	output.WriteString(fmt.Sprintf("Period: **%s** (since %s)\n", period, entries[0].Time.Format(time.RFC3339)))
+	output.WriteString(fmt.Sprintf("Searches: **%d** (%d distinct queries; %s)\n", analytics.Searches, analytics.DistinctQueries, formatCounts(analytics.ByTool)))
+	output.WriteString(fmt.Sprintf("Zero results: **%d** (%.0f%%)\n", analytics.ZeroResults, percent(analytics.ZeroResults, analytics.Searches)))
+	output.WriteString(fmt.Sprintf("Latency: **%.1f ms** average, %.1f ms p95\n", analytics.AvgLatencyMs, analytics.P95LatencyMs))
+	if analytics.Degraded > 0 {
+		output.WriteString(fmt.Sprintf("Degraded (lexical fallback): %d\n", analytics.Degraded))
+	}
+	if analytics.Errors > 0 {
+		output.WriteString(fmt.Sprintf("Failed: %d\n", analytics.Errors))
+	}
+
+	output.WriteString("\n## Top queries\n\n")
+	output.WriteString("| Query | Searches | Avg results | Avg latency |\n")
+	output.WriteString("|-------|----------|-------------|-------------|\n")
+	for _, q := range analytics.TopQueries {
+		output.WriteString(fmt.Sprintf("| %s | %d | %.1f | %.1f ms |\n", tableCell(q.Query), q.Searches, q.AvgResults, q.AvgLatencyMs))
+	}
+
+	if len(analytics.ZeroResultQueries) > 0 {
+		output.WriteString("\n## Zero-result queries\n\n")
+		output.WriteString("| Query | Empty | Searches | Last |\n")
+		output.WriteString("|-------|-------|----------|------|\n")
+		for _, q := range analytics.ZeroResultQueries {
+			output.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n", tableCell(q.Query), q.ZeroResults, q.Searches, q.Last.Format("2006-01-02 15:04")))
+		}
+		output.WriteString("\n💡 Zero-result queries point at code that is not indexed or a `min_score` too strict for them: check `code_paths` and try `tune_threshold`.\n")
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// percent returns n as a percentage of total
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// tableCell keeps a value on one markdown table row
+func tableCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	if runes := []rune(value); len(runes) > 80 {
+		value = string(runes[:77]) + "..."
+	}
+	return value
+}
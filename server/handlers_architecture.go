@@ -0,0 +1,177 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxArchitectureDirs caps the directories of an overview; the largest are kept
+	maxArchitectureDirs = 60
+
+	// maxSummaryFiles caps the file names sent to the model per directory summary
+	maxSummaryFiles = 40
+)
+
+func (s *RAGServer) handleSummarizeArchitecture(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	var under []string
+	if path, ok := arguments["path"].(string); ok && path != "" {
+		under = []string{filepath.Clean(path)}
+	}
+
+	depth := 2
+	if d, ok := arguments["depth"].(float64); ok && d >= 1 {
+		depth = int(d)
+	}
+
+	topSymbols := 5
+	if ts, ok := arguments["top_symbols"].(float64); ok && ts >= 0 {
+		topSymbols = int(ts)
+	}
+
+	generate, _ := arguments["generate_summaries"].(bool)
+	refresh, _ := arguments["refresh"].(bool)
+	if generate {
+		if s.generator == nil {
+			return mcp.NewToolResultError("generate_summaries requires a chat model: set generation.type and generation.model."), nil
+		}
+		if !s.isLeader() {
+			return mcp.NewToolResultError(s.notLeaderMessage()), nil
+		}
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Summarizing architecture",
+		zap.Strings("path", under),
+		zap.Int("depth", depth),
+		zap.Bool("generate_summaries", generate),
+	)
+
+	dirs, err := rag.ArchitectureOverview(ctx, s.vectorDB, s.config.CollectionName, s.config.CodePaths, under, depth, topSymbols)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to scan index: %v", err)), nil
+	}
+	if len(dirs) == 0 {
+		return mcp.NewToolResultText("Nothing is indexed here yet.\n\nRun `index_codebase` first, or check `path`."), nil
+	}
+
+	totalFiles, totalChunks := 0, 0
+	languages := make(map[string]int)
+	for _, dir := range dirs {
+		totalFiles += dir.Files
+		totalChunks += dir.Chunks
+		for language, n := range dir.Languages {
+			languages[language] += n
+		}
+	}
+
+	// Keep the largest directories, still listed by path
+	omitted := 0
+	if len(dirs) > maxArchitectureDirs {
+		sort.SliceStable(dirs, func(i, j int) bool { return dirs[i].Chunks > dirs[j].Chunks })
+		omitted = len(dirs) - maxArchitectureDirs
+		dirs = dirs[:maxArchitectureDirs]
+		sort.Slice(dirs, func(i, j int) bool { return dirs[i].Path < dirs[j].Path })
+	}
+
+	summaries, err := rag.DirectorySummaries(ctx, s.vectorDB, s.config.CollectionName)
+	if err != nil {
+		s.logger.Debug("Directory summaries unavailable", zap.Error(err))
+	}
+	for _, dir := range dirs {
+		dir.Summary = summaries[dir.Path]
+	}
+
+	generated, failed := 0, 0
+	if generate {
+		var fresh []*rag.DirectoryStats
+		for _, dir := range dirs {
+			if dir.Summary != nil && !refresh {
+				continue
+			}
+			text, err := s.generator.Generate(ctx, rag.DirectorySummaryMessages(dir, maxSummaryFiles))
+			if err != nil {
+				s.logger.Warn("Directory summary failed", zap.String("dir", dir.Path), zap.Error(err))
+				failed++
+				if ctx.Err() != nil {
+					break
+				}
+				continue
+			}
+			dir.Summary = &rag.DirectorySummary{Text: text, Files: dir.Files, GeneratedAt: time.Now()}
+			fresh = append(fresh, dir)
+		}
+		if err := s.indexer.StoreDirectorySummaries(ctx, s.config.CollectionName, fresh); err != nil {
+			s.logger.Warn("Failed to store directory summaries", zap.Error(err))
+		}
+		generated = len(fresh)
+	}
+
+	var output strings.Builder
+	output.WriteString("# Architecture Overview\n\n")
+	scope := "all indexed code"
+	if len(under) > 0 {
+		scope = under[0]
+	}
+	output.WriteString(fmt.Sprintf("Scope: **%s** (directories %d level(s) below each code path)\n", scope, depth))
+	output.WriteString(fmt.Sprintf("Indexed: **%d files**, %d chunks in %d directories (%s)\n", totalFiles, totalChunks, len(dirs)+omitted, formatCounts(languages)))
+	if generate {
+		output.WriteString(fmt.Sprintf("Summaries generated: %d", generated))
+		if failed > 0 {
+			output.WriteString(fmt.Sprintf(" (%d failed, see logs)", failed))
+		}
+		output.WriteString("\n")
+	}
+	output.WriteString("\n")
+
+	for _, dir := range dirs {
+		output.WriteString(fmt.Sprintf("## `%s`\n\n", displayDir(dir)))
+		output.WriteString(fmt.Sprintf("%d files, %d chunks (%s)\n", dir.Files, dir.Chunks, formatCounts(dir.Languages)))
+		if dir.Summary != nil {
+			stale := ""
+			if dir.Summary.Files != dir.Files {
+				stale = fmt.Sprintf(" _(generated with %d files; refresh it with `refresh: true`)_", dir.Summary.Files)
+			}
+			output.WriteString(fmt.Sprintf("\n%s%s\n", dir.Summary.Text, stale))
+		}
+		if len(dir.TopSymbols) > 0 {
+			symbols := make([]string, len(dir.TopSymbols))
+			for i, sym := range dir.TopSymbols {
+				symbols[i] = fmt.Sprintf("`%s` (%s, used by %d files)", sym.Name, sym.Kind, sym.UsedBy)
+			}
+			output.WriteString(fmt.Sprintf("\nTop symbols: %s\n", strings.Join(symbols, ", ")))
+		}
+		output.WriteString("\n")
+	}
+
+	if omitted > 0 {
+		output.WriteString(fmt.Sprintf("➡️ %d smaller directories not shown: narrow with `path` or lower `depth`.\n\n", omitted))
+	}
+	if !generate && s.generator != nil {
+		output.WriteString("💡 Add `generate_summaries: true` for a description of each directory.\n")
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// displayDir names a directory relative to its code path, prefixed by the
+// code path's name so several repositories stay apart
+func displayDir(dir *rag.DirectoryStats) string {
+	if dir.Root == "" {
+		return dir.Path
+	}
+	rel, err := filepath.Rel(dir.Root, dir.Path)
+	if err != nil {
+		return dir.Path
+	}
+	return filepath.Join(filepath.Base(dir.Root), rel)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func (s *RAGServer) handleAskCodebase(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if s.generator == nil {
+		return mcp.NewToolResultError("Answer generation is disabled: set generation.type and generation.model to a chat model, or use semantic_code_search and answer from its results."), nil
+	}
+
+	question, ok := arguments["question"].(string)
+	if !ok || strings.TrimSpace(question) == "" {
+		return mcp.NewToolResultError("question must be a non-empty string"), nil
+	}
+
+	limit := 8
+	if l, ok := arguments["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+
+	minScore := s.config.MinScore
+	if ms, ok := arguments["min_score"].(float64); ok {
+		minScore = float32(ms)
+	}
+
+	tags := stringArgs(arguments["tags"])
+	root, err := s.rootArg(arguments)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Answering question",
+		zap.String("question", question),
+		zap.Int("limit", limit),
+		zap.Strings("tags", tags),
+	)
+
+	outcome, err := s.search(ctx, searchRequest{Tool: "ask_codebase", Query: question, Limit: limit, MinScore: minScore, Hybrid: s.config.HybridSearch, Tags: tags, Root: root})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+	}
+	if len(outcome.Results) == 0 {
+		reason := "Try exact identifiers or keywords from the code."
+		if outcome.Stats != nil {
+			reason = outcome.Stats.emptyReason(minScore, 0)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("No indexed code matches this question, so there is nothing to answer from.\n\n%s", reason)), nil
+	}
+
+	// Excerpts are trimmed to the context budget, best matches first
+	budget := newTokenBudget(s.config.Generation.ContextTokens)
+	var sources []rag.SearchResult
+	for i, result := range outcome.Results {
+		excerpt := ""
+		block := budget.fitBlock(result.Content, func(content string) string {
+			excerpt = content
+			return fmt.Sprintf("[%d] %s:%d-%d\n```%s\n%s\n```\n\n", i+1, s.displayPath(result), result.LineStart, result.LineEnd, result.Language, content)
+		})
+		if block == "" {
+			budget.dropRest(len(outcome.Results) - i - 1)
+			break
+		}
+		result.Content = excerpt
+		sources = append(sources, result)
+	}
+
+	answer, err := s.generator.Generate(ctx, rag.AnswerMessages(question, sources))
+	if err != nil {
+		s.logger.Warn("Answer generation failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Answer generation failed: %v. Use semantic_code_search and answer from its results.", err)), nil
+	}
+
+	var output strings.Builder
+	if outcome.Degraded {
+		output.WriteString(degradedBanner)
+	}
+	output.WriteString(lexicalNotice(outcome, minScore))
+	output.WriteString(fmt.Sprintf("# Answer: %s\n\n", question))
+	output.WriteString(answer + "\n\n")
+
+	output.WriteString("## Sources\n\n")
+	for i, source := range sources {
+		output.WriteString(fmt.Sprintf("%d. `%s:%d-%d` (Score: %.3f, %s)\n", i+1, source.FilePath, source.LineStart, source.LineEnd, source.Score, source.Language))
+	}
+	output.WriteString(fmt.Sprintf("\n_Generated by %s from %d excerpt(s); verify citations with `read_file_range`._\n", s.config.Generation.Model, len(sources)))
+	if budget.truncated > 0 || budget.dropped > 0 {
+		output.WriteString(fmt.Sprintf("\n✂️ **generation.context_tokens=%d:** %d excerpt(s) trimmed, %d lower-score excerpt(s) left out.\n",
+			budget.limit, budget.truncated, budget.dropped))
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
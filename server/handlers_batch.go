@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func (s *RAGServer) handleBatchSearch(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	rawQueries, ok := arguments["queries"].([]interface{})
+	if !ok || len(rawQueries) == 0 {
+		return mcp.NewToolResultError("queries must be a non-empty array of strings"), nil
+	}
+	if len(rawQueries) > maxBatchQueries {
+		return mcp.NewToolResultError(fmt.Sprintf("too many queries: %d (max %d)", len(rawQueries), maxBatchQueries)), nil
+	}
+
+	queries := make([]string, 0, len(rawQueries))
+	for _, q := range rawQueries {
+		query, ok := q.(string)
+		if !ok || strings.TrimSpace(query) == "" {
+			return mcp.NewToolResultError("queries must be non-empty strings"), nil
+		}
+		queries = append(queries, query)
+	}
+
+	limit := 3
+	if l, ok := arguments["limit"].(float64); ok {
+		limit = int(l)
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)), nil
+	}
+
+	minScore := s.config.MinScore
+	if ms, ok := arguments["min_score"].(float64); ok {
+		minScore = float32(ms)
+	}
+
+	compact := true
+	if c, ok := arguments["compact"].(bool); ok {
+		compact = c
+	}
+
+	tags := stringArgs(arguments["tags"])
+	root, err := s.rootArg(arguments)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Batch search", zap.Int("queries", len(queries)), zap.Int("limit", limit), zap.Strings("tags", tags))
+
+	batch := s.searchBatch(ctx, queries, limit, minScore, tags, root)
+
+	var output strings.Builder
+	for _, r := range batch {
+		if r.Outcome != nil && r.Outcome.Degraded {
+			output.WriteString(degradedBanner)
+			break
+		}
+	}
+	output.WriteString("# Batch Search Results\n\n")
+	output.WriteString(fmt.Sprintf("Queries: **%d**\n\n", len(queries)))
+
+	dedup, showRepeats := s.sessionDedup(), showRepeatsArg(arguments)
+	repeats := 0
+	for i, r := range batch {
+		output.WriteString(fmt.Sprintf("## %d. %s\n\n", i+1, r.Query))
+
+		if r.Err != nil {
+			output.WriteString(fmt.Sprintf("❌ Search failed: %v\n\n", r.Err))
+			continue
+		}
+		if len(r.Outcome.Results) == 0 {
+			output.WriteString("_No results._")
+			if r.Outcome.Stats != nil {
+				output.WriteString(" " + r.Outcome.Stats.emptyReason(minScore, 0))
+			}
+			output.WriteString("\n\n")
+			continue
+		}
+		output.WriteString(lexicalNotice(r.Outcome, minScore))
+		if r.Outcome.Stats != nil {
+			output.WriteString(r.Outcome.Stats.summary(minScore) + "\n")
+		}
+
+		for j, result := range r.Outcome.Results {
+			output.WriteString(fmt.Sprintf("%d. `%s:%d-%d` (Score: %.3f, %s)\n",
+				j+1, s.displayPath(result), result.LineStart, result.LineEnd, result.Score, result.Language))
+			if compact {
+				continue
+			}
+			if dedup && !showRepeats {
+				if previous, ok := s.shown.lookup(result, result.Content); ok {
+					output.WriteString(previous.reference())
+					repeats++
+					continue
+				}
+			}
+			output.WriteString("```" + result.Language + "\n")
+			output.WriteString(result.Content)
+			output.WriteString("\n```\n")
+			if dedup {
+				s.shown.record(result, result.Content, shownExcerpt{Tool: "batch_search", Query: r.Query, Result: j + 1})
+			}
+		}
+		output.WriteString("\n")
+	}
+
+	if repeats > 0 {
+		output.WriteString(showRepeatsHint)
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
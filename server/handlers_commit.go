@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// commitHistoryPerFiles is the number of past commits touching the changed files
+	commitHistoryPerFiles = 10
+
+	// commitHistoryRepo is the number of recent repo commits used to infer conventions
+	commitHistoryRepo = 30
+)
+
+func (s *RAGServer) handleCommitMessageContext(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	repoPath, _ := arguments["repo_path"].(string)
+	if repoPath == "" && len(s.config.CodePaths) > 0 {
+		repoPath = s.config.CodePaths[0]
+	}
+	if repoPath == "" {
+		return mcp.NewToolResultError("repo_path is required (no code_paths configured)"), nil
+	}
+
+	diff, _ := arguments["diff"].(string)
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Building commit message context", zap.String("repo", repoPath))
+
+	if strings.TrimSpace(diff) == "" {
+		var err error
+		diff, err = rag.GitStagedDiff(ctx, repoPath)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if strings.TrimSpace(diff) == "" {
+			return mcp.NewToolResultText("Nothing staged. Stage changes with `git add` or pass a `diff`."), nil
+		}
+	}
+
+	hunks := rag.ParseUnifiedDiff(diff, repoPath)
+
+	// Changed files (relative, as git log expects) and changed functions
+	seenFiles := make(map[string]bool)
+	seenSymbols := make(map[string]bool)
+	var files, symbols []string
+	added, removed := 0, 0
+	for _, hunk := range hunks {
+		path := hunk.FilePath
+		if path == "" {
+			path = hunk.OldPath
+		}
+		if rel, err := filepath.Rel(repoPath, path); err == nil && !seenFiles[rel] {
+			seenFiles[rel] = true
+			files = append(files, rel)
+		}
+		if hunk.FilePath != "" {
+			if sym := rag.EnclosingSymbol(hunk.FilePath, hunk.NewStart); sym != nil && !seenSymbols[sym.Name] {
+				seenSymbols[sym.Name] = true
+				symbols = append(symbols, sym.Name)
+			}
+		}
+		added += len(hunk.Added)
+		removed += len(hunk.Removed)
+	}
+
+	var output strings.Builder
+	output.WriteString("# Commit Message Context\n\n")
+	output.WriteString(fmt.Sprintf("**Change:** %d files, +%d/-%d lines\n", len(files), added, removed))
+	output.WriteString("**Files:** " + strings.Join(files, ", ") + "\n")
+	if len(symbols) > 0 {
+		output.WriteString("**Changed definitions:** " + strings.Join(symbols, ", ") + "\n")
+	}
+	output.WriteString("\n")
+
+	// Past changes to the same files show how they are usually described
+	fileCommits, err := rag.RecentCommits(ctx, repoPath, files, commitHistoryPerFiles)
+	if err != nil {
+		s.logger.Warn("Failed to read file history", zap.Error(err))
+	}
+	output.WriteString("## Past Commits Touching These Files\n\n")
+	if len(fileCommits) == 0 {
+		output.WriteString("_No history for these files (new files?)._\n\n")
+	}
+	for _, c := range fileCommits {
+		output.WriteString(fmt.Sprintf("- `%s` %s: %s\n", c.Hash, c.Date, c.Subject))
+	}
+	if len(fileCommits) > 0 {
+		output.WriteString("\n")
+	}
+
+	// Repository-wide conventions
+	repoCommits, err := rag.RecentCommits(ctx, repoPath, nil, commitHistoryRepo)
+	if err != nil {
+		s.logger.Warn("Failed to read repository history", zap.Error(err))
+	}
+	if len(repoCommits) > 0 {
+		output.WriteString(fmt.Sprintf("## Subject Conventions (last %d commits)\n\n", len(repoCommits)))
+		for _, conv := range rag.CommitConventions(repoCommits) {
+			output.WriteString(fmt.Sprintf("- `%s` × %d\n", conv.Pattern, conv.Count))
+		}
+		withBody := 0
+		for _, c := range repoCommits {
+			if c.Body != "" {
+				withBody++
+			}
+		}
+		output.WriteString(fmt.Sprintf("- %d/%d commits have a body\n\n", withBody, len(repoCommits)))
+
+		output.WriteString("## Recent Subjects\n\n")
+		for i, c := range repoCommits {
+			if i == commitHistoryPerFiles {
+				break
+			}
+			output.WriteString(fmt.Sprintf("- %s\n", c.Subject))
+		}
+		output.WriteString("\n")
+	}
+
+	output.WriteString("_Commit history is read from git directly; past commits are not part of the semantic index, so matches are by file, not by similarity._\n")
+
+	return mcp.NewToolResultText(output.String()), nil
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func (s *RAGServer) handleCancelIndexing(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if !s.incrementalIndexer.Cancel() {
+		return mcp.NewToolResultText("ℹ️ No background indexing is running. Directories indexed with `index_codebase` run as jobs: stop them with `cancel_job`."), nil
+	}
+
+	s.logger.Info("Background indexing cancelled")
+	return mcp.NewToolResultText(`🛑 **Background indexing cancelled**
+
+It stops after the current batch. Progress is saved: indexing the same path again resumes where it stopped.
+`), nil
+}
+
+func (s *RAGServer) handlePauseIndexing(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if !s.incrementalIndexer.Pause() {
+		return mcp.NewToolResultText("ℹ️ Background indexing is already paused. Call `resume_indexing` to continue."), nil
+	}
+
+	s.logger.Info("Background indexing paused")
+	if !s.incrementalIndexer.Running() {
+		return mcp.NewToolResultText("⏸️ **Indexing paused.** No run is active; the next one waits before its first batch until `resume_indexing`."), nil
+	}
+	return mcp.NewToolResultText(`⏸️ **Background indexing paused**
+
+It stops after the current batch and waits, leaving the embedding server idle. Call ` + "`resume_indexing`" + ` to continue.
+`), nil
+}
+
+func (s *RAGServer) handleResumeIndexing(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if !s.incrementalIndexer.Resume() {
+		return mcp.NewToolResultText("ℹ️ Background indexing is not paused."), nil
+	}
+
+	s.logger.Info("Background indexing resumed")
+	return mcp.NewToolResultText("▶️ **Background indexing resumed.** Follow it with `get_indexing_progress`."), nil
+}
+
+func (s *RAGServer) handleRetryFailedFiles(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	report, err := s.incrementalIndexer.RetryFailedFiles(ctx, s.config.CollectionName)
+	if errors.Is(err, rag.ErrIndexingRunning) {
+		return mcp.NewToolResultText("ℹ️ Background indexing is running: it retries its failed files before completing. Follow it with `get_indexing_progress`."), nil
+	}
+	if err != nil && report == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Retry failed: %v", err)), nil
+	}
+	if report.Retried == 0 {
+		return mcp.NewToolResultText("✅ No failed files to retry."), nil
+	}
+
+	var output strings.Builder
+	output.WriteString("# Retry Failed Files\n\n")
+	output.WriteString(fmt.Sprintf("**Retried:** %d\n", report.Retried))
+	output.WriteString(fmt.Sprintf("**Indexed:** %d\n", len(report.Recovered)))
+	output.WriteString(fmt.Sprintf("**Still failing:** %d\n\n", len(report.Failed)))
+	if len(report.Failed) > 0 {
+		output.WriteString("## Still Failing\n\n")
+		files := make([]string, 0, len(report.Failed))
+		for f := range report.Failed {
+			files = append(files, f)
+		}
+		sort.Strings(files)
+		for i, f := range files {
+			if i == pruneFileListLimit {
+				output.WriteString(fmt.Sprintf("- ... and %d more\n", len(files)-i))
+				break
+			}
+			output.WriteString(fmt.Sprintf("- `%s`: %s\n", f, report.Failed[f]))
+		}
+		output.WriteString("\n")
+	}
+	if err != nil {
+		output.WriteString(fmt.Sprintf("⚠️ Retry stopped early: %v\n", err))
+	}
+	return mcp.NewToolResultText(output.String()), nil
+}
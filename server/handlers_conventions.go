@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxConventionPatterns caps the number of recurring patterns listed
+	maxConventionPatterns = 10
+
+	// conventionExampleLines caps the length of each exemplar snippet
+	conventionExampleLines = 30
+)
+
+func (s *RAGServer) handleFindConventions(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	topic, ok := arguments["topic"].(string)
+	if !ok || strings.TrimSpace(topic) == "" {
+		return mcp.NewToolResultError("topic must be a non-empty string"), nil
+	}
+
+	limit := 40 // Chunks analyzed; more chunks give steadier frequencies
+	if l, ok := arguments["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	examples := 3
+	if e, ok := arguments["examples"].(float64); ok && e >= 0 {
+		examples = int(e)
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Finding conventions", zap.String("topic", topic), zap.Int("limit", limit), zap.Int("examples", examples))
+
+	outcome, err := s.search(ctx, searchRequest{Tool: "find_conventions", Query: topic, Limit: limit, MinScore: s.config.MinScore})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+	}
+	results := outcome.Results
+
+	if len(results) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No code found for topic: '%s'\n\nTry a broader description, e.g. \"error wrapping\" or \"HTTP handler\".", topic)), nil
+	}
+
+	patterns := rag.AnalyzeConventions(results, maxConventionPatterns)
+
+	var output strings.Builder
+	if outcome.Degraded {
+		output.WriteString(degradedBanner)
+	}
+	output.WriteString(fmt.Sprintf("# Conventions: %s\n\n", topic))
+	output.WriteString(fmt.Sprintf("Analyzed **%d chunks** matching the topic.\n\n", len(results)))
+
+	if len(patterns) == 0 {
+		output.WriteString("No line pattern recurs across files; showing the closest matches instead.\n\n")
+	} else {
+		output.WriteString("## Recurring Patterns\n\n")
+		output.WriteString("_Identifiers are shown as `_`, numbers as `N`, strings keep only their format verbs._\n\n")
+		for i, p := range patterns {
+			output.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, p.Pattern))
+			output.WriteString(fmt.Sprintf("   - %d uses in %d files, e.g. `%s:%d`: `%s`\n",
+				p.Count, p.Files, p.Example.FilePath, p.Example.Line, p.Example.Text))
+		}
+		output.WriteString("\n")
+	}
+
+	exemplars := rag.RepresentativeResults(results, patterns, examples)
+	if len(exemplars) == 0 && examples > 0 {
+		exemplars = results[:min(examples, len(results))]
+	}
+
+	if len(exemplars) > 0 {
+		output.WriteString("## Representative Examples\n\n")
+		for i, result := range exemplars {
+			content := result.Content
+			lines := strings.Split(content, "\n")
+			if len(lines) > conventionExampleLines {
+				content = strings.Join(lines[:conventionExampleLines], "\n")
+				content += fmt.Sprintf("\n... (%d more lines)", len(lines)-conventionExampleLines)
+			}
+			output.WriteString(fmt.Sprintf("### %d. %s:%d-%d\n\n", i+1, result.FilePath, result.LineStart, result.LineEnd))
+			output.WriteString("```" + result.Language + "\n" + content + "\n```\n\n")
+		}
+	}
+
+	output.WriteString("💡 Follow the most widespread patterns when writing new code for this topic.\n")
+
+	return mcp.NewToolResultText(output.String()), nil
+}
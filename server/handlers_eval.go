@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// Evaluate runs queries through the search pipeline with the current index
+// and settings and scores the first k results of each. Evaluation searches
+// are kept out of the search log.
+func (s *RAGServer) Evaluate(ctx context.Context, queries []rag.GoldenQuery, k int, minScore float32) *rag.Evaluation {
+	evaluations := make([]rag.QueryEvaluation, 0, len(queries))
+	for _, q := range queries {
+		outcome, err := s.search(ctx, searchRequest{
+			Tool:     "evaluate_index",
+			Query:    q.Query,
+			Limit:    k,
+			MinScore: minScore,
+			Hybrid:   s.config.HybridSearch,
+			Tags:     q.Tags,
+			Unlogged: true,
+		})
+		if err != nil {
+			failed := rag.EvaluateResults(q, nil, k)
+			failed.Error = err.Error()
+			evaluations = append(evaluations, failed)
+			continue
+		}
+		evaluations = append(evaluations, rag.EvaluateResults(q, outcome.Results, k))
+	}
+
+	evaluation := rag.Summarize(k, evaluations)
+	s.logger.Info("Evaluated index",
+		zap.Int("queries", len(queries)),
+		zap.Int("k", k),
+		zap.Float64("recall", evaluation.Recall),
+		zap.Float64("mrr", evaluation.MRR),
+		zap.Float64("ndcg", evaluation.NDCG),
+	)
+	return evaluation
+}
+
+func (s *RAGServer) handleEvaluateIndex(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	path := s.config.EvalQueries
+	if p, ok := arguments["queries_file"].(string); ok && strings.TrimSpace(p) != "" {
+		path = p
+	}
+	if path == "" {
+		path = rag.EvalQueriesFileName
+	}
+
+	k := s.config.TopK
+	if l, ok := arguments["limit"].(float64); ok && l >= 1 {
+		k = int(l)
+	}
+	minScore := s.config.MinScore
+	if m, ok := arguments["min_score"].(float64); ok {
+		minScore = float32(m)
+	}
+
+	queries, err := rag.LoadGoldenQueries(path, filepath.Dir(path))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to load evaluation queries: %v", err)), nil
+	}
+	if len(queries) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("No evaluation queries in %s", path)), nil
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	evaluation := s.Evaluate(ctx, queries, k, minScore)
+	return mcp.NewToolResultText(formatEvaluation(evaluation, path, minScore)), nil
+}
+
+// formatEvaluation renders an evaluation as markdown
+func formatEvaluation(evaluation *rag.Evaluation, path string, minScore float32) string {
+	var output strings.Builder
+	output.WriteString("# Index Evaluation\n\n")
+	output.WriteString(fmt.Sprintf("Queries: **%d** from `%s`\n", len(evaluation.Queries), path))
+	output.WriteString(fmt.Sprintf("Settings: top %d results, min_score %.2f\n\n", evaluation.K, minScore))
+
+	output.WriteString("| Metric | Score |\n")
+	output.WriteString("|--------|-------|\n")
+	output.WriteString(fmt.Sprintf("| Recall@%d | %.3f |\n", evaluation.K, evaluation.Recall))
+	output.WriteString(fmt.Sprintf("| MRR | %.3f |\n", evaluation.MRR))
+	output.WriteString(fmt.Sprintf("| nDCG@%d | %.3f |\n\n", evaluation.K, evaluation.NDCG))
+
+	output.WriteString("## Queries\n\n")
+	output.WriteString("| Query | Recall | RR | nDCG |\n")
+	output.WriteString("|-------|--------|----|------|\n")
+	for _, q := range evaluation.Queries {
+		output.WriteString(fmt.Sprintf("| %s | %.2f | %.2f | %.2f |\n", q.Query, q.Recall, q.ReciprocalRank, q.NDCG))
+	}
+
+	var misses strings.Builder
+	for _, q := range evaluation.Queries {
+		switch {
+		case q.Error != "":
+			misses.WriteString(fmt.Sprintf("- **%s**: ❌ %s\n", q.Query, q.Error))
+		case len(q.Missed) > 0:
+			misses.WriteString(fmt.Sprintf("- **%s**: %s\n", q.Query, strings.Join(q.Missed, ", ")))
+		}
+	}
+	if misses.Len() > 0 {
+		output.WriteString("\n## Missed\n\n")
+		output.WriteString(misses.String())
+		output.WriteString("\n💡 Try `tune_threshold` on missed queries, or compare settings by re-running the evaluation after changing them.\n")
+	}
+	return output.String()
+}
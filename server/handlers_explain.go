@@ -0,0 +1,252 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultExplainTokens caps the response when max_tokens is not given
+	defaultExplainTokens = 8000
+
+	// explainRelatedResults is the number of related chunks from other files
+	explainRelatedResults = 5
+
+	// explainRelatedShare is the share of the budget kept for related context
+	explainRelatedShare = 3
+
+	// explainQueryTokens caps the code used as the related-context query
+	explainQueryTokens = 512
+
+	// explainOutlineSymbols caps the symbols listed in the file outline
+	explainOutlineSymbols = 25
+)
+
+func (s *RAGServer) handleExplainCode(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	filePath, ok := arguments["file_path"].(string)
+	if !ok || filePath == "" {
+		return mcp.NewToolResultError("file_path must be a string"), nil
+	}
+
+	focus := ""
+	if f, ok := arguments["focus"].(string); ok {
+		focus = strings.TrimSpace(f)
+	}
+
+	symbol := ""
+	if sym, ok := arguments["symbol"].(string); ok {
+		symbol = strings.TrimSpace(sym)
+	}
+
+	startLine, endLine := 0, 0 // 0 = unset
+	if sl, ok := arguments["start_line"].(float64); ok {
+		startLine = int(sl)
+	}
+	if el, ok := arguments["end_line"].(float64); ok {
+		endLine = int(el)
+	}
+	if symbol != "" && (startLine != 0 || endLine != 0) {
+		return mcp.NewToolResultError("Pass either symbol or start_line/end_line, not both"), nil
+	}
+	if startLine < 0 || endLine < 0 || (endLine != 0 && endLine < startLine) {
+		return mcp.NewToolResultError("start_line and end_line must be >= 1, with end_line >= start_line"), nil
+	}
+
+	maxTokens := defaultExplainTokens
+	if mt, ok := arguments["max_tokens"].(float64); ok && mt >= 1 {
+		maxTokens = int(mt)
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Explaining code",
+		zap.String("file", filePath),
+		zap.String("focus", focus),
+		zap.String("symbol", symbol),
+		zap.Int("start_line", startLine),
+		zap.Int("end_line", endLine),
+	)
+
+	chunks, symbols, err := rag.FileChunks(ctx, s.vectorDB, s.config.CollectionName, filePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read indexed chunks: %v", err)), nil
+	}
+	if len(chunks) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is not indexed: index it with `index_codebase`, or read it with `read_file_range`.", filePath)), nil
+	}
+	fileEnd := chunks[len(chunks)-1].LineEnd
+
+	// The focus range: a symbol's definition, the requested lines, or the whole file
+	rangeStart, rangeEnd := 1, fileEnd
+	scope := fmt.Sprintf("whole file (lines 1-%d)", fileEnd)
+	switch {
+	case symbol != "":
+		start, end, ok := symbolRange(symbols, symbol, fileEnd)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("No symbol %q is defined in %s. Defined symbols: %s", symbol, filePath, symbolNames(symbols))), nil
+		}
+		rangeStart, rangeEnd = start, end
+		scope = fmt.Sprintf("symbol `%s` (lines %d-%d)", symbol, start, end)
+	case startLine != 0 || endLine != 0:
+		if startLine != 0 {
+			rangeStart = startLine
+		}
+		if endLine != 0 {
+			rangeEnd = endLine
+		}
+		scope = fmt.Sprintf("lines %d-%d", rangeStart, rangeEnd)
+	}
+
+	var focused []rag.SearchResult
+	for _, chunk := range chunks {
+		if clipped, ok := clipChunk(chunk, rangeStart, rangeEnd); ok {
+			focused = append(focused, clipped)
+		}
+	}
+	if len(focused) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Lines %d-%d are outside the indexed content of %s (lines 1-%d)", rangeStart, rangeEnd, filePath, fileEnd)), nil
+	}
+
+	// Related context: code of other files similar to the focused code
+	var code strings.Builder
+	for _, chunk := range focused {
+		code.WriteString(chunk.Content + "\n")
+	}
+	query, _ := rag.TruncateToTokens(code.String(), explainQueryTokens)
+	if focus != "" {
+		query = focus + "\n" + query
+	}
+	outcome, err := s.search(ctx, searchRequest{Tool: "explain_code_with_context", Query: query, Limit: explainRelatedResults * 3, MinScore: s.config.MinScore})
+	if err != nil {
+		s.logger.Warn("Related context search failed", zap.Error(err))
+		outcome = &searchOutcome{}
+	}
+	var related []rag.SearchResult
+	for _, result := range outcome.Results {
+		if result.FilePath != filePath && len(related) < explainRelatedResults {
+			related = append(related, result)
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# Code Explanation: %s\n\n", filePath))
+	output.WriteString(fmt.Sprintf("Focus: **%s**\n", scope))
+	if focus != "" {
+		output.WriteString(fmt.Sprintf("Aspect: %s\n", focus))
+	}
+	output.WriteString("\n")
+
+	if len(symbols) > 0 {
+		entries := make([]string, 0, explainOutlineSymbols)
+		for i, sym := range symbols {
+			if i == explainOutlineSymbols {
+				entries = append(entries, fmt.Sprintf("... %d more", len(symbols)-i))
+				break
+			}
+			entries = append(entries, fmt.Sprintf("`%s` (L%d)", sym.Name, sym.Line))
+		}
+		output.WriteString(fmt.Sprintf("Outline: %s\n\n", strings.Join(entries, ", ")))
+	}
+
+	// The focused code comes first; related context keeps a share of the budget
+	mainTokens := maxTokens - rag.CountTokens(output.String())
+	if len(related) > 0 {
+		mainTokens -= maxTokens / explainRelatedShare
+	}
+	mainBudget := newTokenBudget(max(mainTokens, maxTokens/2))
+
+	output.WriteString("## Main Code\n\n")
+	for i, chunk := range focused {
+		block := mainBudget.fitBlock(chunk.Content, func(content string) string {
+			return fmt.Sprintf("`%s:%d-%d`\n\n", filePath, chunk.LineStart, chunk.LineEnd) +
+				"```" + chunk.Language + "\n" + content + "\n```\n\n"
+		})
+		if block == "" {
+			mainBudget.dropRest(len(focused) - i - 1)
+			break
+		}
+		output.WriteString(block)
+	}
+	if mainBudget.truncated > 0 || mainBudget.dropped > 0 {
+		output.WriteString(fmt.Sprintf("✂️ **max_tokens=%d:** %d chunk(s) trimmed, %d left out. Narrow the focus with `symbol` or `start_line`/`end_line`, or read the rest with `read_file_range`.\n\n",
+			maxTokens, mainBudget.truncated, mainBudget.dropped))
+	}
+
+	if len(related) > 0 {
+		budget := newTokenBudget(max(maxTokens-rag.CountTokens(output.String()), minBudgetExcerptTokens))
+		output.WriteString("## Related Context\n\n")
+		if outcome.Degraded {
+			output.WriteString(degradedBanner)
+		}
+		for i, result := range related {
+			block := budget.fitBlock(result.Content, func(content string) string {
+				return fmt.Sprintf("### %d. %s:%d-%d (score %.3f)\n\n", i+1, result.FilePath, result.LineStart, result.LineEnd, result.Score) +
+					"```" + result.Language + "\n" + content + "\n```\n\n"
+			})
+			if block == "" {
+				budget.dropRest(len(related) - i - 1)
+				break
+			}
+			output.WriteString(block)
+		}
+		output.WriteString(budget.notice())
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// symbolRange returns the lines of the definition of name: from its line to
+// the line before the next symbol of the file, or to fileEnd
+func symbolRange(symbols []rag.Symbol, name string, fileEnd int) (int, int, bool) {
+	for i, sym := range symbols {
+		if sym.Name != name {
+			continue
+		}
+		end := fileEnd
+		for _, next := range symbols[i+1:] {
+			if next.Line > sym.Line {
+				end = next.Line - 1
+				break
+			}
+		}
+		return sym.Line, end, true
+	}
+	return 0, 0, false
+}
+
+// symbolNames lists the names of symbols for an error message
+func symbolNames(symbols []rag.Symbol) string {
+	if len(symbols) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		names = append(names, sym.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// clipChunk keeps the lines of chunk between start and end, if any
+func clipChunk(chunk rag.SearchResult, start, end int) (rag.SearchResult, bool) {
+	if chunk.LineEnd < start || chunk.LineStart > end {
+		return chunk, false
+	}
+	lines := strings.Split(chunk.Content, "\n")
+	from := max(start-chunk.LineStart, 0)
+	to := min(end-chunk.LineStart+1, len(lines))
+	for to > from && strings.TrimSpace(lines[to-1]) == "" {
+		to-- // Blank lines before the next definition
+	}
+	if from >= to {
+		return chunk, false
+	}
+	chunk.Content = strings.Join(lines[from:to], "\n")
+	chunk.LineStart += from
+	chunk.LineEnd = chunk.LineStart + (to - from) - 1
+	return chunk, true
+}
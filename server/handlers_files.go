@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// maxReadLines caps the number of lines read_file_range returns in one call
+const maxReadLines = 500
+
+// resolveAllowedPath resolves symlinks in filePath and checks that it lives
+// under one of the allowed read roots (allowed_read_paths, or code_paths) and
+// is not on the sensitive file list the indexer skips.
+func (s *RAGServer) resolveAllowedPath(filePath string) (string, error) {
+	roots := s.config.AllowedReadPaths
+	if len(roots) == 0 {
+		roots = s.config.CodePaths
+	}
+	if len(roots) == 0 {
+		return "", fmt.Errorf("file access is disabled: configure allowed_read_paths or code_paths")
+	}
+
+	if !filepath.IsAbs(filePath) {
+		return "", fmt.Errorf("path must be absolute: %s", filePath)
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(filePath))
+	if err != nil {
+		return "", err
+	}
+	if s.indexer.IsSensitive(filePath) || s.indexer.IsSensitive(resolved) {
+		return "", fmt.Errorf("sensitive files cannot be read: %s", filePath)
+	}
+
+	for _, root := range roots {
+		resolvedRoot, err := filepath.EvalSymlinks(filepath.Clean(root))
+		if err != nil {
+			continue
+		}
+		if resolved == resolvedRoot || strings.HasPrefix(resolved, resolvedRoot+string(os.PathSeparator)) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("path is outside the allowed directories: %s", filePath)
+}
+
+func (s *RAGServer) handleReadFileRange(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	filePath, ok := arguments["file_path"].(string)
+	if !ok || filePath == "" {
+		return mcp.NewToolResultError("file_path must be a string"), nil
+	}
+
+	startLine := 1
+	if sl, ok := arguments["start_line"].(float64); ok {
+		startLine = int(sl)
+	}
+
+	endLine := startLine + 99
+	if el, ok := arguments["end_line"].(float64); ok {
+		endLine = int(el)
+	}
+
+	if startLine < 1 {
+		return mcp.NewToolResultError("start_line must be >= 1"), nil
+	}
+	if endLine < startLine {
+		return mcp.NewToolResultError("end_line must be >= start_line"), nil
+	}
+	if endLine-startLine+1 > maxReadLines {
+		return mcp.NewToolResultError(fmt.Sprintf("Cannot read more than %d lines at once", maxReadLines)), nil
+	}
+
+	resolved, err := s.resolveAllowedPath(filePath)
+	if err != nil {
+		s.logger.Warn("Rejected file read", zap.String("file", filePath), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Access denied: %v", err)), nil
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read file: %v", err)), nil
+	}
+	if info.IsDir() {
+		return mcp.NewToolResultError(fmt.Sprintf("Path is a directory: %s", filePath)), nil
+	}
+
+	s.logger.Info("Reading file range",
+		zap.String("file", resolved),
+		zap.Int("start_line", startLine),
+		zap.Int("end_line", endLine),
+	)
+
+	lines, totalLines, err := s.readLines(resolved, startLine, endLine)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read file: %v", err)), nil
+	}
+
+	lastLine := startLine + len(lines) - 1
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# %s\n\n", filePath))
+	output.WriteString(fmt.Sprintf("**Lines:** %d-%d of %d\n\n", startLine, lastLine, totalLines))
+	output.WriteString("```" + rag.DetectFileLanguage(resolved) + "\n")
+	width := len(fmt.Sprintf("%d", lastLine))
+	for i, line := range lines {
+		output.WriteString(fmt.Sprintf("%*d  %s\n", width, startLine+i, line))
+	}
+	output.WriteString("```\n")
+
+	if lastLine < totalLines {
+		output.WriteString(fmt.Sprintf("\n💡 %d more lines. Continue with `start_line: %d`.\n", totalLines-lastLine, lastLine+1))
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
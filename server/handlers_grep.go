@@ -0,0 +1,163 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// grepLinesPerResult caps the matching lines quoted under one semantic match
+const grepLinesPerResult = 3
+
+func (s *RAGServer) handleGrepAndSemantic(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	query, ok := arguments["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("query must be a non-empty string"), nil
+	}
+
+	// Without a pattern, the query itself is matched literally
+	expr := regexp.QuoteMeta(query)
+	if p, ok := arguments["pattern"].(string); ok && p != "" {
+		expr = p
+	}
+	if caseSensitive, _ := arguments["case_sensitive"].(bool); !caseSensitive {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid pattern: %v", err)), nil
+	}
+
+	limit := 10
+	if l, ok := arguments["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+
+	minScore := s.config.MinScore
+	if ms, ok := arguments["min_score"].(float64); ok {
+		minScore = float32(ms)
+	}
+
+	tags := stringArgs(arguments["tags"])
+	root, err := s.rootArg(arguments)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Grep and semantic search",
+		zap.String("query", query),
+		zap.String("pattern", pattern.String()),
+		zap.Int("limit", limit),
+		zap.Strings("tags", tags),
+	)
+
+	var filter map[string]interface{}
+	if len(tags) > 0 {
+		filter = map[string]interface{}{"tags": tags}
+	}
+	if root != "" {
+		if filter == nil {
+			filter = make(map[string]interface{})
+		}
+		filter["root"] = root
+	}
+	exact, total, err := rag.GrepIndex(ctx, s.vectorDB, s.config.CollectionName, pattern, filter, 0)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Pattern scan failed: %v", err)), nil
+	}
+
+	outcome, err := s.search(ctx, searchRequest{Tool: "grep_and_semantic", Query: query, Limit: limit, MinScore: minScore, Tags: tags, Root: root})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+	}
+
+	// Exact lines inside a semantic match are reported with it
+	var both, semanticOnly []rag.SearchResult
+	linesIn := make(map[string][]rag.GrepMatch)
+	covered := make(map[rag.GrepMatch]bool)
+	for _, result := range outcome.Results {
+		for _, match := range exact {
+			if match.FilePath == result.FilePath && match.Line >= result.LineStart && match.Line <= result.LineEnd {
+				linesIn[result.ID] = append(linesIn[result.ID], match)
+				covered[match] = true
+			}
+		}
+		if len(linesIn[result.ID]) > 0 {
+			both = append(both, result)
+		} else {
+			semanticOnly = append(semanticOnly, result)
+		}
+	}
+	var exactOnly []rag.GrepMatch
+	for _, match := range exact {
+		if !covered[match] {
+			exactOnly = append(exactOnly, match)
+		}
+	}
+
+	var output strings.Builder
+	if outcome.Degraded {
+		output.WriteString(degradedBanner)
+	}
+	output.WriteString(lexicalNotice(outcome, minScore))
+	output.WriteString("# Grep + Semantic Results\n\n")
+	output.WriteString(fmt.Sprintf("Query: **%s**\n", query))
+	output.WriteString(fmt.Sprintf("Pattern: `%s`\n", pattern.String()))
+	output.WriteString(fmt.Sprintf("Found: **%d exact lines** in %d files, **%d semantic matches** (%d containing exact lines)\n\n",
+		total, countFiles(exact), len(outcome.Results), len(both)))
+
+	if len(both) == 0 && len(exactOnly) == 0 && len(semanticOnly) == 0 {
+		output.WriteString("No exact or semantic matches. Try a broader pattern, `case_sensitive: false`, or `tune_threshold` for the query.\n")
+		return mcp.NewToolResultText(output.String()), nil
+	}
+
+	n := 0
+	for _, result := range both {
+		n++
+		lines := linesIn[result.ID]
+		output.WriteString(fmt.Sprintf("%d. 🎯 **exact + semantic** `%s:%d-%d` (Score: %.3f, %s): %d matching lines\n",
+			n, s.displayPath(result), result.LineStart, result.LineEnd, result.Score, result.Language, len(lines)))
+		for i, line := range lines {
+			if i == grepLinesPerResult {
+				output.WriteString(fmt.Sprintf("   - ... %d more\n", len(lines)-i))
+				break
+			}
+			output.WriteString(fmt.Sprintf("   - L%d: `%s`\n", line.Line, line.Text))
+		}
+	}
+
+	shown := min(len(exactOnly), limit)
+	for _, match := range exactOnly[:shown] {
+		n++
+		output.WriteString(fmt.Sprintf("%d. 🔎 **exact** `%s:%d`: `%s`\n", n, match.FilePath, match.Line, match.Text))
+	}
+
+	for _, result := range semanticOnly {
+		n++
+		output.WriteString(fmt.Sprintf("%d. 🧠 **semantic** `%s:%d-%d` (Score: %.3f, %s)\n",
+			n, s.displayPath(result), result.LineStart, result.LineEnd, result.Score, result.Language))
+	}
+
+	if hidden := len(exactOnly) - shown; hidden > 0 {
+		output.WriteString(fmt.Sprintf("\n➡️ %d more exact lines not shown: raise `limit` or narrow the `pattern`.\n", hidden))
+	}
+	output.WriteString("\n💡 Use `read_file_range` to read any of these locations.\n")
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// countFiles returns the number of distinct files of matches
+func countFiles(matches []rag.GrepMatch) int {
+	files := make(map[string]bool)
+	for _, match := range matches {
+		files[match.FilePath] = true
+	}
+	return len(files)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func (s *RAGServer) handleGetJobStatus(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	jobID, _ := arguments["job_id"].(string)
+	jobID = strings.TrimSpace(jobID)
+
+	if jobID == "" {
+		jobs := s.jobs.list()
+		if len(jobs) == 0 {
+			return mcp.NewToolResultText("ℹ️ No jobs have been started."), nil
+		}
+
+		var output strings.Builder
+		output.WriteString("# Jobs\n\n")
+		for _, j := range jobs {
+			output.WriteString(fmt.Sprintf("- `%s`: %s %s — %s%s, started %s\n",
+				j.ID,
+				j.Kind,
+				j.Target,
+				j.Status,
+				jobProgress(j),
+				j.StartedAt.Format("2006-01-02 15:04:05"),
+			))
+		}
+		output.WriteString("\n💡 Call `get_job_status` with a `job_id` for details.\n")
+		return mcp.NewToolResultText(output.String()), nil
+	}
+
+	j, ok := s.jobs.get(jobID)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("Unknown job: %s", jobID)), nil
+	}
+
+	return mcp.NewToolResultText(formatJob(j)), nil
+}
+
+func (s *RAGServer) handleCancelJob(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	jobID, _ := arguments["job_id"].(string)
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return mcp.NewToolResultError("job_id is required"), nil
+	}
+
+	j, err := s.jobs.cancel(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Cancel failed: %v", err)), nil
+	}
+
+	s.logger.Info("Cancelling job", zap.String("job_id", j.ID), zap.String("kind", j.Kind), zap.String("target", j.Target))
+
+	return mcp.NewToolResultText(fmt.Sprintf(`🛑 **Cancelling job** `+"`%s`"+`: %s %s
+
+Chunks indexed before the cancellation stay searchable. Run `+"`index_codebase`"+` again to index the rest.
+`, j.ID, j.Kind, j.Target)), nil
+}
+
+// formatJob renders the status of one job
+func formatJob(j job) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# Job `%s`\n\n", j.ID))
+	output.WriteString(fmt.Sprintf("**Operation:** %s %s\n", j.Kind, j.Target))
+	output.WriteString(fmt.Sprintf("**Status:** %s%s\n", j.Status, jobProgress(j)))
+	output.WriteString(fmt.Sprintf("**Started:** %s\n", j.StartedAt.Format("2006-01-02 15:04:05")))
+
+	if j.FinishedAt.IsZero() {
+		output.WriteString(fmt.Sprintf("**Running for:** %s\n", time.Since(j.StartedAt).Round(time.Second)))
+	} else {
+		output.WriteString(fmt.Sprintf("**Finished:** %s (took %s)\n",
+			j.FinishedAt.Format("2006-01-02 15:04:05"),
+			j.FinishedAt.Sub(j.StartedAt).Round(time.Second),
+		))
+	}
+	if j.Error != "" {
+		output.WriteString(fmt.Sprintf("**Error:** %s\n", j.Error))
+	}
+
+	return output.String()
+}
+
+// jobProgress describes how far a job got, e.g. " (300/1200 chunks, 25.0%)"
+func jobProgress(j job) string {
+	if j.ChunksTotal == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d/%d chunks, %.1f%%)", j.ChunksDone, j.ChunksTotal,
+		float64(j.ChunksDone)*100/float64(j.ChunksTotal))
+}
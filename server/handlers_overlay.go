@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// overlayFileListLimit caps the files listed per category in overlay output
+const overlayFileListLimit = 20
+
+func (s *RAGServer) handleRefreshOverlay(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	repoPaths := s.config.CodePaths
+	if p, ok := arguments["repo_path"].(string); ok && p != "" {
+		repoPaths = []string{p}
+	}
+	if len(repoPaths) == 0 {
+		return mcp.NewToolResultError("repo_path is required (no code_paths configured)"), nil
+	}
+
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	s.logger.Info("Refreshing overlay", zap.Strings("repos", repoPaths))
+
+	var output strings.Builder
+	output.WriteString("# Uncommitted Changes Overlay\n\n")
+	output.WriteString(fmt.Sprintf("Collection: `%s`\n\n", rag.OverlayCollection(s.config.CollectionName)))
+
+	writeList := func(title string, files []string) {
+		if len(files) == 0 {
+			return
+		}
+		output.WriteString(fmt.Sprintf("**%s:**\n", title))
+		for i, f := range files {
+			if i == overlayFileListLimit {
+				output.WriteString(fmt.Sprintf("- ... and %d more\n", len(files)-i))
+				break
+			}
+			output.WriteString(fmt.Sprintf("- `%s`\n", f))
+		}
+		output.WriteString("\n")
+	}
+
+	for _, repoPath := range repoPaths {
+		output.WriteString(fmt.Sprintf("## %s\n\n", repoPath))
+
+		report, err := s.indexer.RefreshOverlay(ctx, repoPath, s.config.CollectionName, s.config.FileExtensions, s.embedder.Dimension())
+		if err != nil && report == nil {
+			output.WriteString(fmt.Sprintf("❌ %v\n\n", err))
+			continue
+		}
+		s.overlayActive.Store(true)
+
+		output.WriteString(fmt.Sprintf("- Indexed: %d files\n", len(report.Indexed)))
+		output.WriteString(fmt.Sprintf("- Already up to date: %d files\n", report.Unchanged))
+		output.WriteString(fmt.Sprintf("- No longer dirty (dropped): %d files\n", len(report.Removed)))
+		output.WriteString(fmt.Sprintf("- Deleted in working tree: %d files\n\n", len(report.Deleted)))
+		writeList("Indexed", report.Indexed)
+		writeList("Deleted", report.Deleted)
+		if err != nil {
+			output.WriteString(fmt.Sprintf("⚠️ Refresh stopped early: %v\n\n", err))
+		}
+	}
+
+	if s.config.OverlayInterval > 0 {
+		output.WriteString(fmt.Sprintf("💡 The overlay also refreshes every %s.\n", s.config.OverlayInterval))
+	} else {
+		output.WriteString("💡 Set `overlay_interval` (e.g. \"30s\") to refresh the overlay in the background.\n")
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
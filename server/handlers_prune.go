@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// pruneFileListLimit caps the files listed per category in prune output
+const pruneFileListLimit = 20
+
+func (s *RAGServer) handlePruneIndex(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	token, _ := arguments["confirmation_token"].(string)
+	dryRun := token == ""
+	if !dryRun {
+		if err := s.confirmations.consume(token, "prune_index", s.config.CollectionName); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Confirmation failed: %v", err)), nil
+		}
+	}
+
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	s.logger.Info("Pruning index", zap.Bool("dry_run", dryRun))
+
+	report, err := s.indexer.PruneIndex(ctx, s.config.CollectionName, s.trash, dryRun)
+	if err != nil && report == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Prune failed: %v", err)), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("**Files checked:** %d\n", report.FilesChecked))
+	output.WriteString(fmt.Sprintf("**Deleted/renamed (chunks moved to trash):** %d\n", len(report.Missing)))
+	output.WriteString(fmt.Sprintf("**Modified since indexing (re-indexed):** %d\n\n", len(report.Modified)))
+
+	writeList := func(title string, files []string) {
+		if len(files) == 0 {
+			return
+		}
+		output.WriteString(fmt.Sprintf("## %s\n\n", title))
+		for i, f := range files {
+			if i == pruneFileListLimit {
+				output.WriteString(fmt.Sprintf("- ... and %d more\n", len(files)-i))
+				break
+			}
+			output.WriteString(fmt.Sprintf("- `%s`\n", f))
+		}
+		output.WriteString("\n")
+	}
+	writeList("Deleted or Renamed", report.Missing)
+	writeList("Modified", report.Modified)
+	writeList("Skipped Code Paths (missing or empty on disk)", report.UnavailableRoots)
+
+	if len(report.Failed) > 0 {
+		output.WriteString("## Errors\n\n")
+		for f, msg := range report.Failed {
+			output.WriteString(fmt.Sprintf("- `%s`: %s\n", f, msg))
+		}
+		output.WriteString("\n")
+	}
+	if err != nil {
+		output.WriteString(fmt.Sprintf("⚠️ Prune stopped early: %v\n", err))
+	}
+
+	if dryRun {
+		if len(report.Missing) == 0 && len(report.Modified) == 0 {
+			return mcp.NewToolResultText("# Prune Index\n\n" + output.String() + "ℹ️ Nothing to prune.\n"), nil
+		}
+		return mcp.NewToolResultText(formatDryRun("prune_index", output.String(), s.confirmations.issue("prune_index", s.config.CollectionName))), nil
+	}
+	if report.Trashed != nil {
+		return mcp.NewToolResultText("# Prune Index\n\n" + output.String() + formatTrashEntry("🗑️ **Chunks of deleted files removed**", report.Trashed)), nil
+	}
+	return mcp.NewToolResultText("# Prune Index\n\n" + output.String()), nil
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func (s *RAGServer) handleReindexAll(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	paths := s.config.CodePaths
+	if rawPaths, ok := arguments["paths"].([]interface{}); ok && len(rawPaths) > 0 {
+		paths = make([]string, 0, len(rawPaths))
+		for _, p := range rawPaths {
+			if path, ok := p.(string); ok && path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return mcp.NewToolResultError("no paths to index: pass paths or configure code_paths"), nil
+	}
+	if err := checkReindexPaths(paths); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	recreate := false
+	if r, ok := arguments["recreate_collection"].(bool); ok {
+		recreate = r
+	}
+
+	// Dropping the collection cannot be undone: require a confirmation round trip
+	if recreate {
+		token, _ := arguments["confirmation_token"].(string)
+		if token == "" {
+			summary := fmt.Sprintf("**Collection:** %s (dropped and recreated, all chunks and trash contents permanently lost)\n**Paths re-indexed:** %s\n",
+				s.config.CollectionName, strings.Join(paths, ", "))
+			return mcp.NewToolResultText(formatDryRun("reindex_all", summary, s.confirmations.issue("reindex_all", s.config.CollectionName))), nil
+		}
+		if err := s.confirmations.consume(token, "reindex_all", s.config.CollectionName); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Confirmation failed: %v", err)), nil
+		}
+	}
+
+	if err := s.startReindexAll(paths, recreate); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var output strings.Builder
+	output.WriteString("🔄 **Full re-index started in background**\n\n")
+	if recreate {
+		output.WriteString(fmt.Sprintf("**Collection:** %s (recreated)\n", s.config.CollectionName))
+	}
+	output.WriteString("**Paths:**\n")
+	for _, path := range paths {
+		output.WriteString(fmt.Sprintf("- %s\n", path))
+	}
+	output.WriteString("\n💡 Use `get_indexing_progress` to follow progress.\n")
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+func (s *RAGServer) handleReindexChanged(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	paths := stringArgs(arguments["paths"])
+	if len(paths) == 0 {
+		paths = s.config.CodePaths
+	}
+	if len(paths) == 0 {
+		return mcp.NewToolResultError("no paths to re-index: pass paths or configure code_paths"), nil
+	}
+
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	var output strings.Builder
+	output.WriteString("# Re-index Changed Files\n\n")
+	failed := 0
+	for _, path := range paths {
+		report, err := s.incrementalIndexer.ReindexChanged(ctx, path, s.config.FileExtensions, s.config.CollectionName)
+		switch {
+		case errors.Is(err, rag.ErrNoIndexedCommit):
+			failed++
+			output.WriteString(fmt.Sprintf("## %s\n\n⚠️ No indexed commit recorded: run `reindex_all` once to set a base.\n\n", path))
+			continue
+		case err != nil:
+			failed++
+			s.logger.Error("Re-indexing changed files failed", zap.String("path", path), zap.Error(err))
+			output.WriteString(fmt.Sprintf("## %s\n\n❌ %v\n\n", path, err))
+			continue
+		}
+
+		output.WriteString(fmt.Sprintf("## %s\n\n**Commits:** `%s..%s`\n", path, rag.ShortCommit(report.From), rag.ShortCommit(report.To)))
+		if len(report.Files) == 0 {
+			output.WriteString("\n✅ Up to date, nothing to re-index.\n\n")
+			continue
+		}
+		output.WriteString(fmt.Sprintf("**Files re-indexed or removed:** %d\n\n", len(report.Files)))
+		for _, file := range report.Files {
+			output.WriteString(fmt.Sprintf("- %s\n", file))
+		}
+		output.WriteString("\n")
+	}
+
+	if failed == len(paths) {
+		return mcp.NewToolResultError(output.String()), nil
+	}
+	return mcp.NewToolResultText(output.String()), nil
+}
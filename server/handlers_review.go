@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxReviewHunks caps the hunks analyzed in one review call
+	maxReviewHunks = 20
+
+	// reviewCallersPerSymbol is the number of call sites listed per changed function
+	reviewCallersPerSymbol = 8
+
+	// reviewSimilarPerHunk is the number of similar implementations listed per hunk
+	reviewSimilarPerHunk = 3
+)
+
+func (s *RAGServer) handleReviewDiff(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	diff, _ := arguments["diff"].(string)
+	commitRange, _ := arguments["commit_range"].(string)
+	if strings.TrimSpace(diff) == "" && strings.TrimSpace(commitRange) == "" {
+		return mcp.NewToolResultError("either diff or commit_range is required"), nil
+	}
+
+	repoPath, _ := arguments["repo_path"].(string)
+	if repoPath == "" && len(s.config.CodePaths) > 0 {
+		repoPath = s.config.CodePaths[0]
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Building review context", zap.String("commit_range", commitRange), zap.Int("diff_length", len(diff)))
+
+	if diff == "" {
+		var err error
+		diff, err = rag.GitDiff(ctx, repoPath, commitRange)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	hunks := rag.ParseUnifiedDiff(diff, repoPath)
+	if len(hunks) == 0 {
+		return mcp.NewToolResultText("No hunks found in the diff."), nil
+	}
+
+	var output strings.Builder
+	output.WriteString("# Review Context\n\n")
+	output.WriteString(fmt.Sprintf("**%d hunks** in the diff. Each hunk lists the changed functions, their callers, similar implementations elsewhere, and related docs.\n\n", len(hunks)))
+	if len(hunks) > maxReviewHunks {
+		output.WriteString(fmt.Sprintf("_Only the first %d hunks are analyzed._\n\n", maxReviewHunks))
+		hunks = hunks[:maxReviewHunks]
+	}
+
+	for i, hunk := range hunks {
+		output.WriteString(s.reviewHunkContext(ctx, i+1, hunk))
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// reviewHunkContext builds the context pack of one hunk
+func (s *RAGServer) reviewHunkContext(ctx context.Context, n int, hunk rag.DiffHunk) string {
+	var out strings.Builder
+
+	filePath := hunk.FilePath
+	if filePath == "" {
+		filePath = hunk.OldPath + " (deleted)"
+	}
+	out.WriteString(fmt.Sprintf("## Hunk %d: `%s:%d` (+%d/-%d)\n\n", n, filePath, hunk.NewStart, len(hunk.Added), len(hunk.Removed)))
+	out.WriteString("```diff\n" + strings.TrimRight(hunk.Text, "\n") + "\n```\n\n")
+
+	// Changed functions: the enclosing definition plus any defined in the hunk
+	changed := make(map[string]bool)
+	var changedNames []string
+	addChanged := func(name string) {
+		if name != "" && !changed[name] {
+			changed[name] = true
+			changedNames = append(changedNames, name)
+		}
+	}
+	if hunk.FilePath != "" {
+		if sym := rag.EnclosingSymbol(hunk.FilePath, hunk.NewStart); sym != nil {
+			addChanged(sym.Name)
+		}
+	}
+	language := rag.DetectFileLanguage(filePath)
+	for _, sym := range rag.ExtractSymbols(language, hunk.Added) {
+		addChanged(sym.Name)
+	}
+	for _, sym := range rag.ExtractSymbols(language, hunk.Removed) {
+		addChanged(sym.Name)
+	}
+
+	if len(changedNames) > 0 {
+		out.WriteString("### Changed Functions and Their Callers\n\n")
+		for _, name := range changedNames {
+			refs, err := rag.FindReferences(ctx, s.vectorDB, s.config.CollectionName, name, false, reviewCallersPerSymbol+1)
+			if err != nil {
+				out.WriteString(fmt.Sprintf("- `%s`: caller lookup failed (%v)\n", name, err))
+				continue
+			}
+			if len(refs) == 0 {
+				out.WriteString(fmt.Sprintf("- `%s`: no indexed callers\n", name))
+				continue
+			}
+			out.WriteString(fmt.Sprintf("- `%s`:\n", name))
+			for j, ref := range refs {
+				if j == reviewCallersPerSymbol {
+					out.WriteString("  - ... more (use `find_references`)\n")
+					break
+				}
+				out.WriteString(fmt.Sprintf("  - `%s:%d`: `%s`\n", ref.FilePath, ref.Line, ref.Text))
+			}
+		}
+		out.WriteString("\n")
+	}
+
+	// Similar implementations and related docs, found from the new code
+	query := strings.Join(hunk.Added, "\n")
+	if strings.TrimSpace(query) == "" {
+		query = strings.Join(hunk.Removed, "\n")
+	}
+	if strings.TrimSpace(query) == "" {
+		return out.String()
+	}
+
+	outcome, err := s.search(ctx, searchRequest{Tool: "review_diff", Query: query, Limit: 20, MinScore: s.config.MinScore})
+	if err != nil {
+		out.WriteString(fmt.Sprintf("_Similar code search failed: %v_\n\n", err))
+		return out.String()
+	}
+
+	var similar, docs []rag.SearchResult
+	for _, result := range outcome.Results {
+		if result.FilePath == hunk.FilePath {
+			continue
+		}
+		if result.Language == "markdown" {
+			if len(docs) < 2 {
+				docs = append(docs, result)
+			}
+		} else if len(similar) < reviewSimilarPerHunk {
+			similar = append(similar, result)
+		}
+	}
+
+	if len(similar) > 0 {
+		out.WriteString("### Similar Implementations\n\n")
+		for _, result := range similar {
+			out.WriteString(fmt.Sprintf("- `%s:%d-%d` (score %.3f)\n", result.FilePath, result.LineStart, result.LineEnd, result.Score))
+		}
+		out.WriteString("\n")
+	}
+	if len(docs) > 0 {
+		out.WriteString("### Related Docs\n\n")
+		for _, result := range docs {
+			out.WriteString(fmt.Sprintf("- `%s:%d-%d` (score %.3f)\n", result.FilePath, result.LineStart, result.LineEnd, result.Score))
+		}
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// reviewerQueryTokens caps the part of a file embedded to find similar code
+	reviewerQueryTokens = 1000
+
+	// reviewerCommitsPerFile is the number of recent commits read per similar file
+	reviewerCommitsPerFile = 20
+)
+
+func (s *RAGServer) handleSuggestReviewers(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	filePath, _ := arguments["file_path"].(string)
+	snippet, _ := arguments["code_snippet"].(string)
+	if strings.TrimSpace(filePath) == "" && strings.TrimSpace(snippet) == "" {
+		return mcp.NewToolResultError("either file_path or code_snippet is required"), nil
+	}
+
+	repoPath, _ := arguments["repo_path"].(string)
+	if repoPath == "" && len(s.config.CodePaths) > 0 {
+		repoPath = s.config.CodePaths[0]
+	}
+	if repoPath == "" {
+		return mcp.NewToolResultError("repo_path is required (no code_paths configured)"), nil
+	}
+
+	limit := 10 // Similar chunks considered
+	if l, ok := arguments["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	var exclude []string
+	if ex, ok := arguments["exclude"].([]interface{}); ok {
+		for _, e := range ex {
+			if name, ok := e.(string); ok {
+				exclude = append(exclude, name)
+			}
+		}
+	}
+
+	if snippet == "" {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read file: %v", err)), nil
+		}
+		snippet = string(content)
+	}
+	snippet, _ = rag.TruncateToTokens(snippet, reviewerQueryTokens)
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Suggesting reviewers", zap.String("file", filePath), zap.String("repo", repoPath), zap.Int("limit", limit))
+
+	// Over-fetch so the file's own chunks can be dropped
+	outcome, err := s.search(ctx, searchRequest{Tool: "suggest_reviewers", Query: snippet, Limit: limit * 2, MinScore: s.config.MinScore})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+	}
+	var results []rag.SearchResult
+	for _, result := range outcome.Results {
+		if filePath != "" && result.FilePath == filePath {
+			continue
+		}
+		if len(results) < limit {
+			results = append(results, result)
+		}
+	}
+
+	if len(results) == 0 {
+		return mcp.NewToolResultText("No similar code found, so no reviewers can be suggested.\n\nCheck the codebase is indexed."), nil
+	}
+
+	suggestions, err := rag.SuggestReviewers(ctx, repoPath, results, reviewerCommitsPerFile, exclude)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read ownership: %v", err)), nil
+	}
+
+	var output strings.Builder
+	if outcome.Degraded {
+		output.WriteString(degradedBanner)
+	}
+	output.WriteString("# Suggested Reviewers\n\n")
+	if filePath != "" {
+		output.WriteString(fmt.Sprintf("For: `%s`\n", filePath))
+	}
+	output.WriteString(fmt.Sprintf("Based on **%d similar chunks** and their CODEOWNERS and git authors.\n\n", len(results)))
+
+	if len(suggestions) == 0 {
+		output.WriteString("No owners or authors found for the similar files (no CODEOWNERS file, and no git history under repo_path).\n")
+		return mcp.NewToolResultText(output.String()), nil
+	}
+
+	for i, suggestion := range suggestions {
+		if i == limit {
+			break
+		}
+		files := suggestion.Files
+		more := ""
+		if len(files) > 3 {
+			more = fmt.Sprintf(" +%d more", len(files)-3)
+			files = files[:3]
+		}
+		output.WriteString(fmt.Sprintf("%d. **%s** (score %.2f, %s)\n", i+1, suggestion.Reviewer, suggestion.Score, strings.Join(suggestion.Sources, " + ")))
+		output.WriteString(fmt.Sprintf("   - Similar files: `%s`%s\n", strings.Join(files, "`, `"), more))
+	}
+
+	output.WriteString("\n💡 Code owners get the full similarity of a match; authors share it by their number of recent commits.\n")
+
+	return mcp.NewToolResultText(output.String()), nil
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func (s *RAGServer) handleSearchSymbols(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	name, ok := arguments["name"].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return mcp.NewToolResultError("name must be a non-empty string"), nil
+	}
+	name = strings.TrimSpace(name)
+
+	mode := rag.SymbolMatchPrefix
+	if m, ok := arguments["match"].(string); ok && m != "" {
+		mode = m
+	}
+	if mode != rag.SymbolMatchExact && mode != rag.SymbolMatchPrefix && mode != rag.SymbolMatchFuzzy {
+		return mcp.NewToolResultError(fmt.Sprintf("unknown match mode: %s (use exact, prefix or fuzzy)", mode)), nil
+	}
+
+	kind := ""
+	if k, ok := arguments["kind"].(string); ok {
+		kind = k
+	}
+
+	limit := 20
+	if l, ok := arguments["limit"].(float64); ok {
+		limit = int(l)
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Symbol search",
+		zap.String("name", name),
+		zap.String("match", mode),
+		zap.String("kind", kind),
+		zap.Int("limit", limit),
+	)
+
+	matches, err := rag.SearchSymbols(ctx, s.vectorDB, s.config.CollectionName, name, mode, kind, limit)
+	if err != nil {
+		s.logger.Error("Symbol search failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Symbol search failed: %v", err)), nil
+	}
+
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No symbols found matching '%s' (%s match)\n\nTry:\n- `match: \"fuzzy\"` for partial names\n- Removing the `kind` filter\n- Re-indexing files indexed before symbol extraction was available", name, mode)), nil
+	}
+
+	var output strings.Builder
+	output.WriteString("# Symbol Search Results\n\n")
+	output.WriteString(fmt.Sprintf("Symbol: **%s** (%s match)\n", name, mode))
+	output.WriteString(fmt.Sprintf("Found: **%d definitions**\n\n", len(matches)))
+	output.WriteString("---\n\n")
+
+	for i, match := range matches {
+		output.WriteString(fmt.Sprintf("%d. `%s` (%s) → `%s:%d` (%s)\n",
+			i+1, match.Name, match.Kind, match.FilePath, match.Line, match.Language))
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+func (s *RAGServer) handleFindReferences(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	symbol, ok := arguments["symbol"].(string)
+	if !ok || strings.TrimSpace(symbol) == "" {
+		return mcp.NewToolResultError("symbol must be a non-empty string"), nil
+	}
+	symbol = strings.TrimSpace(symbol)
+
+	includeDefinitions := false
+	if d, ok := arguments["include_definitions"].(bool); ok {
+		includeDefinitions = d
+	}
+
+	limit := 100
+	if l, ok := arguments["limit"].(float64); ok {
+		limit = int(l)
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Find references",
+		zap.String("symbol", symbol),
+		zap.Bool("include_definitions", includeDefinitions),
+		zap.Int("limit", limit),
+	)
+
+	refs, err := rag.FindReferences(ctx, s.vectorDB, s.config.CollectionName, symbol, includeDefinitions, limit)
+	if err != nil {
+		s.logger.Error("Find references failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Find references failed: %v", err)), nil
+	}
+
+	if len(refs) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No references found for '%s'\n\nNote: matching is exact and case-sensitive. Files indexed before reference tracking was available must be re-indexed.", symbol)), nil
+	}
+
+	var output strings.Builder
+	output.WriteString("# References\n\n")
+	output.WriteString(fmt.Sprintf("Symbol: **%s**\n", symbol))
+	output.WriteString(fmt.Sprintf("Found: **%d locations**\n\n", len(refs)))
+	output.WriteString("---\n\n")
+
+	currentFile := ""
+	for _, ref := range refs {
+		if ref.FilePath != currentFile {
+			currentFile = ref.FilePath
+			output.WriteString(fmt.Sprintf("\n**%s** (%s)\n", ref.FilePath, ref.Language))
+		}
+		marker := ""
+		if ref.IsDefinition {
+			marker = " [definition]"
+		}
+		output.WriteString(fmt.Sprintf("- `%d`: `%s`%s\n", ref.Line, ref.Text, marker))
+	}
+
+	if len(refs) == limit {
+		output.WriteString(fmt.Sprintf("\n💡 Showing the first %d locations. Increase `limit` to see more.\n", limit))
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
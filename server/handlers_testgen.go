@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxTestContextDeps caps the dependencies included in a test context pack
+	maxTestContextDeps = 8
+
+	// depExcerptLines is the number of lines shown per dependency (signature and start of body)
+	depExcerptLines = 12
+
+	// testExampleLines is the number of lines shown per test example
+	testExampleLines = 60
+)
+
+func (s *RAGServer) handleGetTestContext(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	symbol, ok := arguments["symbol"].(string)
+	if !ok || strings.TrimSpace(symbol) == "" {
+		return mcp.NewToolResultError("symbol must be a non-empty string"), nil
+	}
+	symbol = strings.TrimSpace(symbol)
+
+	filePath := ""
+	if fp, ok := arguments["file_path"].(string); ok {
+		filePath = fp
+	}
+
+	maxExamples := 3
+	if me, ok := arguments["max_examples"].(float64); ok {
+		maxExamples = int(me)
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Building test context", zap.String("symbol", symbol), zap.String("file", filePath))
+
+	// 1. Locate the function under test
+	matches, err := rag.SearchSymbols(ctx, s.vectorDB, s.config.CollectionName, symbol, rag.SymbolMatchExact, "", 20)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Symbol lookup failed: %v", err)), nil
+	}
+	var target *rag.SymbolMatch
+	for i, m := range matches {
+		if rag.IsTestFile(m.FilePath) || (filePath != "" && m.FilePath != filePath) {
+			continue
+		}
+		target = &matches[i]
+		break
+	}
+	if target == nil {
+		return mcp.NewToolResultText(fmt.Sprintf("No definition found for '%s'\n\nTry `search_symbols` with `match: \"fuzzy\"` to find the exact name, or pass `file_path`.", symbol)), nil
+	}
+
+	start, end, body, err := rag.DefinitionRange(target.FilePath, target.Line)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read %s: %v", target.FilePath, err)), nil
+	}
+	bodyText := strings.Join(body, "\n")
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# Test Context: %s\n\n", symbol))
+	output.WriteString("Context pack for writing tests. Sections: function under test, its dependencies, existing tests that call it, and tests of similar code to copy conventions from.\n\n")
+
+	output.WriteString("## Function Under Test\n\n")
+	output.WriteString(fmt.Sprintf("**%s** `%s` in `%s:%d-%d`\n\n", target.Kind, symbol, target.FilePath, start, end))
+	output.WriteString("```" + target.Language + "\n" + bodyText + "\n```\n\n")
+
+	// 2. Dependencies: identifiers used in the body that are defined in the index
+	var names []string
+	for _, ident := range rag.ExtractIdentifiers(bodyText) {
+		if ident != symbol {
+			names = append(names, ident)
+		}
+	}
+	deps, err := rag.DefinitionsOf(ctx, s.vectorDB, s.config.CollectionName, names, true)
+	if err != nil {
+		s.logger.Warn("Dependency lookup failed", zap.Error(err))
+	}
+	if len(deps) > maxTestContextDeps {
+		deps = deps[:maxTestContextDeps]
+	}
+
+	output.WriteString(fmt.Sprintf("## Dependencies (%d)\n\n", len(deps)))
+	if len(deps) == 0 {
+		output.WriteString("_No indexed dependencies found._\n\n")
+	}
+	for _, dep := range deps {
+		lines, _, err := s.readLines(dep.FilePath, dep.Line, dep.Line+depExcerptLines-1)
+		if err != nil {
+			output.WriteString(fmt.Sprintf("- %s `%s` in `%s:%d` (unreadable: %v)\n\n", dep.Kind, dep.Name, dep.FilePath, dep.Line, err))
+			continue
+		}
+		output.WriteString(fmt.Sprintf("### %s `%s` (`%s:%d`)\n\n", dep.Kind, dep.Name, dep.FilePath, dep.Line))
+		output.WriteString("```" + dep.Language + "\n" + strings.Join(lines, "\n") + "\n```\n\n")
+	}
+
+	// 3. Existing tests that already reference the function
+	refs, err := rag.FindReferences(ctx, s.vectorDB, s.config.CollectionName, symbol, false, 0)
+	if err != nil {
+		s.logger.Warn("Reference lookup failed", zap.Error(err))
+	}
+	var testRefs []rag.Reference
+	for _, ref := range refs {
+		if rag.IsTestFile(ref.FilePath) {
+			testRefs = append(testRefs, ref)
+		}
+	}
+
+	output.WriteString(fmt.Sprintf("## Existing Tests Calling %s (%d)\n\n", symbol, len(testRefs)))
+	if len(testRefs) == 0 {
+		output.WriteString("_None found: this function appears untested._\n\n")
+	}
+	for _, ref := range testRefs {
+		output.WriteString(fmt.Sprintf("- `%s:%d`: `%s`\n", ref.FilePath, ref.Line, ref.Text))
+	}
+	if len(testRefs) > 0 {
+		output.WriteString("\n")
+	}
+
+	// 4. Tests of similar code, to copy framework, naming and fixture conventions
+	outcome, err := s.search(ctx, searchRequest{Tool: "get_test_context", Query: "test " + symbol + "\n" + bodyText, Limit: 30})
+	if err != nil {
+		s.logger.Warn("Similar test search failed", zap.Error(err))
+		outcome = &searchOutcome{}
+	}
+
+	output.WriteString("## Tests of Similar Code\n\n")
+	examples := 0
+	for _, result := range outcome.Results {
+		if examples >= maxExamples {
+			break
+		}
+		if !rag.IsTestFile(result.FilePath) {
+			continue
+		}
+		examples++
+
+		content := result.Content
+		lines := strings.Split(content, "\n")
+		if len(lines) > testExampleLines {
+			content = strings.Join(lines[:testExampleLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-testExampleLines)
+		}
+		output.WriteString(fmt.Sprintf("### %s:%d-%d (score %.3f)\n\n", result.FilePath, result.LineStart, result.LineEnd, result.Score))
+		output.WriteString("```" + result.Language + "\n" + content + "\n```\n\n")
+	}
+	if examples == 0 {
+		output.WriteString("_No indexed tests found for similar code. Follow the standard test conventions for " + target.Language + "._\n\n")
+	}
+
+	return mcp.NewToolResultText(output.String()), nil
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func (s *RAGServer) handleClearIndex(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	token, _ := arguments["confirmation_token"].(string)
+	if token == "" {
+		count, err := s.vectorDB.Count(ctx, s.config.CollectionName, nil)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to count chunks: %v", err)), nil
+		}
+		if count == 0 {
+			return mcp.NewToolResultText("ℹ️ The index is already empty. Nothing to delete."), nil
+		}
+
+		summary := fmt.Sprintf("**Collection:** %s\n**Chunks that would be moved to trash:** %d\n", s.config.CollectionName, count)
+		return mcp.NewToolResultText(formatDryRun("clear_index", summary, s.confirmations.issue("clear_index", s.config.CollectionName))), nil
+	}
+
+	if err := s.confirmations.consume(token, "clear_index", s.config.CollectionName); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Confirmation failed: %v", err)), nil
+	}
+
+	s.logger.Info("Clearing index (soft delete)", zap.String("collection", s.config.CollectionName))
+
+	entry, err := s.trash.SoftDelete(ctx, s.config.CollectionName, nil, "clear_index")
+	if err != nil {
+		s.logger.Error("Clear index failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Clear index failed: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(formatTrashEntry("🗑️ **Index cleared**", entry)), nil
+}
+
+func (s *RAGServer) handleDeletePath(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	path, ok := arguments["path"].(string)
+	if !ok || strings.TrimSpace(path) == "" {
+		return mcp.NewToolResultError("path must be a non-empty string"), nil
+	}
+	path = filepath.Clean(path)
+
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	files, err := rag.FilesUnderPath(ctx, s.vectorDB, s.config.CollectionName, path)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list indexed files: %v", err)), nil
+	}
+	if len(files) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("No indexed files under: %s", path)), nil
+	}
+
+	token, _ := arguments["confirmation_token"].(string)
+	if token == "" {
+		count, err := s.vectorDB.Count(ctx, s.config.CollectionName, map[string]interface{}{"file_path": files})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to count chunks: %v", err)), nil
+		}
+
+		var summary strings.Builder
+		summary.WriteString(fmt.Sprintf("**Path:** %s\n**Files:** %d\n**Chunks that would be moved to trash:** %d\n\n", path, len(files), count))
+		for i, file := range files {
+			if i == dryRunFileListLimit {
+				summary.WriteString(fmt.Sprintf("- ... and %d more\n", len(files)-dryRunFileListLimit))
+				break
+			}
+			summary.WriteString(fmt.Sprintf("- %s\n", file))
+		}
+
+		return mcp.NewToolResultText(formatDryRun("delete_path", summary.String(), s.confirmations.issue("delete_path", path))), nil
+	}
+
+	if err := s.confirmations.consume(token, "delete_path", path); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Confirmation failed: %v", err)), nil
+	}
+
+	s.logger.Info("Deleting path from index (soft delete)", zap.String("path", path), zap.Int("files", len(files)))
+
+	entry, err := s.trash.SoftDelete(ctx, s.config.CollectionName, map[string]interface{}{
+		"file_path": files,
+	}, fmt.Sprintf("delete_path %s (%d files)", path, len(files)))
+	if err != nil {
+		s.logger.Error("Delete path failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Delete path failed: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(formatTrashEntry(fmt.Sprintf("🗑️ **Removed from index:** %s", path), entry)), nil
+}
+
+func (s *RAGServer) handleRestoreDeleted(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	trashID, _ := arguments["trash_id"].(string)
+	trashID = strings.TrimSpace(trashID)
+
+	if trashID == "" {
+		entries := s.trash.List()
+		if len(entries) == 0 {
+			return mcp.NewToolResultText("ℹ️ The trash is empty. Nothing to restore."), nil
+		}
+
+		var output strings.Builder
+		output.WriteString("# Trash\n\n")
+		for _, entry := range entries {
+			output.WriteString(fmt.Sprintf("- `%s`: %s — %d chunks, deleted %s, expires %s\n",
+				entry.ID,
+				entry.Description,
+				entry.Points,
+				entry.DeletedAt.Format("2006-01-02 15:04:05"),
+				entry.ExpiresAt.Format("2006-01-02 15:04:05"),
+			))
+		}
+		output.WriteString("\n💡 Call `restore_deleted` with a `trash_id` to restore an entry.\n")
+		return mcp.NewToolResultText(output.String()), nil
+	}
+
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	entry, err := s.trash.Restore(ctx, trashID)
+	if err != nil {
+		s.logger.Error("Restore failed", zap.String("trash_id", trashID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Restore failed: %v", err)), nil
+	}
+
+	s.logger.Info("Restored trash entry", zap.String("trash_id", trashID), zap.Int64("points", entry.Points))
+
+	return mcp.NewToolResultText(fmt.Sprintf(`✅ **Restored:** %s
+
+**Chunks:** %d
+
+💡 Files re-indexed since the deletion may now have duplicate chunks; use `+"`reindex_files`"+` on them if needed.
+`, entry.Description, entry.Points)), nil
+}
+
+// dryRunFileListLimit caps the number of files listed in a dry-run summary
+const dryRunFileListLimit = 20
+
+func formatDryRun(tool, summary, token string) string {
+	return fmt.Sprintf(`# Dry Run: %s
+
+Nothing has been deleted yet.
+
+%s
+**Confirmation token:** %s (valid %s, single use)
+
+💡 Call `+"`%s`"+` again with the same arguments and `+"`confirmation_token: \"%s\"`"+` to proceed.
+`, tool, summary, token, confirmationTTL, tool, token)
+}
+
+func formatTrashEntry(title string, entry *rag.TrashEntry) string {
+	return fmt.Sprintf(`%s
+
+**Chunks moved to trash:** %d
+**Trash ID:** %s
+**Restorable until:** %s
+
+💡 Undo with `+"`restore_deleted`"+` and `+"`trash_id: \"%s\"`"+`.
+`,
+		title,
+		entry.Points,
+		entry.ID,
+		entry.ExpiresAt.Format("2006-01-02 15:04:05"),
+		entry.ID,
+	)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// tuneCandidates is how many best matches tune_threshold scores
+	tuneCandidates = 50
+
+	// tuneScoreGap is the drop between consecutive scores reported as the
+	// edge of the closely related matches
+	tuneScoreGap = 0.08
+)
+
+// defaultTuneLevels are the min_score values tried when none are given
+var defaultTuneLevels = []float32{0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+
+func (s *RAGServer) handleTuneThreshold(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	query, ok := arguments["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("query must be a non-empty string"), nil
+	}
+
+	limit := s.config.TopK
+	if l, ok := arguments["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+
+	levels := append([]float32(nil), defaultTuneLevels...)
+	if rawLevels, ok := arguments["levels"].([]interface{}); ok && len(rawLevels) > 0 {
+		levels = levels[:0]
+		for _, l := range rawLevels {
+			level, ok := l.(float64)
+			if !ok || level < 0 || level > 1 {
+				return mcp.NewToolResultError("levels must be numbers between 0 and 1"), nil
+			}
+			levels = append(levels, float32(level))
+		}
+	}
+	levels = append(levels, s.config.MinScore)
+	levels = uniqueLevels(levels)
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+	opts := s.searchOptions(nil)
+
+	s.logger.Info("Tuning threshold", zap.String("query", query), zap.Int("levels", len(levels)))
+
+	embedding, err := s.embed(ctx, query)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Embedding failed, thresholds only apply to semantic search: %v", err)), nil
+	}
+	results, err := s.vectorDB.Search(ctx, s.config.CollectionName, nil, embedding, tuneCandidates, 0, noScoreThreshold, opts)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+	}
+	results = s.applyOverlay(ctx, nil, embedding, results, tuneCandidates, noScoreThreshold, opts, true)
+	if len(results) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No indexed code to tune against for query: '%s'\n\nRun `index_codebase` or check `get_index_stats`.", query)), nil
+	}
+
+	var output strings.Builder
+	output.WriteString("# Threshold Tuning\n\n")
+	output.WriteString(fmt.Sprintf("Query: **%s**\n", query))
+	output.WriteString(fmt.Sprintf("Scored: **%d best matches** (best %.3f, worst %.3f)\n\n", len(results), results[0].Score, results[len(results)-1].Score))
+
+	output.WriteString("| min_score | Matches | Top files |\n")
+	output.WriteString("|-----------|---------|-----------|\n")
+	for _, level := range levels {
+		kept := aboveMinScore(results, level)
+		label := fmt.Sprintf("%.2f", level)
+		if level == s.config.MinScore {
+			label += " (current)"
+		}
+		count := fmt.Sprintf("%d", len(kept))
+		if len(kept) == len(results) {
+			count += "+"
+		}
+		output.WriteString(fmt.Sprintf("| %s | %s | %s |\n", label, count, topFiles(kept, 3)))
+	}
+	output.WriteString("\n")
+
+	recommended, reason := recommendThreshold(results, levels, limit)
+	output.WriteString(fmt.Sprintf("✅ **Recommended min_score: %.2f**: %s\n", recommended, reason))
+	if i, ok := scoreGap(results, 2*limit); ok {
+		output.WriteString(fmt.Sprintf("\n📉 Scores drop from %.3f to %.3f after match %d: the matches above are the closely related ones.\n",
+			results[i].Score, results[i+1].Score, i+1))
+	}
+	output.WriteString(fmt.Sprintf("\n💡 Pass `min_score: %.2f` to `semantic_code_search`, or set `min_score` in the config for every search.\n", recommended))
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// recommendThreshold returns the highest level keeping at least limit
+// matches or, when none does, the highest level keeping any
+func recommendThreshold(results []rag.SearchResult, levels []float32, limit int) (float32, string) {
+	for i := len(levels) - 1; i >= 0; i-- {
+		if n := len(aboveMinScore(results, levels[i])); n >= limit {
+			return levels[i], fmt.Sprintf("the strictest level still returning %d matches (asked for %d).", n, limit)
+		}
+	}
+	for i := len(levels) - 1; i >= 0; i-- {
+		if n := len(aboveMinScore(results, levels[i])); n > 0 {
+			return levels[i], fmt.Sprintf("only %d matches score above it; no level returns %d, so the index may hold little code about this.", n, limit)
+		}
+	}
+	return levels[0], fmt.Sprintf("no match scores above any level (best: %.3f); rephrase the query with terms from the code.", results[0].Score)
+}
+
+// scoreGap returns the index of the match after which scores drop the most
+// among the first n, if the drop reaches tuneScoreGap
+func scoreGap(results []rag.SearchResult, n int) (int, bool) {
+	best, at := float32(0), -1
+	for i := 0; i+1 < len(results) && i+1 < n; i++ {
+		if drop := results[i].Score - results[i+1].Score; drop > best {
+			best, at = drop, i
+		}
+	}
+	return at, at >= 0 && best >= tuneScoreGap
+}
+
+// topFiles lists the base names of the first n distinct files of results
+func topFiles(results []rag.SearchResult, n int) string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if len(files) == n {
+			break
+		}
+		if !seen[result.FilePath] {
+			seen[result.FilePath] = true
+			files = append(files, "`"+filepath.Base(result.FilePath)+"`")
+		}
+	}
+	if len(files) == 0 {
+		return "-"
+	}
+	return strings.Join(files, ", ")
+}
+
+// uniqueLevels sorts levels and removes duplicates
+func uniqueLevels(levels []float32) []float32 {
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	unique := levels[:0]
+	for i, level := range levels {
+		if i == 0 || level != levels[i-1] {
+			unique = append(unique, level)
+		}
+	}
+	return unique
+}
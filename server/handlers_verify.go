@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func (s *RAGServer) handleVerifyIndex(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	paths := stringArgs(arguments["paths"])
+	if len(paths) == 0 {
+		paths = s.config.CodePaths
+	}
+	if len(paths) == 0 {
+		return mcp.NewToolResultError("no paths to verify: pass paths or configure code_paths"), nil
+	}
+	wantRepair, _ := arguments["repair"].(bool)
+
+	// Repairing deletes chunks: it takes a confirmation token from a first call
+	target := strings.Join(paths, ",")
+	token, _ := arguments["confirmation_token"].(string)
+	repair := wantRepair && token != ""
+	if repair {
+		if err := s.confirmations.consume(token, "verify_index", target); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Confirmation failed: %v", err)), nil
+		}
+	}
+
+	ctx, cancel := s.indexingContext()
+	defer cancel()
+
+	s.logger.Info("Verifying index", zap.Strings("paths", paths), zap.Bool("repair", repair))
+
+	report, err := s.indexer.VerifyIndex(ctx, paths, s.config.FileExtensions, s.config.CollectionName, s.trash, repair)
+	if err != nil && report == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Verification failed: %v", err)), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("**Files on disk:** %d\n", report.FilesOnDisk))
+	output.WriteString(fmt.Sprintf("**Files indexed:** %d\n", report.FilesIndexed))
+	output.WriteString(fmt.Sprintf("**Not indexed:** %d\n", len(report.NotIndexed)))
+	output.WriteString(fmt.Sprintf("**Stale (changed since indexing):** %d\n", len(report.Stale)))
+	output.WriteString(fmt.Sprintf("**Deleted or excluded, still indexed:** %d\n\n", len(report.Deleted)))
+
+	writeList := func(title string, files []string) {
+		if len(files) == 0 {
+			return
+		}
+		output.WriteString(fmt.Sprintf("## %s\n\n", title))
+		for i, f := range files {
+			if i == pruneFileListLimit {
+				output.WriteString(fmt.Sprintf("- ... and %d more\n", len(files)-i))
+				break
+			}
+			output.WriteString(fmt.Sprintf("- `%s`\n", f))
+		}
+		output.WriteString("\n")
+	}
+	writeList("Not Indexed", report.NotIndexed)
+	writeList("Stale", report.Stale)
+	writeList("Deleted or Excluded", report.Deleted)
+
+	if len(report.Failed) > 0 {
+		output.WriteString("## Errors\n\n")
+		for f, msg := range report.Failed {
+			output.WriteString(fmt.Sprintf("- `%s`: %s\n", f, msg))
+		}
+		output.WriteString("\n")
+	}
+	switch {
+	case err != nil:
+		output.WriteString(fmt.Sprintf("⚠️ Verification stopped early: %v\n", err))
+	case !report.Drifted():
+		output.WriteString("✅ The index matches the files on disk.\n")
+	case report.Repaired && report.Trashed != nil:
+		output.WriteString(formatTrashEntry("✅ **Repaired:** missing and stale files indexed, deleted ones moved to trash", report.Trashed))
+	case report.Repaired:
+		output.WriteString("✅ Repaired: missing and stale files indexed.\n")
+	case wantRepair:
+		return mcp.NewToolResultText(formatDryRun("verify_index", output.String(), s.confirmations.issue("verify_index", target))), nil
+	default:
+		output.WriteString("💡 Run again with `repair: true` to fix the drift.\n")
+	}
+
+	return mcp.NewToolResultText("# Verify Index\n\n" + output.String()), nil
+}
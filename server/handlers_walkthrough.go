@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxWalkthroughFiles caps the reading list length
+	maxWalkthroughFiles = 30
+
+	// walkthroughSymbolsPerFile is the number of symbols listed per file
+	walkthroughSymbolsPerFile = 8
+)
+
+func (s *RAGServer) handleGenerateWalkthrough(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	feature, _ := arguments["feature"].(string)
+	path, _ := arguments["path"].(string)
+	feature, path = strings.TrimSpace(feature), strings.TrimSpace(path)
+	if feature == "" && path == "" {
+		return mcp.NewToolResultError("either feature or path is required"), nil
+	}
+
+	limit := 15
+	if l, ok := arguments["limit"].(float64); ok {
+		limit = min(int(l), maxWalkthroughFiles)
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+
+	s.logger.Info("Generating walkthrough", zap.String("feature", feature), zap.String("path", path), zap.Int("limit", limit))
+
+	// Collect the files the walkthrough covers
+	var files []string
+	var err error
+	if path != "" {
+		files, err = rag.FilesUnderPath(ctx, s.vectorDB, s.config.CollectionName, path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list indexed files: %v", err)), nil
+		}
+	} else {
+		outcome, err := s.search(ctx, searchRequest{Tool: "generate_walkthrough", Query: feature, Limit: limit * 2, MinScore: s.config.MinScore})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+		}
+		seen := make(map[string]bool)
+		for _, result := range outcome.Results {
+			if !seen[result.FilePath] {
+				seen[result.FilePath] = true
+				files = append(files, result.FilePath)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return mcp.NewToolResultText("No indexed files found for this walkthrough.\n\nCheck the path is indexed, or try a broader feature description."), nil
+	}
+	truncated := 0
+	if len(files) > limit {
+		truncated = len(files) - limit
+		files = files[:limit]
+	}
+
+	outlines, err := rag.FileOutlines(ctx, s.vectorDB, s.config.CollectionName, files)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read file outlines: %v", err)), nil
+	}
+	steps := rag.BuildWalkthrough(outlines)
+
+	subject := feature
+	if path != "" {
+		subject = path
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# Walkthrough: %s\n\n", subject))
+	output.WriteString(fmt.Sprintf("Reading list of **%d files**: entry points first, then core logic, then helpers.\n", len(steps)))
+	if truncated > 0 {
+		output.WriteString(fmt.Sprintf("_%d more files left out; raise `limit` or narrow the path._\n", truncated))
+	}
+	output.WriteString("\n")
+
+	stage := ""
+	for i, step := range steps {
+		if step.Stage != stage {
+			stage = step.Stage
+			output.WriteString(fmt.Sprintf("## %s\n\n", walkthroughStageTitle(stage)))
+		}
+
+		output.WriteString(fmt.Sprintf("### %d. `%s` (%s)\n\n", i+1, step.FilePath, step.Language))
+
+		if len(step.Symbols) > 0 {
+			var names []string
+			for j, sym := range step.Symbols {
+				if j == walkthroughSymbolsPerFile {
+					names = append(names, fmt.Sprintf("+%d more", len(step.Symbols)-j))
+					break
+				}
+				names = append(names, fmt.Sprintf("`%s` (%s, L%d)", sym.Name, sym.Kind, sym.Line))
+			}
+			output.WriteString("- Defines: " + strings.Join(names, ", ") + "\n")
+		}
+		if len(step.Uses) > 0 {
+			output.WriteString("- Uses: " + walkthroughFileList(step.Uses) + "\n")
+		}
+		if len(step.UsedBy) > 0 {
+			output.WriteString("- Used by: " + walkthroughFileList(step.UsedBy) + "\n")
+		}
+		output.WriteString("\n")
+	}
+
+	output.WriteString("💡 Use `read_file_range` to read each file, or `search_symbols` to jump to a definition.\n")
+
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+func walkthroughStageTitle(stage string) string {
+	switch stage {
+	case rag.StageEntryPoint:
+		return "1️⃣ Entry Points"
+	case rag.StageCore:
+		return "2️⃣ Core Logic"
+	default:
+		return "3️⃣ Helpers"
+	}
+}
+
+// walkthroughFileList formats file paths by base name to keep lines short
+func walkthroughFileList(paths []string) string {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = "`" + filepath.Base(p) + "`"
+	}
+	return strings.Join(names, ", ")
+}
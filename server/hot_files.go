@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"go.uber.org/zap"
+)
+
+// hotFilesPrefetchInterval is how often the hottest files of the search log
+// are loaded into the cache
+const hotFilesPrefetchInterval = time.Minute
+
+// runHotFilesPrefetch refreshes the hot file cache every
+// hotFilesPrefetchInterval until ctx is done
+func (s *RAGServer) runHotFilesPrefetch(ctx context.Context) {
+	ticker := time.NewTicker(hotFilesPrefetchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cached := s.hotFiles.Prefetch()
+		s.logger.Debug("Hot file cache refreshed", zap.Int("files", cached))
+	}
+}
+
+// recordHits logs the files returned by a search for the hot file cache
+func (s *RAGServer) recordHits(results []rag.SearchResult) {
+	if s.hotFiles != nil {
+		s.hotFiles.Record(results)
+	}
+}
+
+// readLines is rag.ReadLines served from the hot file cache when enabled
+func (s *RAGServer) readLines(filePath string, start, end int) ([]string, int, error) {
+	if s.hotFiles != nil {
+		return s.hotFiles.ReadLines(filePath, start, end)
+	}
+	return rag.ReadLines(filePath, start, end)
+}
+
+// expandResult is rag.ExpandResult served from the hot file cache when enabled
+func (s *RAGServer) expandResult(result rag.SearchResult, n int) rag.SearchResult {
+	if s.hotFiles != nil {
+		return s.hotFiles.ExpandResult(result, n)
+	}
+	return rag.ExpandResult(result, n)
+}
+
+// parentResult is rag.ParentResult served from the hot file cache when enabled
+func (s *RAGServer) parentResult(result rag.SearchResult) rag.SearchResult {
+	if s.hotFiles != nil {
+		return s.hotFiles.ParentResult(result)
+	}
+	return rag.ParentResult(result)
+}
+
+// parentResults replaces results by their parents, keeping the best match of
+// results sharing a parent
+func (s *RAGServer) parentResults(results []rag.SearchResult) []rag.SearchResult {
+	seen := make(map[string]bool, len(results))
+	parents := make([]rag.SearchResult, 0, len(results))
+	for _, result := range results {
+		parent := s.parentResult(result)
+		key := fmt.Sprintf("%s:%d-%d", parent.FilePath, parent.LineStart, parent.LineEnd)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		parents = append(parents, parent)
+	}
+	return parents
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
 	"go.uber.org/zap"
 )
 
@@ -18,11 +20,16 @@ type HTTPAPIServer struct {
 	httpSrv *http.Server
 	logger  *zap.Logger
 	port    int
+	replays replayGuard
+
+	limiter      *clientLimiter // nil: no rate limit
+	reindexSlots chan struct{}  // Re-indexing requests in progress; nil: unlimited
 }
 
 // ReindexRequest is the request body for the /reindex endpoint
 type ReindexRequest struct {
-	Files []string `json:"files"`
+	Files   []string         `json:"files"`
+	Renames []rag.FileRename `json:"renames,omitempty"` // Renamed files, moved in the index without re-embedding when unchanged
 }
 
 // ReindexResponse is the response body for the /reindex endpoint
@@ -30,47 +37,103 @@ type ReindexResponse struct {
 	Success      bool     `json:"success"`
 	Message      string   `json:"message"`
 	FilesIndexed int      `json:"files_indexed"`
+	FilesRenamed int      `json:"files_renamed,omitempty"`
 	Errors       []string `json:"errors,omitempty"`
+
+	ConfirmationToken string `json:"confirmation_token,omitempty"` // Returned by /reindex-all with recreate_collection and no token
 }
 
 // HealthResponse is the response body for the /health endpoint
 type HealthResponse struct {
-	Status  string `json:"status"`
-	Version string `json:"version"`
+	Status        string `json:"status"`
+	Version       string `json:"version"`
+	Embedder      string `json:"embedder"`
+	EmbedderError string `json:"embedder_error,omitempty"`
+	Role          string `json:"role,omitempty"` // "leader" or "follower" with leader election
+
+	InvalidEmbeddings *rag.EmbeddingValidationStats `json:"invalid_embeddings,omitempty"` // Vectors rejected by validation
+}
+
+// ReindexAllRequest is the request body for the /reindex-all endpoint
+type ReindexAllRequest struct {
+	Paths              []string `json:"paths,omitempty"`
+	RecreateCollection bool     `json:"recreate_collection"`
+	ConfirmationToken  string   `json:"confirmation_token,omitempty"` // Required with recreate_collection, from a first call without it
+}
+
+// BatchSearchRequest is the request body for the /search/batch endpoint
+type BatchSearchRequest struct {
+	Queries  []string `json:"queries"`
+	Limit    int      `json:"limit"`
+	MinScore *float32 `json:"min_score,omitempty"`
+	Tags     []string `json:"tags,omitempty"` // Only match code carrying any of these tags
+	Root     string   `json:"root,omitempty"` // Only match code indexed from this code path (path or directory name)
+}
+
+// SearchHit is one search result in HTTP responses
+type SearchHit struct {
+	FilePath  string  `json:"file_path"`
+	LineStart int     `json:"line_start"`
+	LineEnd   int     `json:"line_end"`
+	Language  string  `json:"language"`
+	Score     float32 `json:"score"`
+	Content   string  `json:"content"`
+	Root      string  `json:"root,omitempty"` // Code path the file was indexed from
+}
+
+// BatchQueryResult holds the results of one query of a batch search
+type BatchQueryResult struct {
+	Query    string          `json:"query"`
+	Degraded bool            `json:"degraded,omitempty"`
+	Lexical  bool            `json:"lexical,omitempty"` // Keyword matches: no semantic match passed min_score
+	Results  []SearchHit     `json:"results"`
+	Metadata *SearchMetadata `json:"metadata,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// BatchSearchResponse is the response body for the /search/batch endpoint
+type BatchSearchResponse struct {
+	Results []BatchQueryResult `json:"results"`
 }
 
 // NewHTTPAPIServer creates a new HTTP API server
 func NewHTTPAPIServer(ragServer *RAGServer, port int, logger *zap.Logger) *HTTPAPIServer {
-	return &HTTPAPIServer{
-		server: ragServer,
-		logger: logger,
-		port:   port,
+	h := &HTTPAPIServer{
+		server:  ragServer,
+		logger:  logger,
+		port:    port,
+		limiter: newClientLimiter(ragServer.config.HTTPRateLimit, ragServer.config.HTTPRateBurst),
+	}
+	if n := ragServer.config.ReindexMaxConcurrent; n > 0 {
+		h.reindexSlots = make(chan struct{}, n)
 	}
+	return h
 }
 
 // Start starts the HTTP API server in a goroutine
 func (h *HTTPAPIServer) Start() error {
-	mux := http.NewServeMux()
-
-	// Health check endpoint
-	mux.HandleFunc("/health", h.handleHealth)
-
-	// Reindex endpoint - accepts POST with file paths
-	mux.HandleFunc("/reindex", h.handleReindex)
-
-	// Reindex from marker file endpoint - reads .code-rag-pending-reindex
-	mux.HandleFunc("/reindex-pending", h.handleReindexPending)
+	tlsConfig, err := h.tlsConfig()
+	if err != nil {
+		return err
+	}
 
 	h.httpSrv = &http.Server{
 		Addr:         fmt.Sprintf(":%d", h.port),
-		Handler:      mux,
+		Handler:      h.Handler(),
+		TLSConfig:    tlsConfig,
+		BaseContext:  func(net.Listener) context.Context { return h.server.ctx }, // Requests are canceled on shutdown
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 300 * time.Second, // Long timeout for reindexing
 	}
 
 	go func() {
-		h.logger.Info("HTTP API server starting", zap.Int("port", h.port))
-		if err := h.httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		h.logger.Info("HTTP API server starting", zap.Int("port", h.port), zap.Bool("tls", tlsConfig != nil))
+		serve := h.httpSrv.ListenAndServe
+		if tlsConfig != nil {
+			// The certificate comes from TLSConfig.GetCertificate
+			serve = func() error { return h.httpSrv.ListenAndServeTLS("", "") }
+		}
+		if err := serve(); err != nil && err != http.ErrServerClosed {
 			h.logger.Error("HTTP API server error", zap.Error(err))
 		}
 	}()
@@ -78,6 +141,39 @@ func (h *HTTPAPIServer) Start() error {
 	return nil
 }
 
+// Handler returns the API routes, for serving them without Start
+func (h *HTTPAPIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// Health check endpoint
+	mux.HandleFunc("/health", h.handleHealth)
+
+	// Kubernetes probes and preStop hook
+	mux.HandleFunc("/livez", h.handleLivez)
+	mux.HandleFunc("/readyz", h.handleReadyz)
+	mux.HandleFunc("/drain", h.indexingKey(h.limitBody(h.signed(h.handleDrain))))
+
+	// Reindex endpoint - accepts POST with file paths
+	mux.HandleFunc("/reindex", h.indexingKey(h.limitBody(h.signed(h.leaderOnly(h.handleReindex)))))
+
+	// Reindex from marker file endpoint - reads .code-rag-pending-reindex
+	mux.HandleFunc("/reindex-pending", h.indexingKey(h.limitBody(h.signed(h.leaderOnly(h.handleReindexPending)))))
+
+	// Full re-index endpoint - resets progress, optionally recreates the collection
+	mux.HandleFunc("/reindex-all", h.indexingKey(h.limitBody(h.signed(h.leaderOnly(h.handleReindexAll)))))
+
+	// Batch search endpoint - several queries in one round trip
+	mux.HandleFunc("/search/batch", h.limitBody(h.handleBatchSearch))
+
+	return h.protect(mux)
+}
+
+// protect applies the checks every route goes through: client certificate,
+// API key and rate limit
+func (h *HTTPAPIServer) protect(next http.Handler) http.Handler {
+	return h.requireClientCert(h.requireAPIKey(h.rateLimited(next)))
+}
+
 // Stop gracefully stops the HTTP API server
 func (h *HTTPAPIServer) Stop(ctx context.Context) error {
 	if h.httpSrv != nil {
@@ -86,6 +182,18 @@ func (h *HTTPAPIServer) Stop(ctx context.Context) error {
 	return nil
 }
 
+// leaderOnly answers 503 on indexing endpoints unless this instance is the
+// indexing leader, so load balancers can retry on another instance
+func (h *HTTPAPIServer) leaderOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.server.isLeader() {
+			http.Error(w, h.server.notLeaderMessage(), http.StatusServiceUnavailable)
+			return
+		}
+		handler(w, r)
+	}
+}
+
 // handleHealth handles GET /health
 func (h *HTTPAPIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -98,10 +206,70 @@ func (h *HTTPAPIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 		Version: h.server.config.ServerVersion,
 	}
 
+	if h.server.leader != nil {
+		resp.Role = "follower"
+		if h.server.leader.Held() {
+			resp.Role = "leader"
+		}
+	}
+
+	embedderStatus, embedderErr := h.server.embedderHealth.status()
+	resp.Embedder = embedderStatus
+	if embedderErr != nil {
+		resp.EmbedderError = embedderErr.Error()
+	}
+	if stats, ok := h.server.embeddingValidation(); ok {
+		resp.InvalidEmbeddings = &stats
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// readyTimeout bounds the vector database check of /readyz
+const readyTimeout = 2 * time.Second
+
+// handleLivez handles GET /livez: the process is up and serving HTTP
+func (h *HTTPAPIServer) handleLivez(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz handles GET /readyz: the vector database answers and the
+// instance is not draining, so it can take search traffic
+func (h *HTTPAPIServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if h.server.Draining() {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	if _, err := h.server.vectorDB.GetCollectionInfo(ctx, h.server.config.CollectionName); err != nil {
+		http.Error(w, fmt.Sprintf("vector database unavailable: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintln(w, "ok")
+}
+
+// handleDrain handles POST /drain, for a preStop hook: it stops indexing and
+// answers once it has stopped, or 504 after drain_timeout
+func (h *HTTPAPIServer) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.server.config.DrainTimeout)
+	defer cancel()
+	if err := h.server.Drain(ctx); err != nil {
+		http.Error(w, fmt.Sprintf("indexing still running: %v", err), http.StatusGatewayTimeout)
+		return
+	}
+
+	fmt.Fprintln(w, "drained")
+}
+
 // handleReindex handles POST /reindex with JSON body containing file paths
 func (h *HTTPAPIServer) handleReindex(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -111,24 +279,53 @@ func (h *HTTPAPIServer) handleReindex(w http.ResponseWriter, r *http.Request) {
 
 	var req ReindexRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if bodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		h.logger.Error("Failed to decode reindex request", zap.Error(err))
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if len(req.Files) == 0 {
+	if len(req.Files) == 0 && len(req.Renames) == 0 {
 		http.Error(w, "No files specified", http.StatusBadRequest)
 		return
 	}
+	if h.tooManyFiles(w, len(req.Files)+len(req.Renames)) {
+		return
+	}
+
+	release, ok := h.reindexSlot(w)
+	if !ok {
+		return
+	}
+	defer release()
 
-	h.logger.Info("Received reindex request", zap.Int("file_count", len(req.Files)))
+	h.logger.Info("Received reindex request", zap.Int("file_count", len(req.Files)), zap.Int("rename_count", len(req.Renames)))
 
-	// Perform reindexing
-	ctx := context.Background()
+	// Perform reindexing; stops if the client goes away
+	ctx, cancel := withTimeout(r.Context(), h.server.config.ReindexTimeout)
+	defer cancel()
 	var errors []string
-	successCount := 0
+	successCount, renamedCount := 0, 0
 
-	for _, filePath := range req.Files {
+	// Unchanged renamed files keep their embeddings; the others are
+	// re-indexed at their new path and removed from the old one
+	files := req.Files
+	for _, rename := range req.Renames {
+		renamed, err := h.server.incrementalIndexer.RenameFile(ctx, rename.From, rename.To, h.server.config.CollectionName)
+		if err != nil {
+			h.logger.Warn("Failed to rename file in index", zap.String("from", rename.From), zap.String("to", rename.To), zap.Error(err))
+		}
+		if renamed {
+			renamedCount++
+			continue
+		}
+		files = append(files, rename.From, rename.To)
+	}
+
+	for _, filePath := range files {
 		filePath = strings.TrimSpace(filePath)
 		if filePath == "" {
 			continue
@@ -144,8 +341,9 @@ func (h *HTTPAPIServer) handleReindex(w http.ResponseWriter, r *http.Request) {
 
 	resp := ReindexResponse{
 		Success:      len(errors) == 0,
-		Message:      fmt.Sprintf("Reindexed %d/%d files", successCount, len(req.Files)),
+		Message:      fmt.Sprintf("Reindexed %d/%d files, renamed %d", successCount, len(files), renamedCount),
 		FilesIndexed: successCount,
+		FilesRenamed: renamedCount,
 		Errors:       errors,
 	}
 
@@ -216,10 +414,22 @@ func (h *HTTPAPIServer) handleReindexPending(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// The marker file is kept, so the files are picked up once it is trimmed
+	if h.tooManyFiles(w, len(filePaths)) {
+		return
+	}
+
+	release, ok := h.reindexSlot(w)
+	if !ok {
+		return
+	}
+	defer release()
+
 	h.logger.Info("Processing pending reindex from marker file", zap.Int("file_count", len(filePaths)))
 
-	// Perform reindexing
-	ctx := context.Background()
+	// Perform reindexing; stops if the client goes away
+	ctx, cancel := withTimeout(r.Context(), h.server.config.ReindexTimeout)
+	defer cancel()
 	var errors []string
 	successCount := 0
 
@@ -252,3 +462,152 @@ func (h *HTTPAPIServer) handleReindexPending(w http.ResponseWriter, r *http.Requ
 	}
 	json.NewEncoder(w).Encode(resp)
 }
+
+// handleBatchSearch handles POST /search/batch with JSON body containing queries
+func (h *HTTPAPIServer) handleBatchSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if bodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Queries) == 0 {
+		http.Error(w, "No queries specified", http.StatusBadRequest)
+		return
+	}
+	if len(req.Queries) > maxBatchQueries {
+		http.Error(w, fmt.Sprintf("Too many queries (max %d)", maxBatchQueries), http.StatusBadRequest)
+		return
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = min(h.server.config.TopK, maxSearchLimit)
+	}
+	if limit > maxSearchLimit {
+		http.Error(w, fmt.Sprintf("Limit too large (max %d)", maxSearchLimit), http.StatusBadRequest)
+		return
+	}
+	minScore := h.server.config.MinScore
+	if req.MinScore != nil {
+		minScore = *req.MinScore
+	}
+
+	tags, err := scopedTags(r.Context(), req.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	root, err := h.server.resolveRoot(req.Root)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r.Context(), h.server.config.RequestTimeout)
+	defer cancel()
+	batch := h.server.searchBatch(ctx, req.Queries, limit, minScore, tags, root)
+
+	resp := BatchSearchResponse{Results: make([]BatchQueryResult, 0, len(batch))}
+	for _, b := range batch {
+		result := BatchQueryResult{Query: b.Query, Results: []SearchHit{}}
+		if b.Err != nil {
+			result.Error = b.Err.Error()
+		} else {
+			result.Degraded = b.Outcome.Degraded
+			result.Lexical = b.Outcome.Lexical
+			result.Metadata = b.Outcome.Stats
+			for _, hit := range b.Outcome.Results {
+				result.Results = append(result.Results, SearchHit{
+					FilePath:  hit.FilePath,
+					LineStart: hit.LineStart,
+					LineEnd:   hit.LineEnd,
+					Language:  hit.Language,
+					Score:     hit.Score,
+					Content:   hit.Content,
+					Root:      hit.Root,
+				})
+			}
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleReindexAll handles POST /reindex-all, starting a full re-index in background
+func (h *HTTPAPIServer) handleReindexAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ReindexAllRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if bodyTooLarge(err) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	paths := req.Paths
+	if len(paths) == 0 {
+		paths = h.server.config.CodePaths
+	}
+	if len(paths) == 0 {
+		http.Error(w, "No paths specified and no code_paths configured", http.StatusBadRequest)
+		return
+	}
+	if err := checkReindexPaths(paths); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Dropping the collection cannot be undone: like the reindex_all tool, the
+	// first call only returns a confirmation token
+	if req.RecreateCollection {
+		collection := h.server.config.CollectionName
+		if req.ConfirmationToken == "" {
+			resp := ReindexResponse{
+				Message:           fmt.Sprintf("Collection %s would be dropped and recreated; repeat with the confirmation token to proceed", collection),
+				ConfirmationToken: h.server.confirmations.issue("reindex_all", collection),
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+		if err := h.server.confirmations.consume(req.ConfirmationToken, "reindex_all", collection); err != nil {
+			http.Error(w, fmt.Sprintf("Confirmation failed: %v", err), http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := h.server.startReindexAll(paths, req.RecreateCollection); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	resp := ReindexResponse{
+		Success: true,
+		Message: fmt.Sprintf("Full re-index of %d paths started", len(paths)),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
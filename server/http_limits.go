@@ -0,0 +1,160 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tokenBucket holds the requests a client may still send
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// clientLimiter rate-limits clients, each with its own token bucket refilled
+// at rate tokens per second up to burst
+type clientLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// newClientLimiter creates a limiter, or returns nil when rate is not
+// positive
+func newClientLimiter(rate float64, burst int) *clientLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &clientLimiter{
+		rate:    rate,
+		burst:   math.Max(float64(burst), 1),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from client's bucket. When it is empty, it reports
+// false and how long until the next token.
+func (l *clientLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// Buckets refilled to burst are the same as new ones
+	for name, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, name)
+		}
+	}
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// clientID identifies the client of r for rate limiting: its API key, or
+// else its IP address
+func clientID(r *http.Request) string {
+	if key := requestAPIKey(r.Context()); key != nil {
+		return "key:" + key.Name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimited answers 429 to clients sending more than http_rate_limit
+// requests per second (after a burst of http_rate_burst). Probe endpoints
+// are never limited.
+func (h *HTTPAPIServer) rateLimited(next http.Handler) http.Handler {
+	if h.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probePaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client := clientID(r)
+		if ok, wait := h.limiter.allow(client); !ok {
+			h.logger.Warn("Rate limited HTTP client", zap.String("client", client), zap.String("path", r.URL.Path))
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitBody answers 413 to indexing requests whose body is larger than
+// reindex_max_body, before anything reads it
+func (h *HTTPAPIServer) limitBody(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := h.server.config.ReindexMaxBody
+		if limit <= 0 {
+			handler(w, r)
+			return
+		}
+		if r.ContentLength > limit {
+			http.Error(w, fmt.Sprintf("request body too large (max %d bytes)", limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		handler(w, r)
+	}
+}
+
+// bodyTooLarge reports whether err comes from reading past the body limit
+func bodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// reindexSlot takes one of the reindex_max_concurrent slots of file
+// re-indexing requests, answering 429 when all are taken. The returned
+// function releases it.
+func (h *HTTPAPIServer) reindexSlot(w http.ResponseWriter) (func(), bool) {
+	if h.reindexSlots == nil {
+		return func() {}, true
+	}
+	select {
+	case h.reindexSlots <- struct{}{}:
+		return func() { <-h.reindexSlots }, true
+	default:
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "too many re-indexing requests in progress", http.StatusTooManyRequests)
+		return nil, false
+	}
+}
+
+// tooManyFiles answers 413 when a request names more than reindex_max_files
+// files
+func (h *HTTPAPIServer) tooManyFiles(w http.ResponseWriter, count int) bool {
+	limit := h.server.config.ReindexMaxFiles
+	if limit <= 0 || count <= limit {
+		return false
+	}
+	h.logger.Warn("Rejected re-indexing request naming too many files", zap.Int("files", count), zap.Int("max", limit))
+	http.Error(w, fmt.Sprintf("too many files: %d (max %d); use /reindex-all for full re-indexing", count, limit), http.StatusRequestEntityTooLarge)
+	return true
+}
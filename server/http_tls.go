@@ -0,0 +1,107 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// probePaths are served to clients without a certificate under mTLS, since
+// Kubernetes probes cannot present one. They reveal no code.
+var probePaths = map[string]bool{"/health": true, "/livez": true, "/readyz": true}
+
+// certReloader serves the certificate from cert/key files, reloading it when
+// the certificate file changes so renewed certificates are picked up without
+// a restart
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// newCertReloader loads the certificate, failing if it cannot
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.getCertificate(nil); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// getCertificate is a tls.Config.GetCertificate. A certificate that fails to
+// reload (e.g. key not renewed yet) keeps the previous one in use.
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, err := os.Stat(r.certFile)
+	if err == nil && r.cert != nil && info.ModTime().Equal(r.modTime) {
+		return r.cert, nil
+	}
+	cert, loadErr := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if loadErr != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", loadErr)
+	}
+	r.cert = &cert
+	if err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r.cert, nil
+}
+
+// tlsConfig returns the TLS configuration of the HTTP API, or nil to serve
+// plain HTTP
+func (h *HTTPAPIServer) tlsConfig() (*tls.Config, error) {
+	cfg := h.server.config
+	if cfg.HTTPAPITLSCert == "" {
+		return nil, nil
+	}
+
+	certs, err := newCertReloader(cfg.HTTPAPITLSCert, cfg.HTTPAPITLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.getCertificate,
+	}
+
+	if cfg.HTTPAPIClientCA != "" {
+		pem, err := os.ReadFile(cfg.HTTPAPIClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in client CA %s", cfg.HTTPAPIClientCA)
+		}
+		tlsCfg.ClientCAs = pool
+		// Verified when given, required by requireClientCert outside probes
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsCfg, nil
+}
+
+// requireClientCert rejects requests without a verified client certificate,
+// except to probe endpoints, when http_api_client_ca is set
+func (h *HTTPAPIServer) requireClientCert(next http.Handler) http.Handler {
+	if h.server.config.HTTPAPIClientCA == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !probePaths[r.URL.Path] && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
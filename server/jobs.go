@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job states
+const (
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// maxFinishedJobs is how many finished jobs are kept for get_job_status
+const maxFinishedJobs = 50
+
+// job is a background operation started by a tool call
+type job struct {
+	ID          string
+	Kind        string // e.g. "index_codebase"
+	Target      string // What the job works on, e.g. the indexed path
+	Status      string
+	Error       string
+	ChunksDone  int
+	ChunksTotal int
+	StartedAt   time.Time
+	FinishedAt  time.Time
+
+	cancel context.CancelFunc
+}
+
+// jobStore runs jobs in goroutines and tracks them by ID, so long tool calls
+// return immediately instead of timing out MCP clients
+type jobStore struct {
+	mu     sync.Mutex
+	jobs   map[string]*job
+	parent context.Context // Cancels every job when done
+}
+
+func newJobStore(parent context.Context) *jobStore {
+	return &jobStore{
+		jobs:   make(map[string]*job),
+		parent: parent,
+	}
+}
+
+// start runs fn in the background and returns the new job's ID. fn reports
+// progress through the given function and must stop when ctx is cancelled.
+func (js *jobStore) start(kind, target string, fn func(ctx context.Context, progress func(done, total int)) error) string {
+	buf := make([]byte, 4)
+	rand.Read(buf)
+
+	ctx, cancel := context.WithCancel(js.parent)
+	j := &job{
+		ID:        hex.EncodeToString(buf),
+		Kind:      kind,
+		Target:    target,
+		Status:    jobRunning,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+	}
+
+	js.mu.Lock()
+	js.pruneLocked()
+	js.jobs[j.ID] = j
+	js.mu.Unlock()
+
+	go func() {
+		defer cancel()
+
+		err := fn(ctx, func(done, total int) {
+			js.mu.Lock()
+			defer js.mu.Unlock()
+			j.ChunksDone, j.ChunksTotal = done, total
+		})
+
+		js.mu.Lock()
+		defer js.mu.Unlock()
+		j.FinishedAt = time.Now()
+		switch {
+		case ctx.Err() != nil:
+			j.Status = jobCancelled
+		case err != nil:
+			j.Status = jobFailed
+			j.Error = err.Error()
+		default:
+			j.Status = jobCompleted
+		}
+	}()
+
+	return j.ID
+}
+
+// get returns a snapshot of a job
+func (js *jobStore) get(id string) (job, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	j, ok := js.jobs[id]
+	if !ok {
+		return job{}, false
+	}
+	return *j, true
+}
+
+// list returns snapshots of all jobs, most recent first
+func (js *jobStore) list() []job {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	jobs := make([]job, 0, len(js.jobs))
+	for _, j := range js.jobs {
+		jobs = append(jobs, *j)
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].StartedAt.After(jobs[b].StartedAt)
+	})
+	return jobs
+}
+
+// cancel asks a running job to stop; it ends as cancelled shortly after
+func (js *jobStore) cancel(id string) (job, error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	j, ok := js.jobs[id]
+	if !ok {
+		return job{}, fmt.Errorf("unknown job %q", id)
+	}
+	if j.Status != jobRunning {
+		return *j, fmt.Errorf("job %s already %s", id, j.Status)
+	}
+	j.cancel()
+	return *j, nil
+}
+
+// pruneLocked drops the oldest finished jobs beyond maxFinishedJobs
+func (js *jobStore) pruneLocked() {
+	var finished []*job
+	for _, j := range js.jobs {
+		if j.Status != jobRunning {
+			finished = append(finished, j)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(a, b int) bool {
+		return finished[a].FinishedAt.Before(finished[b].FinishedAt)
+	})
+	for _, j := range finished[:len(finished)-maxFinishedJobs] {
+		delete(js.jobs, j.ID)
+	}
+}
+
+// cancelAll asks every running job to stop
+func (js *jobStore) cancelAll() {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	for _, j := range js.jobs {
+		if j.Status == jobRunning {
+			j.cancel()
+		}
+	}
+}
+
+// running returns the number of jobs still running
+func (js *jobStore) running() int {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	count := 0
+	for _, j := range js.jobs {
+		if j.Status == jobRunning {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SetLeaderLease makes indexing, pruning and the overlay refresh run only
+// while lease is held. Without a lease this instance always indexes.
+func (s *RAGServer) SetLeaderLease(lease *rag.Lease) {
+	s.leader = lease
+}
+
+// isLeader reports whether this instance may write to the index
+func (s *RAGServer) isLeader() bool {
+	if s.draining.Load() {
+		return false
+	}
+	return s.leader == nil || s.leader.Held()
+}
+
+// notLeaderMessage explains why a write was refused on a search-only node
+func (s *RAGServer) notLeaderMessage() string {
+	if s.draining.Load() {
+		return "this instance is shutting down; send indexing requests to another instance"
+	}
+	return fmt.Sprintf("instance %s is not the indexing leader; send indexing requests to the leader", s.leader.Holder())
+}
+
+// leaderOnly refuses calls of an indexing tool unless this instance is the leader
+func (s *RAGServer) leaderOnly(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		if !s.isLeader() {
+			return mcp.NewToolResultError(s.notLeaderMessage()), nil
+		}
+		return handler(arguments)
+	}
+}
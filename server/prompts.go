@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// promptExcerptLines caps the lines of each chunk embedded in a prompt
+const promptExcerptLines = 40
+
+func (s *RAGServer) registerPrompts(mcpServer *mcpserver.MCPServer) {
+	mcpServer.AddPrompt(mcp.NewPrompt("explain-feature",
+		mcp.WithPromptDescription("Explain how a feature works, grounded in the most relevant indexed code"),
+		mcp.WithArgument("feature",
+			mcp.ArgumentDescription("Feature or behavior to explain (e.g. 'authentication flow')"),
+			mcp.RequiredArgument(),
+		),
+	), s.handleExplainFeaturePrompt)
+
+	mcpServer.AddPrompt(mcp.NewPrompt("find-and-summarize",
+		mcp.WithPromptDescription("Find code matching a query and summarize what each match does"),
+		mcp.WithArgument("query",
+			mcp.ArgumentDescription("What to look for (e.g. 'database retry logic')"),
+			mcp.RequiredArgument(),
+		),
+		mcp.WithArgument("limit",
+			mcp.ArgumentDescription("Number of matches to retrieve (default: 8)"),
+		),
+	), s.handleFindAndSummarizePrompt)
+
+	mcpServer.AddPrompt(mcp.NewPrompt("impact-analysis",
+		mcp.WithPromptDescription("Assess the impact of changing a symbol using its definition and all its references"),
+		mcp.WithArgument("symbol",
+			mcp.ArgumentDescription("Exact name of the function, type or variable to change"),
+			mcp.RequiredArgument(),
+		),
+		mcp.WithArgument("change",
+			mcp.ArgumentDescription("Optional description of the planned change"),
+		),
+	), s.handleImpactAnalysisPrompt)
+}
+
+func (s *RAGServer) handleExplainFeaturePrompt(arguments map[string]string) (*mcp.GetPromptResult, error) {
+	feature := strings.TrimSpace(arguments["feature"])
+	if feature == "" {
+		return nil, fmt.Errorf("feature is required")
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+	s.logger.Info("Prompt explain-feature", zap.String("feature", feature))
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Explain how **%s** works in this codebase.\n\n", feature))
+	text.WriteString("Instructions:\n")
+	text.WriteString("- Start with a short overview, then walk through the flow step by step\n")
+	text.WriteString("- Cite every claim with `file:line` references from the context below\n")
+	text.WriteString("- If the context is incomplete, use `semantic_code_search`, `search_symbols` or `read_file_range` before answering\n")
+	text.WriteString("- Say explicitly when something is not covered by the retrieved code\n\n")
+	text.WriteString(s.promptSearchContext(ctx, feature, 8))
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Explain feature: %s", feature),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String()))},
+	), nil
+}
+
+func (s *RAGServer) handleFindAndSummarizePrompt(arguments map[string]string) (*mcp.GetPromptResult, error) {
+	query := strings.TrimSpace(arguments["query"])
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	limit := 8
+	if l, err := strconv.Atoi(arguments["limit"]); err == nil && l > 0 && l <= 20 {
+		limit = l
+	}
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+	s.logger.Info("Prompt find-and-summarize", zap.String("query", query), zap.Int("limit", limit))
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Find and summarize the code related to: **%s**\n\n", query))
+	text.WriteString("Instructions:\n")
+	text.WriteString("- For each relevant match, give its `file:line` location and a one or two sentence summary\n")
+	text.WriteString("- Group matches that belong to the same component\n")
+	text.WriteString("- Skip matches that are not actually relevant and say why\n")
+	text.WriteString("- End with a short synthesis of how the pieces fit together\n\n")
+	text.WriteString(s.promptSearchContext(ctx, query, limit))
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Find and summarize: %s", query),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String()))},
+	), nil
+}
+
+func (s *RAGServer) handleImpactAnalysisPrompt(arguments map[string]string) (*mcp.GetPromptResult, error) {
+	symbol := strings.TrimSpace(arguments["symbol"])
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	change := strings.TrimSpace(arguments["change"])
+
+	ctx, cancel := s.toolContext()
+	defer cancel()
+	s.logger.Info("Prompt impact-analysis", zap.String("symbol", symbol))
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Analyze the impact of changing **%s**.\n\n", symbol))
+	if change != "" {
+		text.WriteString(fmt.Sprintf("Planned change: %s\n\n", change))
+	}
+	text.WriteString("Instructions:\n")
+	text.WriteString("- Identify every caller/user below that would need to change, with `file:line`\n")
+	text.WriteString("- Classify each as breaking, needs review, or unaffected\n")
+	text.WriteString("- Point out tests, docs and configuration that reference the symbol\n")
+	text.WriteString("- Propose an order of changes that keeps the code building\n\n")
+
+	definitions, err := rag.SearchSymbols(ctx, s.vectorDB, s.config.CollectionName, symbol, rag.SymbolMatchExact, "", 10)
+	if err != nil {
+		s.logger.Warn("Prompt symbol lookup failed", zap.Error(err))
+	}
+	text.WriteString("## Definitions\n\n")
+	if len(definitions) == 0 {
+		text.WriteString("_No indexed definition found. Use `search_symbols` with `match: \"fuzzy\"` to locate it._\n")
+	}
+	for _, def := range definitions {
+		text.WriteString(fmt.Sprintf("- `%s` (%s) at `%s:%d`\n", def.Name, def.Kind, def.FilePath, def.Line))
+	}
+
+	refs, err := rag.FindReferences(ctx, s.vectorDB, s.config.CollectionName, symbol, false, 200)
+	if err != nil {
+		s.logger.Warn("Prompt reference lookup failed", zap.Error(err))
+	}
+	text.WriteString(fmt.Sprintf("\n## References (%d)\n\n", len(refs)))
+	if len(refs) == 0 {
+		text.WriteString("_No indexed references found. Use `find_references` after re-indexing if this is unexpected._\n")
+	}
+	for _, ref := range refs {
+		text.WriteString(fmt.Sprintf("- `%s:%d`: `%s`\n", ref.FilePath, ref.Line, ref.Text))
+	}
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Impact analysis: %s", symbol),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String()))},
+	), nil
+}
+
+// promptSearchContext retrieves chunks for query and formats them as prompt context.
+// Retrieval failures are reported inline so the prompt stays usable.
+func (s *RAGServer) promptSearchContext(ctx context.Context, query string, limit int) string {
+	outcome, err := s.search(ctx, searchRequest{Tool: "prompt", Query: query, Limit: limit, MinScore: s.config.MinScore, Hybrid: s.config.HybridSearch})
+	if err != nil {
+		s.logger.Warn("Prompt retrieval failed", zap.Error(err))
+		return fmt.Sprintf("## Retrieved Context\n\n_Retrieval failed (%v). Call `semantic_code_search` with query %q instead._\n", err, query)
+	}
+	results, degraded := outcome.Results, outcome.Degraded
+	if len(results) == 0 {
+		return fmt.Sprintf("## Retrieved Context\n\n_No matches found. Try `semantic_code_search` with a broader query or lower min_score than %.2f._\n", s.config.MinScore)
+	}
+
+	var text strings.Builder
+	text.WriteString("## Retrieved Context\n\n")
+	if degraded {
+		text.WriteString(degradedBanner)
+	}
+	text.WriteString(lexicalNotice(outcome, s.config.MinScore))
+	for i, result := range results {
+		text.WriteString(fmt.Sprintf("### %d. %s:%d-%d (score %.3f)\n\n", i+1, result.FilePath, result.LineStart, result.LineEnd, result.Score))
+		content := result.Content
+		lines := strings.Split(content, "\n")
+		if len(lines) > promptExcerptLines {
+			content = strings.Join(lines[:promptExcerptLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-promptExcerptLines)
+		}
+		text.WriteString("```" + result.Language + "\n" + content + "\n```\n\n")
+	}
+
+	return text.String()
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/config"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+const (
+	// proxyConnectTimeout bounds connecting to and listing the tools of a proxied server
+	proxyConnectTimeout = 30 * time.Second
+
+	// proxyCallTimeout bounds one forwarded tool call
+	proxyCallTimeout = 2 * time.Minute
+)
+
+// proxyNamePattern restricts proxy names to characters valid in tool names
+var proxyNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// mountProxies connects to the configured MCP servers and registers their
+// tools as "<name>_<tool>", forwarding calls. A server that cannot be
+// reached, or named like an earlier one, is logged and skipped, as are tools
+// whose name is already registered. Must run before the server starts serving.
+func (s *RAGServer) mountProxies(ctx context.Context) {
+	taken := s.registeredTools(ctx)
+	mountedProxies := make(map[string]bool, len(s.config.ProxyServers))
+	for _, proxy := range s.config.ProxyServers {
+		if mountedProxies[proxy.Name] {
+			s.logger.Warn("Skipping MCP server: name already used by another proxy", zap.String("name", proxy.Name))
+			continue
+		}
+		mountedProxies[proxy.Name] = true
+
+		c, tools, err := connectProxy(ctx, proxy, s.config)
+		if err != nil {
+			s.logger.Warn("Failed to mount MCP server", zap.String("name", proxy.Name), zap.Error(err))
+			continue
+		}
+		s.proxies = append(s.proxies, c)
+
+		allowed := make(map[string]bool, len(proxy.Tools))
+		for _, name := range proxy.Tools {
+			allowed[name] = true
+		}
+
+		mounted := 0
+		for _, tool := range tools {
+			if len(allowed) > 0 && !allowed[tool.Name] {
+				continue
+			}
+			remoteName := tool.Name
+			tool.Name = proxy.Name + "_" + remoteName
+			if taken[tool.Name] {
+				s.logger.Warn("Skipping proxied tool: name already registered",
+					zap.String("name", proxy.Name), zap.String("tool", tool.Name))
+				continue
+			}
+			taken[tool.Name] = true
+			tool.Description = fmt.Sprintf("[%s] %s", proxy.Name, tool.Description)
+			s.mcp.AddTool(tool, s.forwardTool(proxy.Name, c, remoteName))
+			mounted++
+		}
+		s.logger.Info("Mounted MCP server", zap.String("name", proxy.Name), zap.Int("tools", mounted))
+	}
+}
+
+// registeredTools returns the names of the tools registered so far
+func (s *RAGServer) registeredTools(ctx context.Context) map[string]bool {
+	names := make(map[string]bool)
+	request, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      0,
+		"method":  "tools/list",
+	})
+	response, ok := s.mcp.HandleMessage(ctx, request).(mcp.JSONRPCResponse)
+	if !ok {
+		return names
+	}
+	if result, ok := response.Result.(mcp.ListToolsResult); ok {
+		for _, tool := range result.Tools {
+			names[tool.Name] = true
+		}
+	}
+	return names
+}
+
+// connectProxy starts or connects to a proxied server and lists its tools
+func connectProxy(ctx context.Context, proxy config.ProxyServer, cfg *config.Config) (client.MCPClient, []mcp.Tool, error) {
+	if !proxyNamePattern.MatchString(proxy.Name) {
+		return nil, nil, fmt.Errorf("invalid proxy name %q (letters, digits and '-' only)", proxy.Name)
+	}
+
+	var c client.MCPClient
+	switch {
+	case proxy.Command != "" && proxy.URL != "":
+		return nil, nil, fmt.Errorf("set either command or url, not both")
+	case proxy.Command != "":
+		stdio, err := client.NewStdioMCPClient(proxy.Command, proxy.Args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		c = stdio
+	case proxy.URL != "":
+		sse, err := client.NewSSEMCPClient(proxy.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The SSE stream lives as long as ctx, not just the connect timeout
+		if err := sse.Start(ctx); err != nil {
+			return nil, nil, err
+		}
+		c = sse
+	default:
+		return nil, nil, fmt.Errorf("command or url is required")
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, proxyConnectTimeout)
+	defer cancel()
+
+	var initRequest mcp.InitializeRequest
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: cfg.ServerName, Version: cfg.ServerVersion}
+	if _, err := c.Initialize(connectCtx, initRequest); err != nil {
+		c.Close()
+		return nil, nil, fmt.Errorf("initialize failed: %w", err)
+	}
+
+	var tools []mcp.Tool
+	var listRequest mcp.ListToolsRequest
+	for {
+		result, err := c.ListTools(connectCtx, listRequest)
+		if err != nil {
+			c.Close()
+			return nil, nil, fmt.Errorf("tools/list failed: %w", err)
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			break
+		}
+		listRequest.Params.Cursor = result.NextCursor
+	}
+	return c, tools, nil
+}
+
+// forwardTool returns a handler calling remoteName on a proxied server
+func (s *RAGServer) forwardTool(proxyName string, c client.MCPClient, remoteName string) server.ToolHandlerFunc {
+	return func(arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(s.ctx, proxyCallTimeout)
+		defer cancel()
+
+		var request mcp.CallToolRequest
+		request.Params.Name = remoteName
+		request.Params.Arguments = arguments
+
+		result, err := c.CallTool(ctx, request)
+		if err != nil {
+			s.logger.Warn("Proxied tool call failed",
+				zap.String("server", proxyName),
+				zap.String("tool", remoteName),
+				zap.Error(err),
+			)
+			return mcp.NewToolResultError(fmt.Sprintf("%s: %s failed: %v", proxyName, remoteName, err)), nil
+		}
+		return result, nil
+	}
+}
+
+// closeProxies disconnects from proxied servers, stopping launched ones
+func (s *RAGServer) closeProxies() {
+	for _, c := range s.proxies {
+		if err := c.Close(); err != nil {
+			s.logger.Debug("Failed to close proxied MCP server", zap.Error(err))
+		}
+	}
+}
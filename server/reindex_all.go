@@ -0,0 +1,47 @@
+package server
+
+import (
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// errReindexRunning is returned when a full re-index or another indexing run
+// is already in progress
+var errReindexRunning = fmt.Errorf("indexing is already running; check get_indexing_progress")
+
+// checkReindexPaths returns an error naming the first of paths missing on disk
+func checkReindexPaths(paths []string) error {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("path does not exist: %s", path)
+		}
+	}
+	return nil
+}
+
+// startReindexAll launches a full re-index of paths in the background,
+// canceled when the server stops. Only one indexing run can be active at a
+// time.
+func (s *RAGServer) startReindexAll(paths []string, recreate bool) error {
+	if s.incrementalIndexer.Running() || !s.reindexing.CompareAndSwap(false, true) {
+		return errReindexRunning
+	}
+
+	s.logger.Info("Starting full re-index", zap.Strings("paths", paths), zap.Bool("recreate_collection", recreate))
+
+	go func() {
+		defer s.reindexing.Store(false)
+
+		err := s.incrementalIndexer.ReindexAll(s.ctx, paths, s.config.FileExtensions,
+			s.config.CollectionName, recreate, s.embedder.Dimension())
+		if err != nil {
+			s.logger.Error("Full re-index failed", zap.Error(err))
+			return
+		}
+		s.logger.Info("Full re-index complete", zap.Strings("paths", paths))
+	}()
+
+	return nil
+}
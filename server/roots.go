@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+)
+
+// resolveRoot returns the code path named by root: the path itself or its
+// directory name. Empty root means all code paths.
+func (s *RAGServer) resolveRoot(root string) (string, error) {
+	if root == "" {
+		return "", nil
+	}
+	abs, _ := filepath.Abs(root)
+	var names []string
+	for _, path := range s.codePaths() {
+		if abs == path || root == filepath.Base(path) {
+			return path, nil
+		}
+		names = append(names, filepath.Base(path))
+	}
+	return "", fmt.Errorf("unknown root %q: use one of the code paths %v", root, names)
+}
+
+// rootArg reads the root argument of a search tool. Without one, searches
+// default to the project open in the client; "" searches all code paths.
+func (s *RAGServer) rootArg(arguments map[string]interface{}) (string, error) {
+	root, ok := arguments["root"].(string)
+	if !ok {
+		return s.activeRoot(), nil
+	}
+	return s.resolveRoot(root)
+}
+
+// scopeFilter returns the search filter matching chunks carrying any of tags
+// and, when set, indexed from root; nil matches everything
+func scopeFilter(tags []string, root string) map[string]interface{} {
+	filter := make(map[string]interface{})
+	if len(tags) > 0 {
+		filter["tags"] = tags
+	}
+	if root != "" {
+		filter["root"] = root
+	}
+	if len(filter) == 0 {
+		return nil
+	}
+	return filter
+}
+
+// displayPath is the path of result shown in responses: relative to its
+// root with relative_result_paths, absolute otherwise
+func (s *RAGServer) displayPath(result rag.SearchResult) string {
+	if !s.config.RelativePaths {
+		return result.FilePath
+	}
+	return rag.RelativePath(result.Root, result.FilePath)
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// runScheduledReindex syncs the code paths with the index at every time
+// matched by the ReindexSchedule cron expression until ctx is done, for
+// servers where neither git hooks nor watchers are practical
+func (s *RAGServer) runScheduledReindex(ctx context.Context) {
+	schedule, err := cron.ParseStandard(s.config.ReindexSchedule)
+	if err != nil {
+		s.logger.Error("Invalid reindex_schedule, scheduled reindexing disabled",
+			zap.String("schedule", s.config.ReindexSchedule), zap.Error(err))
+		return
+	}
+	s.logger.Info("Scheduled reindexing enabled", zap.String("schedule", s.config.ReindexSchedule))
+
+	for {
+		next := schedule.Next(time.Now())
+		if !sleepContext(ctx, time.Until(next)) {
+			return
+		}
+
+		if !s.isLeader() {
+			continue
+		}
+		// A full or incremental run already covers these files
+		if s.reindexing.Load() || s.incrementalIndexer.Running() {
+			s.logger.Info("Indexing in progress, skipping scheduled reindex")
+			continue
+		}
+
+		for _, path := range s.config.CodePaths {
+			if _, err := os.Stat(path); err != nil {
+				s.logger.Warn("Skipping missing code path", zap.String("path", path), zap.Error(err))
+				continue
+			}
+			if _, err := s.incrementalIndexer.SyncDirectory(ctx, path, s.config.FileExtensions, s.config.CollectionName); err != nil {
+				s.logger.Warn("Scheduled reindex failed", zap.String("path", path), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,580 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"go.uber.org/zap"
+)
+
+// embedderRetryAfter is how long the embedder is skipped after a failure
+// before searches try it again
+const embedderRetryAfter = 30 * time.Second
+
+// degradedBanner is prepended to results produced without embeddings
+const degradedBanner = "⚠️ **Degraded mode:** the embedding service is unavailable. Results below are lexical (BM25 keyword) matches over indexed content, not semantic matches.\n\n"
+
+// embedderHealth tracks embedding failures so searches can switch to
+// lexical mode immediately instead of waiting on a dead service each time
+type embedderHealth struct {
+	mu          sync.Mutex
+	lastFailure time.Time
+	lastError   error
+}
+
+// available reports whether the embedder should be tried
+func (h *embedderHealth) available() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastFailure.IsZero() || time.Since(h.lastFailure) > embedderRetryAfter
+}
+
+func (h *embedderHealth) recordFailure(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastFailure = time.Now()
+	h.lastError = err
+}
+
+func (h *embedderHealth) recordSuccess() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastFailure = time.Time{}
+	h.lastError = nil
+}
+
+// status returns "ok" or "degraded" with the last embedding error
+func (h *embedderHealth) status() (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.lastFailure.IsZero() {
+		return "ok", nil
+	}
+	return "degraded", h.lastError
+}
+
+// embed generates the embedding of a query and records the embedder's health
+func (s *RAGServer) embed(ctx context.Context, text string) ([]float32, error) {
+	embedding, err := s.embedder.Embed(ctx, s.indexer.QueryText(text))
+	if err != nil {
+		// A canceled or timed out request says nothing about the embedder
+		if ctx.Err() == nil {
+			s.embedderHealth.recordFailure(err)
+		}
+		return nil, err
+	}
+	s.embedderHealth.recordSuccess()
+	return embedding, nil
+}
+
+// searchRequest describes one search through the pipeline
+type searchRequest struct {
+	Tool     string // Tool or endpoint searching, for the search log
+	Query    string
+	Limit    int
+	Offset   int // Matches to skip, for paging
+	MinScore float32
+	Hybrid   bool              // Merge BM25 lexical matches into vector results
+	Tags     []string          // Only match chunks carrying any of these tags
+	Root     string            // Only match chunks indexed from this code path ("" = all)
+	Language string            // Only match chunks of this language ("" = all)
+	Timeout  time.Duration     // Budget for optional stages (0 = config default)
+	Unlogged bool              // Keep out of the search log (evaluation queries)
+	Debug    bool              // Trace candidates and time each step
+	Params   *rag.SearchParams // Overrides search_params
+}
+
+// filter returns the search filter scoping req to its tags, root and language
+func (req searchRequest) filter() map[string]interface{} {
+	filter := scopeFilter(req.Tags, req.Root)
+	if req.Language == "" {
+		return filter
+	}
+	if filter == nil {
+		filter = make(map[string]interface{})
+	}
+	filter["language"] = req.Language
+	return filter
+}
+
+// searchOutcome is the result of a search and how it was produced
+type searchOutcome struct {
+	Results       []rag.SearchResult
+	Degraded      bool            // Lexical fallback used because the embedder is down
+	Lexical       bool            // Lexical fallback used because no semantic match passed min_score
+	SkippedStages []string        // Optional stages skipped to meet the deadline
+	Stats         *SearchMetadata // Candidates of the vector search (nil when degraded)
+	Debug         *searchDebug    // Set when the request asked for debugging
+	NextOffset    int             // Offset of the next page
+}
+
+// searchStage is an optional post-retrieval step (merging, reranking...).
+// Stages are skipped when they would not finish before the search deadline.
+type searchStage struct {
+	name    string
+	enabled func(req searchRequest) bool
+	run     func(ctx context.Context, req searchRequest, results []rag.SearchResult) ([]rag.SearchResult, error)
+}
+
+// stageTimings keeps a moving average of each stage's duration, used to
+// predict whether a stage fits in the remaining time budget. The average of a
+// skipped stage decays, so one slow run does not disable it for good: once
+// the estimate fits again, the stage runs and its duration is measured anew.
+type stageTimings struct {
+	mu       sync.Mutex
+	averages map[string]time.Duration
+}
+
+func (t *stageTimings) estimate(stage string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.averages[stage]
+}
+
+func (t *stageTimings) record(stage string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.averages == nil {
+		t.averages = make(map[string]time.Duration)
+	}
+	if avg, ok := t.averages[stage]; ok {
+		d = (avg*4 + d) / 5
+	}
+	t.averages[stage] = d
+}
+
+// skipped decays the average of a stage skipped for lack of time
+func (t *stageTimings) skipped(stage string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if avg, ok := t.averages[stage]; ok {
+		t.averages[stage] = avg * 4 / 5
+	}
+}
+
+// searchStages returns the optional stages, in execution order
+func (s *RAGServer) searchStages() []searchStage {
+	return []searchStage{
+		{
+			// Fused rankings cannot be paged consistently, so only the first page is merged
+			name:    "hybrid",
+			enabled: func(req searchRequest) bool { return req.Hybrid && req.Offset == 0 && s.nativeHybrid(req) == nil },
+			run: func(ctx context.Context, req searchRequest, results []rag.SearchResult) ([]rag.SearchResult, error) {
+				lexical, err := rag.LexicalSearch(ctx, s.vectorDB, s.config.CollectionName, req.filter(), req.Query, req.Limit, s.dedupOptions())
+				if err != nil {
+					return nil, err
+				}
+				return rag.FuseResults(results, lexical, req.Limit, s.dedupOptions()), nil
+			},
+		},
+	}
+}
+
+// rankingStage re-ranks results with the path, filename and recency boosts.
+// It runs last, after the lexical fallback.
+func (s *RAGServer) rankingStage() searchStage {
+	return searchStage{
+		name:    "ranking",
+		enabled: func(searchRequest) bool { return s.ranker != nil },
+		run: func(ctx context.Context, req searchRequest, results []rag.SearchResult) ([]rag.SearchResult, error) {
+			return s.ranker.Rerank(req.Query, results), nil
+		},
+	}
+}
+
+// runStage runs an optional stage on the results of outcome, unless it would
+// not finish before deadline; skipped or failed stages leave them unchanged
+func (s *RAGServer) runStage(ctx context.Context, stage searchStage, req searchRequest, deadline time.Time, outcome *searchOutcome) {
+	if !stage.enabled(req) {
+		return
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 || s.stageTimings.estimate(stage.name) > remaining {
+		s.stageTimings.skipped(stage.name)
+		outcome.SkippedStages = append(outcome.SkippedStages, stage.name)
+		return
+	}
+
+	stageCtx, cancel := context.WithDeadline(ctx, deadline)
+	started := time.Now()
+	results, err := stage.run(stageCtx, req, outcome.Results)
+	cancel()
+	s.stageTimings.record(stage.name, time.Since(started))
+	outcome.Debug.time(stage.name, started)
+
+	if err != nil {
+		s.logger.Warn("Search stage skipped", zap.String("stage", stage.name), zap.Error(err))
+		outcome.SkippedStages = append(outcome.SkippedStages, stage.name)
+		return
+	}
+	outcome.Results = results
+}
+
+// nativeHybrid returns the vector database when it fuses the hybrid search
+// of req itself with the sparse vectors stored with chunks, nil when hybrid
+// results are fused in-process
+func (s *RAGServer) nativeHybrid(req searchRequest) rag.HybridSearcher {
+	if !req.Hybrid || s.indexer.SparseEncoder() == nil {
+		return nil
+	}
+	hybrid, _ := s.vectorDB.(rag.HybridSearcher)
+	return hybrid
+}
+
+// vectorSearch returns the chunks nearest to embedding. With two-tier search,
+// the first page ranks chunks of the files whose summaries match best first,
+// topped up from the whole collection when those files hold too few chunks.
+// stats describe the whole-collection search when one ran. Hybrid searches
+// the vector database fuses natively skip both and page like flat searches.
+func (s *RAGServer) vectorSearch(ctx context.Context, embedding []float32, req searchRequest, opts rag.SearchOptions, stats *rag.SearchStats) ([]rag.SearchResult, error) {
+	if hybrid := s.nativeHybrid(req); hybrid != nil {
+		sparse := s.indexer.SparseEncoder().EncodeQuery(req.Query)
+		return hybrid.HybridSearch(rag.WithSearchStats(ctx, stats), s.config.CollectionName, req.filter(), embedding, sparse, req.Limit, req.Offset, req.MinScore, opts)
+	}
+	if !s.config.TwoTierSearch || req.Offset > 0 {
+		return s.vectorDB.Search(rag.WithSearchStats(ctx, stats), s.config.CollectionName, req.filter(), embedding, req.Limit, req.Offset, noScoreThreshold, opts)
+	}
+
+	var tierStats rag.SearchStats
+	results, candidates, err := rag.TwoTierSearch(rag.WithSearchStats(ctx, &tierStats), s.vectorDB, s.config.CollectionName, req.filter(), embedding, s.config.TwoTierFiles, req.Limit, noScoreThreshold, opts)
+	if err != nil {
+		// Chunks stay searchable without file summaries
+		s.logger.Debug("Two-tier search unavailable", zap.Error(err))
+	}
+	if len(results) >= req.Limit {
+		*stats = tierStats
+		// Later pages are flat searches of the whole collection
+		stats.Consumed = 0
+		return results, nil
+	}
+
+	flat, err := s.vectorDB.Search(rag.WithSearchStats(ctx, stats), s.config.CollectionName, req.filter(), embedding, req.Limit, 0, noScoreThreshold, opts)
+	if err != nil || len(candidates) == 0 {
+		return flat, err
+	}
+	seen := make(map[string]bool, len(results))
+	for _, result := range results {
+		seen[result.ID] = true
+	}
+	for _, result := range flat {
+		if len(results) == req.Limit {
+			break
+		}
+		if !seen[result.ID] {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// search runs the search pipeline: a vector search (or a lexical fallback
+// when the embedder is down), then optional stages while the deadline allows,
+// a lexical fallback when nothing passed min_score, then the ranking boosts.
+// Vector-only results are always returned; vector DB errors are returned as-is.
+func (s *RAGServer) search(ctx context.Context, req searchRequest) (outcome *searchOutcome, err error) {
+	started := time.Now()
+	defer func() { s.logSearch(req, outcome, err, time.Since(started)) }()
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = s.config.SearchTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	opts := s.searchOptions(req.Params)
+
+	outcome = &searchOutcome{NextOffset: req.Offset + req.Limit}
+	if req.Debug {
+		outcome.Debug = s.newSearchDebug(req)
+		ctx = rag.WithSearchTrace(ctx, &outcome.Debug.trace)
+	}
+	debug := outcome.Debug
+
+	if s.embedderHealth.available() {
+		step := time.Now()
+		embedding, embedErr := s.embed(ctx, req.Query)
+		debug.time("embed", step)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if embedErr == nil {
+			var dbStats rag.SearchStats
+			step = time.Now()
+			results, err := s.vectorSearch(ctx, embedding, req, opts, &dbStats)
+			debug.time("vector search", step)
+			if err != nil {
+				return nil, err
+			}
+			outcome.Stats = s.newSearchStats(dbStats, results, req.MinScore, req.Tags)
+			if dbStats.Consumed > 0 {
+				outcome.NextOffset = req.Offset + dbStats.Consumed
+			}
+			// Fused scores rank rather than measure similarity: the vector
+			// database applied min_score to the dense matches
+			if s.nativeHybrid(req) == nil {
+				results = aboveMinScore(results, req.MinScore)
+			}
+			// Overlay matches are only ranked into the first page
+			step = time.Now()
+			outcome.Results = s.applyOverlay(ctx, req.filter(), embedding, results, req.Limit, req.MinScore, opts, req.Offset == 0)
+			if s.overlayActive.Load() {
+				debug.time("overlay", step)
+			}
+		} else {
+			s.logger.Warn("Embedding failed, falling back to lexical search", zap.Error(embedErr))
+			outcome.Degraded = true
+		}
+	} else {
+		outcome.Degraded = true
+	}
+
+	if outcome.Degraded {
+		step := time.Now()
+		results, err := rag.LexicalSearch(ctx, s.vectorDB, s.config.CollectionName, req.filter(), req.Query, req.Offset+req.Limit, opts.Dedup)
+		debug.time("lexical search", step)
+		if err != nil {
+			return nil, err
+		}
+		if req.Offset < len(results) {
+			outcome.Results = results[req.Offset:]
+			s.runStage(ctx, s.rankingStage(), req, deadline, outcome)
+		}
+		s.recordHits(outcome.Results)
+		// Lexical results already are the best we can do without embeddings
+		return outcome, nil
+	}
+
+	for _, stage := range s.searchStages() {
+		s.runStage(ctx, stage, req, deadline, outcome)
+	}
+
+	if len(outcome.Results) == 0 && req.Offset == 0 {
+		step := time.Now()
+		s.lexicalFallback(ctx, req.filter(), req.Query, req.Limit, outcome)
+		debug.time("lexical fallback", step)
+	}
+
+	s.runStage(ctx, s.rankingStage(), req, deadline, outcome)
+	s.recordHits(outcome.Results)
+	return outcome, nil
+}
+
+// lexicalFallback fills an empty outcome with keyword matches over indexed
+// code, so a query naming an identifier still finds it when no semantic
+// match passes min_score. Only chunks matching filter are searched. Failures
+// leave the outcome empty.
+func (s *RAGServer) lexicalFallback(ctx context.Context, filter map[string]interface{}, query string, limit int, outcome *searchOutcome) {
+	if !s.config.LexicalFallback {
+		return
+	}
+	results, err := rag.LexicalSearch(ctx, s.vectorDB, s.config.CollectionName, filter, query, limit, s.dedupOptions())
+	if err != nil {
+		s.logger.Warn("Lexical fallback failed", zap.Error(err))
+		return
+	}
+	outcome.Results = results
+	outcome.Lexical = len(results) > 0
+}
+
+// lexicalNotice labels results of the lexical fallback, or is "" for others
+func lexicalNotice(outcome *searchOutcome, minScore float32) string {
+	if !outcome.Lexical {
+		return ""
+	}
+	best := ""
+	if outcome.Stats != nil && outcome.Stats.Candidates > 0 {
+		best = fmt.Sprintf(" (best: %.3f)", outcome.Stats.BestScore)
+	}
+	return fmt.Sprintf("🔤 **Lexical matches:** no semantic match scored above min_score %.2f%s. Results below are keyword (BM25) matches of identifiers and terms over indexed code, not semantic matches.\n\n", minScore, best)
+}
+
+// dedupOptions returns how search results are deduplicated, as configured
+func (s *RAGServer) dedupOptions() rag.DedupOptions {
+	return rag.DedupOptions{
+		Disabled:  !s.config.ResultDedup,
+		Threshold: s.config.ResultDedupThreshold,
+		OverFetch: s.config.SearchOverFetch,
+	}
+}
+
+// searchOptions returns the options of vector searches: the configured
+// deduplication, and params or else the configured search_params
+func (s *RAGServer) searchOptions(params *rag.SearchParams) rag.SearchOptions {
+	return rag.SearchOptions{Dedup: s.dedupOptions(), Params: s.searchParams(params)}
+}
+
+// searchParams returns params, or else the configured search_params
+func (s *RAGServer) searchParams(params *rag.SearchParams) rag.SearchParams {
+	if params != nil {
+		return *params
+	}
+	return rag.SearchParams{HnswEf: s.config.SearchParams.HnswEf, Exact: s.config.SearchParams.Exact}
+}
+
+// searchParamsArg reads the search_params argument of a search tool, or
+// returns nil when it is absent
+func searchParamsArg(arguments map[string]interface{}) (*rag.SearchParams, error) {
+	raw, ok := arguments["search_params"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	var params rag.SearchParams
+	if ef, ok := raw["hnsw_ef"].(float64); ok {
+		if ef < 0 {
+			return nil, fmt.Errorf("search_params.hnsw_ef must not be negative")
+		}
+		params.HnswEf = int(ef)
+	}
+	params.Exact, _ = raw["exact"].(bool)
+	return &params, nil
+}
+
+// applyOverlay replaces committed matches of files being edited by matches from
+// the uncommitted changes overlay, so stale pre-edit code is never returned.
+// Without withMatches, committed matches of those files are only dropped.
+// Overlay matches are limited to chunks matching filter and searched with
+// opts. Overlay errors leave the results unchanged.
+func (s *RAGServer) applyOverlay(ctx context.Context, filter map[string]interface{}, embedding []float32, results []rag.SearchResult, limit int, minScore float32, opts rag.SearchOptions, withMatches bool) []rag.SearchResult {
+	if !s.overlayActive.Load() {
+		return results
+	}
+
+	overlayFiles, err := rag.OverlayFiles(ctx, s.vectorDB, s.config.CollectionName)
+	if err != nil {
+		s.logger.Debug("Overlay unavailable", zap.Error(err))
+		return results
+	}
+	if len(overlayFiles) == 0 {
+		return results
+	}
+
+	var overlayResults []rag.SearchResult
+	if withMatches {
+		overlayResults, err = s.vectorDB.Search(ctx, rag.OverlayCollection(s.config.CollectionName), filter, embedding, limit, 0, minScore, opts)
+		if err != nil {
+			s.logger.Debug("Overlay search failed", zap.Error(err))
+			return results
+		}
+	}
+	return rag.MergeOverlay(results, overlayResults, overlayFiles, limit)
+}
+
+// partialResultsNotice explains which stages were skipped, or "" if none
+func partialResultsNotice(outcome *searchOutcome) string {
+	if len(outcome.SkippedStages) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("⏱️ **Partial results:** skipped %s to stay within the search deadline. Increase `timeout_ms` for complete results.\n\n",
+		strings.Join(outcome.SkippedStages, ", "))
+}
+
+// maxBatchQueries caps the number of queries in one batch search
+const maxBatchQueries = 10
+
+// maxSearchLimit caps the number of results of one search query
+const maxSearchLimit = 20
+
+// batchSearchResult is the outcome of one query in a batch search
+type batchSearchResult struct {
+	Query   string
+	Outcome *searchOutcome
+	Err     error
+}
+
+// searchBatch embeds all queries in one embedder call and runs the vector
+// searches in parallel. Results keep the order of queries; a failing query
+// does not fail the others. Falls back to lexical search when the embedder is down.
+// With tags, only chunks carrying any of them match, and with root only
+// chunks indexed from it.
+func (s *RAGServer) searchBatch(ctx context.Context, queries []string, limit int, minScore float32, tags []string, root string) []batchSearchResult {
+	opts := s.searchOptions(nil)
+	filter := scopeFilter(tags, root)
+	results := make([]batchSearchResult, len(queries))
+	for i, query := range queries {
+		results[i].Query = query
+	}
+
+	started := time.Now()
+	defer func() {
+		for _, r := range results {
+			s.logSearch(searchRequest{Tool: "batch_search", Query: r.Query, Limit: limit, MinScore: minScore, Tags: tags, Root: root}, r.Outcome, r.Err, time.Since(started))
+		}
+	}()
+
+	var embeddings [][]float32
+	if s.embedderHealth.available() {
+		var err error
+		texts := make([]string, len(queries))
+		for i, query := range queries {
+			texts[i] = s.indexer.QueryText(query)
+		}
+		embeddings, err = s.embedder.EmbedBatch(ctx, texts)
+		if err == nil && len(embeddings) != len(queries) {
+			err = fmt.Errorf("embedder returned %d embeddings for %d queries", len(embeddings), len(queries))
+		}
+		if err != nil && ctx.Err() != nil {
+			for i := range results {
+				results[i].Err = ctx.Err()
+			}
+			return results
+		}
+		if err != nil {
+			s.embedderHealth.recordFailure(err)
+			s.logger.Warn("Batch embedding failed, falling back to lexical search", zap.Error(err))
+			embeddings = nil
+		} else {
+			s.embedderHealth.recordSuccess()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range queries {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			outcome := &searchOutcome{}
+			var err error
+			if embeddings != nil {
+				var dbStats rag.SearchStats
+				outcome.Results, err = s.vectorDB.Search(rag.WithSearchStats(ctx, &dbStats), s.config.CollectionName, filter, embeddings[i], limit, 0, noScoreThreshold, opts)
+				if err == nil {
+					outcome.Stats = s.newSearchStats(dbStats, outcome.Results, minScore, tags)
+					outcome.Results = aboveMinScore(outcome.Results, minScore)
+					outcome.Results = s.applyOverlay(ctx, filter, embeddings[i], outcome.Results, limit, minScore, opts, true)
+					if len(outcome.Results) == 0 {
+						s.lexicalFallback(ctx, filter, queries[i], limit, outcome)
+					}
+				}
+			} else {
+				outcome.Degraded = true
+				outcome.Results, err = rag.LexicalSearch(ctx, s.vectorDB, s.config.CollectionName, filter, queries[i], limit, opts.Dedup)
+			}
+
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			outcome.Results = s.ranker.Rerank(queries[i], outcome.Results)
+			results[i].Outcome = outcome
+			s.recordHits(outcome.Results)
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// embeddingValidation returns the invalid vectors counted by the embedder,
+// if it validates its output
+func (s *RAGServer) embeddingValidation() (rag.EmbeddingValidationStats, bool) {
+	validating, ok := s.embedder.(*rag.ValidatingEmbedder)
+	if !ok {
+		return rag.EmbeddingValidationStats{}, false
+	}
+	return validating.Stats(), true
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+)
+
+// searchDebug explains how a search produced its results: the raw vector
+// candidates and what became of them, the filters applied and where the time
+// went
+type searchDebug struct {
+	trace   rag.SearchTrace
+	filters []string
+	timings []stageTiming
+	started time.Time
+}
+
+// stageTiming is how long one step of a search took
+type stageTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// newSearchDebug starts debugging a search of req
+func (s *RAGServer) newSearchDebug(req searchRequest) *searchDebug {
+	d := &searchDebug{started: time.Now()}
+	d.filters = append(d.filters, fmt.Sprintf("min_score %.2f", req.MinScore), fmt.Sprintf("limit %d", req.Limit))
+	if req.Offset > 0 {
+		d.filters = append(d.filters, fmt.Sprintf("offset %d", req.Offset))
+	}
+	if len(req.Tags) > 0 {
+		d.filters = append(d.filters, "tags "+strings.Join(req.Tags, ", "))
+	}
+	if req.Language != "" {
+		d.filters = append(d.filters, "language "+req.Language)
+	}
+	if s.nativeHybrid(req) != nil {
+		d.filters = append(d.filters, "hybrid (Qdrant sparse vectors)")
+	} else if req.Hybrid {
+		d.filters = append(d.filters, "hybrid (BM25 fusion)")
+	}
+	if params := s.searchParams(req.Params); params.Exact {
+		d.filters = append(d.filters, "exact search")
+	} else if params.HnswEf > 0 {
+		d.filters = append(d.filters, fmt.Sprintf("hnsw_ef %d", params.HnswEf))
+	}
+	if s.config.TwoTierSearch && req.Offset == 0 {
+		d.filters = append(d.filters, fmt.Sprintf("two-tier (best %d files first)", s.config.TwoTierFiles))
+	}
+	return d
+}
+
+// time records that step, begun at started, just finished. It does nothing
+// on searches without debugging.
+func (d *searchDebug) time(step string, started time.Time) {
+	if d == nil {
+		return
+	}
+	d.timings = append(d.timings, stageTiming{name: step, duration: time.Since(started)})
+}
+
+// candidates returns the distinct traced candidates by decreasing score,
+// explaining the ones the final results lack
+func (d *searchDebug) candidates(results []rag.SearchResult, minScore float32) []rag.TracedResult {
+	returned := make(map[string]bool, len(results))
+	for _, r := range results {
+		returned[r.ID] = true
+	}
+
+	// Two-tier searches can match a point twice: keep its best outcome
+	byID := make(map[string]int)
+	var candidates []rag.TracedResult
+	for _, c := range d.trace.Candidates {
+		if i, ok := byID[c.ID]; ok {
+			if candidates[i].Dropped != "" && c.Dropped == "" {
+				candidates[i] = c
+			}
+			continue
+		}
+		byID[c.ID] = len(candidates)
+		candidates = append(candidates, c)
+	}
+
+	for i, c := range candidates {
+		switch {
+		case c.Dropped != "":
+		case c.Score < minScore:
+			candidates[i].Dropped = fmt.Sprintf("below min_score %.2f", minScore)
+		case !returned[c.ID]:
+			candidates[i].Dropped = "displaced by overlay or hybrid matches"
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	return candidates
+}
+
+// debugReport renders the debug information of outcome as markdown
+func (outcome *searchOutcome) debugReport(minScore float32) string {
+	d := outcome.Debug
+	if d == nil {
+		return ""
+	}
+
+	var output strings.Builder
+	output.WriteString("\n## 🔍 Search Debug\n\n")
+	output.WriteString(fmt.Sprintf("**Filters:** %s\n", strings.Join(d.filters, ", ")))
+
+	steps := make([]string, 0, len(d.timings)+1)
+	for _, t := range d.timings {
+		steps = append(steps, fmt.Sprintf("%s %s", t.name, formatDuration(t.duration)))
+	}
+	steps = append(steps, "total "+formatDuration(time.Since(d.started)))
+	output.WriteString(fmt.Sprintf("**Timings:** %s\n", strings.Join(steps, ", ")))
+	if len(outcome.SkippedStages) > 0 {
+		output.WriteString(fmt.Sprintf("**Skipped stages:** %s\n", strings.Join(outcome.SkippedStages, ", ")))
+	}
+	output.WriteString("\n")
+
+	if outcome.Degraded {
+		output.WriteString("No vector candidates: the embedder is unavailable, so results are lexical (BM25) matches.\n")
+		return output.String()
+	}
+	candidates := d.candidates(outcome.Results, minScore)
+	if len(candidates) == 0 {
+		output.WriteString("No vector candidates: nothing indexed matched the filters.\n")
+		return output.String()
+	}
+
+	output.WriteString(fmt.Sprintf("**Vector candidates** (%d, before deduplication and min_score):\n\n", len(candidates)))
+	output.WriteString("| # | Chunk | Score | Outcome |\n")
+	output.WriteString("|---|-------|-------|---------|\n")
+	for i, c := range candidates {
+		result := "✅ returned"
+		if c.Dropped != "" {
+			result = "❌ " + c.Dropped
+		}
+		output.WriteString(fmt.Sprintf("| %d | `%s:%d-%d` | %.3f | %s |\n", i+1, c.FilePath, c.LineStart, c.LineEnd, c.Score, result))
+	}
+	if outcome.Lexical {
+		output.WriteString("\nNo candidate passed min_score, so the results are lexical fallback matches.\n")
+	}
+	return output.String()
+}
+
+// formatDuration renders d in milliseconds
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+}
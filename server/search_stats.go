@@ -0,0 +1,127 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+)
+
+// noScoreThreshold asks the vector database for the best matches whatever
+// their score, so the ones below min_score can be counted before dropping them
+const noScoreThreshold = -math.MaxFloat32
+
+// SearchMetadata describes the candidates a vector search considered, so an
+// empty result can be told apart: nothing indexed, or a threshold too strict
+type SearchMetadata struct {
+	Candidates    int            `json:"candidates"`      // Matches of the page before dropping anything
+	Duplicates    int            `json:"duplicates"`      // Overlapping or superseded chunks merged into a better match
+	BelowMinScore int            `json:"below_min_score"` // Distinct matches scoring under min_score
+	BestScore     float32        `json:"best_score"`      // Score of the best distinct match
+	Languages     map[string]int `json:"languages"`       // Distinct matches per language
+	Paths         map[string]int `json:"paths"`           // Distinct matches per code path
+	Tags          []string       `json:"tags,omitempty"`  // Tags the search was scoped to
+}
+
+// newSearchStats describes the distinct matches of a search, before min_score
+func (s *RAGServer) newSearchStats(db rag.SearchStats, results []rag.SearchResult, minScore float32, tags []string) *SearchMetadata {
+	stats := &SearchMetadata{
+		Tags:       tags,
+		Candidates: db.Candidates,
+		Duplicates: db.Superseded + db.Deduplicated,
+		Languages:  make(map[string]int),
+		Paths:      make(map[string]int),
+	}
+	for i, result := range results {
+		if i == 0 || result.Score > stats.BestScore {
+			stats.BestScore = result.Score
+		}
+		if result.Score < minScore {
+			stats.BelowMinScore++
+		}
+		language := result.Language
+		if language == "" {
+			language = "unknown"
+		}
+		stats.Languages[language]++
+		stats.Paths[s.codePathOf(result.FilePath)]++
+	}
+	return stats
+}
+
+// codePathOf returns the configured code path containing filePath, or its
+// directory when none does
+func (s *RAGServer) codePathOf(filePath string) string {
+	for _, root := range s.config.CodePaths {
+		if rel, err := filepath.Rel(root, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return root
+		}
+	}
+	return filepath.Dir(filePath)
+}
+
+// aboveMinScore returns the results scoring at least minScore
+func aboveMinScore(results []rag.SearchResult, minScore float32) []rag.SearchResult {
+	kept := results[:0:0]
+	for _, result := range results {
+		if result.Score >= minScore {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// summary is the metadata line appended to search responses
+func (st *SearchMetadata) summary(minScore float32) string {
+	scope := ""
+	if len(st.Tags) > 0 {
+		scope = fmt.Sprintf(" Scoped to tags: %s.", strings.Join(st.Tags, ", "))
+	}
+	if st.Candidates == 0 {
+		return "📊 **Search metadata:** no indexed chunk matched." + scope + "\n"
+	}
+	return fmt.Sprintf("📊 **Search metadata:** %d candidates considered (%s; %s), %d below min_score %.2f, %d duplicates merged, best score %.3f.%s\n",
+		st.Candidates, formatCounts(st.Languages), formatCounts(st.Paths), st.BelowMinScore, minScore, st.Duplicates, st.BestScore, scope)
+}
+
+// emptyReason explains why a search returned nothing
+func (st *SearchMetadata) emptyReason(minScore float32, offset int) string {
+	if st.Candidates == 0 && offset > 0 {
+		return fmt.Sprintf("No more matches after the first %d.", offset)
+	}
+	if st.Candidates == 0 && len(st.Tags) > 0 {
+		return fmt.Sprintf("No indexed code carries any of the tags %s.\n\nCheck the tags assigned with `path_tags` or `index_codebase`, or search without `tags`.", strings.Join(st.Tags, ", "))
+	}
+	if st.Candidates == 0 {
+		return "Nothing is indexed in this collection yet (no candidates at any score).\n\nRun `index_codebase` or check `get_index_stats`."
+	}
+	found := fmt.Sprintf("%d candidates were found but all scored below min_score %.2f (best: %.3f, %s).",
+		st.Candidates-st.Duplicates, minScore, st.BestScore, formatCounts(st.Languages))
+	if st.BestScore <= 0 {
+		return found + "\n\nNone of the indexed code relates to this query: rephrase it with terms from the code."
+	}
+	return found + fmt.Sprintf("\n\nThe threshold is too strict for this query: retry with `min_score` at or below %.2f, run `tune_threshold`, or rephrase it.", st.BestScore)
+}
+
+// formatCounts renders counts as "key n" by decreasing count
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s %d", key, counts[key])
+	}
+	return strings.Join(parts, ", ")
+}
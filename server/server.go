@@ -2,9 +2,16 @@ package server
 
 import (
 	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/Mirrdhyn/code-rag-mcp/config"
 	"github.com/Mirrdhyn/code-rag-mcp/rag"
+	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
@@ -15,31 +22,274 @@ type RAGServer struct {
 	incrementalIndexer *rag.IncrementalIndexer
 	vectorDB           rag.VectorDB
 	embedder           rag.Embedder
+	trash              *rag.Trash
+	confirmations      *confirmationStore
+	jobs               *jobStore
+	shown              *shownExcerpts
+	hotFiles           *rag.HotFiles  // nil: hot file cache disabled
+	searchLog          *rag.SearchLog // nil: search log disabled
+	ranker             *rag.Ranker    // nil: results ordered by similarity only
+	generator          rag.Generator  // nil: ask_codebase disabled
+	embedderHealth     embedderHealth
+	stageTimings       stageTimings
+	reindexing         atomic.Bool // A reindex_all run is in progress
+	overlayActive      atomic.Bool // The overlay was refreshed by this process and is merged into searches
+	draining           atomic.Bool // Shutting down: not ready, no new indexing
+	clientRoots        clientRoots // Workspace roots of the stdio client
+	proxies            []client.MCPClient
+	leader             *rag.Lease         // nil: this instance always indexes
+	ctx                context.Context    // Parent of request contexts, canceled when Serve returns
+	stop               context.CancelFunc // Cancels ctx
 	config             *config.Config
 	logger             *zap.Logger
 }
 
+// trashPurgeInterval is how often expired trash entries are purged
+const trashPurgeInterval = time.Hour
+
 func NewRAGServer(indexer *rag.Indexer, incrementalIndexer *rag.IncrementalIndexer, vectorDB rag.VectorDB, embedder rag.Embedder, cfg *config.Config, logger *zap.Logger) *RAGServer {
 	s := &RAGServer{
 		indexer:            indexer,
 		incrementalIndexer: incrementalIndexer,
 		vectorDB:           vectorDB,
 		embedder:           embedder,
+		trash:              rag.NewTrash(vectorDB, incrementalIndexer.WorkDir(), cfg.TrashRetention),
+		confirmations:      newConfirmationStore(),
+		shown:              newShownExcerpts(),
 		config:             cfg,
 		logger:             logger,
 	}
+	s.ctx, s.stop = context.WithCancel(context.Background())
+	s.jobs = newJobStore(s.ctx)
+
+	if cfg.HotFileCache > 0 {
+		s.hotFiles = rag.NewHotFiles(cfg.HotFileCache)
+	}
+
+	if r := cfg.Ranking; r.PathBoost != 0 || r.FilenameBoost != 0 || (r.RecencyWeight != 0 && r.RecencyHalfLife > 0) {
+		s.ranker = &rag.Ranker{
+			Config: rag.RankingConfig{
+				PathBoost:       r.PathBoost,
+				FilenameBoost:   r.FilenameBoost,
+				RecencyWeight:   r.RecencyWeight,
+				RecencyHalfLife: r.RecencyHalfLife,
+			},
+			Roots: cfg.CodePaths,
+		}
+	}
+
+	if g := cfg.Generation; g.Type != "" {
+		generator, err := rag.NewGenerator(rag.GeneratorConfig{
+			Type:        g.Type,
+			Model:       g.Model,
+			BaseURL:     g.BaseURL,
+			APIKey:      g.APIKey,
+			MaxTokens:   g.MaxTokens,
+			Temperature: g.Temperature,
+		})
+		if err != nil {
+			logger.Warn("ask_codebase disabled", zap.Error(err))
+		} else {
+			s.generator = generator
+		}
+	}
+
+	if cfg.SearchLog {
+		searchLog, err := rag.NewSearchLog(incrementalIndexer.WorkDir(), cfg.SearchLogRetention)
+		if err != nil {
+			logger.Warn("Search log disabled", zap.Error(err))
+		} else {
+			s.searchLog = searchLog
+		}
+	}
 
 	mcpServer := server.NewMCPServer(
 		cfg.ServerName,
 		cfg.ServerVersion,
+		server.WithPromptCapabilities(false),
 	)
 
 	s.registerTools(mcpServer)
+	s.registerPrompts(mcpServer)
 	s.mcp = mcpServer
 
 	return s
 }
 
+// MCPServer returns the underlying MCP server, e.g. to handle messages in-process
+func (s *RAGServer) MCPServer() *server.MCPServer {
+	return s.mcp
+}
+
 func (s *RAGServer) Serve(ctx context.Context) error {
+	// In-flight tool calls and HTTP requests are canceled on shutdown
+	context.AfterFunc(ctx, s.stop)
+	defer s.stop()
+
+	go s.runTrashPurge(ctx)
+	if s.config.PruneInterval > 0 {
+		go s.runPrune(ctx)
+	}
+	if s.config.OverlayInterval > 0 {
+		go s.runOverlayRefresh(ctx)
+	}
+	if s.config.ReindexSchedule != "" {
+		go s.runScheduledReindex(ctx)
+	}
+	if s.hotFiles != nil {
+		go s.runHotFilesPrefetch(ctx)
+	}
+	if s.config.EventBus.Type != "" {
+		go s.runEventBus(ctx)
+	}
+
+	s.mountProxies(ctx)
+	defer s.closeProxies()
+
+	if s.config.Transport == "sse" {
+		return s.serveSSE(ctx)
+	}
+	if s.config.ClientRoots.Enabled {
+		return s.serveStdio(ctx)
+	}
 	return server.ServeStdio(s.mcp)
 }
+
+// serveStdio serves MCP over stdin/stdout until the client disconnects or
+// ctx is done, following the workspace roots of the client
+func (s *RAGServer) serveStdio(ctx context.Context) error {
+	transport := newStdioTransport(s.mcp, os.Stdout, s.logger)
+	transport.observe = s.observeClient(transport)
+	return transport.listen(ctx, os.Stdin)
+}
+
+// toolContext returns the context of an MCP tool call or prompt: canceled
+// after request_timeout or when the server stops. mcp-go handlers receive no
+// request context, so a client cancelling a call cannot reach it.
+func (s *RAGServer) toolContext() (context.Context, context.CancelFunc) {
+	return withTimeout(s.ctx, s.config.RequestTimeout)
+}
+
+// indexingContext is toolContext for tools that index files, bounded by
+// reindex_timeout instead
+func (s *RAGServer) indexingContext() (context.Context, context.CancelFunc) {
+	return withTimeout(s.ctx, s.config.ReindexTimeout)
+}
+
+// withTimeout is context.WithTimeout where a timeout <= 0 means none
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// serveSSE serves MCP over HTTP server-sent events until ctx is done, for
+// containers where no client is attached to stdin. It is protected like the
+// HTTP API: client certificates, API keys and rate limits.
+func (s *RAGServer) serveSSE(ctx context.Context) error {
+	api := NewHTTPAPIServer(s, 0, s.logger)
+	tlsConfig, err := api.tlsConfig()
+	if err != nil {
+		return err
+	}
+	sse := &http.Server{
+		Addr:        s.config.SSEAddress,
+		Handler:     api.protect(api.fullAccessKey(newSSETransport(s.mcp, s.config.SSEBaseURL))),
+		TLSConfig:   tlsConfig,
+		BaseContext: func(net.Listener) context.Context { return s.ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			errCh <- sse.ListenAndServeTLS("", "")
+			return
+		}
+		errCh <- sse.ListenAndServe()
+	}()
+	s.logger.Info("Serving MCP over SSE",
+		zap.String("address", s.config.SSEAddress),
+		zap.String("base_url", s.config.SSEBaseURL),
+		zap.Bool("tls", tlsConfig != nil),
+	)
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return sse.Shutdown(shutdownCtx)
+	}
+}
+
+// runTrashPurge permanently deletes expired trash entries until ctx is done
+func (s *RAGServer) runTrashPurge(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := s.trash.PurgeExpired(ctx); err != nil {
+			s.logger.Warn("Failed to purge expired trash", zap.Error(err))
+		} else if purged > 0 {
+			s.logger.Info("Purged expired trash entries", zap.Int("entries", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runPrune removes stale chunks every PruneInterval until ctx is done
+func (s *RAGServer) runPrune(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !s.isLeader() {
+			continue
+		}
+		if _, err := s.indexer.PruneIndex(ctx, s.config.CollectionName, s.trash, false); err != nil {
+			s.logger.Warn("Failed to prune index", zap.Error(err))
+		}
+	}
+}
+
+// runOverlayRefresh indexes uncommitted files of the code paths every
+// OverlayInterval until ctx is done
+func (s *RAGServer) runOverlayRefresh(ctx context.Context) {
+	ticker := time.NewTicker(s.config.OverlayInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, path := range s.config.CodePaths {
+			if !s.isLeader() {
+				break
+			}
+			if _, err := s.indexer.RefreshOverlay(ctx, path, s.config.CollectionName, s.config.FileExtensions, s.embedder.Dimension()); err != nil {
+				s.logger.Debug("Failed to refresh overlay", zap.String("path", path), zap.Error(err))
+				continue
+			}
+			s.overlayActive.Store(true)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
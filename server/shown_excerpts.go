@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+)
+
+// maxShownExcerpts bounds the memory of shown excerpts; past it, the memory
+// starts over and excerpts are sent in full again
+const maxShownExcerpts = 2000
+
+// shownExcerpt is where an excerpt was first sent in the session
+type shownExcerpt struct {
+	Tool   string
+	Query  string
+	Result int // Result number in that response
+}
+
+// shownExcerpts remembers the code excerpts sent during the MCP session, so
+// later searches refer back to them instead of sending them again. The
+// stdio transport serves one session per process; with SSE, clients cannot
+// be told apart and nothing is remembered.
+type shownExcerpts struct {
+	mu    sync.Mutex
+	byKey map[string]shownExcerpt
+}
+
+func newShownExcerpts() *shownExcerpts {
+	return &shownExcerpts{byKey: make(map[string]shownExcerpt)}
+}
+
+// excerptKey identifies the excerpt content of result
+func excerptKey(result rag.SearchResult, content string) string {
+	return fmt.Sprintf("%s:%d-%d:%s", result.FilePath, result.LineStart, result.LineEnd, rag.ContentHash(content))
+}
+
+// lookup returns where the excerpt content of result was sent before
+func (e *shownExcerpts) lookup(result rag.SearchResult, content string) (shownExcerpt, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	shown, ok := e.byKey[excerptKey(result, content)]
+	return shown, ok
+}
+
+// record remembers that the excerpt content of result was sent, at shown
+func (e *shownExcerpts) record(result rag.SearchResult, content string, shown shownExcerpt) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.byKey) >= maxShownExcerpts {
+		e.byKey = make(map[string]shownExcerpt)
+	}
+	e.byKey[excerptKey(result, content)] = shown
+}
+
+// sessionDedup reports whether sent excerpts are remembered so repeats can
+// be referenced rather than sent again
+func (s *RAGServer) sessionDedup() bool {
+	return s.config.SessionDedup && s.config.Transport != "sse"
+}
+
+// maxReferenceQuery caps the query quoted in a reference, e.g. a code
+// snippet given to find_similar_code
+const maxReferenceQuery = 60
+
+// reference replaces a repeated excerpt in a response
+func (p shownExcerpt) reference() string {
+	query := strings.Join(strings.Fields(p.Query), " ")
+	if runes := []rune(query); len(runes) > maxReferenceQuery {
+		query = string(runes[:maxReferenceQuery]) + "…"
+	}
+	return fmt.Sprintf("↩️ _Previously shown as result #%d of `%s` (%q)._\n\n", p.Result, p.Tool, query)
+}
+
+// showRepeatsHint tells how to get repeated excerpts in full
+const showRepeatsHint = "💡 Excerpts already shown in this session are referenced instead of repeated; pass `show_repeats: true` to repeat them.\n"
+
+// showRepeatsArg reads the show_repeats argument of a search tool
+func showRepeatsArg(arguments map[string]interface{}) bool {
+	repeat, _ := arguments["show_repeats"].(bool)
+	return repeat
+}
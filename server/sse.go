@@ -0,0 +1,136 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sseTransport serves MCP over server-sent events like server.SSEServer,
+// as an http.Handler so it can sit behind the HTTP API middleware: mcp-go
+// v0.6.0 only serves SSE from its own unprotected listener
+type sseTransport struct {
+	mcp      *server.MCPServer
+	baseURL  string   // URL clients reach the transport at
+	sessions sync.Map // Session ID -> *sseSession
+}
+
+// sseSession is an open event stream
+type sseSession struct {
+	mu      sync.Mutex // Serializes events written by concurrent messages
+	writer  http.ResponseWriter
+	flusher http.Flusher
+	done    chan struct{}
+}
+
+func newSSETransport(mcpServer *server.MCPServer, baseURL string) *sseTransport {
+	return &sseTransport{mcp: mcpServer, baseURL: baseURL}
+}
+
+// ServeHTTP serves GET /sse, which opens a session, and POST /message
+func (t *sseTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/sse":
+		t.handleSSE(w, r)
+	case "/message":
+		t.handleMessage(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleSSE opens an event stream, sends the session's message endpoint and
+// keeps the stream open until the client goes away
+func (t *sseTransport) handleSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	sessionID := hex.EncodeToString(buf)
+	session := &sseSession{writer: w, flusher: flusher, done: make(chan struct{})}
+	t.sessions.Store(sessionID, session)
+	defer t.sessions.Delete(sessionID)
+
+	session.send("endpoint", fmt.Sprintf("%s/message?sessionId=%s", t.baseURL, sessionID))
+
+	<-r.Context().Done()
+	session.mu.Lock()
+	close(session.done)
+	session.mu.Unlock()
+}
+
+// handleMessage handles a JSON-RPC message of a session, answering on its
+// event stream and in the HTTP response
+func (t *sseTransport) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONRPCError(w, nil, mcp.INVALID_REQUEST, "Method not allowed")
+		return
+	}
+	value, ok := t.sessions.Load(r.URL.Query().Get("sessionId"))
+	if !ok {
+		writeJSONRPCError(w, nil, mcp.INVALID_PARAMS, "Invalid session ID")
+		return
+	}
+	session := value.(*sseSession)
+
+	var message json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		writeJSONRPCError(w, nil, mcp.PARSE_ERROR, "Parse error")
+		return
+	}
+
+	response := t.mcp.HandleMessage(r.Context(), message)
+	if response == nil {
+		// Notifications have no response
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	data, _ := json.Marshal(response)
+	session.send("message", string(data))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(data)
+}
+
+// send writes an event to the stream, unless it was closed
+func (s *sseSession) send(event, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	fmt.Fprintf(s.writer, "event: %s\ndata: %s\n\n", event, data)
+	s.flusher.Flush()
+}
+
+// writeJSONRPCError answers a message with a JSON-RPC error
+func writeJSONRPCError(w http.ResponseWriter, id interface{}, code int, message string) {
+	response := mcp.JSONRPCError{JSONRPC: mcp.JSONRPC_VERSION, ID: id}
+	response.Error.Code = code
+	response.Error.Message = message
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
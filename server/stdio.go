@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// stdioTransport serves MCP over stdin/stdout like server.ServeStdio, and
+// can also send requests to the client (e.g. roots/list), which mcp-go
+// v0.6.0 has no API for
+type stdioTransport struct {
+	mcp    *server.MCPServer
+	out    io.Writer
+	logger *zap.Logger
+
+	// observe is called with each client request and notification before it
+	// is handled
+	observe func(method string, params json.RawMessage)
+
+	writeMu   sync.Mutex
+	nextID    atomic.Int64
+	pendingMu sync.Mutex
+	pending   map[string]chan rpcResponse // Requests sent to the client, by ID
+}
+
+// rpcResponse is a client response to a request of the server
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// errTransportClosed is returned by requests pending when the client disconnects
+var errTransportClosed = errors.New("client disconnected")
+
+func newStdioTransport(mcpServer *server.MCPServer, out io.Writer, logger *zap.Logger) *stdioTransport {
+	return &stdioTransport{
+		mcp:     mcpServer,
+		out:     out,
+		logger:  logger,
+		observe: func(string, json.RawMessage) {},
+		pending: make(map[string]chan rpcResponse),
+	}
+}
+
+// listen handles the messages read from in until it is closed or ctx is done
+func (t *stdioTransport) listen(ctx context.Context, in io.Reader) error {
+	defer t.closePending()
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read message: %w", err)
+		case line := <-lines:
+			if err := t.handle(ctx, []byte(line)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle routes a message: responses go to the pending request, requests and
+// notifications to the MCP server
+func (t *stdioTransport) handle(ctx context.Context, message []byte) error {
+	var base struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	// Malformed messages are left to the MCP server, which answers them
+	if err := json.Unmarshal(message, &base); err == nil {
+		if base.Method == "" && len(base.ID) > 0 {
+			t.deliver(string(base.ID), message)
+			return nil
+		}
+		t.observe(base.Method, base.Params)
+	}
+
+	response := t.mcp.HandleMessage(ctx, message)
+	if response == nil {
+		// Notifications have no response
+		return nil
+	}
+	if err := t.write(response); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
+
+// request sends a request to the client and waits for its result
+func (t *stdioTransport) request(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	id := "code-rag-" + strconv.FormatInt(t.nextID.Add(1), 10)
+	key, _ := json.Marshal(id)
+	response := make(chan rpcResponse, 1)
+
+	t.pendingMu.Lock()
+	t.pending[string(key)] = response
+	t.pendingMu.Unlock()
+	defer func() {
+		t.pendingMu.Lock()
+		delete(t.pending, string(key))
+		t.pendingMu.Unlock()
+	}()
+
+	request := struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      string      `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+	}{mcp.JSONRPC_VERSION, id, method, params}
+	if err := t.write(request); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r, ok := <-response:
+		if !ok {
+			return nil, errTransportClosed
+		}
+		if r.Error != nil {
+			return nil, fmt.Errorf("%s failed: %s (%d)", method, r.Error.Message, r.Error.Code)
+		}
+		return r.Result, nil
+	}
+}
+
+// deliver passes a client response to the request waiting for it
+func (t *stdioTransport) deliver(id string, message []byte) {
+	t.pendingMu.Lock()
+	response, ok := t.pending[id]
+	t.pendingMu.Unlock()
+	if !ok {
+		t.logger.Debug("Ignoring response to an unknown request", zap.String("id", id))
+		return
+	}
+
+	var r rpcResponse
+	if err := json.Unmarshal(message, &r); err != nil {
+		t.logger.Warn("Malformed client response", zap.String("id", id), zap.Error(err))
+		return
+	}
+	select {
+	case response <- r:
+	default: // Answered twice
+	}
+}
+
+// closePending fails the requests still waiting for a response
+func (t *stdioTransport) closePending() {
+	t.pendingMu.Lock()
+	defer t.pendingMu.Unlock()
+	for id, response := range t.pending {
+		close(response)
+		delete(t.pending, id)
+	}
+}
+
+// write sends a message to the client, one JSON object per line
+func (t *stdioTransport) write(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = fmt.Fprintf(t.out, "%s\n", data)
+	return err
+}
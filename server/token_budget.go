@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/Mirrdhyn/code-rag-mcp/rag"
+)
+
+// minBudgetExcerptTokens is the smallest excerpt worth keeping when trimming
+// a result to fit a token budget; below that the result is dropped instead
+const minBudgetExcerptTokens = 40
+
+// tokenBudget tracks the tokens left for a response. A zero or negative
+// limit means unlimited.
+type tokenBudget struct {
+	limit     int
+	used      int
+	truncated int // Results whose excerpt was trimmed
+	dropped   int // Results left out entirely
+}
+
+func newTokenBudget(maxTokens int) *tokenBudget {
+	return &tokenBudget{limit: maxTokens}
+}
+
+func (b *tokenBudget) unlimited() bool {
+	return b.limit <= 0
+}
+
+func (b *tokenBudget) remaining() int {
+	return b.limit - b.used
+}
+
+// take reserves the tokens of text and reports whether they fit.
+// Text that does not fit is not counted.
+func (b *tokenBudget) take(text string) bool {
+	if b.unlimited() {
+		return true
+	}
+	n := rag.CountTokens(text)
+	if n > b.remaining() {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+// fitBlock renders a block around content (frame receives the content to embed)
+// and reserves it, trimming content to the remaining budget if needed.
+// Returns "" when not even a minimal excerpt fits.
+func (b *tokenBudget) fitBlock(content string, frame func(content string) string) string {
+	block := frame(content)
+	if b.take(block) {
+		return block
+	}
+
+	overhead := rag.CountTokens(frame(""))
+	available := b.remaining() - overhead
+	if available < minBudgetExcerptTokens {
+		b.dropped++
+		return ""
+	}
+
+	trimmed, droppedLines := rag.TruncateToTokens(content, available-10) // keep room for the marker
+	block = frame(trimmed + fmt.Sprintf("\n... (%d more lines, trimmed to fit max_tokens)", droppedLines))
+	if !b.take(block) {
+		b.dropped++
+		return ""
+	}
+	b.truncated++
+	return block
+}
+
+// dropRest records n results left out once the budget ran out
+func (b *tokenBudget) dropRest(n int) {
+	b.dropped += n
+}
+
+// notice summarizes what the budget removed, or "" if nothing was
+func (b *tokenBudget) notice() string {
+	if b.truncated == 0 && b.dropped == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n✂️ **max_tokens=%d:** %d excerpt(s) trimmed, %d lower-score result(s) dropped.\n",
+		b.limit, b.truncated, b.dropped)
+}
@@ -42,6 +42,12 @@ DO NOT use grep/find commands - use this tool instead. It understands code seman
 					"minimum":     1,
 					"maximum":     20,
 				},
+				"offset": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of matches to skip, to fetch the next page (e.g. offset 5 with limit 5 returns matches 6-10)",
+					"default":     0,
+					"minimum":     0,
+				},
 				"min_score": map[string]interface{}{
 					"type":        "number",
 					"description": "Minimum similarity threshold 0-1 (default: 0.7 for precise, 0.5 for broad)",
@@ -62,14 +68,303 @@ DO NOT use grep/find commands - use this tool instead. It understands code seman
 				},
 				"language": map[string]interface{}{
 					"type":        "string",
-					"description": "Filter by language: go, python, javascript, typescript, terraform, yaml",
-					"enum":        []string{"go", "python", "javascript", "typescript", "terraform", "yaml", "all"},
+					"description": "Only match code of this language as detected at indexing, e.g. go, python, typescript, terraform, ruby, csharp, protobuf (get_index_stats lists the indexed ones). Default: all",
+				},
+				"expand_context": map[string]interface{}{
+					"type":        "integer",
+					"description": "Read N extra lines before/after each match from disk, so cut-off signatures and returns are included (max: 100)",
+					"minimum":     0,
+					"maximum":     100,
+				},
+				"return_parent": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return the whole enclosing function, class or section of each match (read from disk) instead of the matched fragment. Matches sharing one are merged. Default: false",
+				},
+				"max_tokens": map[string]interface{}{
+					"type":        "integer",
+					"description": "Token budget for the response. Excerpts are trimmed and lower-score results dropped to stay under it.",
+					"minimum":     100,
+				},
+				"hybrid": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also match exact keywords (BM25) and merge with semantic results. Helps with identifiers and error strings.",
+				},
+				"timeout_ms": map[string]interface{}{
+					"type":        "integer",
+					"description": "Search deadline in milliseconds (default: search_timeout). Optional stages like hybrid merging are skipped past it; vector results are always returned.",
+					"minimum":     100,
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only search code carrying any of these tags, assigned to repositories with index_codebase or path_tags (e.g. ['team:payments', 'tier:critical'])",
+				},
+				"root": map[string]interface{}{
+					"type":        "string",
+					"description": "Only search code indexed from this code path, given as its path or directory name (default: the project open in the client, else all code paths; \"\" for all)",
+				},
+				"show_repeats": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Send excerpts already shown earlier in the session in full instead of referencing them (default: false)",
+				},
+				"search_params": map[string]interface{}{
+					"type":        "object",
+					"description": "Vector index settings trading latency for recall on large collections (default: config search_params)",
+					"properties": map[string]interface{}{
+						"hnsw_ef": map[string]interface{}{
+							"type":        "integer",
+							"description": "Candidates explored by the HNSW search; higher finds more true matches, slower (0: collection default)",
+							"minimum":     0,
+						},
+						"exact": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Compare the query with every vector instead of using the index (slow, exact)",
+						},
+					},
+				},
+				"debug": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Append the raw vector candidates with their scores and why each was dropped (deduplication, min_score...), the filters applied and a timing breakdown. Use to diagnose why some code did not show up. Default: false",
 				},
 			},
 			Required: []string{"query"},
 		},
 	}, s.handleSemanticSearch)
 
+	// Several searches in one call
+	mcpServer.AddTool(mcp.Tool{
+		Name: "batch_search",
+		Description: `Run several semantic searches in one call.
+
+Use when a task breaks down into sub-questions (e.g. "where are users created", "where are
+users validated", "where are users persisted"). All queries are embedded together and
+searched in parallel; results are grouped per query. Max 10 queries.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"queries": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Natural language queries (max 10)",
+					"minItems":    1,
+					"maxItems":    10,
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Results per query (default: 3, max: 20)",
+					"default":     3,
+					"minimum":     1,
+					"maximum":     20,
+				},
+				"min_score": map[string]interface{}{
+					"type":        "number",
+					"description": "Minimum similarity threshold 0-1 (default: config min_score)",
+					"minimum":     0.0,
+					"maximum":     1.0,
+				},
+				"compact": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return file:line references only (default: true)",
+					"default":     true,
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only search code carrying any of these tags (e.g. ['team:payments'])",
+				},
+				"root": map[string]interface{}{
+					"type":        "string",
+					"description": "Only search code indexed from this code path, given as its path or directory name (default: the project open in the client, else all code paths; \"\" for all)",
+				},
+				"show_repeats": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Send excerpts already shown earlier in the session in full instead of referencing them (default: false)",
+				},
+			},
+			Required: []string{"queries"},
+		},
+	}, s.handleBatchSearch)
+
+	// Exact pattern matches and semantic matches in one call
+	mcpServer.AddTool(mcp.Tool{
+		Name: "grep_and_semantic",
+		Description: `Run an exact pattern match AND a semantic search for the same query, merged.
+
+Use instead of shell grep when a lookup needs exact matching (an error message, a config key,
+a call like "retry.Do(") but related code matters too. Results are labeled:
+- 🎯 exact + semantic: a semantic match containing lines that match the pattern
+- 🔎 exact: a line matching the pattern
+- 🧠 semantic: related code without an exact match
+
+The pattern is matched over indexed content, line by line (Go regexp syntax).`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "What to look for. Searched semantically, and matched literally when no pattern is given.",
+				},
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Regular expression for the exact match (default: the query, literally), e.g. 'ErrNotFound|ErrMissing'",
+				},
+				"case_sensitive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Match the pattern case-sensitively (default: false)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Semantic matches, and exact lines outside them, to return (default: 10)",
+					"default":     10,
+					"minimum":     1,
+				},
+				"min_score": map[string]interface{}{
+					"type":        "number",
+					"description": "Minimum similarity threshold 0-1 of semantic matches (default: config min_score)",
+					"minimum":     0.0,
+					"maximum":     1.0,
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only search code carrying any of these tags (e.g. ['team:payments'])",
+				},
+				"root": map[string]interface{}{
+					"type":        "string",
+					"description": "Only search code indexed from this code path, given as its path or directory name (default: the project open in the client, else all code paths; \"\" for all)",
+				},
+			},
+			Required: []string{"query"},
+		},
+	}, s.handleGrepAndSemantic)
+
+	// Answer a question from retrieved code with the generation model
+	mcpServer.AddTool(mcp.Tool{
+		Name: "ask_codebase",
+		Description: `Answer a question about the codebase: retrieves the most relevant chunks and has the
+configured chat model (generation settings) write an answer citing file:line.
+
+Use when the client wants a final answer rather than search results, e.g.
+"How are webhooks authenticated?" or "Where does pagination happen in the API?".
+Requires generation.type and generation.model; otherwise use semantic_code_search.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"question": map[string]interface{}{
+					"type":        "string",
+					"description": "Question about the code, in natural language",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Chunks retrieved to answer from (default: 8)",
+					"default":     8,
+					"minimum":     1,
+					"maximum":     30,
+				},
+				"min_score": map[string]interface{}{
+					"type":        "number",
+					"description": "Minimum similarity threshold 0-1 of retrieved chunks (default: config min_score)",
+					"minimum":     0.0,
+					"maximum":     1.0,
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only answer from code carrying any of these tags (e.g. ['team:payments'])",
+				},
+				"root": map[string]interface{}{
+					"type":        "string",
+					"description": "Only search code indexed from this code path, given as its path or directory name (default: the project open in the client, else all code paths; \"\" for all)",
+				},
+			},
+			Required: []string{"question"},
+		},
+	}, s.handleAskCodebase)
+
+	// Threshold tuning (result counts at several min_score values)
+	mcpServer.AddTool(mcp.Tool{
+		Name: "tune_threshold",
+		Description: `Find the right min_score for a query.
+
+Scores the best matches once, then shows how many results and which top files each min_score
+level keeps, and recommends a threshold. Use when searches return nothing or too much noise.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Query to tune the threshold for",
+				},
+				"levels": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "number", "minimum": 0.0, "maximum": 1.0},
+					"description": "min_score values to try (default: 0.3 to 0.9 by 0.1; the configured min_score is always added)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of results wanted, used for the recommendation (default: config top_k)",
+					"minimum":     1,
+				},
+			},
+			Required: []string{"query"},
+		},
+	}, s.handleTuneThreshold)
+
+	// Retrieval quality against expected results
+	mcpServer.AddTool(mcp.Tool{
+		Name: "evaluate_index",
+		Description: `Measure search quality on a set of queries with known answers.
+
+Runs each query of an eval_queries.yaml file (query and golden files or line ranges) through
+search with the current index and settings, and reports recall, MRR and nDCG. Re-run after
+changing chunking, embedder or thresholds to compare.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"queries_file": map[string]interface{}{
+					"type":        "string",
+					"description": "Evaluation queries file (default: config eval_queries)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Results scored per query, the K of recall@K (default: config top_k)",
+					"minimum":     1,
+				},
+				"min_score": map[string]interface{}{
+					"type":        "number",
+					"description": "Minimum similarity score (default: config min_score)",
+					"minimum":     0.0,
+					"maximum":     1.0,
+				},
+			},
+		},
+	}, s.handleEvaluateIndex)
+
+	// Search analytics from the search log
+	mcpServer.AddTool(mcp.Tool{
+		Name: "get_search_analytics",
+		Description: `Summarize recorded searches: top queries, zero-result queries and latency.
+
+Every search (query, filters, top results, latency) is recorded locally. Use to learn what
+developers search for and where the index underperforms: zero-result queries point at code
+that is not indexed or a threshold that is too strict.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"since": map[string]interface{}{
+					"type":        "string",
+					"description": "Only searches in this period, as a duration (e.g. '24h', '168h'). Default: all recorded searches.",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Queries listed per table (default: 10)",
+					"default":     10,
+					"minimum":     1,
+				},
+			},
+		},
+	}, s.handleSearchAnalytics)
+
 	// Find similar code
 	mcpServer.AddTool(mcp.Tool{
 		Name: "find_similar_code",
@@ -96,6 +391,19 @@ Example: "Find code similar to this error handling pattern: [code snippet]"`,
 					"type":    "number",
 					"default": 0.75,
 				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only search code carrying any of these tags (e.g. ['team:payments'])",
+				},
+				"root": map[string]interface{}{
+					"type":        "string",
+					"description": "Only search code indexed from this code path, given as its path or directory name (default: the project open in the client, else all code paths; \"\" for all)",
+				},
+				"show_repeats": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Send excerpts already shown earlier in the session in full instead of referencing them (default: false)",
+				},
 			},
 			Required: []string{"code_snippet"},
 		},
@@ -104,24 +412,45 @@ Example: "Find code similar to this error handling pattern: [code snippet]"`,
 	// Explain code with context
 	mcpServer.AddTool(mcp.Tool{
 		Name: "explain_code_with_context",
-		Description: `Get explanation of code with relevant context from the entire codebase.
+		Description: `Get a file's indexed code with relevant context from the rest of the codebase.
 
 Use when:
 - User asks "how does this work" or "explain this code"
 - Need to understand code in relation to the rest of the system
 - Finding dependencies, callers, or related implementations
 
-Automatically retrieves relevant surrounding code for better understanding.`,
+Returns the file's outline, its indexed chunks (all of them, or only a symbol or line range),
+and similar chunks from other files. Output is capped by max_tokens (default 8000): focus on a
+symbol or line range to explain part of a large file.`,
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
 				"file_path": map[string]interface{}{
 					"type":        "string",
-					"description": "Path to file to explain",
+					"description": "Path to the indexed file to explain",
+				},
+				"symbol": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: only explain this function, type or class of the file (exact name)",
+				},
+				"start_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "Optional: first line to explain (1-based)",
+					"minimum":     1,
+				},
+				"end_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "Optional: last line to explain (inclusive)",
+					"minimum":     1,
 				},
 				"focus": map[string]interface{}{
 					"type":        "string",
-					"description": "Optional: specific aspect to focus on (e.g., 'dependencies', 'callers', 'implementation')",
+					"description": "Optional: specific aspect to focus on (e.g., 'dependencies', 'callers', 'implementation'), used to find related context",
+				},
+				"max_tokens": map[string]interface{}{
+					"type":        "integer",
+					"description": "Token budget for the response (default: 8000). The focused code gets two thirds, related context the rest.",
+					"minimum":     100,
 				},
 			},
 			Required: []string{"file_path"},
@@ -138,7 +467,8 @@ Use when:
 - Code has been significantly updated
 - Adding a new project directory
 
-This builds the semantic search index. Takes 30s-2min depending on codebase size.`,
+This builds the semantic search index. Runs in the background: returns a job ID
+immediately; follow it with get_job_status and stop it with cancel_job.`,
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
@@ -151,13 +481,146 @@ This builds the semantic search index. Takes 30s-2min depending on codebase size
 					"items": map[string]interface{}{
 						"type": "string",
 					},
-					"description": "File extensions to include (default: ['.go', '.py', '.js', '.ts', '.tf', '.yaml'])",
-					"default":     []string{".go", ".py", ".js", ".ts", ".tf", ".yaml", ".yml"},
+					"description": "File extensions to include (default: the configured file_extensions). Entries without a leading dot match file names, e.g. 'Dockerfile', 'Makefile'. Extensionless scripts are included by shebang when their language's extension is listed.",
+				},
+				"exclude_patterns": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "string",
+					},
+					"description": "Globs relative to path to skip, added to config exclude_patterns (e.g. ['**/generated/**', '*.pb.go', '*_test.go'])",
+				},
+				"include_patterns": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "string",
+					},
+					"description": "Only index files matching one of these globs relative to path, added to config include_patterns (e.g. ['src/**', 'internal/**'])",
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Tags stored with every chunk under path, to scope searches with their tags argument (e.g. ['team:payments', 'tier:critical']). Kept for later re-indexes of path; an empty list removes them.",
 				},
 			},
 			Required: []string{"path"},
 		},
-	}, s.handleIndexDirectory)
+	}, s.leaderOnly(s.handleIndexDirectory))
+
+	// Background indexing controls
+	mcpServer.AddTool(mcp.Tool{
+		Name: "cancel_indexing",
+		Description: `Cancel the running background indexing (auto-index on startup, reindex_all).
+
+Progress is saved: indexing the same path again resumes where it stopped.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]interface{}{},
+		},
+	}, s.handleCancelIndexing)
+
+	mcpServer.AddTool(mcp.Tool{
+		Name: "pause_indexing",
+		Description: `Pause background indexing after its current batch, e.g. while it saturates the embedding server during work hours.
+
+Resume it with resume_indexing.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]interface{}{},
+		},
+	}, s.handlePauseIndexing)
+
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "resume_indexing",
+		Description: `Resume background indexing paused with pause_indexing.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]interface{}{},
+		},
+	}, s.handleResumeIndexing)
+
+	// Background job status
+	mcpServer.AddTool(mcp.Tool{
+		Name: "get_job_status",
+		Description: `Get the status and progress of a background job started by index_codebase.
+
+Use when:
+- Checking whether indexing has finished
+- Finding out why a job failed
+
+Without job_id, lists recent jobs.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"job_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Job ID returned when the job was started (omit to list jobs)",
+				},
+			},
+		},
+	}, s.handleGetJobStatus)
+
+	// Cancel a background job
+	mcpServer.AddTool(mcp.Tool{
+		Name: "cancel_job",
+		Description: `Cancel a running background job, e.g. indexing the wrong directory.
+
+Chunks indexed before the cancellation stay in the index.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"job_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Job ID returned when the job was started",
+				},
+			},
+			Required: []string{"job_id"},
+		},
+	}, s.handleCancelJob)
+
+	// Map of the codebase by directory
+	mcpServer.AddTool(mcp.Tool{
+		Name: "summarize_architecture",
+		Description: `Get a map of the codebase before searching: indexed directories with their file and
+chunk counts, languages and top symbols (those used by the most other files).
+
+Use when:
+- Starting work on an unfamiliar codebase
+- Deciding where a feature probably lives before searching
+- Scoping searches or reviews to the right part of a monorepo
+
+With generate_summaries (requires a generation model), each directory also gets a short
+generated description, stored and reused by later calls.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Only map the code under this directory (default: all indexed code)",
+				},
+				"depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "Directory levels below each code path to group by (default: 2)",
+					"default":     2,
+					"minimum":     1,
+				},
+				"top_symbols": map[string]interface{}{
+					"type":        "integer",
+					"description": "Symbols listed per directory (default: 5)",
+					"default":     5,
+					"minimum":     0,
+				},
+				"generate_summaries": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Generate a summary of directories without one, with the generation model (default: false)",
+				},
+				"refresh": map[string]interface{}{
+					"type":        "boolean",
+					"description": "With generate_summaries, regenerate existing summaries too (default: false)",
+				},
+			},
+		},
+	}, s.handleSummarizeArchitecture)
 
 	// Get index stats
 	mcpServer.AddTool(mcp.Tool{
@@ -166,9 +629,10 @@ This builds the semantic search index. Takes 30s-2min depending on codebase size
 
 Shows:
 - Number of indexed files and chunks
-- Supported languages
+- Files and chunks per language and per top-level directory
 - Last index time
 - Index size
+- Index manifest (embedding model, distance, sparse vectors, chunker version, last full index, source roots) and mismatches with this server
 
 Use to verify index is ready before searching.`,
 		InputSchema: mcp.ToolInputSchema{
@@ -196,6 +660,20 @@ Use this to monitor background indexing without blocking.`,
 		},
 	}, s.handleGetIndexingProgress)
 
+	// Retry files that failed to index
+	mcpServer.AddTool(mcp.Tool{
+		Name: "retry_failed_files",
+		Description: `Index again the files that failed in the last background indexing run.
+
+Failures (embedding server errors, unreadable files) are recorded in the indexing state.
+Runs retry them with backoff before completing; use this once the cause is fixed, e.g.
+after restarting the embedding server.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]interface{}{},
+		},
+	}, s.leaderOnly(s.handleRetryFailedFiles))
+
 	// Re-index specific files (for git hooks)
 	mcpServer.AddTool(mcp.Tool{
 		Name: "reindex_files",
@@ -225,5 +703,495 @@ This tool:
 			},
 			Required: []string{"file_paths"},
 		},
-	}, s.handleReindexFiles)
+	}, s.leaderOnly(s.handleReindexFiles))
+
+	// Force a full re-index
+	mcpServer.AddTool(mcp.Tool{
+		Name: "reindex_all",
+		Description: `Force a full re-index, ignoring saved progress.
+
+Runs in the background (follow it with get_indexing_progress). Existing chunks of each
+file are replaced. Set recreate_collection to drop and recreate the collection first,
+e.g. after changing the embedding model or dimension; this requires a dry run and a
+confirmation_token because it cannot be undone.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Directories to re-index (default: configured code_paths)",
+				},
+				"recreate_collection": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Drop and recreate the collection before indexing (default: false)",
+					"default":     false,
+				},
+				"confirmation_token": map[string]interface{}{
+					"type":        "string",
+					"description": "Token from the dry run, required with recreate_collection",
+				},
+			},
+		},
+	}, s.leaderOnly(s.handleReindexAll))
+
+	// Re-index what changed in git since the last index
+	mcpServer.AddTool(mcp.Tool{
+		Name: "reindex_changed",
+		Description: `Re-index exactly the files changed in git since the directory was last indexed.
+
+Runs git diff --name-only <last indexed commit>..HEAD: changed files are re-indexed and
+deleted ones removed, then HEAD is recorded for the next run. Use after pulls, merges or
+branch switches instead of reindex_all. Uncommitted changes are not included.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Indexed directories inside git repositories (default: configured code_paths)",
+				},
+			},
+		},
+	}, s.leaderOnly(s.handleReindexChanged))
+
+	// Symbol lookup (definitions by name)
+	mcpServer.AddTool(mcp.Tool{
+		Name: "search_symbols",
+		Description: `Find where a function, type, class or other symbol is DEFINED.
+
+Use when:
+- You know (part of) a symbol name: "where is function ParseConfig defined"
+- Looking up a Terraform resource address (e.g. "aws_vpc.main")
+- Semantic search returns usages instead of the definition
+
+Matching modes:
+- exact: case-sensitive name equality (fastest)
+- prefix: case-insensitive name prefix (default)
+- fuzzy: case-insensitive substring or in-order characters (e.g. "hdlSrch" → handleSemanticSearch)`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Symbol name or fragment to look up",
+				},
+				"match": map[string]interface{}{
+					"type":        "string",
+					"description": "Matching mode (default: prefix)",
+					"enum":        []string{"exact", "prefix", "fuzzy"},
+					"default":     "prefix",
+				},
+				"kind": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: restrict to a symbol kind (function, method, type, class, interface, struct, resource, module...)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of definitions (default: 20)",
+					"default":     20,
+					"minimum":     1,
+					"maximum":     100,
+				},
+			},
+			Required: []string{"name"},
+		},
+	}, s.handleSearchSymbols)
+
+	// Symbol usages (references)
+	mcpServer.AddTool(mcp.Tool{
+		Name: "find_references",
+		Description: `Find every place where a symbol (function, type, variable...) is USED.
+
+Use when:
+- Renaming or refactoring a function and you need all call sites
+- Checking the impact of changing a type or constant
+- "Who calls X?" questions
+
+Matches whole identifiers exactly (case-sensitive) and returns file:line locations.
+Pair with search_symbols to find the definition.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"symbol": map[string]interface{}{
+					"type":        "string",
+					"description": "Exact identifier to look up (e.g. 'NewIndexer')",
+				},
+				"include_definitions": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also list the definition lines (default: false)",
+					"default":     false,
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of locations (default: 100)",
+					"default":     100,
+					"minimum":     1,
+					"maximum":     1000,
+				},
+			},
+			Required: []string{"symbol"},
+		},
+	}, s.handleFindReferences)
+
+	// Clear the whole index (soft delete)
+	mcpServer.AddTool(mcp.Tool{
+		Name: "clear_index",
+		Description: `Remove ALL indexed chunks from search results.
+
+Two-step operation:
+1. Call without confirmation_token: returns what would be deleted plus a token (nothing is deleted)
+2. Call again with that confirmation_token to execute
+
+Chunks are moved to the trash, not destroyed: use restore_deleted with the returned
+trash ID to undo. Trash entries are permanently deleted after the retention period.
+
+Only use when the user explicitly asks to wipe the index.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"confirmation_token": map[string]interface{}{
+					"type":        "string",
+					"description": "Token returned by the first (dry-run) call",
+				},
+			},
+		},
+	}, s.leaderOnly(s.handleClearIndex))
+
+	// Remove a path from the index (soft delete)
+	mcpServer.AddTool(mcp.Tool{
+		Name: "delete_path",
+		Description: `Remove the indexed chunks of a file or directory from search results.
+
+Two-step operation:
+1. Call without confirmation_token: returns the affected files plus a token (nothing is deleted)
+2. Call again with the same path and that confirmation_token to execute
+
+Chunks are moved to the trash and can be restored with restore_deleted until the
+retention period expires.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute file or directory path to remove from the index",
+				},
+				"confirmation_token": map[string]interface{}{
+					"type":        "string",
+					"description": "Token returned by the first (dry-run) call",
+				},
+			},
+			Required: []string{"path"},
+		},
+	}, s.leaderOnly(s.handleDeletePath))
+
+	// Restore soft-deleted chunks
+	mcpServer.AddTool(mcp.Tool{
+		Name: "restore_deleted",
+		Description: `Undo a clear_index or delete_path operation.
+
+Call without arguments to list restorable trash entries, or with a trash_id to restore it.
+Restored files replace the chunks indexed for them since the delete.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"trash_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Trash entry to restore (omit to list entries)",
+				},
+			},
+		},
+	}, s.leaderOnly(s.handleRestoreDeleted))
+
+	// Read a line range from disk
+	mcpServer.AddTool(mcp.Tool{
+		Name: "read_file_range",
+		Description: `Read a specific line range of a file.
+
+Use to follow up a compact search result (e.g. "auth.go:78-128") when you need the
+actual code or a bit more surrounding context. Returns numbered lines.
+
+Only files under the configured code directories can be read, secrets files such as .env
+excepted. Max 500 lines per call.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path of the file to read",
+				},
+				"start_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "First line to read, 1-based (default: 1)",
+					"default":     1,
+					"minimum":     1,
+				},
+				"end_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "Last line to read, inclusive (default: start_line + 99)",
+					"minimum":     1,
+				},
+			},
+			Required: []string{"file_path"},
+		},
+	}, s.handleReadFileRange)
+
+	// Context pack for test generation
+	mcpServer.AddTool(mcp.Tool{
+		Name: "get_test_context",
+		Description: `Gather everything needed to write tests for a function in one call.
+
+Returns the function's full definition, the definitions of what it calls, existing
+tests that already call it, and tests of similar code in the repo so new tests can
+follow the same framework, naming and fixture conventions.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"symbol": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the function or method to test (exact, case-sensitive)",
+				},
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: file defining the symbol, when the name is defined in several places",
+				},
+				"max_examples": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of similar test examples to include (default: 3)",
+					"default":     3,
+					"minimum":     0,
+					"maximum":     10,
+				},
+			},
+			Required: []string{"symbol"},
+		},
+	}, s.handleGetTestContext)
+
+	// Ordered reading list for onboarding
+	mcpServer.AddTool(mcp.Tool{
+		Name: "generate_walkthrough",
+		Description: `Build an ordered reading list for a feature or directory.
+
+Use when onboarding onto unfamiliar code ("walk me through the auth module").
+Files are ordered entry points first, then core logic, then helpers, based on which
+files use symbols defined in which. Each file lists what it defines and its
+dependencies within the list. Give either a feature description or a path.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"feature": map[string]interface{}{
+					"type":        "string",
+					"description": "Feature to walk through, in natural language (e.g. 'payment processing')",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to walk through (alternative to feature)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of files (default: 15, max: 30)",
+					"default":     15,
+					"minimum":     1,
+					"maximum":     30,
+				},
+			},
+		},
+	}, s.handleGenerateWalkthrough)
+
+	// Per-hunk context for code review
+	mcpServer.AddTool(mcp.Tool{
+		Name: "review_diff",
+		Description: `Gather review context for a diff, hunk by hunk.
+
+Pass a unified diff (git diff output) or a commit range. For each hunk, returns the
+changed functions with their callers, similar implementations elsewhere in the codebase
+(to check consistency), and related docs that may need updating.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"diff": map[string]interface{}{
+					"type":        "string",
+					"description": "Unified diff to review",
+				},
+				"commit_range": map[string]interface{}{
+					"type":        "string",
+					"description": "Git commit range to diff instead (e.g. 'main..HEAD', 'HEAD~1')",
+				},
+				"repo_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Repository root, used to run git and resolve relative diff paths (default: first code path)",
+				},
+			},
+		},
+	}, s.handleReviewDiff)
+
+	// Remove chunks of deleted files
+	mcpServer.AddTool(mcp.Tool{
+		Name: "prune_index",
+		Description: `Remove stale chunks from the index.
+
+Moves chunks of files that no longer exist (deleted or renamed) to the trash and re-indexes
+files whose content changed since they were indexed. Files under code paths missing or empty
+on disk (e.g. an unmounted volume) are left alone.
+
+Two-step operation:
+1. Call without confirmation_token: returns the stale files plus a token (nothing is changed)
+2. Call again with that confirmation_token to execute
+
+Use restore_deleted with the returned trash ID to undo. Also runs in the background every
+prune_interval when it is set.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"confirmation_token": map[string]interface{}{
+					"type":        "string",
+					"description": "Token from the first call, required to prune",
+				},
+			},
+		},
+	}, s.leaderOnly(s.handlePruneIndex))
+
+	// Compare the index with the files on disk
+	mcpServer.AddTool(mcp.Tool{
+		Name: "verify_index",
+		Description: `Check the index for drift from the files on disk.
+
+Walks the code paths with the indexing rules (extensions, exclusions, size limit) and
+compares the files found with the indexed ones and their content hashes. Reports files
+not indexed, stale files changed since indexing, and deleted or excluded files still
+indexed. With repair, indexes the missing and stale files and moves the chunks of the
+others to the trash (undo with restore_deleted).
+
+Repair is two-step:
+1. Call with repair and without confirmation_token: returns the drift plus a token (nothing is changed)
+2. Call again with the same paths, repair and that confirmation_token to execute`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Directories to verify (default: code_paths)",
+				},
+				"repair": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Fix the drift found (default: false, only report)",
+					"default":     false,
+				},
+				"confirmation_token": map[string]interface{}{
+					"type":        "string",
+					"description": "Token from the first repair call, required to repair",
+				},
+			},
+		},
+	}, s.leaderOnly(s.handleVerifyIndex))
+
+	// History context for commit messages
+	mcpServer.AddTool(mcp.Tool{
+		Name: "commit_message_context",
+		Description: `Gather context for writing a commit message that follows the repository's conventions.
+
+Given the staged changes (or a diff), returns the changed files and definitions, past
+commits touching the same files, and the subject conventions used in recent commits
+(prefix styles like "feat:" or "[TICKET-1]", how often bodies are written).`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"diff": map[string]interface{}{
+					"type":        "string",
+					"description": "Unified diff of the change (default: staged changes, git diff --cached)",
+				},
+				"repo_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Repository root (default: first code path)",
+				},
+			},
+		},
+	}, s.handleCommitMessageContext)
+
+	// House-style exemplars
+	mcpServer.AddTool(mcp.Tool{
+		Name: "find_conventions",
+		Description: `Find how this codebase usually does something, to match house style.
+
+Searches code related to the topic (e.g. "error wrapping", "HTTP handler", "logging"),
+lists the line patterns recurring across several files, and returns representative
+examples using them. Use before writing new code so it follows existing conventions.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"topic": map[string]interface{}{
+					"type":        "string",
+					"description": "What the code does, e.g. 'error wrapping' or 'HTTP handler structure'",
+				},
+				"limit": map[string]interface{}{
+					"type":        "number",
+					"description": "Number of matching chunks analyzed (default: 40)",
+					"default":     40,
+				},
+				"examples": map[string]interface{}{
+					"type":        "number",
+					"description": "Number of representative examples returned (default: 3)",
+					"default":     3,
+				},
+			},
+			Required: []string{"topic"},
+		},
+	}, s.handleFindConventions)
+
+	// Reviewer suggestions from ownership of similar code
+	mcpServer.AddTool(mcp.Tool{
+		Name: "suggest_reviewers",
+		Description: `Suggest reviewers for a new file or snippet.
+
+Finds the most similar existing code and aggregates the CODEOWNERS entries and recent git
+authors of those files, weighted by similarity. Useful for assigning reviewers in PR automation.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "File to find reviewers for (its own chunks are ignored)",
+				},
+				"code_snippet": map[string]interface{}{
+					"type":        "string",
+					"description": "Code to find reviewers for, instead of file_path",
+				},
+				"repo_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Repository root holding CODEOWNERS and git history (default: first code path)",
+				},
+				"exclude": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Reviewers to leave out, e.g. the change author",
+				},
+				"limit": map[string]interface{}{
+					"type":        "number",
+					"description": "Number of similar chunks considered and reviewers returned (default: 10)",
+					"default":     10,
+				},
+			},
+		},
+	}, s.handleSuggestReviewers)
+
+	// Uncommitted changes overlay
+	mcpServer.AddTool(mcp.Tool{
+		Name: "refresh_overlay",
+		Description: `Index uncommitted changes of the working tree into the overlay collection.
+
+Modified, added and untracked files (from git status) are indexed with a dirty flag
+into <collection>_overlay, so the code being edited right now can be searched.
+Unchanged files are skipped and files no longer dirty are dropped. Also runs every
+overlay_interval when configured.`,
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"repo_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Git repository to scan (default: all code paths)",
+				},
+			},
+		},
+	}, s.leaderOnly(s.handleRefreshOverlay))
 }
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Headers of signed requests
+	timestampHeader = "X-Code-Rag-Timestamp" // Unix seconds when the request was signed
+	signatureHeader = "X-Code-Rag-Signature" // "sha256=" + hex HMAC of the signed payload
+
+	// maxSignedBodySize bounds the body read to verify a signature
+	maxSignedBodySize = 10 << 20
+)
+
+// requestSignature returns the signature of a request: the hex HMAC-SHA256,
+// keyed with secret, of the timestamp, method, request URI and body joined
+// by newlines
+func requestSignature(secret, timestamp, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n", timestamp, method, requestURI)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest signs a request to an indexing endpoint with secret, for Go
+// clients of a server configured with webhook_secret. body must be the
+// request's body.
+func SignRequest(r *http.Request, body []byte, secret string) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	r.Header.Set(timestampHeader, timestamp)
+	r.Header.Set(signatureHeader, requestSignature(secret, timestamp, r.Method, r.URL.RequestURI(), body))
+}
+
+// replayGuard remembers the signatures accepted within the allowed clock
+// skew, so a captured request cannot be sent again
+type replayGuard struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // Signature -> when it stops being accepted anyway
+}
+
+// accept records signature until expires, reporting false if it was seen
+func (g *replayGuard) accept(signature string, expires time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if g.seen == nil {
+		g.seen = make(map[string]time.Time)
+	}
+	for sig, exp := range g.seen {
+		if now.After(exp) {
+			delete(g.seen, sig)
+		}
+	}
+	if _, ok := g.seen[signature]; ok {
+		return false
+	}
+	g.seen[signature] = expires
+	return true
+}
+
+// signed rejects requests to indexing endpoints that are not signed with
+// webhook_secret, are older or newer than webhook_max_skew, or were already
+// accepted. Without a secret, every request is let through.
+func (h *HTTPAPIServer) signed(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret := h.server.config.WebhookSecret
+		if secret == "" {
+			handler(w, r)
+			return
+		}
+
+		timestamp := r.Header.Get(timestampHeader)
+		signature := r.Header.Get(signatureHeader)
+		if timestamp == "" || signature == "" {
+			http.Error(w, "missing request signature", http.StatusUnauthorized)
+			return
+		}
+
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid signature timestamp", http.StatusUnauthorized)
+			return
+		}
+		signedAt := time.Unix(unix, 0)
+		skew := h.server.config.WebhookMaxSkew
+		if d := time.Since(signedAt); d > skew || d < -skew {
+			http.Error(w, "request signature expired", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize))
+		if bodyTooLarge(err) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		expected := requestSignature(secret, timestamp, r.Method, r.URL.RequestURI(), body)
+		if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+			h.logger.Warn("Rejected request with invalid signature", zap.String("path", r.URL.Path), zap.String("remote", r.RemoteAddr))
+			http.Error(w, "invalid request signature", http.StatusUnauthorized)
+			return
+		}
+
+		// Past signedAt+skew the timestamp check rejects it anyway
+		if !h.replays.accept(expected, signedAt.Add(skew)) {
+			h.logger.Warn("Rejected replayed request", zap.String("path", r.URL.Path), zap.String("remote", r.RemoteAddr))
+			http.Error(w, "request already processed", http.StatusConflict)
+			return
+		}
+
+		handler(w, r)
+	}
+}